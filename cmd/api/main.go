@@ -10,14 +10,17 @@ import (
 	"os"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	chimiddleware "github.com/go-chi/chi/v5/middleware"
 	_ "github.com/lib/pq"
 
 	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/bot"
 	"github.com/penshort/penshort/internal/cache"
 	"github.com/penshort/penshort/internal/config"
+	"github.com/penshort/penshort/internal/geo"
 	"github.com/penshort/penshort/internal/handler"
 	"github.com/penshort/penshort/internal/metrics"
 	"github.com/penshort/penshort/internal/middleware"
@@ -41,68 +44,219 @@ func main() {
 	// Initialize logger
 	logger := initLogger(cfg)
 
-	// Initialize database
-	repo, err := repository.New(ctx, cfg.DatabaseURL)
-	if err != nil {
-		logger.Error(
-			"failed to connect to database",
-			slog.String("error", sanitizeError(err, cfg.DatabaseURL)),
-			slog.String("database_url", redactURL(cfg.DatabaseURL)),
-		)
-		os.Exit(1)
+	// Initialize storage. A sqlite: DATABASE_URL selects the embedded SQLite
+	// backend; anything else is treated as a PostgreSQL connection string.
+	var (
+		repo           repository.Store
+		pgRepo         *repository.Repository // nil with SQLite
+		clickEventRepo repository.ClickEventStore
+		webhookRepo    webhook.Store
+	)
+	if repository.IsSQLiteURL(cfg.DatabaseURL) {
+		sqliteStore, err := repository.OpenSQLite(ctx, cfg.DatabaseURL)
+		if err != nil {
+			logger.Error("failed to open SQLite database",
+				slog.String("error", err.Error()),
+				slog.String("database_url", redactURL(cfg.DatabaseURL)),
+			)
+			os.Exit(1)
+		}
+		if cfg.DatabaseReadURL != "" {
+			logger.Warn("DATABASE_READ_URL is ignored with the SQLite backend")
+		}
+		repo = sqliteStore
+		clickEventRepo = repository.NewSQLiteClickEventRepository(sqliteStore)
+		webhookRepo = webhook.NewSQLiteRepository(sqliteStore.DB())
+		logger.Info("opened SQLite database")
+	} else {
+		pgRepo, err = repository.New(ctx, cfg.DatabaseURL, cfg.DatabaseReadURL)
+		if err != nil {
+			logger.Error(
+				"failed to connect to database",
+				slog.String("error", sanitizeError(err, cfg.DatabaseURL, cfg.DatabaseReadURL)),
+				slog.String("database_url", redactURL(cfg.DatabaseURL)),
+			)
+			os.Exit(1)
+		}
+		logger.Info("connected to database", "read_replica", pgRepo.HasReplica())
+
+		// Initialize webhook database connection (stdlib sql)
+		webhookDB, err := sql.Open("postgres", cfg.DatabaseURL)
+		if err != nil {
+			logger.Error("failed to open webhook database", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		if err := webhookDB.PingContext(ctx); err != nil {
+			logger.Error("failed to ping webhook database", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		defer webhookDB.Close()
+		logger.Info("connected to webhook database")
+
+		repo = pgRepo
+		clickEventRepo = repository.NewClickEventRepository(pgRepo)
+		webhookRepo = webhook.NewRepository(webhookDB)
 	}
 	defer repo.Close()
-	logger.Info("connected to database")
 
-	// Initialize webhook database connection (stdlib sql)
-	webhookDB, err := sql.Open("postgres", cfg.DatabaseURL)
-	if err != nil {
-		logger.Error("failed to open webhook database", slog.String("error", err.Error()))
-		os.Exit(1)
-	}
-	if err := webhookDB.PingContext(ctx); err != nil {
-		logger.Error("failed to ping webhook database", slog.String("error", err.Error()))
-		os.Exit(1)
-	}
-	defer webhookDB.Close()
-	logger.Info("connected to webhook database")
+	// Initialize cache and analytics queue. redisCache stays nil with the
+	// memory backend; Redis-only features are skipped then.
+	var (
+		cacheClient    cache.Backend
+		redisCache     *cache.Cache
+		analyticsQueue analytics.Queue
+	)
+	if cfg.CacheBackend == cache.BackendMemory {
+		cacheClient = cache.NewMemory(cfg.LocalCacheSize)
+		analyticsQueue = analytics.NewMemoryQueue()
+		logger.Warn("using in-memory cache backend; run a single instance only")
+	} else {
+		redisCache, err = cache.NewFromConfig(ctx, cache.RedisConfig{
+			Mode:             cfg.RedisMode,
+			URL:              cfg.RedisURL,
+			Addrs:            cfg.RedisAddrs,
+			MasterName:       cfg.RedisSentinelMaster,
+			SentinelPassword: cfg.RedisSentinelPassword,
+		})
+		if err != nil {
+			logger.Error(
+				"failed to connect to Redis",
+				slog.String("error", sanitizeError(err, cfg.RedisURL, cfg.RedisSentinelPassword)),
+				slog.String("redis_url", redactURL(cfg.RedisURL)),
+				slog.String("redis_mode", cfg.RedisMode),
+			)
+			os.Exit(1)
+		}
+		logger.Info("connected to Redis", "mode", cfg.RedisMode)
 
-	// Initialize cache
-	cacheClient, err := cache.New(ctx, cfg.RedisURL)
-	if err != nil {
-		logger.Error(
-			"failed to connect to Redis",
-			slog.String("error", sanitizeError(err, cfg.RedisURL)),
-			slog.String("redis_url", redactURL(cfg.RedisURL)),
-		)
-		os.Exit(1)
+		if cfg.LocalCacheSize > 0 {
+			redisCache.EnableLocalCache(cache.NewLocalLinkCache(cfg.LocalCacheSize, cfg.LocalCacheTTL))
+			logger.Info("local link cache enabled", "size", cfg.LocalCacheSize, "ttl", cfg.LocalCacheTTL)
+		}
+		cacheClient = redisCache
+		analyticsQueue = analytics.NewRedisQueue(redisCache.Client())
 	}
 	defer cacheClient.Close()
-	logger.Info("connected to Redis")
 
 	// Initialize services
 	metricsRecorder := metrics.NewInMemory()
 	linkService := service.NewLinkService(repo, cacheClient, cfg.BaseURL, metricsRecorder)
-	clickEventRepo := repository.NewClickEventRepository(repo)
-	webhookRepo := webhook.NewRepository(webhookDB)
+	linkService.SetLookupTimeout(cfg.LinkLookupTimeout)
+	if cfg.CacheRefillLockTTL > 0 {
+		linkService.SetRefillLock(cfg.CacheRefillLockTTL, cfg.CacheRefillWait)
+	}
+
+	// Skip Redis while it is unreachable and serve redirects from PostgreSQL
+	if redisCache != nil && cfg.RedisBreakerThreshold > 0 {
+		breaker := cache.NewBreaker(cfg.RedisBreakerThreshold, cfg.RedisBreakerCooldown)
+		var degradedCache *cache.LocalLinkCache
+		if cfg.DegradedCacheSize > 0 {
+			degradedCache = cache.NewLocalLinkCache(cfg.DegradedCacheSize, cfg.DegradedCacheTTL)
+			linkService.SetDegradedCache(degradedCache)
+		}
+		breaker.OnStateChange(func(from, to cache.BreakerState) {
+			logger.Warn("redis circuit breaker state changed",
+				slog.String("from", from.String()),
+				slog.String("to", to.String()),
+			)
+			metricsRecorder.SetRedisCircuitState(int64(to))
+			if to == cache.BreakerOpen && from == cache.BreakerClosed && degradedCache != nil {
+				// Start each outage without entries from the last one
+				degradedCache.Purge()
+			}
+			if to == cache.BreakerClosed && redisCache.Local() != nil {
+				// Invalidations may have been missed during the outage
+				redisCache.Local().Purge()
+			}
+		})
+		redisCache.EnableBreaker(breaker)
+	}
+
+	// Route replica reads by health and sample pool stats
+	if pgRepo != nil {
+		pgRepo.SetMetrics(metricsRecorder)
+		if cfg.DatabaseCheckInterval > 0 {
+			poolCtx, poolCancel := context.WithCancel(context.Background())
+			go pgRepo.MonitorPools(poolCtx, cfg.DatabaseCheckInterval, logger)
+			defer poolCancel()
+		}
+	}
 
 	// Initialize analytics publisher
-	analyticsPublisher := analytics.NewPublisher(cacheClient.Client(), logger, metricsRecorder)
+	analyticsPublisher := analytics.NewPublisher(analyticsQueue, logger, metricsRecorder)
+
+	// Spool click events to disk while Redis is unreachable, if configured
+	var analyticsSpool *analytics.Spool
+	if cfg.AnalyticsSpoolDir != "" {
+		spoolSync, err := analytics.ParseSyncPolicy(cfg.AnalyticsSpoolSync)
+		if err != nil {
+			logger.Error("invalid ANALYTICS_SPOOL_SYNC", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		analyticsSpool, err = analytics.OpenSpool(analytics.SpoolConfig{
+			Dir:            cfg.AnalyticsSpoolDir,
+			MaxBytes:       cfg.AnalyticsSpoolMaxBytes,
+			SegmentBytes:   cfg.AnalyticsSpoolSegmentBytes,
+			Sync:           spoolSync,
+			SyncInterval:   cfg.AnalyticsSpoolSyncInterval,
+			ReplayInterval: cfg.AnalyticsSpoolReplayInterval,
+		}, logger, metricsRecorder)
+		if err != nil {
+			logger.Error("failed to open analytics spool", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		analyticsPublisher.SetSpool(analyticsSpool)
+		logger.Info("analytics spool enabled", "dir", cfg.AnalyticsSpoolDir)
+	}
 	webhookPublisher := webhook.NewPublisher(webhookRepo, logger)
 
+	// Initialize bot classifier, with optional extra signatures
+	botClassifier := bot.NewClassifier()
+	if cfg.BotSignaturesFile != "" {
+		if err := botClassifier.LoadFile(cfg.BotSignaturesFile); err != nil {
+			logger.Error("failed to load bot signatures", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		logger.Info("loaded bot signatures", "path", cfg.BotSignaturesFile)
+	}
+
+	// Open the GeoIP database, if configured
+	var geoDB *geo.Database
+	if cfg.GeoIPDatabase != "" {
+		geoDB, err = geo.OpenDatabase(cfg.GeoIPDatabase)
+		if err != nil {
+			logger.Error("failed to open geoip database", slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		logger.Info("loaded geoip database", "path", cfg.GeoIPDatabase)
+	}
+
 	// Initialize handlers
 	h := handler.New()
 	healthHandler := handler.NewHealthHandler(repo, cacheClient)
 	linkHandler := handler.NewLinkHandler(linkService, logger)
-	analyticsHandler := handler.NewAnalyticsHandler(clickEventRepo, logger)
+	analyticsHandler := handler.NewAnalyticsHandler(clickEventRepo, linkService, logger)
+	analyticsHandler.SetExportUserAgents(cfg.AnalyticsExportUserAgents)
 	metricsHandler := handler.NewMetricsHandler(metricsRecorder)
 	redirectHandler := handler.NewRedirectHandler(linkService, analyticsPublisher, logger)
+	redirectHandler.SetCountryResolver(geo.NewHeaderResolver(cfg.GeoCountryHeader))
+	redirectHandler.SetBotClassifier(botClassifier)
+	if geoDB != nil {
+		redirectHandler.SetLocator(geoDB)
+	}
 	apiKeyHandler := handler.NewAPIKeyHandler(logger, repo)
 	adminHandler := handler.NewAdminHandler(repo, repo, logger)
+	errorPageHandler := handler.NewErrorPageHandler(linkService, logger)
+	linkTransferHandler := handler.NewLinkTransferHandler(linkService, logger)
 	webhookHandler := handler.NewWebhookHandler(webhookRepo, logger, cfg.WebhookAllowInsecure)
+	liveFeed := analytics.NewLiveFeed(analyticsQueue, logger)
+	liveClickHandler := handler.NewLiveClickHandler(liveFeed, linkService, logger)
+	liveClickHandler.SetMaxStreams(cfg.AnalyticsLiveMaxStreams)
+	liveClickHandler.SetHeartbeat(cfg.AnalyticsLiveHeartbeat)
+	deadLetterHandler := handler.NewDeadLetterHandler(analytics.NewDeadLetterQueue(analyticsQueue, logger), logger)
 
 	// Setup router
-	r := setupRouter(h, healthHandler, metricsHandler, linkHandler, analyticsHandler, redirectHandler, apiKeyHandler, adminHandler, webhookHandler, repo, cacheClient, cfg, logger)
+	r := setupRouter(h, healthHandler, metricsHandler, linkHandler, analyticsHandler, liveClickHandler, redirectHandler, apiKeyHandler, adminHandler, deadLetterHandler, errorPageHandler, linkTransferHandler, webhookHandler, repo, cacheClient, cfg, logger)
 
 	// Create and run server
 	srv := server.New(
@@ -115,8 +269,10 @@ func main() {
 	)
 
 	// Start analytics worker in the background.
-	worker := analytics.NewWorker(cacheClient.Client(), clickEventRepo, logger, analytics.NewConsumerID(), metricsRecorder)
+	worker := analytics.NewWorker(analyticsQueue, clickEventRepo, logger, analytics.NewConsumerID(), metricsRecorder)
 	worker.SetWebhookPublisher(webhookPublisher)
+	worker.SetHourlyPrune(cfg.AnalyticsHourlyRetention, cfg.AnalyticsRollupInterval)
+	worker.SetRawRetention(time.Duration(cfg.AnalyticsRawRetentionDays) * 24 * time.Hour)
 
 	// Register worker for graceful shutdown (called after HTTP server stops)
 	srv.OnShutdown("analytics-worker", worker.Shutdown)
@@ -128,6 +284,59 @@ func main() {
 		}
 	}()
 
+	// Replay spooled click events once Redis is back
+	if analyticsSpool != nil {
+		spoolCtx, spoolCancel := context.WithCancel(context.Background())
+		go func() {
+			if err := analyticsSpool.Run(spoolCtx, analyticsQueue); err != nil && !errors.Is(err, context.Canceled) {
+				logger.Error("analytics spool stopped unexpectedly", "error", err)
+			}
+		}()
+		srv.OnShutdown("analytics-spool", func(ctx context.Context) error {
+			spoolCancel()
+			return analyticsSpool.Close()
+		})
+	}
+
+	// Fan out clicks to live streams; streams end as soon as shutdown begins
+	liveCtx, liveCancel := context.WithCancel(context.Background())
+	go func() {
+		if err := liveFeed.Run(liveCtx); err != nil {
+			logger.Error("live click feed stopped unexpectedly", "error", err)
+		}
+	}()
+	srv.OnDrain(liveCancel)
+
+	// Apply link invalidations from other replicas to the local cache
+	if redisCache != nil && redisCache.Local() != nil {
+		invalidationCtx, invalidationCancel := context.WithCancel(context.Background())
+		go redisCache.RunLinkInvalidation(invalidationCtx, logger)
+		srv.OnShutdown("link-invalidation", func(context.Context) error {
+			invalidationCancel()
+			return nil
+		})
+	}
+
+	// Pick up edits to the bot signatures file without a restart
+	if cfg.BotSignaturesFile != "" && cfg.BotSignaturesReload > 0 {
+		botCtx, botCancel := context.WithCancel(context.Background())
+		go botClassifier.Watch(botCtx, cfg.BotSignaturesFile, cfg.BotSignaturesReload, logger)
+		srv.OnShutdown("bot-signatures", func(context.Context) error {
+			botCancel()
+			return nil
+		})
+	}
+
+	// Pick up GeoIP database updates without a restart
+	if geoDB != nil && cfg.GeoIPReload > 0 {
+		geoCtx, geoCancel := context.WithCancel(context.Background())
+		go geoDB.Watch(geoCtx, cfg.GeoIPDatabase, cfg.GeoIPReload, logger)
+		srv.OnShutdown("geoip-database", func(context.Context) error {
+			geoCancel()
+			return nil
+		})
+	}
+
 	webhookWorker := webhook.NewWorker(webhookRepo, logger, metricsRecorder)
 	webhookCtx, webhookCancel := context.WithCancel(context.Background())
 	webhookDone := make(chan struct{})
@@ -205,12 +414,16 @@ func setupRouter(
 	metricsHandler *handler.MetricsHandler,
 	linkHandler *handler.LinkHandler,
 	analyticsHandler *handler.AnalyticsHandler,
+	liveClickHandler *handler.LiveClickHandler,
 	redirectHandler *handler.RedirectHandler,
 	apiKeyHandler *handler.APIKeyHandler,
 	adminHandler *handler.AdminHandler,
+	deadLetterHandler *handler.DeadLetterHandler,
+	errorPageHandler *handler.ErrorPageHandler,
+	linkTransferHandler *handler.LinkTransferHandler,
 	webhookHandler *handler.WebhookHandler,
-	repo *repository.Repository,
-	cacheClient *cache.Cache,
+	repo repository.APIKeyStore,
+	cacheClient cache.Backend,
 	cfg *config.Config,
 	logger *slog.Logger,
 ) *chi.Mux {
@@ -269,6 +482,7 @@ func setupRouter(
 	r.Route("/api/v1", func(r chi.Router) {
 		// Apply security middleware first
 		r.Use(middleware.MaxBodySize(cfg.MaxRequestBodySize))
+		r.Use(middleware.ReadYourWrites)
 
 		// Apply auth and rate limit middleware to all API routes
 		r.Use(middleware.Auth(authCfg))
@@ -279,11 +493,18 @@ func setupRouter(
 			r.With(middleware.RequireRead()).Get("/", linkHandler.List)
 			r.With(middleware.RequireRead()).Get("/{id}", linkHandler.Get)
 			r.With(middleware.RequireRead()).Get("/{id}/analytics", analyticsHandler.GetLinkAnalytics)
+			r.With(middleware.RequireRead()).Get("/{id}/clicks/export", analyticsHandler.ExportLinkClicks)
+			r.With(middleware.RequireRead()).Get("/{id}/clicks/live", liveClickHandler.StreamLinkClicks)
 			r.With(middleware.RequireWrite()).Post("/", linkHandler.Create)
 			r.With(middleware.RequireWrite()).Patch("/{id}", linkHandler.Update)
 			r.With(middleware.RequireAdmin()).Delete("/{id}", linkHandler.Delete)
 		})
 
+		// Account-wide analytics across the caller's links
+		r.With(middleware.RequireRead()).Get("/analytics", analyticsHandler.GetAccountAnalytics)
+		r.With(middleware.RequireRead()).Get("/clicks/export", analyticsHandler.ExportAccountClicks)
+		r.With(middleware.RequireRead()).Get("/clicks/live", liveClickHandler.StreamAccountClicks)
+
 		// API key management (requires admin scope for mutations)
 		r.Route("/api-keys", func(r chi.Router) {
 			r.With(middleware.RequireRead()).Get("/", apiKeyHandler.ListAPIKeys)
@@ -311,6 +532,16 @@ func setupRouter(
 			r.Get("/links", adminHandler.LookupLinks)
 			r.Get("/api-keys", adminHandler.ListAPIKeysByUser)
 			r.Get("/stats", adminHandler.Stats)
+			r.Get("/error-pages/{scope}/{id}", errorPageHandler.Get)
+			r.Put("/error-pages/{scope}/{id}", errorPageHandler.Put)
+			r.Delete("/error-pages/{scope}/{id}", errorPageHandler.Delete)
+			r.Get("/link-transfers", linkTransferHandler.List)
+			r.Post("/link-transfers", linkTransferHandler.Transfer)
+			r.Post("/analytics/recompute", analyticsHandler.RecomputeStats)
+			r.Get("/analytics/dlq", deadLetterHandler.List)
+			r.Get("/analytics/dlq/{id}", deadLetterHandler.Get)
+			r.Post("/analytics/dlq/replay", deadLetterHandler.Replay)
+			r.Post("/analytics/dlq/purge", deadLetterHandler.Purge)
 		})
 	})
 
@@ -0,0 +1,228 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// deadLetterScanCount is the page size used when scanning the dead-letter
+// queue for entries matching a reason.
+const deadLetterScanCount = 500
+
+// ErrDeadLetterNotFound is returned when a dead-letter entry does not exist.
+var ErrDeadLetterNotFound = errors.New("dead letter not found")
+
+// DeadLetter is a message the worker could not process.
+type DeadLetter struct {
+	ID             string // ID in the dead-letter queue
+	OriginalID     string // Event ID of the original message
+	OriginalStream string
+	Reason         string // invalid_format, unmarshal_error or validation_error
+	Detail         string
+	Payload        string    // Empty if the message had no payload
+	DeadLetteredAt time.Time // Zero if missing or malformed
+}
+
+// DeadLetterSelection picks dead-letter entries by ID or by reason.
+type DeadLetterSelection struct {
+	IDs    []string
+	Reason string
+	All    bool // Every entry; only honoured by Purge
+}
+
+// DeadLetterReplay reports the outcome of a replay.
+type DeadLetterReplay struct {
+	Replayed int // Requeued and removed from the dead-letter queue
+	Skipped  int // Without a payload to requeue; left in place
+	NotFound int // Selected IDs that do not exist
+}
+
+// DeadLetterQueue inspects, replays and purges the dead-letter queue.
+type DeadLetterQueue struct {
+	queue  Queue
+	logger *slog.Logger
+}
+
+// NewDeadLetterQueue creates a DeadLetterQueue over queue.
+func NewDeadLetterQueue(queue Queue, logger *slog.Logger) *DeadLetterQueue {
+	return &DeadLetterQueue{
+		queue:  queue,
+		logger: logger.With("component", "analytics.dlq"),
+	}
+}
+
+// List returns up to limit entries after the entry ID after ("" for the
+// oldest), optionally only those with reason. The returned cursor is the ID
+// to pass as after for the next page, or empty when there are no more.
+func (d *DeadLetterQueue) List(ctx context.Context, reason, after string, limit int) ([]*DeadLetter, string, error) {
+	var entries []*DeadLetter
+	start := "-"
+	if after != "" {
+		start = "(" + after
+	}
+	for {
+		messages, err := d.queue.DeadLetters(ctx, start, "+", deadLetterScanCount)
+		if err != nil {
+			return nil, "", err
+		}
+		for _, msg := range messages {
+			entry := parseDeadLetter(msg)
+			if reason != "" && entry.Reason != reason {
+				continue
+			}
+			if len(entries) == limit {
+				return entries, entries[len(entries)-1].ID, nil
+			}
+			entries = append(entries, entry)
+		}
+		if len(messages) < deadLetterScanCount {
+			return entries, "", nil
+		}
+		start = "(" + messages[len(messages)-1].ID
+	}
+}
+
+// Get returns a single entry.
+func (d *DeadLetterQueue) Get(ctx context.Context, id string) (*DeadLetter, error) {
+	messages, err := d.queue.DeadLetters(ctx, id, id, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(messages) == 0 {
+		return nil, ErrDeadLetterNotFound
+	}
+	return parseDeadLetter(messages[0]), nil
+}
+
+// Depth returns the number of entries.
+func (d *DeadLetterQueue) Depth(ctx context.Context) (int64, error) {
+	return d.queue.DeadLetterDepth(ctx)
+}
+
+// Replay requeues the selected entries' payloads onto the click stream and
+// removes them from the dead-letter queue. A requeued event keeps its
+// original event ID, so replaying an entry twice records it once. Entries
+// that fail again are dead-lettered again under a new ID.
+func (d *DeadLetterQueue) Replay(ctx context.Context, sel DeadLetterSelection) (DeadLetterReplay, error) {
+	var result DeadLetterReplay
+	err := d.each(ctx, sel, &result.NotFound, func(entry *DeadLetter) error {
+		if entry.Payload == "" {
+			result.Skipped++
+			return nil
+		}
+		if _, err := d.queue.Requeue(ctx, entry.Payload, entry.eventID()); err != nil {
+			return fmt.Errorf("requeue %s: %w", entry.ID, err)
+		}
+		if _, err := d.queue.DeleteDeadLetters(ctx, entry.ID); err != nil {
+			return fmt.Errorf("delete %s: %w", entry.ID, err)
+		}
+		result.Replayed++
+		return nil
+	})
+	if result.Replayed > 0 {
+		d.logger.Info("dead letters replayed", "replayed", result.Replayed, "reason", sel.Reason)
+	}
+	return result, err
+}
+
+// Purge deletes the selected entries and returns how many were deleted.
+func (d *DeadLetterQueue) Purge(ctx context.Context, sel DeadLetterSelection) (int, error) {
+	if len(sel.IDs) > 0 {
+		deleted, err := d.queue.DeleteDeadLetters(ctx, sel.IDs...)
+		return int(deleted), err
+	}
+
+	purged := 0
+	var notFound int
+	err := d.each(ctx, sel, &notFound, func(entry *DeadLetter) error {
+		deleted, err := d.queue.DeleteDeadLetters(ctx, entry.ID)
+		if err != nil {
+			return fmt.Errorf("delete %s: %w", entry.ID, err)
+		}
+		purged += int(deleted)
+		return nil
+	})
+	if purged > 0 {
+		d.logger.Info("dead letters purged", "purged", purged, "reason", sel.Reason)
+	}
+	return purged, err
+}
+
+// each calls fn for every selected entry, counting selected IDs that do
+// not exist in notFound. Entries matching a reason are collected before fn
+// runs, so entries dead-lettered again during a replay are not revisited.
+func (d *DeadLetterQueue) each(ctx context.Context, sel DeadLetterSelection, notFound *int, fn func(*DeadLetter) error) error {
+	var entries []*DeadLetter
+	switch {
+	case len(sel.IDs) > 0:
+		for _, id := range sel.IDs {
+			entry, err := d.Get(ctx, id)
+			if errors.Is(err, ErrDeadLetterNotFound) {
+				*notFound++
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+	case sel.Reason != "" || sel.All:
+		start := "-"
+		for {
+			messages, err := d.queue.DeadLetters(ctx, start, "+", deadLetterScanCount)
+			if err != nil {
+				return err
+			}
+			for _, msg := range messages {
+				if entry := parseDeadLetter(msg); sel.Reason == "" || entry.Reason == sel.Reason {
+					entries = append(entries, entry)
+				}
+			}
+			if len(messages) < deadLetterScanCount {
+				break
+			}
+			start = "(" + messages[len(messages)-1].ID
+		}
+	}
+
+	for _, entry := range entries {
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eventID returns the idempotency key to requeue the entry with. Entries
+// without an original_id fall back to their own ID.
+func (e *DeadLetter) eventID() string {
+	if e.OriginalID != "" {
+		return e.OriginalID
+	}
+	return e.ID
+}
+
+// parseDeadLetter reads the fields written by Worker.deadLetterMessage.
+func parseDeadLetter(msg redis.XMessage) *DeadLetter {
+	field := func(name string) string {
+		value, _ := msg.Values[name].(string)
+		return value
+	}
+	entry := &DeadLetter{
+		ID:             msg.ID,
+		OriginalID:     field("original_id"),
+		OriginalStream: field("original_stream"),
+		Reason:         field("reason"),
+		Detail:         field("detail"),
+		Payload:        field("payload"),
+	}
+	if at, err := time.Parse(time.RFC3339, field("dead_lettered_at")); err == nil {
+		entry.DeadLetteredAt = at
+	}
+	return entry
+}
@@ -0,0 +1,114 @@
+package analytics
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func newTestDeadLetterQueue(t *testing.T) (*MemoryQueue, *DeadLetterQueue) {
+	t.Helper()
+	q := NewMemoryQueue()
+	w := NewWorker(q, &recordingRepository{}, slog.New(slog.NewTextHandler(io.Discard, nil)), "test-consumer", nil)
+
+	// Two unmarshal errors around a message without a payload
+	w.parseMessages(context.Background(), []redis.XMessage{
+		{ID: "1-0", Values: map[string]any{"payload": "{"}},
+		{ID: "2-0", Values: map[string]any{}},
+		{ID: "3-0", Values: map[string]any{"payload": "not json"}},
+	})
+	return q, NewDeadLetterQueue(q, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestDeadLetterQueue_List(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, dlq := newTestDeadLetterQueue(t)
+
+	entries, next, err := dlq.List(ctx, "", "", 2)
+	if err != nil || len(entries) != 2 || next != entries[1].ID {
+		t.Fatalf("List(limit 2) = %d entries, next %q, %v; want 2 and a cursor", len(entries), next, err)
+	}
+	if entries[0].OriginalID != "1-0" || entries[0].Reason != "unmarshal_error" || entries[0].Payload != "{" {
+		t.Errorf("first entry = %+v", entries[0])
+	}
+	if entries[0].DeadLetteredAt.IsZero() {
+		t.Error("first entry has no dead_lettered_at")
+	}
+
+	rest, next, err := dlq.List(ctx, "", next, 2)
+	if err != nil || len(rest) != 1 || next != "" || rest[0].OriginalID != "3-0" {
+		t.Fatalf("List(after) = %+v, next %q, %v; want the last entry", rest, next, err)
+	}
+
+	byReason, _, _ := dlq.List(ctx, "invalid_format", "", 10)
+	if len(byReason) != 1 || byReason[0].OriginalID != "2-0" {
+		t.Errorf("List(invalid_format) = %+v, want the message without payload", byReason)
+	}
+
+	got, err := dlq.Get(ctx, entries[1].ID)
+	if err != nil || got.OriginalID != "2-0" {
+		t.Errorf("Get(%s) = %+v, %v", entries[1].ID, got, err)
+	}
+	if _, err := dlq.Get(ctx, "9-9"); err != ErrDeadLetterNotFound {
+		t.Errorf("Get(missing) error = %v, want ErrDeadLetterNotFound", err)
+	}
+}
+
+func TestDeadLetterQueue_Replay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q, dlq := newTestDeadLetterQueue(t)
+	entries, _, _ := dlq.List(ctx, "", "", 10)
+
+	result, err := dlq.Replay(ctx, DeadLetterSelection{IDs: []string{entries[0].ID, entries[1].ID, "9-9"}})
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if result != (DeadLetterReplay{Replayed: 1, Skipped: 1, NotFound: 1}) {
+		t.Errorf("Replay = %+v, want 1 replayed, 1 skipped, 1 not found", result)
+	}
+
+	// The requeued message keeps the original event ID
+	msgs, _ := q.Read(ctx, "c1", 10, 0)
+	if len(msgs) != 1 || msgs[0].Values["payload"] != "{" || messageEventID(msgs[0]) != "1-0" {
+		t.Fatalf("requeued = %v, want the first payload with event ID 1-0", msgs)
+	}
+	if depth, _ := dlq.Depth(ctx); depth != 2 {
+		t.Errorf("Depth() = %d, want 2 after replay", depth)
+	}
+
+	result, err = dlq.Replay(ctx, DeadLetterSelection{Reason: "unmarshal_error"})
+	if err != nil || result.Replayed != 1 {
+		t.Fatalf("Replay(reason) = %+v, %v; want 1 replayed", result, err)
+	}
+	if depth, _ := dlq.Depth(ctx); depth != 1 {
+		t.Errorf("Depth() = %d, want only the entry without payload", depth)
+	}
+}
+
+func TestDeadLetterQueue_Purge(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	_, dlq := newTestDeadLetterQueue(t)
+	entries, _, _ := dlq.List(ctx, "", "", 10)
+
+	if n, err := dlq.Purge(ctx, DeadLetterSelection{IDs: []string{entries[0].ID, "9-9"}}); err != nil || n != 1 {
+		t.Fatalf("Purge(ids) = %d, %v; want 1", n, err)
+	}
+	if n, err := dlq.Purge(ctx, DeadLetterSelection{Reason: "invalid_format"}); err != nil || n != 1 {
+		t.Fatalf("Purge(reason) = %d, %v; want 1", n, err)
+	}
+	if n, err := dlq.Purge(ctx, DeadLetterSelection{All: true}); err != nil || n != 1 {
+		t.Fatalf("Purge(all) = %d, %v; want 1", n, err)
+	}
+	if depth, _ := dlq.Depth(ctx); depth != 0 {
+		t.Errorf("Depth() = %d, want 0", depth)
+	}
+}
@@ -0,0 +1,252 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+const (
+	// liveReadCount is the max messages read per Tail call.
+	liveReadCount = 100
+
+	// liveSubscriberBuffer is the number of events queued per subscriber.
+	// A subscriber that falls further behind is dropped and must resume.
+	liveSubscriberBuffer = 256
+
+	// MaxLiveReplay is the max events replayed when a stream resumes.
+	MaxLiveReplay = 1000
+
+	// maxLiveReplayScan bounds the stream messages read while replaying.
+	maxLiveReplayScan = 20000
+)
+
+// LiveFeed fans click events out to live stream subscribers. It tails the
+// queue outside the consumer group, so subscribers never affect processing,
+// and each process runs a single reader however many subscribers it has.
+type LiveFeed struct {
+	queue  Queue
+	logger *slog.Logger
+
+	mu     sync.Mutex
+	subs   map[*LiveSubscription]struct{}
+	closed bool
+
+	blockTimeout time.Duration
+	maxReplay    int
+	maxScan      int
+}
+
+// LiveReplay is the result of a replay.
+type LiveReplay struct {
+	Events []*model.ClickEvent
+
+	// Truncated is set when the replay stopped at a limit and more events
+	// may follow. Next is the message ID to resume the replay after.
+	Truncated bool
+	Next      string
+}
+
+// LiveFilter selects the events a subscriber receives.
+type LiveFilter struct {
+	OwnerID string // Required
+	LinkID  string // Empty for all the owner's links
+}
+
+func (f LiveFilter) match(event *model.ClickEvent) bool {
+	return event.OwnerID == f.OwnerID && (f.LinkID == "" || event.LinkID == f.LinkID)
+}
+
+// LiveSubscription receives the events matching its filter.
+type LiveSubscription struct {
+	filter LiveFilter
+	events chan *model.ClickEvent
+}
+
+// Events returns the subscription's events. The channel is closed when the
+// subscriber falls too far behind or the feed stops.
+func (s *LiveSubscription) Events() <-chan *model.ClickEvent {
+	return s.events
+}
+
+// NewLiveFeed creates a feed reading from queue.
+func NewLiveFeed(queue Queue, logger *slog.Logger) *LiveFeed {
+	return &LiveFeed{
+		queue:        queue,
+		logger:       logger.With("component", "analytics.live"),
+		subs:         make(map[*LiveSubscription]struct{}),
+		blockTimeout: DefaultBlockTimeout,
+		maxReplay:    MaxLiveReplay,
+		maxScan:      maxLiveReplayScan,
+	}
+}
+
+// Run tails the queue until ctx is cancelled, then closes all
+// subscriptions.
+func (f *LiveFeed) Run(ctx context.Context) error {
+	defer f.close()
+
+	// Stream IDs start with the time in milliseconds. Starting from an
+	// explicit ID rather than "$" means no click is missed between reads.
+	after := fmt.Sprintf("%d-0", time.Now().UnixMilli()-1)
+	for {
+		messages, err := f.queue.Tail(ctx, after, liveReadCount, f.blockTimeout)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			f.logger.Error("tail click events", "error", err)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(time.Second):
+			}
+			continue
+		}
+
+		for _, msg := range messages {
+			after = msg.ID
+			if event, ok := parseLiveEvent(msg); ok {
+				f.dispatch(event)
+			}
+		}
+	}
+}
+
+// SetMaxReplay sets the max events replayed when a stream resumes.
+func (f *LiveFeed) SetMaxReplay(n int) {
+	if n > 0 {
+		f.maxReplay = n
+	}
+}
+
+// Subscribe registers a subscriber for the events matching filter.
+func (f *LiveFeed) Subscribe(filter LiveFilter) (*LiveSubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return nil, errors.New("live feed stopped")
+	}
+
+	sub := &LiveSubscription{
+		filter: filter,
+		events: make(chan *model.ClickEvent, liveSubscriberBuffer),
+	}
+	f.subs[sub] = struct{}{}
+	return sub, nil
+}
+
+// Unsubscribe removes a subscriber and closes its channel.
+func (f *LiveFeed) Unsubscribe(sub *LiveSubscription) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if _, ok := f.subs[sub]; ok {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
+
+// Replay returns up to MaxLiveReplay events matching filter that were
+// added after the message ID after, as far back as the queue keeps them.
+// It stops early, marking the replay truncated, once it has MaxLiveReplay
+// events or has scanned maxLiveReplayScan messages.
+func (f *LiveFeed) Replay(ctx context.Context, filter LiveFilter, after string) (*LiveReplay, error) {
+	replay := &LiveReplay{Next: after}
+	for scanned := 0; ; scanned += liveReadCount {
+		if scanned >= f.maxScan {
+			replay.Truncated = true
+			return replay, nil
+		}
+		messages, err := f.queue.Tail(ctx, replay.Next, liveReadCount, 0)
+		if err != nil {
+			return nil, err
+		}
+		for _, msg := range messages {
+			replay.Next = msg.ID
+			if event, ok := parseLiveEvent(msg); ok && filter.match(event) {
+				replay.Events = append(replay.Events, event)
+				if len(replay.Events) == f.maxReplay {
+					replay.Truncated = true
+					return replay, nil
+				}
+			}
+		}
+		if len(messages) < liveReadCount {
+			return replay, nil
+		}
+	}
+}
+
+// dispatch sends event to every matching subscriber, dropping subscribers
+// whose buffer is full.
+func (f *LiveFeed) dispatch(event *model.ClickEvent) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for sub := range f.subs {
+		if !sub.filter.match(event) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			delete(f.subs, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (f *LiveFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closed = true
+	for sub := range f.subs {
+		delete(f.subs, sub)
+		close(sub.events)
+	}
+}
+
+// parseLiveEvent decodes a queue message. Messages the worker would
+// dead-letter are skipped.
+func parseLiveEvent(msg redis.XMessage) (*model.ClickEvent, bool) {
+	payload, ok := msg.Values["payload"].(string)
+	if !ok {
+		return nil, false
+	}
+	var eventPayload ClickEventPayload
+	if err := json.Unmarshal([]byte(payload), &eventPayload); err != nil {
+		return nil, false
+	}
+	if ValidateClickEventPayload(eventPayload) != nil {
+		return nil, false
+	}
+
+	return &model.ClickEvent{
+		EventID:     msg.ID,
+		ShortCode:   eventPayload.ShortCode,
+		LinkID:      eventPayload.LinkID,
+		OwnerID:     eventPayload.OwnerID,
+		Referrer:    eventPayload.Referrer,
+		UserAgent:   eventPayload.UserAgent,
+		VisitorHash: eventPayload.VisitorHash,
+		CountryCode: eventPayload.CountryCode,
+		Region:      eventPayload.Region,
+		City:        eventPayload.City,
+		ClickedAt:   time.UnixMilli(eventPayload.ClickedAt).UTC(),
+		Blocked:     eventPayload.Blocked,
+		IsBot:       eventPayload.IsBot,
+		BotFamily:   eventPayload.BotFamily,
+	}, true
+}
+
+// StreamIDAfter reports whether the stream message ID a comes after b.
+func StreamIDAfter(a, b string) bool {
+	return compareStreamIDs(a, b) > 0
+}
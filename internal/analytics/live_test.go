@@ -0,0 +1,157 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func addLiveClick(t *testing.T, q Queue, ownerID, linkID string) string {
+	t.Helper()
+	data, err := json.Marshal(ClickEventPayload{
+		ShortCode:   "abc123",
+		LinkID:      linkID,
+		OwnerID:     ownerID,
+		VisitorHash: "0123456789abcdef",
+		ClickedAt:   time.Now().UnixMilli(),
+	})
+	if err != nil {
+		t.Fatalf("marshal payload: %v", err)
+	}
+	id, err := q.Add(context.Background(), string(data))
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	return id
+}
+
+func TestLiveFeed_DispatchesByOwnerAndLink(t *testing.T) {
+	q := NewMemoryQueue()
+	feed := NewLiveFeed(q, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	feed.blockTimeout = 10 * time.Millisecond
+
+	account, _ := feed.Subscribe(LiveFilter{OwnerID: "alice"})
+	link, _ := feed.Subscribe(LiveFilter{OwnerID: "alice", LinkID: "link-2"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		_ = feed.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(20 * time.Millisecond) // Let Run pin the start
+
+	first := addLiveClick(t, q, "alice", "link-1")
+	_ = addLiveClick(t, q, "bob", "link-3")
+	second := addLiveClick(t, q, "alice", "link-2")
+
+	for _, want := range []string{first, second} {
+		select {
+		case event := <-account.Events():
+			if event.EventID != want {
+				t.Errorf("account event = %s, want %s", event.EventID, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("account subscriber did not receive %s", want)
+		}
+	}
+	select {
+	case event := <-link.Events():
+		if event.EventID != second || event.LinkID != "link-2" {
+			t.Errorf("link event = %+v, want %s on link-2", event, second)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("link subscriber did not receive its click")
+	}
+
+	cancel()
+	<-done
+	if _, ok := <-account.Events(); ok {
+		t.Error("subscription still open after the feed stopped")
+	}
+	if _, err := feed.Subscribe(LiveFilter{OwnerID: "alice"}); err == nil {
+		t.Error("Subscribe after stop succeeded, want error")
+	}
+}
+
+func TestLiveFeed_Replay(t *testing.T) {
+	q := NewMemoryQueue()
+	feed := NewLiveFeed(q, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	first := addLiveClick(t, q, "alice", "link-1")
+	_ = addLiveClick(t, q, "bob", "link-1")
+	second := addLiveClick(t, q, "alice", "link-1")
+	third := addLiveClick(t, q, "alice", "link-2")
+
+	replay, err := feed.Replay(context.Background(), LiveFilter{OwnerID: "alice"}, first)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if events := replay.Events; len(events) != 2 || events[0].EventID != second || events[1].EventID != third {
+		t.Errorf("Replay = %v, want %s and %s", events, second, third)
+	}
+	if replay.Truncated {
+		t.Error("complete replay marked truncated")
+	}
+}
+
+func TestLiveFeed_ReplayTruncated(t *testing.T) {
+	q := NewMemoryQueue()
+	feed := NewLiveFeed(q, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	feed.SetMaxReplay(2)
+	feed.maxScan = liveReadCount
+
+	start := addLiveClick(t, q, "alice", "link-1")
+	first := addLiveClick(t, q, "alice", "link-1")
+	second := addLiveClick(t, q, "alice", "link-1")
+	_ = addLiveClick(t, q, "alice", "link-1")
+
+	// Stops at the event limit and resumes after the last event returned
+	replay, err := feed.Replay(context.Background(), LiveFilter{OwnerID: "alice"}, start)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(replay.Events) != 2 || replay.Events[0].EventID != first || !replay.Truncated || replay.Next != second {
+		t.Errorf("Replay = %d events, truncated %v, next %s; want 2, true, %s", len(replay.Events), replay.Truncated, replay.Next, second)
+	}
+
+	// Stops at the scan limit and resumes after the last message scanned
+	for i := 0; i < liveReadCount; i++ {
+		_ = addLiveClick(t, q, "bob", "link-2")
+	}
+	replay, err = feed.Replay(context.Background(), LiveFilter{OwnerID: "carol"}, start)
+	if err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if len(replay.Events) != 0 || !replay.Truncated || replay.Next == start {
+		t.Errorf("Replay = %d events, truncated %v, next %s; want none, truncated and progress", len(replay.Events), replay.Truncated, replay.Next)
+	}
+}
+
+func TestLiveFeed_DropsSlowSubscriber(t *testing.T) {
+	feed := NewLiveFeed(NewMemoryQueue(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	sub, _ := feed.Subscribe(LiveFilter{OwnerID: "alice"})
+
+	q := NewMemoryQueue()
+	id := addLiveClick(t, q, "alice", "link-1")
+	msgs, _ := q.Tail(context.Background(), "0-0", 1, 0)
+	event, ok := parseLiveEvent(msgs[0])
+	if !ok || event.EventID != id {
+		t.Fatalf("parseLiveEvent = %+v, %v", event, ok)
+	}
+
+	for i := 0; i <= liveSubscriberBuffer; i++ {
+		feed.dispatch(event)
+	}
+
+	received := 0
+	for range sub.Events() {
+		received++
+	}
+	if received != liveSubscriberBuffer {
+		t.Errorf("received %d events before the drop, want %d", received, liveSubscriberBuffer)
+	}
+}
@@ -4,6 +4,7 @@ package analytics
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/binary"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
@@ -12,8 +13,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/redis/go-redis/v9"
-
+	"github.com/penshort/penshort/internal/hll"
 	"github.com/penshort/penshort/internal/metrics"
 )
 
@@ -27,6 +27,9 @@ const (
 	// MaxStreamLen is the approximate max length of the stream.
 	MaxStreamLen = 100000
 
+	// MaxDeadLetterLen is the approximate max length of the dead-letter queue.
+	MaxDeadLetterLen = 10000
+
 	// PublishTimeout is the max time to wait for Redis publish.
 	PublishTimeout = 100 * time.Millisecond
 )
@@ -39,29 +42,42 @@ type ClickEventPayload struct {
 	Referrer    string `json:"r,omitempty"`  // referrer (truncated)
 	UserAgent   string `json:"ua,omitempty"` // user_agent (truncated)
 	VisitorHash string `json:"vh"`           // visitor_hash
+	VisitorReg  uint32 `json:"vr,omitempty"` // HyperLogLog register for multi-day uniques
 	CountryCode string `json:"cc,omitempty"` // country_code
+	Region      string `json:"rg,omitempty"` // ISO 3166-2 region
+	City        string `json:"ci,omitempty"` // city name
 	ClickedAt   int64  `json:"t"`            // Unix milliseconds
+	Blocked     bool   `json:"gb,omitempty"` // refused by country rules
+	IsBot       bool   `json:"bot,omitempty"` // classified as bot
+	BotFamily   string `json:"bf,omitempty"`  // bot family
 }
 
-// Publisher enqueues click events to Redis stream.
+// Publisher enqueues click events to the analytics queue.
 type Publisher struct {
-	redis   *redis.Client
+	queue   Queue
+	spool   *Spool
 	logger  *slog.Logger
 	metrics metrics.Recorder
 }
 
 // NewPublisher creates a new analytics event publisher.
-func NewPublisher(client *redis.Client, logger *slog.Logger, recorder metrics.Recorder) *Publisher {
+func NewPublisher(queue Queue, logger *slog.Logger, recorder metrics.Recorder) *Publisher {
 	if recorder == nil {
 		recorder = metrics.NewNoop()
 	}
 	return &Publisher{
-		redis:   client,
+		queue:   queue,
 		logger:  logger.With("component", "analytics.publisher"),
 		metrics: recorder,
 	}
 }
 
+// SetSpool spools events that PublishAsync cannot add to the stream,
+// instead of dropping them. The spool replays them once Redis is back.
+func (p *Publisher) SetSpool(spool *Spool) {
+	p.spool = spool
+}
+
 // Publish adds a click event to the stream synchronously.
 func (p *Publisher) Publish(ctx context.Context, event ClickEventPayload) (string, error) {
 	data, err := json.Marshal(event)
@@ -69,33 +85,17 @@ func (p *Publisher) Publish(ctx context.Context, event ClickEventPayload) (strin
 		return "", fmt.Errorf("marshal event: %w", err)
 	}
 
-	result, err := p.redis.XAdd(ctx, &redis.XAddArgs{
-		Stream: StreamKey,
-		MaxLen: MaxStreamLen,
-		Approx: true, // ~MAXLEN for performance
-		ID:     "*",  // Auto-generate ID
-		Values: map[string]interface{}{
-			"payload": string(data),
-		},
-	}).Result()
-
-	if err != nil {
-		return "", fmt.Errorf("xadd: %w", err)
-	}
-
-	return result, nil
+	return p.queue.Add(ctx, string(data))
 }
 
 // PublishAsync publishes without blocking the caller.
-// Errors are logged but not returned (fire-and-forget).
+// Errors are logged but not returned (fire-and-forget). If a spool is set,
+// events that cannot be added to the stream are spooled instead of dropped.
 func (p *Publisher) PublishAsync(event ClickEventPayload) {
 	go func() {
-		ctx, cancel := context.WithTimeout(context.Background(), PublishTimeout)
-		defer cancel()
-
-		streamID, err := p.Publish(ctx, event)
+		data, err := json.Marshal(event)
 		if err != nil {
-			p.logger.Warn("failed to publish click event",
+			p.logger.Error("failed to marshal click event",
 				"short_code", event.ShortCode,
 				"error", err,
 			)
@@ -103,6 +103,32 @@ func (p *Publisher) PublishAsync(event ClickEventPayload) {
 			return
 		}
 
+		// With a spool, the event ID is chosen up front: an add that timed
+		// out may still have reached the stream, and the spooled copy must
+		// then dedupe against it rather than be recorded twice
+		var eventID string
+		if p.spool != nil {
+			if eventID, err = newEventID(); err != nil {
+				p.logger.Error("failed to generate click event id", "error", err)
+				p.metrics.IncAnalyticsEventPublished("dropped")
+				return
+			}
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), PublishTimeout)
+		defer cancel()
+
+		var streamID string
+		if eventID != "" {
+			streamID, err = p.queue.Requeue(ctx, string(data), eventID)
+		} else {
+			streamID, err = p.queue.Add(ctx, string(data))
+		}
+		if err != nil {
+			p.spoolOrDrop(event, eventID, string(data), err)
+			return
+		}
+
 		p.logger.Debug("click event published",
 			"short_code", event.ShortCode,
 			"stream_id", streamID,
@@ -111,6 +137,35 @@ func (p *Publisher) PublishAsync(event ClickEventPayload) {
 	}()
 }
 
+// spoolOrDrop spools an event the stream rejected, or drops it if there is
+// no spool or the spool cannot take it.
+func (p *Publisher) spoolOrDrop(event ClickEventPayload, eventID, payload string, publishErr error) {
+	if p.spool != nil {
+		err := p.spool.Append(eventID, payload)
+		if err == nil {
+			p.logger.Debug("click event spooled",
+				"short_code", event.ShortCode,
+				"error", publishErr,
+			)
+			p.metrics.IncAnalyticsEventPublished("spooled")
+			return
+		}
+		p.logger.Warn("failed to spool click event",
+			"short_code", event.ShortCode,
+			"publish_error", publishErr,
+			"error", err,
+		)
+		p.metrics.IncAnalyticsEventPublished("dropped")
+		return
+	}
+
+	p.logger.Warn("failed to publish click event",
+		"short_code", event.ShortCode,
+		"error", publishErr,
+	)
+	p.metrics.IncAnalyticsEventPublished("dropped")
+}
+
 // GenerateVisitorHash creates a privacy-safe visitor identifier.
 // Uses SHA256(IP + UserAgent + daily_salt) truncated to 16 hex chars.
 func GenerateVisitorHash(ip, userAgent string, clickedAt time.Time) string {
@@ -122,6 +177,15 @@ func GenerateVisitorHash(ip, userAgent string, clickedAt time.Time) string {
 	return hex.EncodeToString(hash[:])[:16]
 }
 
+// GenerateVisitorRegister reduces a visitor to a HyperLogLog register update,
+// used to count unique visitors across days. It is not salted per day, but
+// keeps only a register index and rank that thousands of visitors share, so
+// it cannot single anyone out.
+func GenerateVisitorRegister(ip, userAgent string) hll.Register {
+	hash := sha256.Sum256([]byte(ip + userAgent + "penshort:visitors"))
+	return hll.NewRegister(binary.BigEndian.Uint64(hash[:8]))
+}
+
 // SanitizeReferrer cleans and truncates the referrer URL.
 // Strips query parameters and fragments for privacy.
 func SanitizeReferrer(ref string) string {
@@ -321,3 +321,19 @@ func TestTruncateUserAgent_PreservesContent(t *testing.T) {
 		t.Errorf("Short UA should be preserved, got %q", result)
 	}
 }
+
+func TestGenerateVisitorRegister_StableAcrossDays(t *testing.T) {
+	t.Parallel()
+
+	ip := "192.168.1.100"
+	userAgent := "Mozilla/5.0"
+
+	reg1 := GenerateVisitorRegister(ip, userAgent)
+	reg2 := GenerateVisitorRegister(ip, userAgent)
+	if reg1 != reg2 {
+		t.Error("Same visitor should map to the same register on every day")
+	}
+	if !reg1.Valid() {
+		t.Errorf("register %#x is not valid", uint32(reg1))
+	}
+}
@@ -0,0 +1,244 @@
+package analytics
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Queue carries click events from the Publisher to the Worker. Messages
+// are delivered at least once: a message read by a consumer stays pending
+// until it is acknowledged, and can be claimed by another consumer after
+// it has been idle long enough.
+type Queue interface {
+	// Add appends a serialized event and returns its message ID.
+	Add(ctx context.Context, payload string) (string, error)
+
+	// EnsureGroup creates the consumer group if needed.
+	EnsureGroup(ctx context.Context) error
+
+	// Read delivers up to count new messages to consumer, waiting up to
+	// block for at least one to arrive.
+	Read(ctx context.Context, consumer string, count int, block time.Duration) ([]redis.XMessage, error)
+
+	// Claim hands consumer up to count pending messages that have been idle
+	// for at least minIdle, scanning from start. It returns the start for
+	// the next scan.
+	Claim(ctx context.Context, consumer string, minIdle time.Duration, start string, count int) ([]redis.XMessage, string, error)
+
+	// Ack marks messages as processed.
+	Ack(ctx context.Context, ids ...string) error
+
+	// Depth returns the number of unread and unacknowledged messages.
+	Depth(ctx context.Context) (int64, error)
+
+	// DeadLetter stores a message that can never be processed.
+	DeadLetter(ctx context.Context, values map[string]any) error
+
+	// DeadLetters returns up to count dead-lettered messages with IDs from
+	// start to end, oldest first. Bounds use XRANGE syntax: "-" and "+" for
+	// the ends, and a "(" prefix for an exclusive bound.
+	DeadLetters(ctx context.Context, start, end string, count int) ([]redis.XMessage, error)
+
+	// DeleteDeadLetters removes dead-lettered messages and returns how many
+	// existed.
+	DeleteDeadLetters(ctx context.Context, ids ...string) (int64, error)
+
+	// DeadLetterDepth returns the number of dead-lettered messages.
+	DeadLetterDepth(ctx context.Context) (int64, error)
+
+	// Requeue appends a payload again under a new message ID. The worker
+	// uses eventID instead of the message ID as the event's idempotency
+	// key, so a payload requeued twice is only recorded once.
+	Requeue(ctx context.Context, payload, eventID string) (string, error)
+
+	// Tail returns up to count messages added after the message ID after,
+	// waiting up to block for one to arrive (not at all if block is zero).
+	// "$" means messages added from now on. Tail reads outside the consumer
+	// group, so it never affects delivery to consumers.
+	Tail(ctx context.Context, after string, count int, block time.Duration) ([]redis.XMessage, error)
+}
+
+// RedisQueue is a Queue backed by a Redis stream and consumer group.
+// After a Sentinel failover the promoted replica may not have the consumer
+// group yet; Read and Claim recreate it when Redis answers NOGROUP.
+type RedisQueue struct {
+	client redis.UniversalClient
+}
+
+// NewRedisQueue creates a queue on StreamKey.
+func NewRedisQueue(client redis.UniversalClient) *RedisQueue {
+	return &RedisQueue{client: client}
+}
+
+// Add appends an event with XADD, trimming the stream to ~MaxStreamLen.
+func (q *RedisQueue) Add(ctx context.Context, payload string) (string, error) {
+	return q.add(ctx, map[string]interface{}{
+		"payload": payload,
+	})
+}
+
+// Requeue appends a payload with XADD, carrying its original event ID.
+func (q *RedisQueue) Requeue(ctx context.Context, payload, eventID string) (string, error) {
+	return q.add(ctx, map[string]interface{}{
+		"payload":  payload,
+		"event_id": eventID,
+	})
+}
+
+func (q *RedisQueue) add(ctx context.Context, values map[string]interface{}) (string, error) {
+	result, err := q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: StreamKey,
+		MaxLen: MaxStreamLen,
+		Approx: true, // ~MAXLEN for performance
+		ID:     "*",  // Auto-generate ID
+		Values: values,
+	}).Result()
+	if err != nil {
+		return "", fmt.Errorf("xadd: %w", err)
+	}
+	return result, nil
+}
+
+// EnsureGroup creates the consumer group if it doesn't exist.
+func (q *RedisQueue) EnsureGroup(ctx context.Context) error {
+	err := q.client.XGroupCreateMkStream(ctx, StreamKey, ConsumerGroup, "0").Err()
+	if err != nil && !isConsumerGroupExistsError(err) {
+		return err
+	}
+	return nil
+}
+
+// Read reads messages from the stream using XREADGROUP.
+func (q *RedisQueue) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]redis.XMessage, error) {
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    ConsumerGroup,
+		Consumer: consumer,
+		Streams:  []string{StreamKey, ">"},
+		Count:    int64(count),
+		Block:    block,
+	}).Result()
+
+	if err == redis.Nil || (err == nil && len(streams) == 0) {
+		return nil, nil
+	}
+	if isNoGroupError(err) {
+		// Lost with the stream on failover; recreate and read next time
+		if err := q.EnsureGroup(ctx); err != nil {
+			return nil, fmt.Errorf("recreate consumer group: %w", err)
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xreadgroup: %w", err)
+	}
+
+	return streams[0].Messages, nil
+}
+
+// Claim reclaims idle pending messages using XAUTOCLAIM.
+func (q *RedisQueue) Claim(ctx context.Context, consumer string, minIdle time.Duration, start string, count int) ([]redis.XMessage, string, error) {
+	messages, next, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   StreamKey,
+		Group:    ConsumerGroup,
+		Consumer: consumer,
+		MinIdle:  minIdle,
+		Start:    start,
+		Count:    int64(count),
+	}).Result()
+	if isNoGroupError(err) {
+		if err := q.EnsureGroup(ctx); err != nil {
+			return nil, "", fmt.Errorf("recreate consumer group: %w", err)
+		}
+		return nil, "0-0", nil
+	}
+	if err != nil && err != redis.Nil {
+		return nil, "", fmt.Errorf("xautoclaim: %w", err)
+	}
+	return messages, next, nil
+}
+
+// Ack acknowledges messages with XACK.
+func (q *RedisQueue) Ack(ctx context.Context, ids ...string) error {
+	if err := q.client.XAck(ctx, StreamKey, ConsumerGroup, ids...).Err(); err != nil {
+		return fmt.Errorf("xack: %w", err)
+	}
+	return nil
+}
+
+// Depth returns pending plus lag of the consumer group.
+func (q *RedisQueue) Depth(ctx context.Context) (int64, error) {
+	groups, err := q.client.XInfoGroups(ctx, StreamKey).Result()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("xinfo groups: %w", err)
+	}
+	for _, group := range groups {
+		if group.Name == ConsumerGroup {
+			return group.Pending + group.Lag, nil
+		}
+	}
+	return 0, nil
+}
+
+// Tail reads the stream with XREAD. Acknowledged messages stay in the
+// stream until trimmed, so recent history can be replayed.
+func (q *RedisQueue) Tail(ctx context.Context, after string, count int, block time.Duration) ([]redis.XMessage, error) {
+	if block <= 0 {
+		block = -1 // Omits BLOCK; zero would wait forever
+	}
+	streams, err := q.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{StreamKey, after},
+		Count:   int64(count),
+		Block:   block,
+	}).Result()
+	if err == redis.Nil || (err == nil && len(streams) == 0) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("xread: %w", err)
+	}
+	return streams[0].Messages, nil
+}
+
+// DeadLetter writes to the dead-letter stream, keeping the last ~10k entries.
+func (q *RedisQueue) DeadLetter(ctx context.Context, values map[string]any) error {
+	return q.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: DeadLetterStreamKey,
+		MaxLen: MaxDeadLetterLen,
+		Approx: true,
+		ID:     "*",
+		Values: values,
+	}).Err()
+}
+
+// DeadLetters reads the dead-letter stream with XRANGE.
+func (q *RedisQueue) DeadLetters(ctx context.Context, start, end string, count int) ([]redis.XMessage, error) {
+	messages, err := q.client.XRangeN(ctx, DeadLetterStreamKey, start, end, int64(count)).Result()
+	if err != nil && err != redis.Nil {
+		return nil, fmt.Errorf("xrange: %w", err)
+	}
+	return messages, nil
+}
+
+// DeleteDeadLetters removes dead-lettered messages with XDEL.
+func (q *RedisQueue) DeleteDeadLetters(ctx context.Context, ids ...string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	deleted, err := q.client.XDel(ctx, DeadLetterStreamKey, ids...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("xdel: %w", err)
+	}
+	return deleted, nil
+}
+
+// DeadLetterDepth returns the length of the dead-letter stream.
+func (q *RedisQueue) DeadLetterDepth(ctx context.Context) (int64, error) {
+	depth, err := q.client.XLen(ctx, DeadLetterStreamKey).Result()
+	if err != nil && err != redis.Nil {
+		return 0, fmt.Errorf("xlen: %w", err)
+	}
+	return depth, nil
+}
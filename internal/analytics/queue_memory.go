@@ -0,0 +1,353 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrQueueFull is returned by MemoryQueue.Add when MaxStreamLen messages are
+// waiting.
+var ErrQueueFull = errors.New("analytics queue full")
+
+// MemoryTailLen is the number of recent messages MemoryQueue keeps for Tail.
+const MemoryTailLen = 1000
+
+// MemoryQueue is an in-process Queue for single-instance deployments. It
+// follows the Redis stream semantics the Worker relies on (pending entries,
+// claiming, stream-style IDs) but does not survive a restart.
+type MemoryQueue struct {
+	mu         sync.Mutex
+	ready      []redis.XMessage
+	pending    map[string]*pendingMessage
+	deadLetter []redis.XMessage
+	lastMs     int64
+	seq        int64
+	notify     chan struct{}
+
+	// The last MemoryTailLen messages, acknowledged or not, for Tail.
+	// tailNotify is closed and replaced whenever a message is added.
+	recent     []redis.XMessage
+	tailNotify chan struct{}
+
+	now func() time.Time
+}
+
+type pendingMessage struct {
+	msg         redis.XMessage
+	consumer    string
+	deliveredAt time.Time
+}
+
+// NewMemoryQueue creates an empty in-process queue.
+func NewMemoryQueue() *MemoryQueue {
+	return &MemoryQueue{
+		pending:    make(map[string]*pendingMessage),
+		notify:     make(chan struct{}, 1),
+		tailNotify: make(chan struct{}),
+		now:        time.Now,
+	}
+}
+
+// Add appends an event. Unlike the Redis stream, a full queue rejects new
+// events instead of trimming unprocessed ones.
+func (q *MemoryQueue) Add(ctx context.Context, payload string) (string, error) {
+	return q.add(map[string]interface{}{"payload": payload})
+}
+
+// Requeue appends a payload carrying its original event ID.
+func (q *MemoryQueue) Requeue(ctx context.Context, payload, eventID string) (string, error) {
+	return q.add(map[string]interface{}{"payload": payload, "event_id": eventID})
+}
+
+func (q *MemoryQueue) add(values map[string]interface{}) (string, error) {
+	q.mu.Lock()
+	if len(q.ready)+len(q.pending) >= MaxStreamLen {
+		q.mu.Unlock()
+		return "", ErrQueueFull
+	}
+
+	id := q.nextID()
+	msg := redis.XMessage{
+		ID:     id,
+		Values: values,
+	}
+	q.ready = append(q.ready, msg)
+	q.recent = append(q.recent, msg)
+	if over := len(q.recent) - MemoryTailLen; over > 0 {
+		q.recent = q.recent[over:]
+	}
+	close(q.tailNotify)
+	q.tailNotify = make(chan struct{})
+	q.mu.Unlock()
+
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+// nextID returns a new "<ms>-<seq>" ID, the same format as Redis stream
+// IDs, which become event IDs. q.mu must be held.
+func (q *MemoryQueue) nextID() string {
+	ms := q.now().UnixMilli()
+	if ms <= q.lastMs {
+		ms = q.lastMs
+		q.seq++
+	} else {
+		q.seq = 0
+	}
+	q.lastMs = ms
+	return fmt.Sprintf("%d-%d", ms, q.seq)
+}
+
+// EnsureGroup is a no-op; the queue has a single implicit group.
+func (q *MemoryQueue) EnsureGroup(ctx context.Context) error {
+	return nil
+}
+
+// Read delivers up to count new messages, waiting up to block.
+func (q *MemoryQueue) Read(ctx context.Context, consumer string, count int, block time.Duration) ([]redis.XMessage, error) {
+	var timeout <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	for {
+		if messages := q.take(consumer, count); len(messages) > 0 {
+			return messages, nil
+		}
+		if timeout == nil {
+			return nil, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, nil
+		case <-q.notify:
+		}
+	}
+}
+
+// take moves up to count ready messages to the pending list.
+func (q *MemoryQueue) take(consumer string, count int) []redis.XMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	n := min(count, len(q.ready))
+	if n == 0 {
+		return nil
+	}
+	messages := make([]redis.XMessage, n)
+	copy(messages, q.ready[:n])
+	q.ready = q.ready[n:]
+
+	now := q.now()
+	for _, msg := range messages {
+		q.pending[msg.ID] = &pendingMessage{msg: msg, consumer: consumer, deliveredAt: now}
+	}
+	return messages
+}
+
+// Claim hands consumer pending messages idle for at least minIdle, oldest
+// first. The whole pending list is scanned each time, so start is ignored
+// and "0-0" is returned.
+func (q *MemoryQueue) Claim(ctx context.Context, consumer string, minIdle time.Duration, start string, count int) ([]redis.XMessage, string, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	now := q.now()
+	idle := make([]*pendingMessage, 0)
+	for _, p := range q.pending {
+		if now.Sub(p.deliveredAt) >= minIdle {
+			idle = append(idle, p)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return compareStreamIDs(idle[i].msg.ID, idle[j].msg.ID) < 0
+	})
+	if len(idle) > count {
+		idle = idle[:count]
+	}
+
+	messages := make([]redis.XMessage, 0, len(idle))
+	for _, p := range idle {
+		p.consumer = consumer
+		p.deliveredAt = now
+		messages = append(messages, p.msg)
+	}
+	return messages, "0-0", nil
+}
+
+// Ack removes messages from the pending list.
+func (q *MemoryQueue) Ack(ctx context.Context, ids ...string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, id := range ids {
+		delete(q.pending, id)
+	}
+	return nil
+}
+
+// Depth returns the number of unread and unacknowledged messages.
+func (q *MemoryQueue) Depth(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.ready) + len(q.pending)), nil
+}
+
+// DeadLetter keeps the last MaxDeadLetterLen poison messages.
+func (q *MemoryQueue) DeadLetter(ctx context.Context, values map[string]any) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.deadLetter = append(q.deadLetter, redis.XMessage{ID: q.nextID(), Values: values})
+	if over := len(q.deadLetter) - MaxDeadLetterLen; over > 0 {
+		q.deadLetter = q.deadLetter[over:]
+	}
+	return nil
+}
+
+// DeadLetters returns dead-lettered messages between start and end, with
+// the same bound syntax as XRANGE.
+func (q *MemoryQueue) DeadLetters(ctx context.Context, start, end string, count int) ([]redis.XMessage, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	var messages []redis.XMessage
+	for _, msg := range q.deadLetter {
+		if len(messages) >= count {
+			break
+		}
+		if afterRangeStart(msg.ID, start) && beforeRangeEnd(msg.ID, end) {
+			messages = append(messages, msg)
+		}
+	}
+	return messages, nil
+}
+
+// DeleteDeadLetters removes dead-lettered messages by ID.
+func (q *MemoryQueue) DeleteDeadLetters(ctx context.Context, ids ...string) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	remove := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		remove[id] = true
+	}
+	kept := q.deadLetter[:0]
+	for _, msg := range q.deadLetter {
+		if !remove[msg.ID] {
+			kept = append(kept, msg)
+		}
+	}
+	deleted := int64(len(q.deadLetter) - len(kept))
+	q.deadLetter = kept
+	return deleted, nil
+}
+
+// DeadLetterDepth returns the number of dead-lettered messages.
+func (q *MemoryQueue) DeadLetterDepth(ctx context.Context) (int64, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return int64(len(q.deadLetter)), nil
+}
+
+// Tail returns recent messages after the given ID, from the last
+// MemoryTailLen messages added.
+func (q *MemoryQueue) Tail(ctx context.Context, after string, count int, block time.Duration) ([]redis.XMessage, error) {
+	var timeout <-chan time.Time
+	if block > 0 {
+		timer := time.NewTimer(block)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	q.mu.Lock()
+	if after == "$" {
+		after = "0-0"
+		if len(q.recent) > 0 {
+			after = q.recent[len(q.recent)-1].ID
+		}
+	}
+	for {
+		i := sort.Search(len(q.recent), func(i int) bool {
+			return compareStreamIDs(q.recent[i].ID, after) > 0
+		})
+		if n := min(count, len(q.recent)-i); n > 0 {
+			messages := make([]redis.XMessage, n)
+			copy(messages, q.recent[i:i+n])
+			q.mu.Unlock()
+			return messages, nil
+		}
+		if timeout == nil {
+			q.mu.Unlock()
+			return nil, nil
+		}
+		added := q.tailNotify
+		q.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout:
+			return nil, nil
+		case <-added:
+		}
+		q.mu.Lock()
+	}
+}
+
+// afterRangeStart reports whether id is within an XRANGE start bound.
+func afterRangeStart(id, start string) bool {
+	switch {
+	case start == "-":
+		return true
+	case strings.HasPrefix(start, "("):
+		return compareStreamIDs(id, start[1:]) > 0
+	default:
+		return compareStreamIDs(id, start) >= 0
+	}
+}
+
+// beforeRangeEnd reports whether id is within an XRANGE end bound.
+func beforeRangeEnd(id, end string) bool {
+	switch {
+	case end == "+":
+		return true
+	case strings.HasPrefix(end, "("):
+		return compareStreamIDs(id, end[1:]) < 0
+	default:
+		return compareStreamIDs(id, end) <= 0
+	}
+}
+
+// compareStreamIDs orders "<ms>-<seq>" IDs numerically.
+func compareStreamIDs(a, b string) int {
+	var aMs, aSeq, bMs, bSeq int64
+	_, _ = fmt.Sscanf(a, "%d-%d", &aMs, &aSeq)
+	_, _ = fmt.Sscanf(b, "%d-%d", &bMs, &bSeq)
+	switch {
+	case aMs != bMs:
+		if aMs < bMs {
+			return -1
+		}
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	default:
+		return 0
+	}
+}
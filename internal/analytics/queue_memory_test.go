@@ -0,0 +1,297 @@
+package analytics
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestMemoryQueue_ReadAckClaim(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := NewMemoryQueue()
+	now := time.Unix(1700000000, 0)
+	q.now = func() time.Time { return now }
+
+	first, _ := q.Add(ctx, "a")
+	second, _ := q.Add(ctx, "b")
+	if first != "1700000000000-0" || second != "1700000000000-1" {
+		t.Fatalf("IDs = %s, %s; want stream-style IDs", first, second)
+	}
+
+	msgs, err := q.Read(ctx, "c1", 10, 0)
+	if err != nil || len(msgs) != 2 {
+		t.Fatalf("Read() = %d messages, %v; want 2", len(msgs), err)
+	}
+	if msgs[0].Values["payload"] != "a" {
+		t.Errorf("payload = %v, want a", msgs[0].Values["payload"])
+	}
+	if depth, _ := q.Depth(ctx); depth != 2 {
+		t.Errorf("Depth() = %d, want 2 pending", depth)
+	}
+
+	_ = q.Ack(ctx, first)
+
+	// Unacked message is reclaimable once idle
+	claimed, _, _ := q.Claim(ctx, "c2", time.Minute, "0-0", 10)
+	if len(claimed) != 0 {
+		t.Fatalf("Claim() before idle = %d messages, want 0", len(claimed))
+	}
+	now = now.Add(time.Minute)
+	claimed, _, _ = q.Claim(ctx, "c2", time.Minute, "0-0", 10)
+	if len(claimed) != 1 || claimed[0].ID != second {
+		t.Fatalf("Claim() = %v, want [%s]", claimed, second)
+	}
+
+	_ = q.Ack(ctx, second)
+	if depth, _ := q.Depth(ctx); depth != 0 {
+		t.Errorf("Depth() = %d, want 0", depth)
+	}
+}
+
+func TestMemoryQueue_ReadBlocksUntilAdd(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = q.Add(ctx, "late")
+	}()
+
+	msgs, err := q.Read(ctx, "c1", 10, time.Second)
+	if err != nil || len(msgs) != 1 {
+		t.Fatalf("Read() = %d messages, %v; want 1", len(msgs), err)
+	}
+
+	msgs, _ = q.Read(ctx, "c1", 10, 10*time.Millisecond)
+	if len(msgs) != 0 {
+		t.Errorf("Read() on empty queue = %d messages, want 0", len(msgs))
+	}
+}
+
+// recordingRepository stores processed events in memory, skipping event IDs
+// it already has like the real repositories.
+type recordingRepository struct {
+	mu     sync.Mutex
+	events []*model.ClickEvent
+	seen   map[string]bool
+
+	partitionsFrom, partitionsThrough time.Time
+	droppedBefore                     time.Time
+}
+
+func (r *recordingRepository) RecordClickEvents(ctx context.Context, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.seen == nil {
+		r.seen = make(map[string]bool)
+	}
+	var recorded []*model.ClickEvent
+	for _, event := range events {
+		if !r.seen[event.EventID] {
+			r.seen[event.EventID] = true
+			recorded = append(recorded, event)
+		}
+	}
+	r.events = append(r.events, recorded...)
+	return recorded, nil
+}
+
+// countingWebhooks counts the webhooks published per event ID.
+type countingWebhooks struct {
+	published map[string]int
+}
+
+func (c *countingWebhooks) PublishClickEvent(ctx context.Context, userID string, click *model.ClickEvent) error {
+	c.published[click.EventID]++
+	return nil
+}
+
+func (r *recordingRepository) PruneHourlyStats(ctx context.Context, before time.Time) (int, error) {
+	return 0, nil
+}
+
+func (r *recordingRepository) CreateClickPartitions(ctx context.Context, from, through time.Time) (int, error) {
+	r.partitionsFrom, r.partitionsThrough = from, through
+	return 0, nil
+}
+
+func (r *recordingRepository) DropClickPartitions(ctx context.Context, before time.Time) (int, error) {
+	r.droppedBefore = before
+	return 0, nil
+}
+
+func (r *recordingRepository) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.events)
+}
+
+func TestWorker_MemoryQueue(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	q := NewMemoryQueue()
+	repo := &recordingRepository{}
+
+	publisher := NewPublisher(q, logger, nil)
+	worker := NewWorker(q, repo, logger, "test-consumer", nil)
+	worker.SetBlockTimeout(20 * time.Millisecond)
+	go func() { _ = worker.Run(ctx) }()
+	defer func() { _ = worker.Shutdown(context.Background()) }()
+
+	event := ClickEventPayload{
+		ShortCode:   "abc",
+		LinkID:      "link-1",
+		VisitorHash: "0123456789abcdef",
+		ClickedAt:   time.Now().UnixMilli(),
+	}
+	if _, err := publisher.Publish(ctx, event); err != nil {
+		t.Fatalf("publish: %v", err)
+	}
+
+	// A poison message is dead-lettered and does not block the queue
+	bad, _ := json.Marshal(map[string]string{"sc": ""})
+	_, _ = q.Add(ctx, string(bad))
+
+	for repo.count() == 0 {
+		select {
+		case <-ctx.Done():
+			t.Fatal("event was not processed")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	for {
+		depth, _ := q.Depth(ctx)
+		if depth == 0 {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			t.Fatalf("queue depth = %d, want 0", depth)
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+
+	q.mu.Lock()
+	deadLettered := len(q.deadLetter)
+	q.mu.Unlock()
+	if deadLettered != 1 {
+		t.Errorf("dead-lettered = %d, want 1", deadLettered)
+	}
+}
+
+func TestWorker_RedeliveredBatchSendsNoWebhooks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	webhooks := &countingWebhooks{published: make(map[string]int)}
+	worker := NewWorker(NewMemoryQueue(), &recordingRepository{}, slog.New(slog.NewTextHandler(io.Discard, nil)), "test-consumer", nil)
+	worker.SetWebhookPublisher(webhooks)
+
+	first := &model.ClickEvent{EventID: "1-0", LinkID: "link-1", OwnerID: "alice"}
+	second := &model.ClickEvent{EventID: "2-0", LinkID: "link-1", OwnerID: "alice"}
+	if err := worker.processBatch(ctx, []*model.ClickEvent{first}); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+	// Redelivered alongside a new event
+	if err := worker.processBatch(ctx, []*model.ClickEvent{first, second}); err != nil {
+		t.Fatalf("processBatch: %v", err)
+	}
+
+	if webhooks.published["1-0"] != 1 || webhooks.published["2-0"] != 1 {
+		t.Errorf("webhooks = %v, want one per event", webhooks.published)
+	}
+}
+
+func TestMemoryQueue_Tail(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	q := NewMemoryQueue()
+
+	first, _ := q.Add(ctx, "a")
+	second, _ := q.Add(ctx, "b")
+
+	// Consumed and acknowledged messages can still be tailed
+	msgs, _ := q.Read(ctx, "c1", 10, 0)
+	for _, msg := range msgs {
+		_ = q.Ack(ctx, msg.ID)
+	}
+
+	tail, err := q.Tail(ctx, first, 10, 0)
+	if err != nil || len(tail) != 1 || tail[0].ID != second {
+		t.Fatalf("Tail(%s) = %v, %v; want [%s]", first, tail, err, second)
+	}
+	if tail, _ := q.Tail(ctx, "$", 10, 0); len(tail) != 0 {
+		t.Errorf("Tail($) without waiting = %v, want none", tail)
+	}
+
+	done := make(chan []string)
+	go func() {
+		tail, _ := q.Tail(ctx, "$", 10, 5*time.Second)
+		ids := make([]string, 0, len(tail))
+		for _, msg := range tail {
+			ids = append(ids, msg.ID)
+		}
+		done <- ids
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	third, _ := q.Add(ctx, "c")
+
+	select {
+	case ids := <-done:
+		if len(ids) != 1 || ids[0] != third {
+			t.Errorf("Tail($) = %v, want [%s]", ids, third)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Tail did not return after Add")
+	}
+}
+
+func TestWorker_MaintainPartitions(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	repo := &recordingRepository{}
+	worker := NewWorker(NewMemoryQueue(), repo, logger, "test-consumer", nil)
+
+	// Without a retention, partitions are created but none dropped
+	worker.maybeMaintainPartitions(ctx)
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if !repo.partitionsFrom.Equal(month) || !repo.partitionsThrough.Equal(month.AddDate(0, partitionMonthsAhead, 0)) {
+		t.Errorf("partitions created for %s..%s, want from %s", repo.partitionsFrom, repo.partitionsThrough, month)
+	}
+	if !repo.droppedBefore.IsZero() {
+		t.Errorf("partitions dropped before %s without a retention", repo.droppedBefore)
+	}
+
+	// Checked again only after the interval
+	worker.SetRawRetention(90 * 24 * time.Hour)
+	worker.maybeMaintainPartitions(ctx)
+	if !repo.droppedBefore.IsZero() {
+		t.Fatalf("partitions maintained again within the interval")
+	}
+
+	worker.lastPartitions = time.Time{}
+	worker.maybeMaintainPartitions(ctx)
+	if age := time.Since(repo.droppedBefore); age < 90*24*time.Hour || age > 91*24*time.Hour {
+		t.Errorf("partitions dropped before %s, want 90 days ago", repo.droppedBefore)
+	}
+}
@@ -0,0 +1,629 @@
+package analytics
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/penshort/penshort/internal/metrics"
+)
+
+// SyncPolicy controls when spooled events are fsynced to disk.
+type SyncPolicy string
+
+const (
+	// SyncAlways fsyncs after every event.
+	SyncAlways SyncPolicy = "always"
+
+	// SyncInterval fsyncs at most once per SpoolConfig.SyncInterval.
+	SyncInterval SyncPolicy = "interval"
+
+	// SyncNever leaves flushing to the operating system.
+	SyncNever SyncPolicy = "never"
+)
+
+// Spool defaults.
+const (
+	DefaultSpoolMaxBytes       = 256 << 20
+	DefaultSpoolSegmentBytes   = 8 << 20
+	DefaultSpoolSyncInterval   = time.Second
+	DefaultSpoolReplayInterval = 5 * time.Second
+
+	// spoolReplayBatch is the number of events read from a segment at a time.
+	spoolReplayBatch = 100
+
+	// spoolReplayTimeout bounds each XADD during a replay.
+	spoolReplayTimeout = time.Second
+
+	// spoolHeaderSize is the record header: body length, CRC-32 of the
+	// spooled-at time and body, and spooled-at in Unix milliseconds.
+	spoolHeaderSize = 16
+
+	spoolSegmentExt = ".seg"
+)
+
+var (
+	// ErrSpoolFull is returned when an event would grow the spool past its cap.
+	ErrSpoolFull = errors.New("spool full")
+
+	// ErrSpoolClosed is returned when appending to a closed spool.
+	ErrSpoolClosed = errors.New("spool closed")
+)
+
+// SpoolConfig configures a Spool.
+type SpoolConfig struct {
+	Dir            string
+	MaxBytes       int64 // Cap on all segments; events past it are dropped
+	SegmentBytes   int64 // A new segment is started once one reaches this size
+	Sync           SyncPolicy
+	SyncInterval   time.Duration // Used with SyncInterval
+	ReplayInterval time.Duration // Delay between replay attempts while Redis is down
+}
+
+// ParseSyncPolicy parses an fsync policy name.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch policy := SyncPolicy(s); policy {
+	case SyncAlways, SyncInterval, SyncNever:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown spool sync policy %q", s)
+	}
+}
+
+// Spool is a bounded on-disk append-only log of click events that could not
+// be added to the stream. Events are written to numbered segment files and
+// replayed onto the stream oldest first by Run; a segment is deleted once
+// every event in it has been replayed.
+//
+// Each spooled event keeps the event ID it was first published with, so an
+// event that reached the stream despite a failed add, or that is replayed
+// twice after a crash part way through a segment, is recorded once.
+type Spool struct {
+	cfg     SpoolConfig
+	logger  *slog.Logger
+	metrics metrics.Recorder
+
+	mu       sync.Mutex
+	segments []*spoolSegment // Oldest first; the last one takes appends
+	active   *os.File        // Append handle for the last segment, if open
+	bytes    int64           // Total size of all segments
+	depth    int64           // Events not yet replayed
+	nextSeq  uint64
+	dirty    bool // Written since the last fsync
+	closed   bool
+}
+
+// spoolSegment is one segment file.
+type spoolSegment struct {
+	seq     uint64
+	path    string
+	size    int64     // Bytes written
+	offset  int64     // Bytes replayed
+	records int64     // Events not yet replayed
+	headAt  time.Time // Spooled-at of the first event not yet replayed
+}
+
+// spoolRecord is a decoded spooled event.
+type spoolRecord struct {
+	eventID string
+	payload string
+	at      time.Time
+	size    int64
+}
+
+// OpenSpool opens or creates the spool in cfg.Dir. Existing segments are
+// scanned so that events spooled before a restart are replayed; a record
+// cut short by a crash is truncated away.
+func OpenSpool(cfg SpoolConfig, logger *slog.Logger, recorder metrics.Recorder) (*Spool, error) {
+	if cfg.Dir == "" {
+		return nil, errors.New("spool directory is required")
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultSpoolMaxBytes
+	}
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = DefaultSpoolSegmentBytes
+	}
+	if cfg.SegmentBytes > cfg.MaxBytes {
+		cfg.SegmentBytes = cfg.MaxBytes
+	}
+	if cfg.Sync == "" {
+		cfg.Sync = SyncInterval
+	}
+	if _, err := ParseSyncPolicy(string(cfg.Sync)); err != nil {
+		return nil, err
+	}
+	if cfg.SyncInterval <= 0 {
+		cfg.SyncInterval = DefaultSpoolSyncInterval
+	}
+	if cfg.ReplayInterval <= 0 {
+		cfg.ReplayInterval = DefaultSpoolReplayInterval
+	}
+	if recorder == nil {
+		recorder = metrics.NewNoop()
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create spool directory: %w", err)
+	}
+
+	s := &Spool{
+		cfg:     cfg,
+		logger:  logger.With("component", "analytics.spool"),
+		metrics: recorder,
+		nextSeq: 1,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	if s.depth > 0 {
+		s.logger.Info("spooled click events found", "events", s.depth, "segments", len(s.segments))
+	}
+	s.reportMetrics()
+	return s, nil
+}
+
+// load scans the segment files in the spool directory.
+func (s *Spool) load() error {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return fmt.Errorf("read spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolSegmentExt) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolSegmentExt), 10, 64)
+		if err != nil {
+			continue
+		}
+		seg, err := s.scanSegment(seq, filepath.Join(s.cfg.Dir, name))
+		if err != nil {
+			return err
+		}
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+		if seg.records == 0 {
+			if err := os.Remove(seg.path); err != nil {
+				return fmt.Errorf("remove empty spool segment: %w", err)
+			}
+			continue
+		}
+		s.segments = append(s.segments, seg)
+		s.bytes += seg.size
+		s.depth += seg.records
+	}
+
+	sort.Slice(s.segments, func(i, j int) bool {
+		return s.segments[i].seq < s.segments[j].seq
+	})
+	return nil
+}
+
+// scanSegment counts the complete records in a segment and truncates it
+// after the last one.
+func (s *Spool) scanSegment(seq uint64, path string) (*spoolSegment, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat spool segment: %w", err)
+	}
+
+	seg := &spoolSegment{seq: seq, path: path}
+	for seg.size < info.Size() {
+		rec, err := readSpoolRecord(f, seg.size)
+		if err != nil {
+			s.logger.Warn("truncating spool segment after incomplete record",
+				"segment", path,
+				"offset", seg.size,
+				"lost_bytes", info.Size()-seg.size,
+				"error", err,
+			)
+			if err := f.Truncate(seg.size); err != nil {
+				return nil, fmt.Errorf("truncate spool segment: %w", err)
+			}
+			break
+		}
+		if seg.records == 0 {
+			seg.headAt = rec.at
+		}
+		seg.size += rec.size
+		seg.records++
+	}
+	return seg, nil
+}
+
+// Append spools an event payload under eventID, the idempotency key it is
+// replayed with. It returns ErrSpoolFull if the event would grow the spool
+// past its cap.
+func (s *Spool) Append(eventID, payload string) error {
+	if eventID == "" {
+		return errors.New("spooled events need an event ID")
+	}
+	now := time.Now()
+	rec := encodeSpoolRecord(eventID, payload, now)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.closed {
+		return ErrSpoolClosed
+	}
+	if s.bytes+int64(len(rec)) > s.cfg.MaxBytes {
+		return ErrSpoolFull
+	}
+
+	seg, err := s.activeSegment(int64(len(rec)))
+	if err != nil {
+		return err
+	}
+	if _, err := s.active.Write(rec); err != nil {
+		// Drop a partial record so later appends stay readable
+		_ = s.active.Truncate(seg.size)
+		return fmt.Errorf("write spool segment: %w", err)
+	}
+	if seg.records == 0 {
+		seg.headAt = now
+	}
+	seg.size += int64(len(rec))
+	seg.records++
+	s.bytes += int64(len(rec))
+	s.depth++
+
+	if s.cfg.Sync == SyncAlways {
+		if err := s.active.Sync(); err != nil {
+			return fmt.Errorf("sync spool segment: %w", err)
+		}
+	} else {
+		s.dirty = true
+	}
+
+	s.metrics.SetAnalyticsSpoolDepth(s.depth)
+	return nil
+}
+
+// activeSegment returns the segment to append n bytes to, starting a new
+// one when there is none open or the open one is full. Callers hold s.mu.
+func (s *Spool) activeSegment(n int64) (*spoolSegment, error) {
+	if s.active != nil {
+		seg := s.segments[len(s.segments)-1]
+		if seg.size == 0 || seg.size+n <= s.cfg.SegmentBytes {
+			return seg, nil
+		}
+		if err := s.closeActive(); err != nil {
+			return nil, err
+		}
+	}
+
+	seg := &spoolSegment{
+		seq:  s.nextSeq,
+		path: filepath.Join(s.cfg.Dir, fmt.Sprintf("%020d%s", s.nextSeq, spoolSegmentExt)),
+	}
+	f, err := os.OpenFile(seg.path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("create spool segment: %w", err)
+	}
+	s.nextSeq++
+	s.active = f
+	s.segments = append(s.segments, seg)
+	return seg, nil
+}
+
+// closeActive syncs and closes the append handle. Callers hold s.mu.
+func (s *Spool) closeActive() error {
+	if s.active == nil {
+		return nil
+	}
+	f := s.active
+	s.active = nil
+	s.dirty = false
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("close spool segment: %w", err)
+	}
+	return nil
+}
+
+// Sync fsyncs events appended since the last sync.
+func (s *Spool) Sync() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil || !s.dirty {
+		return nil
+	}
+	if err := s.active.Sync(); err != nil {
+		return fmt.Errorf("sync spool segment: %w", err)
+	}
+	s.dirty = false
+	return nil
+}
+
+// Depth returns the number of events waiting to be replayed.
+func (s *Spool) Depth() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.depth
+}
+
+// Age returns how long the oldest waiting event has been spooled, or 0 if
+// the spool is empty.
+func (s *Spool) Age() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.age()
+}
+
+func (s *Spool) age() time.Duration {
+	for _, seg := range s.segments {
+		if seg.records > 0 {
+			return time.Since(seg.headAt)
+		}
+	}
+	return 0
+}
+
+// Run replays spooled events onto queue every ReplayInterval, and fsyncs
+// under the interval policy, until ctx is canceled.
+func (s *Spool) Run(ctx context.Context, queue Queue) error {
+	replay := time.NewTicker(s.cfg.ReplayInterval)
+	defer replay.Stop()
+
+	var syncC <-chan time.Time
+	if s.cfg.Sync == SyncInterval {
+		syncTicker := time.NewTicker(s.cfg.SyncInterval)
+		defer syncTicker.Stop()
+		syncC = syncTicker.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-syncC:
+			if err := s.Sync(); err != nil {
+				s.logger.Error("failed to sync spool", "error", err)
+			}
+		case <-replay.C:
+			if s.Depth() == 0 {
+				s.reportMetrics()
+				continue
+			}
+			replayed, err := s.Replay(ctx, queue)
+			if replayed > 0 {
+				s.logger.Info("replayed spooled click events",
+					"replayed", replayed,
+					"remaining", s.Depth(),
+				)
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				s.logger.Warn("spool replay stopped", "error", err)
+			}
+		}
+	}
+}
+
+// Replay adds spooled events to queue, oldest first, until the spool is
+// empty or an add fails. It returns the number of events replayed.
+func (s *Spool) Replay(ctx context.Context, queue Queue) (int, error) {
+	defer s.reportMetrics()
+
+	replayed := 0
+	for {
+		seg, records, err := s.nextBatch()
+		if err != nil {
+			return replayed, err
+		}
+		if seg == nil {
+			return replayed, nil
+		}
+
+		var consumed int64
+		n := 0
+		for _, rec := range records {
+			addCtx, cancel := context.WithTimeout(ctx, spoolReplayTimeout)
+			_, err = queue.Requeue(addCtx, rec.payload, rec.eventID)
+			cancel()
+			if err != nil {
+				break
+			}
+			consumed += rec.size
+			n++
+		}
+
+		if advanceErr := s.advance(seg, consumed, int64(n)); advanceErr != nil && err == nil {
+			err = advanceErr
+		}
+		replayed += n
+		if err != nil {
+			return replayed, err
+		}
+	}
+}
+
+// nextBatch reads up to spoolReplayBatch events from the oldest segment.
+// It returns a nil segment when the spool is empty.
+func (s *Spool) nextBatch() (*spoolSegment, []spoolRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.segments) == 0 || s.depth == 0 {
+		return nil, nil, nil
+	}
+	seg := s.segments[0]
+
+	f, err := os.Open(seg.path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("open spool segment: %w", err)
+	}
+	defer f.Close()
+
+	var records []spoolRecord
+	for offset := seg.offset; offset < seg.size && len(records) < spoolReplayBatch; {
+		rec, err := readSpoolRecord(f, offset)
+		if err != nil {
+			// Checked when written or loaded; give up on the rest of the
+			// segment rather than retrying it forever
+			s.logger.Error("discarding unreadable spool segment",
+				"segment", seg.path,
+				"lost_events", seg.records-int64(len(records)),
+				"error", err,
+			)
+			if len(s.segments) == 1 && s.active != nil {
+				if err := s.closeActive(); err != nil {
+					return nil, nil, err
+				}
+			}
+			s.depth -= seg.records - int64(len(records))
+			s.bytes -= seg.size - offset
+			seg.records = int64(len(records))
+			seg.size = offset
+			break
+		}
+		records = append(records, rec)
+		offset += rec.size
+	}
+	return seg, records, nil
+}
+
+// advance marks n events (size bytes) of seg as replayed and deletes the
+// segment once it has been fully replayed.
+func (s *Spool) advance(seg *spoolSegment, size, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seg.offset += size
+	seg.records -= n
+	s.depth -= n
+	if seg.records > 0 {
+		f, err := os.Open(seg.path)
+		if err != nil {
+			return fmt.Errorf("open spool segment: %w", err)
+		}
+		defer f.Close()
+		rec, err := readSpoolRecord(f, seg.offset)
+		if err != nil {
+			return err
+		}
+		seg.headAt = rec.at
+		return nil
+	}
+	if seg.offset < seg.size {
+		return nil
+	}
+
+	// Fully replayed; the segment may still be taking appends
+	if len(s.segments) == 1 && s.active != nil {
+		if err := s.closeActive(); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(seg.path); err != nil {
+		return fmt.Errorf("remove spool segment: %w", err)
+	}
+	s.segments = s.segments[1:]
+	s.bytes -= seg.size
+	return nil
+}
+
+// Close fsyncs and closes the spool. Events not yet replayed stay on disk
+// and are replayed after the next OpenSpool.
+func (s *Spool) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	return s.closeActive()
+}
+
+func (s *Spool) reportMetrics() {
+	s.mu.Lock()
+	depth, age := s.depth, s.age()
+	s.mu.Unlock()
+
+	s.metrics.SetAnalyticsSpoolDepth(depth)
+	s.metrics.SetAnalyticsSpoolAge(age)
+}
+
+// encodeSpoolRecord frames an event as a header followed by
+// "<event ID>\n<payload>".
+func encodeSpoolRecord(eventID, payload string, at time.Time) []byte {
+	body := len(eventID) + 1 + len(payload)
+	buf := make([]byte, spoolHeaderSize+body)
+	binary.BigEndian.PutUint32(buf[0:4], uint32(body))
+	binary.BigEndian.PutUint64(buf[8:16], uint64(at.UnixMilli()))
+	copy(buf[spoolHeaderSize:], eventID)
+	buf[spoolHeaderSize+len(eventID)] = '\n'
+	copy(buf[spoolHeaderSize+len(eventID)+1:], payload)
+	binary.BigEndian.PutUint32(buf[4:8], crc32.ChecksumIEEE(buf[8:]))
+	return buf
+}
+
+// readSpoolRecord reads and checks the record at offset.
+func readSpoolRecord(r io.ReaderAt, offset int64) (spoolRecord, error) {
+	var header [spoolHeaderSize]byte
+	if _, err := r.ReadAt(header[:], offset); err != nil {
+		return spoolRecord{}, fmt.Errorf("read spool record header: %w", err)
+	}
+	bodyLen := binary.BigEndian.Uint32(header[0:4])
+	if int64(bodyLen) > DefaultSpoolMaxBytes {
+		return spoolRecord{}, fmt.Errorf("spool record too large: %d bytes", bodyLen)
+	}
+	body := make([]byte, bodyLen)
+	if _, err := r.ReadAt(body, offset+spoolHeaderSize); err != nil {
+		return spoolRecord{}, fmt.Errorf("read spool record: %w", err)
+	}
+
+	crc := crc32.NewIEEE()
+	crc.Write(header[8:16])
+	crc.Write(body)
+	if crc.Sum32() != binary.BigEndian.Uint32(header[4:8]) {
+		return spoolRecord{}, errors.New("spool record checksum mismatch")
+	}
+
+	eventID, payload, ok := strings.Cut(string(body), "\n")
+	if !ok || eventID == "" {
+		return spoolRecord{}, errors.New("spool record has no event ID")
+	}
+	return spoolRecord{
+		eventID: eventID,
+		payload: payload,
+		at:      time.UnixMilli(int64(binary.BigEndian.Uint64(header[8:16]))),
+		size:    spoolHeaderSize + int64(bodyLen),
+	}, nil
+}
+
+// newEventID returns an event ID for an event that may be spooled. It is
+// distinct from Redis stream IDs, so it cannot collide with events added
+// without one.
+func newEventID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate event id: %w", err)
+	}
+	return "evt-" + hex.EncodeToString(b[:]), nil
+}
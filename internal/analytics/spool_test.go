@@ -0,0 +1,276 @@
+package analytics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/metrics"
+)
+
+// flakyQueue is a MemoryQueue whose adds fail once failAfter adds succeed.
+type flakyQueue struct {
+	*MemoryQueue
+	mu        sync.Mutex
+	failAfter int // -1 never fails
+}
+
+func (q *flakyQueue) allow() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.failAfter == 0 {
+		return errors.New("redis unavailable")
+	}
+	if q.failAfter > 0 {
+		q.failAfter--
+	}
+	return nil
+}
+
+func (q *flakyQueue) Add(ctx context.Context, payload string) (string, error) {
+	if err := q.allow(); err != nil {
+		return "", err
+	}
+	return q.MemoryQueue.Add(ctx, payload)
+}
+
+func (q *flakyQueue) Requeue(ctx context.Context, payload, eventID string) (string, error) {
+	if err := q.allow(); err != nil {
+		return "", err
+	}
+	return q.MemoryQueue.Requeue(ctx, payload, eventID)
+}
+
+// lossyAckQueue is a MemoryQueue whose adds are stored but then reported as
+// failed, like an XADD that Redis applied after the client timed out.
+type lossyAckQueue struct {
+	*MemoryQueue
+}
+
+func (q *lossyAckQueue) Add(ctx context.Context, payload string) (string, error) {
+	_, _ = q.MemoryQueue.Add(ctx, payload)
+	return "", context.DeadlineExceeded
+}
+
+func (q *lossyAckQueue) Requeue(ctx context.Context, payload, eventID string) (string, error) {
+	_, _ = q.MemoryQueue.Requeue(ctx, payload, eventID)
+	return "", context.DeadlineExceeded
+}
+
+func openTestSpool(t *testing.T, cfg SpoolConfig) *Spool {
+	t.Helper()
+	s, err := OpenSpool(cfg, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func segmentFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*"+spoolSegmentExt))
+	if err != nil {
+		t.Fatalf("glob: %v", err)
+	}
+	return files
+}
+
+func TestSpool_ReplayAfterOutage(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	// Room for two records per segment
+	s := openTestSpool(t, SpoolConfig{Dir: dir, SegmentBytes: 2 * int64(len(encodeSpoolRecord("evt-a", "a", time.Now())))})
+
+	for _, payload := range []string{"a", "b", "c"} {
+		if err := s.Append("evt-"+payload, payload); err != nil {
+			t.Fatalf("Append(%s): %v", payload, err)
+		}
+	}
+	if files := segmentFiles(t, dir); len(files) != 2 {
+		t.Fatalf("segments = %v, want 2", files)
+	}
+	if s.Depth() != 3 || s.Age() <= 0 {
+		t.Fatalf("Depth() = %d, Age() = %v; want 3 and a positive age", s.Depth(), s.Age())
+	}
+
+	// Redis still down after one add
+	q := &flakyQueue{MemoryQueue: NewMemoryQueue(), failAfter: 1}
+	replayed, err := s.Replay(ctx, q)
+	if err == nil || replayed != 1 || s.Depth() != 2 {
+		t.Fatalf("Replay() = %d, %v, depth %d; want 1 replayed and an error", replayed, err, s.Depth())
+	}
+
+	q.failAfter = -1
+	replayed, err = s.Replay(ctx, q)
+	if err != nil || replayed != 2 {
+		t.Fatalf("Replay() = %d, %v; want the other 2", replayed, err)
+	}
+	if s.Depth() != 0 || s.Age() != 0 {
+		t.Errorf("Depth() = %d, Age() = %v after replay; want 0", s.Depth(), s.Age())
+	}
+	if files := segmentFiles(t, dir); len(files) != 0 {
+		t.Errorf("segments = %v after replay, want none", files)
+	}
+
+	msgs, _ := q.Read(ctx, "c1", 10, 0)
+	var payloads []string
+	seen := map[string]bool{}
+	for _, msg := range msgs {
+		payloads = append(payloads, msg.Values["payload"].(string))
+		id := messageEventID(msg)
+		if id != "evt-"+msg.Values["payload"].(string) || seen[id] {
+			t.Errorf("event ID %q, want the spooled ID", id)
+		}
+		seen[id] = true
+	}
+	if strings.Join(payloads, ",") != "a,b,c" {
+		t.Errorf("payloads = %v, want a,b,c in order", payloads)
+	}
+
+	// The spool keeps working once emptied
+	if err := s.Append("evt-d", "d"); err != nil || s.Depth() != 1 {
+		t.Fatalf("Append after replay = %v, depth %d", err, s.Depth())
+	}
+}
+
+func TestSpool_Reopen(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := OpenSpool(SpoolConfig{Dir: dir, Sync: SyncAlways}, slog.New(slog.NewTextHandler(io.Discard, nil)), nil)
+	if err != nil {
+		t.Fatalf("OpenSpool: %v", err)
+	}
+	_ = s.Append("evt-a", "a")
+	_ = s.Append("evt-b", "b")
+	if err := s.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if err := s.Append("evt-c", "c"); !errors.Is(err, ErrSpoolClosed) {
+		t.Errorf("Append after Close = %v, want ErrSpoolClosed", err)
+	}
+
+	// Simulate a crash part way through a write
+	files := segmentFiles(t, dir)
+	f, err := os.OpenFile(files[0], os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatalf("open segment: %v", err)
+	}
+	_, _ = f.Write(encodeSpoolRecord("evt-x", "torn", time.Now())[:20])
+	f.Close()
+
+	recorder := metrics.NewInMemory()
+	s, err = OpenSpool(SpoolConfig{Dir: dir}, slog.New(slog.NewTextHandler(io.Discard, nil)), recorder)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	if s.Depth() != 2 || recorder.Snapshot().AnalyticsSpoolDepth != 2 {
+		t.Fatalf("Depth() = %d after reopen, want 2", s.Depth())
+	}
+
+	// New events go to a new segment, after the old ones
+	_ = s.Append("evt-c", "c")
+	q := NewMemoryQueue()
+	if replayed, err := s.Replay(ctx, q); err != nil || replayed != 3 {
+		t.Fatalf("Replay() = %d, %v; want 3", replayed, err)
+	}
+	msgs, _ := q.Read(ctx, "c1", 10, 0)
+	if len(msgs) != 3 || msgs[0].Values["payload"] != "a" || msgs[2].Values["payload"] != "c" {
+		t.Errorf("replayed = %v, want a, b, c", msgs)
+	}
+}
+
+func TestSpool_MaxBytes(t *testing.T) {
+	t.Parallel()
+
+	s := openTestSpool(t, SpoolConfig{Dir: t.TempDir(), MaxBytes: 2 * int64(len(encodeSpoolRecord("evt-x", "x", time.Now())))})
+	for i := 0; i < 2; i++ {
+		if err := s.Append("evt-x", "x"); err != nil {
+			t.Fatalf("Append %d: %v", i, err)
+		}
+	}
+	if err := s.Append("evt-x", "x"); !errors.Is(err, ErrSpoolFull) {
+		t.Fatalf("Append past cap = %v, want ErrSpoolFull", err)
+	}
+
+	// Replay frees space
+	if _, err := s.Replay(context.Background(), NewMemoryQueue()); err != nil {
+		t.Fatalf("Replay: %v", err)
+	}
+	if err := s.Append("evt-x", "x"); err != nil {
+		t.Errorf("Append after replay = %v", err)
+	}
+}
+
+func TestPublisher_SpoolsWhenQueueFails(t *testing.T) {
+	t.Parallel()
+
+	recorder := metrics.NewInMemory()
+	q := &flakyQueue{MemoryQueue: NewMemoryQueue()}
+	p := NewPublisher(q, slog.New(slog.NewTextHandler(io.Discard, nil)), recorder)
+
+	// Without a spool the event is dropped
+	p.spoolOrDrop(ClickEventPayload{ShortCode: "abc"}, "", `{"sc":"abc"}`, errors.New("timeout"))
+	if snap := recorder.Snapshot(); snap.AnalyticsEventsDropped != 1 {
+		t.Errorf("dropped = %d, want 1", snap.AnalyticsEventsDropped)
+	}
+
+	s := openTestSpool(t, SpoolConfig{Dir: t.TempDir()})
+	p.SetSpool(s)
+	p.PublishAsync(ClickEventPayload{ShortCode: "abc"})
+
+	deadline := time.Now().Add(time.Second)
+	for recorder.Snapshot().AnalyticsEventsSpooled == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if snap := recorder.Snapshot(); snap.AnalyticsEventsSpooled != 1 || s.Depth() != 1 {
+		t.Fatalf("spooled = %d, depth %d; want 1", snap.AnalyticsEventsSpooled, s.Depth())
+	}
+}
+
+func TestPublisher_SpooledEventKeepsEventID(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	recorder := metrics.NewInMemory()
+	mem := NewMemoryQueue()
+	p := NewPublisher(&lossyAckQueue{MemoryQueue: mem}, slog.New(slog.NewTextHandler(io.Discard, nil)), recorder)
+	s := openTestSpool(t, SpoolConfig{Dir: t.TempDir()})
+	p.SetSpool(s)
+
+	p.PublishAsync(ClickEventPayload{ShortCode: "abc"})
+	deadline := time.Now().Add(time.Second)
+	for recorder.Snapshot().AnalyticsEventsSpooled == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if s.Depth() != 1 {
+		t.Fatalf("depth = %d, want the timed-out event spooled", s.Depth())
+	}
+
+	if replayed, err := s.Replay(ctx, mem); err != nil || replayed != 1 {
+		t.Fatalf("Replay() = %d, %v; want 1", replayed, err)
+	}
+
+	// The stream now holds the event twice, under one event ID, so the
+	// worker records it once
+	msgs, _ := mem.Read(ctx, "c1", 10, 0)
+	if len(msgs) != 2 {
+		t.Fatalf("stream has %d messages, want 2", len(msgs))
+	}
+	first, second := messageEventID(msgs[0]), messageEventID(msgs[1])
+	if first != second || !strings.HasPrefix(first, "evt-") {
+		t.Errorf("event IDs = %q, %q; want the same generated ID", first, second)
+	}
+}
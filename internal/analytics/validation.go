@@ -1,13 +1,19 @@
 // Package analytics provides click event capture and processing.
 package analytics
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/penshort/penshort/internal/hll"
+)
 
 const (
 	minShortCodeLength = 3
 	maxShortCodeLength = 50
 	maxMetaLength      = 500
 	visitorHashLength  = 16
+	maxRegionLength    = 6 // "US-CA", "GB-ENG"
+	maxCityLength      = 100
 )
 
 // ValidateClickEventPayload validates click event payload fields.
@@ -27,9 +33,18 @@ func ValidateClickEventPayload(payload ClickEventPayload) error {
 	if len(payload.VisitorHash) != visitorHashLength || !isHex(payload.VisitorHash) {
 		return fmt.Errorf("visitor_hash must be %d hex chars", visitorHashLength)
 	}
+	if payload.VisitorReg != 0 && !hll.Register(payload.VisitorReg).Valid() {
+		return fmt.Errorf("visitor_register out of range")
+	}
 	if payload.CountryCode != "" && len(payload.CountryCode) != 2 {
 		return fmt.Errorf("country_code must be 2 chars")
 	}
+	if len(payload.Region) > maxRegionLength {
+		return fmt.Errorf("region too long")
+	}
+	if len(payload.City) > maxCityLength {
+		return fmt.Errorf("city too long")
+	}
 	if payload.ClickedAt <= 0 {
 		return fmt.Errorf("clicked_at must be set")
 	}
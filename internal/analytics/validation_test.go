@@ -30,6 +30,7 @@ func TestValidateClickEventPayload(t *testing.T) {
 		{"missing_visitor_hash", ClickEventPayload{ShortCode: "abc", LinkID: "link", ClickedAt: 1}},
 		{"invalid_visitor_hash", ClickEventPayload{ShortCode: "abc", LinkID: "link", VisitorHash: "not-hex", ClickedAt: 1}},
 		{"invalid_country_code", ClickEventPayload{ShortCode: "abc", LinkID: "link", VisitorHash: "0123456789abcdef", CountryCode: "USA", ClickedAt: 1}},
+		{"invalid_visitor_register", ClickEventPayload{ShortCode: "abc", LinkID: "link", VisitorHash: "0123456789abcdef", VisitorReg: 1 << 30, ClickedAt: 1}},
 		{"missing_clicked_at", ClickEventPayload{ShortCode: "abc", LinkID: "link", VisitorHash: "0123456789abcdef"}},
 	}
 
@@ -7,11 +7,13 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 
+	"github.com/penshort/penshort/internal/hll"
 	"github.com/penshort/penshort/internal/metrics"
 	"github.com/penshort/penshort/internal/model"
 )
@@ -37,12 +39,22 @@ const (
 
 	// DefaultMetricsInterval is how often to refresh queue depth metrics.
 	DefaultMetricsInterval = 5 * time.Second
+
+	// DefaultPartitionInterval is how often to create upcoming click event
+	// partitions and drop expired ones.
+	DefaultPartitionInterval = time.Hour
+
+	// partitionMonthsAhead is how many months past the current one have
+	// click event partitions created in advance.
+	partitionMonthsAhead = 3
 )
 
 // Repository defines the interface for click event persistence.
 type Repository interface {
-	BulkInsert(ctx context.Context, events []*model.ClickEvent) error
-	UpdateDailyStats(ctx context.Context, events []*model.ClickEvent) error
+	RecordClickEvents(ctx context.Context, events []*model.ClickEvent) ([]*model.ClickEvent, error)
+	PruneHourlyStats(ctx context.Context, before time.Time) (int, error)
+	CreateClickPartitions(ctx context.Context, from, through time.Time) (int, error)
+	DropClickPartitions(ctx context.Context, before time.Time) (int, error)
 }
 
 // WebhookPublisher creates webhook deliveries for click events.
@@ -50,9 +62,9 @@ type WebhookPublisher interface {
 	PublishClickEvent(ctx context.Context, userID string, click *model.ClickEvent) error
 }
 
-// Worker processes click events from Redis stream.
+// Worker processes click events from the analytics queue.
 type Worker struct {
-	redis           *redis.Client
+	queue           Queue
 	repo            Repository
 	logger          *slog.Logger
 	metrics         metrics.Recorder
@@ -66,6 +78,12 @@ type Worker struct {
 	claimStartID    string
 	lastClaim       time.Time
 	lastMetrics     time.Time
+	hourlyRetention time.Duration
+	pruneInterval   time.Duration
+	lastPrune       time.Time
+	rawRetention    time.Duration
+	partitionInterval time.Duration
+	lastPartitions  time.Time
 	webhookPublisher WebhookPublisher
 
 	started  bool
@@ -76,12 +94,12 @@ type Worker struct {
 }
 
 // NewWorker creates a new analytics worker.
-func NewWorker(client *redis.Client, repo Repository, logger *slog.Logger, consumerID string, recorder metrics.Recorder) *Worker {
+func NewWorker(queue Queue, repo Repository, logger *slog.Logger, consumerID string, recorder metrics.Recorder) *Worker {
 	if recorder == nil {
 		recorder = metrics.NewNoop()
 	}
 	return &Worker{
-		redis:           client,
+		queue:           queue,
 		repo:            repo,
 		logger:          logger.With("component", "analytics.worker", "consumer_id", consumerID),
 		metrics:         recorder,
@@ -92,10 +110,24 @@ func NewWorker(client *redis.Client, repo Repository, logger *slog.Logger, consu
 		claimInterval:   DefaultClaimInterval,
 		claimIdle:       DefaultClaimIdle,
 		metricsInterval: DefaultMetricsInterval,
+		partitionInterval: DefaultPartitionInterval,
 		claimStartID:    "0-0",
 	}
 }
 
+// SetHourlyPrune enables pruning hourly stats older than retention, checked
+// every interval. A zero interval disables pruning.
+func (w *Worker) SetHourlyPrune(retention, interval time.Duration) {
+	w.hourlyRetention = retention
+	w.pruneInterval = interval
+}
+
+// SetRawRetention drops raw click events once their whole UTC month is
+// older than retention; daily stats are kept. Zero keeps raw events forever.
+func (w *Worker) SetRawRetention(retention time.Duration) {
+	w.rawRetention = retention
+}
+
 // SetWebhookPublisher configures the optional webhook publisher.
 func (w *Worker) SetWebhookPublisher(publisher WebhookPublisher) {
 	w.webhookPublisher = publisher
@@ -116,7 +148,7 @@ func (w *Worker) Run(ctx context.Context) error {
 	defer close(w.done)
 
 	// Ensure consumer group exists
-	if err := w.ensureConsumerGroup(ctx); err != nil {
+	if err := w.queue.EnsureGroup(ctx); err != nil {
 		return fmt.Errorf("ensure consumer group: %w", err)
 	}
 
@@ -182,18 +214,11 @@ func (w *Worker) Shutdown(ctx context.Context) error {
 	return nil
 }
 
-// ensureConsumerGroup creates the consumer group if it doesn't exist.
-func (w *Worker) ensureConsumerGroup(ctx context.Context) error {
-	err := w.redis.XGroupCreateMkStream(ctx, StreamKey, ConsumerGroup, "0").Err()
-	if err != nil && !isConsumerGroupExistsError(err) {
-		return err
-	}
-	return nil
-}
-
 // processOnce reads and processes a single batch.
 func (w *Worker) processOnce(ctx context.Context) error {
 	w.maybeUpdateQueueDepth(ctx)
+	w.maybePruneHourly(ctx)
+	w.maybeMaintainPartitions(ctx)
 
 	claimed, err := w.maybeClaimPending(ctx)
 	if err != nil {
@@ -241,16 +266,9 @@ func (w *Worker) maybeClaimPending(ctx context.Context) ([]redis.XMessage, error
 	}
 
 	w.lastClaim = time.Now()
-	messages, start, err := w.redis.XAutoClaim(ctx, &redis.XAutoClaimArgs{
-		Stream:   StreamKey,
-		Group:    ConsumerGroup,
-		Consumer: w.consumerID,
-		MinIdle:  w.claimIdle,
-		Start:    w.claimStartID,
-		Count:    int64(w.batchSize),
-	}).Result()
-	if err != nil && err != redis.Nil {
-		return nil, fmt.Errorf("xautoclaim: %w", err)
+	messages, start, err := w.queue.Claim(ctx, w.consumerID, w.claimIdle, w.claimStartID, w.batchSize)
+	if err != nil {
+		return nil, err
 	}
 	if start != "" {
 		w.claimStartID = start
@@ -270,16 +288,68 @@ func (w *Worker) maybeUpdateQueueDepth(ctx context.Context) {
 	}
 	w.lastMetrics = time.Now()
 
-	groups, err := w.redis.XInfoGroups(ctx, StreamKey).Result()
-	if err != nil && err != redis.Nil {
-		w.logger.Warn("failed to read stream group info", "error", err)
+	depth, err := w.queue.Depth(ctx)
+	if err != nil {
+		w.logger.Warn("failed to read queue depth", "error", err)
 		return
 	}
-	for _, group := range groups {
-		if group.Name == ConsumerGroup {
-			w.metrics.SetAnalyticsQueueDepth(group.Pending + group.Lag)
-			return
-		}
+	w.metrics.SetAnalyticsQueueDepth(depth)
+
+	deadLetters, err := w.queue.DeadLetterDepth(ctx)
+	if err != nil {
+		w.logger.Warn("failed to read dead-letter queue depth", "error", err)
+		return
+	}
+	w.metrics.SetAnalyticsDeadLetterDepth(deadLetters)
+}
+
+func (w *Worker) maybePruneHourly(ctx context.Context) {
+	if w.pruneInterval <= 0 || w.hourlyRetention <= 0 {
+		return
+	}
+	if !w.lastPrune.IsZero() && time.Since(w.lastPrune) < w.pruneInterval {
+		return
+	}
+	w.lastPrune = time.Now()
+
+	pruned, err := w.repo.PruneHourlyStats(ctx, time.Now().Add(-w.hourlyRetention))
+	if err != nil {
+		w.logger.Warn("failed to prune hourly stats", "error", err)
+		return
+	}
+	if pruned > 0 {
+		w.logger.Info("hourly stats pruned", "link_days", pruned)
+	}
+}
+
+func (w *Worker) maybeMaintainPartitions(ctx context.Context) {
+	if w.partitionInterval <= 0 {
+		return
+	}
+	if !w.lastPartitions.IsZero() && time.Since(w.lastPartitions) < w.partitionInterval {
+		return
+	}
+	w.lastPartitions = time.Now()
+
+	now := time.Now().UTC()
+	month := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	created, err := w.repo.CreateClickPartitions(ctx, month, month.AddDate(0, partitionMonthsAhead, 0))
+	if err != nil {
+		w.logger.Warn("failed to create click event partitions", "error", err)
+	} else if created > 0 {
+		w.logger.Info("click event partitions created", "months", created)
+	}
+
+	if w.rawRetention <= 0 {
+		return
+	}
+	dropped, err := w.repo.DropClickPartitions(ctx, now.Add(-w.rawRetention))
+	if err != nil {
+		w.logger.Warn("failed to drop expired click event partitions", "error", err)
+		return
+	}
+	if dropped > 0 {
+		w.logger.Info("expired click event partitions dropped", "months", dropped)
 	}
 }
 
@@ -318,24 +388,9 @@ func (w *Worker) SetMetricsInterval(interval time.Duration) {
 	}
 }
 
-// readBatch reads messages from the stream using XREADGROUP.
+// readBatch reads the next batch of new messages from the queue.
 func (w *Worker) readBatch(ctx context.Context) ([]redis.XMessage, error) {
-	streams, err := w.redis.XReadGroup(ctx, &redis.XReadGroupArgs{
-		Group:    ConsumerGroup,
-		Consumer: w.consumerID,
-		Streams:  []string{StreamKey, ">"},
-		Count:    int64(w.batchSize),
-		Block:    w.blockTimeout,
-	}).Result()
-
-	if err == redis.Nil || len(streams) == 0 {
-		return nil, nil
-	}
-	if err != nil {
-		return nil, fmt.Errorf("xreadgroup: %w", err)
-	}
-
-	return streams[0].Messages, nil
+	return w.queue.Read(ctx, w.consumerID, w.batchSize, w.blockTimeout)
 }
 
 // parseMessages converts Redis messages to ClickEvent models.
@@ -365,15 +420,21 @@ func (w *Worker) parseMessages(ctx context.Context, messages []redis.XMessage) (
 
 		event := &model.ClickEvent{
 			ID:          generateULID(),
-			EventID:     msg.ID, // Redis stream ID = idempotency key
+			EventID:     messageEventID(msg), // Idempotency key
 			ShortCode:   eventPayload.ShortCode,
 			LinkID:      eventPayload.LinkID,
 			OwnerID:     eventPayload.OwnerID,
 			Referrer:    eventPayload.Referrer,
 			UserAgent:   eventPayload.UserAgent,
 			VisitorHash: eventPayload.VisitorHash,
+			VisitorReg:  hll.Register(eventPayload.VisitorReg),
 			CountryCode: eventPayload.CountryCode,
+			Region:      eventPayload.Region,
+			City:        eventPayload.City,
 			ClickedAt:   time.UnixMilli(eventPayload.ClickedAt),
+			Blocked:     eventPayload.Blocked,
+			IsBot:       eventPayload.IsBot,
+			BotFamily:   eventPayload.BotFamily,
 		}
 
 		events = append(events, event)
@@ -382,6 +443,15 @@ func (w *Worker) parseMessages(ctx context.Context, messages []redis.XMessage) (
 	return events, messageIDs
 }
 
+// messageEventID returns a message's idempotency key: its stream ID, or
+// the original message's ID for a requeued dead letter.
+func messageEventID(msg redis.XMessage) string {
+	if eventID, ok := msg.Values["event_id"].(string); ok && eventID != "" {
+		return eventID
+	}
+	return msg.ID
+}
+
 // deadLetterMessage moves a poison message to the dead-letter queue.
 func (w *Worker) deadLetterMessage(ctx context.Context, msg redis.XMessage, reason, detail string) {
 	w.logger.Warn("dead-lettering poison message",
@@ -390,21 +460,15 @@ func (w *Worker) deadLetterMessage(ctx context.Context, msg redis.XMessage, reas
 		"detail", detail,
 	)
 
-	// Write to dead-letter stream with metadata
-	_, err := w.redis.XAdd(ctx, &redis.XAddArgs{
-		Stream: DeadLetterStreamKey,
-		MaxLen: 10000, // Keep last 10k poison messages
-		Approx: true,
-		ID:     "*",
-		Values: map[string]interface{}{
-			"original_id":    msg.ID,
-			"original_stream": StreamKey,
-			"reason":         reason,
-			"detail":         detail,
-			"payload":        msg.Values["payload"],
-			"dead_lettered_at": time.Now().UTC().Format(time.RFC3339),
-		},
-	}).Result()
+	// Write to dead-letter queue with metadata
+	err := w.queue.DeadLetter(ctx, map[string]interface{}{
+		"original_id":    messageEventID(msg),
+		"original_stream": StreamKey,
+		"reason":         reason,
+		"detail":         detail,
+		"payload":        msg.Values["payload"],
+		"dead_lettered_at": time.Now().UTC().Format(time.RFC3339),
+	})
 
 	if err != nil {
 		w.logger.Error("failed to write to dead-letter queue",
@@ -447,30 +511,24 @@ func (w *Worker) processBatchWithRetry(ctx context.Context, events []*model.Clic
 	return lastErr
 }
 
-// processBatch inserts events and updates daily stats.
+// processBatch records events, merging them into the daily and hourly
+// stats, and publishes their webhooks.
 func (w *Worker) processBatch(ctx context.Context, events []*model.ClickEvent) error {
 	start := time.Now()
 
-	// Bulk insert with ON CONFLICT DO NOTHING for idempotency
-	if err := w.repo.BulkInsert(ctx, events); err != nil {
-		w.logger.Error("bulk insert failed",
+	// Events already recorded by an earlier delivery are skipped
+	recorded, err := w.repo.RecordClickEvents(ctx, events)
+	if err != nil {
+		w.logger.Error("failed to record click events",
 			"batch_size", len(events),
 			"first_event_id", events[0].EventID,
 			"error", err,
 		)
-		return fmt.Errorf("bulk insert: %w", err)
-	}
-
-	// Update daily aggregations
-	if err := w.repo.UpdateDailyStats(ctx, events); err != nil {
-		w.logger.Error("failed to update daily stats",
-			"batch_size", len(events),
-			"error", err,
-		)
-		return fmt.Errorf("update daily stats: %w", err)
+		return fmt.Errorf("record click events: %w", err)
 	}
 
-	if err := w.publishWebhooks(ctx, events); err != nil {
+	// Only new events get webhooks, so a redelivered batch sends none twice
+	if err := w.publishWebhooks(ctx, recorded); err != nil {
 		w.logger.Error("failed to publish webhooks",
 			"batch_size", len(events),
 			"error", err,
@@ -480,6 +538,7 @@ func (w *Worker) processBatch(ctx context.Context, events []*model.ClickEvent) e
 
 	w.logger.Info("batch processed",
 		"events_count", len(events),
+		"duplicates", len(events)-len(recorded),
 		"duration_ms", float64(time.Since(start).Microseconds())/1000,
 	)
 
@@ -498,7 +557,8 @@ func (w *Worker) publishWebhooks(ctx context.Context, events []*model.ClickEvent
 		return nil
 	}
 	for _, event := range events {
-		if event.OwnerID == "" {
+		// Geo-blocked attempts are not clicks
+		if event.OwnerID == "" || event.Blocked {
 			continue
 		}
 		if err := w.webhookPublisher.PublishClickEvent(ctx, event.OwnerID, event); err != nil {
@@ -514,12 +574,7 @@ func (w *Worker) ackMessages(ctx context.Context, messageIDs []string) error {
 		return nil
 	}
 
-	_, err := w.redis.XAck(ctx, StreamKey, ConsumerGroup, messageIDs...).Result()
-	if err != nil {
-		return fmt.Errorf("xack: %w", err)
-	}
-
-	return nil
+	return w.queue.Ack(ctx, messageIDs...)
 }
 
 // isConsumerGroupExistsError checks if the error is "BUSYGROUP" (group exists).
@@ -528,6 +583,13 @@ func isConsumerGroupExistsError(err error) bool {
 		err.Error() == "BUSYGROUP")
 }
 
+// isNoGroupError checks if the error is "NOGROUP": the stream or consumer
+// group does not exist, as happens when a failover promotes a replica that
+// had not yet received them.
+func isNoGroupError(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "NOGROUP")
+}
+
 // generateULID generates a ULID-like unique ID.
 // Uses timestamp + random suffix for uniqueness.
 func generateULID() string {
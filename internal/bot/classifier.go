@@ -0,0 +1,264 @@
+// Package bot classifies redirect requests made by crawlers, link unfurlers
+// and other automated clients.
+package bot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Behaviour-based families, used when no User-Agent signature matches.
+const (
+	FamilyEmptyUA  = "empty_user_agent"
+	FamilyHead     = "head_request"
+	FamilyPrefetch = "prefetch"
+)
+
+// Signature maps a case-insensitive User-Agent substring to a bot family.
+type Signature struct {
+	Family  string
+	Pattern string
+}
+
+// Result is the outcome of classifying a request.
+type Result struct {
+	IsBot  bool
+	Family string
+}
+
+// builtinSignatures is checked after any signatures loaded from a file.
+// Specific families come before the generic catch-alls.
+var builtinSignatures = []Signature{
+	// Link unfurlers
+	{"slack", "slackbot"},
+	{"slack", "slack-imgproxy"},
+	{"twitter", "twitterbot"},
+	{"facebook", "facebookexternalhit"},
+	{"facebook", "facebookcatalog"},
+	{"facebook", "facebot"},
+	{"linkedin", "linkedinbot"},
+	{"discord", "discordbot"},
+	{"telegram", "telegrambot"},
+	{"whatsapp", "whatsapp"},
+	{"skype", "skypeuripreview"},
+	{"microsoft_teams", "microsoftpreview"},
+	{"pinterest", "pinterest"},
+	{"redditbot", "redditbot"},
+	{"embedly", "embedly"},
+	{"iframely", "iframely"},
+
+	// Email security scanners
+	{"email_scanner", "barracuda"},
+	{"email_scanner", "mimecast"},
+	{"email_scanner", "proofpoint"},
+	{"email_scanner", "ironport"},
+	{"email_scanner", "forcepoint"},
+
+	// Search engines
+	{"google", "googlebot"},
+	{"google", "adsbot-google"},
+	{"google", "mediapartners-google"},
+	{"google", "google-inspectiontool"},
+	{"google", "googleother"},
+	{"bing", "bingbot"},
+	{"bing", "bingpreview"},
+	{"apple", "applebot"},
+	{"yandex", "yandex"},
+	{"baidu", "baiduspider"},
+	{"duckduckgo", "duckduckbot"},
+
+	// Monitoring
+	{"monitoring", "uptimerobot"},
+	{"monitoring", "pingdom"},
+	{"monitoring", "statuscake"},
+
+	// HTTP libraries and command-line tools
+	{"http_client", "curl/"},
+	{"http_client", "wget/"},
+	{"http_client", "python-requests"},
+	{"http_client", "python-urllib"},
+	{"http_client", "aiohttp"},
+	{"http_client", "go-http-client"},
+	{"http_client", "okhttp"},
+	{"http_client", "java/"},
+	{"http_client", "libwww-perl"},
+	{"http_client", "axios/"},
+	{"http_client", "node-fetch"},
+
+	// Headless browsers
+	{"headless", "headlesschrome"},
+	{"headless", "phantomjs"},
+
+	// Generic catch-alls
+	{"other", "bot"},
+	{"other", "crawler"},
+	{"other", "spider"},
+	{"other", "preview"},
+}
+
+// Classifier detects bots from the User-Agent and request behaviour.
+// It is safe for concurrent use; signatures can be replaced at runtime.
+type Classifier struct {
+	mu         sync.RWMutex
+	signatures []Signature
+	modTime    time.Time
+}
+
+// NewClassifier creates a classifier with the built-in signatures.
+func NewClassifier() *Classifier {
+	return &Classifier{signatures: builtinSignatures}
+}
+
+// Classify reports whether the request comes from a bot.
+func (c *Classifier) Classify(r *http.Request) Result {
+	ua := strings.ToLower(r.UserAgent())
+	if ua == "" {
+		return Result{IsBot: true, Family: FamilyEmptyUA}
+	}
+
+	c.mu.RLock()
+	signatures := c.signatures
+	c.mu.RUnlock()
+
+	for _, sig := range signatures {
+		if strings.Contains(ua, sig.Pattern) {
+			return Result{IsBot: true, Family: sig.Family}
+		}
+	}
+
+	// Browsers never follow links with HEAD; link checkers do
+	if r.Method == http.MethodHead {
+		return Result{IsBot: true, Family: FamilyHead}
+	}
+
+	// Speculative loads are not visits
+	if isPrefetch(r) {
+		return Result{IsBot: true, Family: FamilyPrefetch}
+	}
+
+	return Result{}
+}
+
+// isPrefetch reports whether the browser marked the request as a prefetch or preview.
+func isPrefetch(r *http.Request) bool {
+	for _, header := range []string{"Sec-Purpose", "Purpose", "X-Purpose", "X-Moz"} {
+		value := strings.ToLower(r.Header.Get(header))
+		if strings.Contains(value, "prefetch") || strings.Contains(value, "preview") {
+			return true
+		}
+	}
+	return false
+}
+
+// SetSignatures replaces the custom signatures. Built-in signatures are
+// always checked after them.
+func (c *Classifier) SetSignatures(custom []Signature) {
+	signatures := make([]Signature, 0, len(custom)+len(builtinSignatures))
+	signatures = append(signatures, custom...)
+	signatures = append(signatures, builtinSignatures...)
+
+	c.mu.Lock()
+	c.signatures = signatures
+	c.mu.Unlock()
+}
+
+// LoadFile reads custom signatures from path. See ParseSignatures for the format.
+func (c *Classifier) LoadFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open signatures: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("stat signatures: %w", err)
+	}
+
+	signatures, err := ParseSignatures(f)
+	if err != nil {
+		return err
+	}
+
+	c.SetSignatures(signatures)
+
+	c.mu.Lock()
+	c.modTime = info.ModTime()
+	c.mu.Unlock()
+
+	return nil
+}
+
+// Watch reloads the signatures file whenever its modification time changes,
+// until ctx is cancelled. A file that fails to parse keeps the previous list.
+func (c *Classifier) Watch(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				logger.Warn("bot signatures unavailable", "path", path, "error", err)
+				continue
+			}
+
+			c.mu.RLock()
+			unchanged := info.ModTime().Equal(c.modTime)
+			c.mu.RUnlock()
+			if unchanged {
+				continue
+			}
+
+			if err := c.LoadFile(path); err != nil {
+				logger.Error("failed to reload bot signatures", "path", path, "error", err)
+				continue
+			}
+			logger.Info("bot signatures reloaded", "path", path)
+		}
+	}
+}
+
+// ParseSignatures reads one signature per line as "<family> <pattern>".
+// The pattern is the rest of the line and may contain spaces.
+// Blank lines and lines starting with # are ignored.
+func ParseSignatures(r io.Reader) ([]Signature, error) {
+	var signatures []Signature
+
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		family, pattern, ok := strings.Cut(line, " ")
+		pattern = strings.TrimSpace(pattern)
+		if !ok || pattern == "" {
+			return nil, fmt.Errorf("signatures line %d: want \"<family> <pattern>\"", lineNo)
+		}
+
+		signatures = append(signatures, Signature{
+			Family:  family,
+			Pattern: strings.ToLower(pattern),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read signatures: %w", err)
+	}
+
+	return signatures, nil
+}
@@ -0,0 +1,95 @@
+package bot
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const chromeUA = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		userAgent  string
+		headers    map[string]string
+		wantBot    bool
+		wantFamily string
+	}{
+		{"browser", http.MethodGet, chromeUA, nil, false, ""},
+		{"slack", http.MethodGet, "Slackbot-LinkExpanding 1.0 (+https://api.slack.com/robots)", nil, true, "slack"},
+		{"twitter", http.MethodGet, "Twitterbot/1.0", nil, true, "twitter"},
+		{"facebook", http.MethodGet, "facebookexternalhit/1.1", nil, true, "facebook"},
+		{"curl", http.MethodGet, "curl/8.4.0", nil, true, "http_client"},
+		{"generic", http.MethodGet, "SomeNewBot/2.0", nil, true, "other"},
+		{"empty_user_agent", http.MethodGet, "", nil, true, FamilyEmptyUA},
+		{"head_request", http.MethodHead, chromeUA, nil, true, FamilyHead},
+		{"prefetch", http.MethodGet, chromeUA, map[string]string{"Sec-Purpose": "prefetch;prerender"}, true, FamilyPrefetch},
+	}
+
+	c := NewClassifier()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/abc123", nil)
+			r.Header.Set("User-Agent", tt.userAgent)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+
+			got := c.Classify(r)
+			if got.IsBot != tt.wantBot || got.Family != tt.wantFamily {
+				t.Errorf("Classify() = %+v, want bot=%v family=%q", got, tt.wantBot, tt.wantFamily)
+			}
+		})
+	}
+}
+
+func TestParseSignatures(t *testing.T) {
+	input := `
+# custom scanners
+acme_scanner AcmeLinkCheck
+acme_scanner Acme Safe Browse
+`
+	signatures, err := ParseSignatures(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(signatures) != 2 {
+		t.Fatalf("expected 2 signatures, got %d", len(signatures))
+	}
+	if signatures[1].Family != "acme_scanner" || signatures[1].Pattern != "acme safe browse" {
+		t.Errorf("unexpected signature: %+v", signatures[1])
+	}
+
+	if _, err := ParseSignatures(strings.NewReader("family-only\n")); err == nil {
+		t.Error("expected error for line without pattern")
+	}
+}
+
+func TestLoadFile_CustomSignaturesWin(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bots.txt")
+	if err := os.WriteFile(path, []byte("internal_monitor curl/\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := NewClassifier()
+	if err := c.LoadFile(path); err != nil {
+		t.Fatalf("LoadFile: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/abc123", nil)
+	r.Header.Set("User-Agent", "curl/8.4.0")
+	if got := c.Classify(r); got.Family != "internal_monitor" {
+		t.Errorf("expected custom family, got %+v", got)
+	}
+
+	// Built-ins still apply
+	r.Header.Set("User-Agent", "Twitterbot/1.0")
+	if got := c.Classify(r); got.Family != "twitter" {
+		t.Errorf("expected built-in family, got %+v", got)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// Cache backends selected by CACHE_BACKEND.
+const (
+	BackendRedis  = "redis"
+	BackendMemory = "memory"
+)
+
+// LinkCache caches resolved links for the redirect path.
+type LinkCache interface {
+	// GetLink returns ErrCacheMiss if the link is not cached.
+	GetLink(ctx context.Context, shortCode string) (*model.CachedLink, error)
+	SetLink(ctx context.Context, shortCode string, link *model.Link) error
+	DeleteLink(ctx context.Context, shortCode string) error
+
+	// AcquireRefillLock returns a release function when the caller should
+	// load the link from the database, or nil if another instance is doing so.
+	AcquireRefillLock(ctx context.Context, shortCode string, ttl time.Duration) (func(), error)
+
+	// Local returns the in-process tier in front of the backend, or nil.
+	Local() *LocalLinkCache
+
+	// Degraded reports whether the backend is currently being bypassed.
+	Degraded() bool
+}
+
+// NegativeCache remembers short codes that do not exist.
+type NegativeCache interface {
+	IsNegativelyCached(ctx context.Context, shortCode string) (bool, error)
+	SetNegativeCache(ctx context.Context, shortCode string) error
+}
+
+// ClickCounter keeps per-link click counters.
+type ClickCounter interface {
+	IncrementClicks(ctx context.Context, shortCode string) error
+	GetAndResetClicks(ctx context.Context, shortCode string) (int64, error)
+	ScanClickKeys(ctx context.Context) ([]string, error)
+}
+
+// AuthCache caches authenticated API key contexts.
+type AuthCache interface {
+	// GetAuthContext returns nil on a cache miss.
+	GetAuthContext(ctx context.Context, cacheKey string) (*model.AuthContext, error)
+	SetAuthContext(ctx context.Context, cacheKey string, auth *model.AuthContext) error
+	DeleteAuthContext(ctx context.Context, cacheKey string) error
+}
+
+// RateLimiter holds token buckets for API keys and client IPs.
+type RateLimiter interface {
+	CheckAPIRateLimit(ctx context.Context, keyID string, ratePerMinute, burst int) (*RateLimitResult, error)
+	CheckIPRateLimit(ctx context.Context, ip string, ratePerSecond, burst int) (*RateLimitResult, error)
+}
+
+// ErrorPageCache caches branded error page settings.
+type ErrorPageCache interface {
+	GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, bool, error)
+	SetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string, settings *model.ErrorPageSettings) error
+	DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error
+}
+
+// Backend is a complete cache implementation: Redis (*Cache) or in-process
+// (*Memory).
+type Backend interface {
+	LinkCache
+	NegativeCache
+	ClickCounter
+	AuthCache
+	RateLimiter
+	ErrorPageCache
+
+	Ping(ctx context.Context) error
+	Close() error
+}
+
+var (
+	_ Backend = (*Cache)(nil)
+	_ Backend = (*Memory)(nil)
+)
@@ -0,0 +1,273 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Circuit breaker defaults.
+const (
+	// DefaultBreakerThreshold is the number of consecutive Redis failures
+	// that open the circuit.
+	DefaultBreakerThreshold = 5
+
+	// DefaultBreakerCooldown is how long the circuit stays open before a
+	// single probe command is let through.
+	DefaultBreakerCooldown = 5 * time.Second
+)
+
+// ErrCircuitOpen is returned instead of calling Redis while the circuit is open.
+var ErrCircuitOpen = errors.New("redis circuit breaker open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+// Breaker states.
+const (
+	BreakerClosed   BreakerState = iota // Redis calls flow normally
+	BreakerOpen                         // Redis calls are skipped
+	BreakerHalfOpen                     // one probe call is in flight
+)
+
+// String returns the state name used in logs, metrics and /readyz.
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half_open"
+	default:
+		return "closed"
+	}
+}
+
+// Breaker is a consecutive-failure circuit breaker for Redis commands.
+// After threshold failures in a row it opens and rejects commands with
+// ErrCircuitOpen. Once cooldown has passed, the next command is let through
+// as a probe: success closes the circuit, failure re-opens it.
+type Breaker struct {
+	mu        sync.Mutex
+	state     BreakerState
+	failures  int
+	openedAt  time.Time
+	threshold int
+	cooldown  time.Duration
+	listeners []func(from, to BreakerState)
+
+	now func() time.Time
+}
+
+// NewBreaker creates a closed breaker.
+func NewBreaker(threshold int, cooldown time.Duration) *Breaker {
+	if threshold <= 0 {
+		threshold = DefaultBreakerThreshold
+	}
+	if cooldown <= 0 {
+		cooldown = DefaultBreakerCooldown
+	}
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// OnStateChange registers fn to run after every state transition.
+// Listeners run synchronously and must not block.
+func (b *Breaker) OnStateChange(fn func(from, to BreakerState)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.listeners = append(b.listeners, fn)
+}
+
+// State returns the current state.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// Allow reports whether a command may be sent to Redis.
+func (b *Breaker) Allow() error {
+	b.mu.Lock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			b.mu.Unlock()
+			return ErrCircuitOpen
+		}
+		// Let this command through as the probe
+		b.transition(BreakerHalfOpen)
+		return nil
+	case BreakerHalfOpen:
+		b.mu.Unlock()
+		return ErrCircuitOpen
+	default:
+		b.mu.Unlock()
+		return nil
+	}
+}
+
+// Record reports the outcome of a command let through by Allow.
+func (b *Breaker) Record(err error) {
+	b.mu.Lock()
+
+	if !isConnectivityError(err) {
+		b.failures = 0
+		if b.state != BreakerClosed {
+			b.transition(BreakerClosed)
+			return
+		}
+		b.mu.Unlock()
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || (b.state == BreakerClosed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.transition(BreakerOpen)
+		return
+	}
+	b.mu.Unlock()
+}
+
+// transition changes state and notifies listeners. It must be called with
+// b.mu held and releases it.
+func (b *Breaker) transition(to BreakerState) {
+	from := b.state
+	b.state = to
+	listeners := b.listeners
+	b.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(from, to)
+	}
+}
+
+// isConnectivityError reports whether err means Redis could not be reached
+// in time. Misses, server error replies and caller cancellation do not count.
+func isConnectivityError(err error) bool {
+	if err == nil || errors.Is(err, redis.Nil) || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var replyErr redis.Error
+	if errors.As(err, &replyErr) {
+		return false
+	}
+	return true
+}
+
+// breakerHook runs every command and pipeline through the breaker.
+type breakerHook struct {
+	breaker *Breaker
+}
+
+func (h breakerHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (h breakerHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err := next(ctx, cmd)
+		h.breaker.Record(err)
+		return err
+	}
+}
+
+func (h breakerHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		h.breaker.Record(err)
+		return err
+	}
+}
+
+// maxPendingClickCodes bounds the click counters kept in memory while Redis
+// is unreachable.
+const maxPendingClickCodes = 10000
+
+// EnableBreaker routes every Redis command through the circuit breaker.
+// While it is open, commands fail fast with ErrCircuitOpen, click increments
+// are buffered in memory and flushed once the circuit closes again.
+func (c *Cache) EnableBreaker(b *Breaker) {
+	c.breaker = b
+	c.pendingClicks = make(map[string]int64)
+	c.client.AddHook(breakerHook{breaker: b})
+
+	b.OnStateChange(func(_, to BreakerState) {
+		if to == BreakerClosed {
+			go c.flushPendingClicks()
+		}
+	})
+}
+
+// Breaker returns the circuit breaker, or nil if it is disabled.
+func (c *Cache) Breaker() *Breaker {
+	return c.breaker
+}
+
+// Degraded reports whether Redis calls are currently being skipped.
+func (c *Cache) Degraded() bool {
+	return c.breaker != nil && c.breaker.State() != BreakerClosed
+}
+
+// CanServeDegraded reports whether the service keeps working without Redis,
+// which is the case once the circuit breaker is enabled.
+func (c *Cache) CanServeDegraded() bool {
+	return c.breaker != nil
+}
+
+// bufferClick keeps a click increment that could not reach Redis.
+// Returns false if the buffer is full or disabled.
+func (c *Cache) bufferClick(shortCode string) bool {
+	if c.breaker == nil {
+		return false
+	}
+
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+
+	if _, ok := c.pendingClicks[shortCode]; !ok && len(c.pendingClicks) >= maxPendingClickCodes {
+		return false
+	}
+	c.pendingClicks[shortCode]++
+	return true
+}
+
+// flushPendingClicks writes buffered click increments back to Redis.
+// Counts that still fail are put back for the next recovery.
+func (c *Cache) flushPendingClicks() {
+	c.pendingMu.Lock()
+	pending := c.pendingClicks
+	c.pendingClicks = make(map[string]int64)
+	c.pendingMu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for shortCode, count := range pending {
+		if err := c.client.IncrBy(ctx, clicksKeyPrefix+shortCode, count).Err(); err != nil {
+			c.pendingMu.Lock()
+			c.pendingClicks[shortCode] += count
+			c.pendingMu.Unlock()
+		}
+	}
+}
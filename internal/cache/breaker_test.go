@@ -0,0 +1,125 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestBreaker_OpensAfterThreshold(t *testing.T) {
+	t.Parallel()
+
+	b := NewBreaker(3, time.Second)
+	dialErr := errors.New("dial tcp: connection refused")
+
+	for i := 0; i < 2; i++ {
+		b.Record(dialErr)
+	}
+	if b.State() != BreakerClosed {
+		t.Fatalf("State() = %s, want closed below threshold", b.State())
+	}
+
+	// A success resets the failure count
+	b.Record(nil)
+	b.Record(dialErr)
+	b.Record(dialErr)
+	if b.State() != BreakerClosed {
+		t.Fatalf("State() = %s, want closed after reset", b.State())
+	}
+
+	b.Record(dialErr)
+	if b.State() != BreakerOpen {
+		t.Fatalf("State() = %s, want open", b.State())
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("Allow() = %v, want ErrCircuitOpen", err)
+	}
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	b := NewBreaker(1, 5*time.Second)
+	b.now = func() time.Time { return now }
+
+	var transitions []string
+	b.OnStateChange(func(from, to BreakerState) {
+		transitions = append(transitions, from.String()+"->"+to.String())
+	})
+
+	b.Record(errors.New("i/o timeout"))
+	now = now.Add(4 * time.Second)
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("Allow() during cooldown = %v, want ErrCircuitOpen", err)
+	}
+
+	// After the cooldown a single probe goes through
+	now = now.Add(time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second Allow() while probing = %v, want ErrCircuitOpen", err)
+	}
+
+	// Failed probe re-opens for another cooldown
+	b.Record(errors.New("i/o timeout"))
+	if b.State() != BreakerOpen {
+		t.Fatalf("State() after failed probe = %s, want open", b.State())
+	}
+
+	now = now.Add(5 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("probe Allow() = %v, want nil", err)
+	}
+	b.Record(nil)
+	if b.State() != BreakerClosed {
+		t.Fatalf("State() after successful probe = %s, want closed", b.State())
+	}
+
+	want := []string{"closed->open", "open->half_open", "half_open->open", "open->half_open", "half_open->closed"}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions = %v, want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Errorf("transition %d = %s, want %s", i, transitions[i], want[i])
+		}
+	}
+}
+
+// replyError is an error reply sent by the Redis server.
+type replyError string
+
+func (e replyError) Error() string { return string(e) }
+func (replyError) RedisError()     {}
+
+func TestIsConnectivityError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"cache miss", redis.Nil, false},
+		{"canceled", context.Canceled, false},
+		{"server reply", replyError("WRONGTYPE Operation against a key"), false},
+		{"client closed", redis.ErrClosed, true},
+		{"timeout", context.DeadlineExceeded, true},
+		{"dial", errors.New("dial tcp: connection refused"), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isConnectivityError(tt.err); got != tt.want {
+				t.Errorf("isConnectivityError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+const (
+	// errorPageCachePrefix is the Redis key prefix for error page settings.
+	errorPageCachePrefix = "errpage:"
+	// errorPageCacheTTL is the time-to-live for cached error page settings.
+	errorPageCacheTTL = 5 * time.Minute
+)
+
+// GetErrorPageSettings retrieves cached error page settings for a scope.
+// The found result is false on a cache miss. A cached "no settings" marker
+// returns found=true with nil settings so absent rows are not re-queried.
+func (c *Cache) GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, bool, error) {
+	key := errorPageKey(scope, scopeID)
+
+	data, err := c.client.Get(ctx, key).Bytes()
+	if err != nil {
+		// Cache miss is not an error
+		return nil, false, nil //nolint:nilerr
+	}
+
+	var settings *model.ErrorPageSettings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		// Corrupted cache entry - treat as miss
+		return nil, false, nil //nolint:nilerr
+	}
+
+	return settings, true, nil
+}
+
+// SetErrorPageSettings caches settings for a scope. Pass nil to cache
+// the absence of settings.
+func (c *Cache) SetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string, settings *model.ErrorPageSettings) error {
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("marshal error page settings: %w", err)
+	}
+
+	return c.client.Set(ctx, errorPageKey(scope, scopeID), data, errorPageCacheTTL).Err()
+}
+
+// DeleteErrorPageSettings removes cached settings for a scope.
+func (c *Cache) DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error {
+	return c.client.Del(ctx, errorPageKey(scope, scopeID)).Err()
+}
+
+func errorPageKey(scope model.ErrorPageScope, scopeID string) string {
+	return errorPageCachePrefix + string(scope) + ":" + scopeID
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// linkInvalidationChannel carries "<instance>|<short_code>" messages telling
+// other replicas to drop a link from their local cache.
+const linkInvalidationChannel = "cache:link:invalidate"
+
+// EnableLocalCache puts an in-process cache in front of Redis for links.
+// DeleteLink then evicts the entry locally, and both SetLink and DeleteLink
+// broadcast the eviction; run RunLinkInvalidation to apply broadcasts from
+// other replicas.
+func (c *Cache) EnableLocalCache(local *LocalLinkCache) {
+	c.local = local
+	c.instanceID = newInstanceID()
+}
+
+// Local returns the in-process link cache, or nil if it is disabled.
+func (c *Cache) Local() *LocalLinkCache {
+	return c.local
+}
+
+// RunLinkInvalidation applies link invalidations published by other replicas
+// until ctx is cancelled. The local cache is purged whenever the subscription
+// is (re)established, since messages may have been missed meanwhile.
+func (c *Cache) RunLinkInvalidation(ctx context.Context, logger *slog.Logger) {
+	if c.local == nil {
+		return
+	}
+
+	for {
+		c.subscribeLinkInvalidation(ctx, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+func (c *Cache) subscribeLinkInvalidation(ctx context.Context, logger *slog.Logger) {
+	pubsub := c.client.Subscribe(ctx, linkInvalidationChannel)
+	defer pubsub.Close()
+
+	// Wait for the subscription to be confirmed before trusting the cache again
+	if _, err := pubsub.Receive(ctx); err != nil {
+		if ctx.Err() == nil {
+			logger.Warn("link invalidation subscribe failed", "error", err)
+		}
+		c.local.Purge()
+		return
+	}
+	c.local.Purge()
+
+	for {
+		msg, err := pubsub.ReceiveMessage(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Warn("link invalidation subscription lost", "error", err)
+			}
+			c.local.Purge()
+			return
+		}
+
+		origin, shortCode, ok := strings.Cut(msg.Payload, "|")
+		if !ok || origin == c.instanceID {
+			continue
+		}
+		c.local.Delete(shortCode)
+	}
+}
+
+// invalidateLocal drops a link from this replica's cache and tells the
+// others to do the same.
+func (c *Cache) invalidateLocal(ctx context.Context, shortCode string) {
+	if c.local == nil {
+		return
+	}
+
+	c.local.Delete(shortCode)
+	c.publishInvalidation(ctx, shortCode)
+}
+
+// publishInvalidation tells other replicas to drop a link. Publishing is best
+// effort: the local TTL bounds staleness if a message is lost.
+func (c *Cache) publishInvalidation(ctx context.Context, shortCode string) {
+	if c.local == nil {
+		return
+	}
+
+	c.client.Publish(ctx, linkInvalidationChannel, c.instanceID+"|"+shortCode)
+}
+
+// newInstanceID returns a random identifier for this process.
+func newInstanceID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
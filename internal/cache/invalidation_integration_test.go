@@ -0,0 +1,86 @@
+//go:build integration
+
+package cache
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+func TestLinkInvalidation_AcrossReplicas(t *testing.T) {
+	redisURL := testutil.RequireEnv(t, "REDIS_URL")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+
+	// Two replicas, each with its own local cache
+	replicas := make([]*Cache, 2)
+	for i := range replicas {
+		c, err := New(ctx, redisURL)
+		if err != nil {
+			t.Fatalf("connect redis: %v", err)
+		}
+		t.Cleanup(func() { _ = c.Close() })
+		c.EnableLocalCache(NewLocalLinkCache(100, time.Minute))
+		go c.RunLinkInvalidation(ctx, logger)
+		replicas[i] = c
+	}
+	a, b := replicas[0], replicas[1]
+
+	shortCode := testutil.UniqueShortCode("inv")
+	link := testutil.NewTestLink(t, shortCode)
+
+	// Wait until both subscriptions are live: a warm-up entry survives the
+	// purge done on subscribe only once it has happened.
+	waitFor(t, func() bool {
+		b.Local().Set(shortCode, link.ToCachedLink())
+		time.Sleep(50 * time.Millisecond)
+		_, ok := b.Local().Get(shortCode)
+		return ok
+	})
+
+	// Own writes do not evict the writer's entry
+	a.Local().Set(shortCode, link.ToCachedLink())
+	if err := a.SetLink(ctx, shortCode, link); err != nil {
+		t.Fatalf("set link: %v", err)
+	}
+	waitFor(t, func() bool {
+		_, ok := b.Local().Get(shortCode)
+		return !ok
+	})
+	if _, ok := a.Local().Get(shortCode); !ok {
+		t.Error("writer's local entry should survive its own SetLink")
+	}
+
+	// Deletes evict everywhere
+	b.Local().Set(shortCode, &model.CachedLink{Destination: link.Destination})
+	if err := a.DeleteLink(ctx, shortCode); err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	if _, ok := a.Local().Get(shortCode); ok {
+		t.Error("DeleteLink should evict the local entry")
+	}
+	waitFor(t, func() bool {
+		_, ok := b.Local().Get(shortCode)
+		return !ok
+	})
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal("condition not met before deadline")
+}
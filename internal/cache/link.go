@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
@@ -25,6 +26,14 @@ const (
 	NegativeCacheTTL = 5 * time.Minute
 )
 
+// linkKey returns the Redis key for a cached link. The short code is wrapped
+// in a hash tag so the link, its negative-cache marker and its refill lock
+// map to the same Redis Cluster slot and can be used together in multi-key
+// commands.
+func linkKey(shortCode string) string {
+	return linkKeyPrefix + "{" + shortCode + "}"
+}
+
 // Common cache errors.
 var (
 	ErrCacheMiss = errors.New("cache miss")
@@ -33,7 +42,7 @@ var (
 // GetLink retrieves a link from cache by short code.
 // Returns ErrCacheMiss if not found.
 func (c *Cache) GetLink(ctx context.Context, shortCode string) (*model.CachedLink, error) {
-	key := linkKeyPrefix + shortCode
+	key := linkKey(shortCode)
 
 	result, err := c.client.HGetAll(ctx, key).Result()
 	if err != nil {
@@ -45,12 +54,18 @@ func (c *Cache) GetLink(ctx context.Context, shortCode string) (*model.CachedLin
 	}
 
 	cached := &model.CachedLink{
-		Destination:  result["destination"],
-		RedirectType: result["redirect_type"],
-		ExpiresAt:    result["expires_at"],
-		Enabled:      result["enabled"],
-		DeletedAt:    result["deleted_at"],
-		UpdatedAt:    result["updated_at"],
+		ID:               result["id"],
+		OwnerID:          result["owner_id"],
+		Destination:      result["destination"],
+		FallbackURL:      result["fallback_url"],
+		AllowedCountries: result["allowed_countries"],
+		BlockedCountries: result["blocked_countries"],
+		GeoBlockedURL:    result["geo_blocked_url"],
+		RedirectType:     result["redirect_type"],
+		ExpiresAt:        result["expires_at"],
+		Enabled:          result["enabled"],
+		DeletedAt:        result["deleted_at"],
+		UpdatedAt:        result["updated_at"],
 	}
 
 	return cached, nil
@@ -58,7 +73,7 @@ func (c *Cache) GetLink(ctx context.Context, shortCode string) (*model.CachedLin
 
 // SetLink stores a link in cache.
 func (c *Cache) SetLink(ctx context.Context, shortCode string, link *model.Link) error {
-	key := linkKeyPrefix + shortCode
+	key := linkKey(shortCode)
 	cached := link.ToCachedLink()
 
 	ttl := DefaultLinkTTL
@@ -66,6 +81,7 @@ func (c *Cache) SetLink(ctx context.Context, shortCode string, link *model.Link)
 		expiresIn := time.Until(*link.ExpiresAt)
 		if expiresIn <= 0 {
 			c.client.Del(ctx, key, key+negCacheKeySuffix)
+			c.invalidateLocal(ctx, shortCode)
 			return nil
 		}
 		if expiresIn < ttl {
@@ -74,6 +90,8 @@ func (c *Cache) SetLink(ctx context.Context, shortCode string, link *model.Link)
 	}
 
 	fields := map[string]any{
+		"id":            cached.ID,
+		"owner_id":      cached.OwnerID,
 		"destination":   cached.Destination,
 		"redirect_type": cached.RedirectType,
 		"enabled":       cached.Enabled,
@@ -87,6 +105,18 @@ func (c *Cache) SetLink(ctx context.Context, shortCode string, link *model.Link)
 	if cached.DeletedAt != "" {
 		fields["deleted_at"] = cached.DeletedAt
 	}
+	if cached.FallbackURL != "" {
+		fields["fallback_url"] = cached.FallbackURL
+	}
+	if cached.AllowedCountries != "" {
+		fields["allowed_countries"] = cached.AllowedCountries
+	}
+	if cached.BlockedCountries != "" {
+		fields["blocked_countries"] = cached.BlockedCountries
+	}
+	if cached.GeoBlockedURL != "" {
+		fields["geo_blocked_url"] = cached.GeoBlockedURL
+	}
 
 	pipe := c.client.Pipeline()
 	pipe.HSet(ctx, key, fields)
@@ -100,17 +130,23 @@ func (c *Cache) SetLink(ctx context.Context, shortCode string, link *model.Link)
 	// Remove negative cache if exists
 	c.client.Del(ctx, key+negCacheKeySuffix)
 
+	// Other replicas may hold an older copy; the caller refreshes its own
+	c.publishInvalidation(ctx, shortCode)
+
 	return nil
 }
 
 // DeleteLink removes a link from cache.
 func (c *Cache) DeleteLink(ctx context.Context, shortCode string) error {
-	key := linkKeyPrefix + shortCode
+	key := linkKey(shortCode)
 
 	pipe := c.client.Pipeline()
 	pipe.Del(ctx, key)
 	pipe.Del(ctx, key+negCacheKeySuffix)
 
+	// Evict from local caches even if Redis is unavailable
+	c.invalidateLocal(ctx, shortCode)
+
 	_, err := pipe.Exec(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to delete link from cache: %w", err)
@@ -121,7 +157,7 @@ func (c *Cache) DeleteLink(ctx context.Context, shortCode string) error {
 
 // IsNegativelyCached checks if a short code is in negative cache.
 func (c *Cache) IsNegativelyCached(ctx context.Context, shortCode string) (bool, error) {
-	key := linkKeyPrefix + shortCode + negCacheKeySuffix
+	key := linkKey(shortCode) + negCacheKeySuffix
 
 	exists, err := c.client.Exists(ctx, key).Result()
 	if err != nil {
@@ -133,7 +169,7 @@ func (c *Cache) IsNegativelyCached(ctx context.Context, shortCode string) (bool,
 
 // SetNegativeCache marks a short code as not found.
 func (c *Cache) SetNegativeCache(ctx context.Context, shortCode string) error {
-	key := linkKeyPrefix + shortCode + negCacheKeySuffix
+	key := linkKey(shortCode) + negCacheKeySuffix
 
 	err := c.client.SetEx(ctx, key, "", NegativeCacheTTL).Err()
 	if err != nil {
@@ -150,6 +186,10 @@ func (c *Cache) IncrementClicks(ctx context.Context, shortCode string) error {
 
 	err := c.client.Incr(ctx, key).Err()
 	if err != nil {
+		// Keep the click for later if the breaker is tracking the outage
+		if isConnectivityError(err) && c.bufferClick(shortCode) {
+			return nil
+		}
 		return fmt.Errorf("failed to increment clicks: %w", err)
 	}
 
@@ -180,7 +220,35 @@ func (c *Cache) GetAndResetClicks(ctx context.Context, shortCode string) (int64,
 
 // ScanClickKeys scans for all click counter keys.
 // Used by the background job to find which links have pending click updates.
+// In cluster mode every master is scanned, since SCAN is node-local.
 func (c *Cache) ScanClickKeys(ctx context.Context) ([]string, error) {
+	cluster, ok := c.client.(*redis.ClusterClient)
+	if !ok {
+		return scanKeys(ctx, c.client, clicksKeyPrefix+"*")
+	}
+
+	var (
+		mu   sync.Mutex
+		keys []string
+	)
+	err := cluster.ForEachMaster(ctx, func(ctx context.Context, node *redis.Client) error {
+		nodeKeys, err := scanKeys(ctx, node, clicksKeyPrefix+"*")
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		keys = append(keys, nodeKeys...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// scanKeys collects every key on a single node matching pattern.
+func scanKeys(ctx context.Context, client redis.Cmdable, pattern string) ([]string, error) {
 	var keys []string
 	var cursor uint64
 
@@ -188,7 +256,7 @@ func (c *Cache) ScanClickKeys(ctx context.Context) ([]string, error) {
 		var scanKeys []string
 		var err error
 
-		scanKeys, cursor, err = c.client.Scan(ctx, cursor, clicksKeyPrefix+"*", 100).Result()
+		scanKeys, cursor, err = client.Scan(ctx, cursor, pattern, 100).Result()
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan click keys: %w", err)
 		}
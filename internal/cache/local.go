@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// Local link cache defaults.
+const (
+	// DefaultLocalCacheSize is the number of links kept in each replica.
+	DefaultLocalCacheSize = 10000
+
+	// DefaultLocalCacheTTL bounds how long a replica may serve a link
+	// without checking Redis, should an invalidation message be lost.
+	DefaultLocalCacheTTL = 30 * time.Second
+)
+
+// LocalLinkCache is a small in-process LRU cache with per-entry TTL for hot
+// links. It sits in front of Redis on the redirect path and is kept coherent
+// across replicas by the link invalidation channel.
+type LocalLinkCache struct {
+	mu       sync.Mutex
+	capacity int
+	ttl      time.Duration
+	items    map[string]*list.Element
+	order    *list.List // front = most recently used
+
+	// epoch increases on every invalidation. Fills started before an
+	// invalidation are discarded so a racing read cannot re-cache stale data.
+	epoch uint64
+
+	now func() time.Time
+}
+
+type localEntry struct {
+	shortCode string
+	link      model.CachedLink
+	expiresAt time.Time
+}
+
+// NewLocalLinkCache creates a cache holding at most capacity links for ttl.
+func NewLocalLinkCache(capacity int, ttl time.Duration) *LocalLinkCache {
+	if capacity <= 0 {
+		capacity = DefaultLocalCacheSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultLocalCacheTTL
+	}
+	return &LocalLinkCache{
+		capacity: capacity,
+		ttl:      ttl,
+		items:    make(map[string]*list.Element, capacity),
+		order:    list.New(),
+		now:      time.Now,
+	}
+}
+
+// Get returns a copy of the cached link, if present and not stale.
+func (l *LocalLinkCache) Get(shortCode string) (*model.CachedLink, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[shortCode]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*localEntry)
+	if !l.now().Before(entry.expiresAt) {
+		l.removeElement(elem)
+		return nil, false
+	}
+
+	l.order.MoveToFront(elem)
+	link := entry.link
+	return &link, true
+}
+
+// Epoch returns the current invalidation epoch, to be passed to SetIfFresh.
+func (l *LocalLinkCache) Epoch() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.epoch
+}
+
+// Set stores a link, evicting the least recently used entry when full.
+func (l *LocalLinkCache) Set(shortCode string, link *model.CachedLink) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.set(shortCode, link)
+}
+
+// SetIfFresh stores a link only if nothing was invalidated since epoch.
+func (l *LocalLinkCache) SetIfFresh(shortCode string, link *model.CachedLink, epoch uint64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.epoch != epoch {
+		return
+	}
+	l.set(shortCode, link)
+}
+
+func (l *LocalLinkCache) set(shortCode string, link *model.CachedLink) {
+	expiresAt := l.now().Add(l.ttl)
+
+	if elem, ok := l.items[shortCode]; ok {
+		entry := elem.Value.(*localEntry)
+		entry.link = *link
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	elem := l.order.PushFront(&localEntry{
+		shortCode: shortCode,
+		link:      *link,
+		expiresAt: expiresAt,
+	})
+	l.items[shortCode] = elem
+
+	if l.order.Len() > l.capacity {
+		l.removeElement(l.order.Back())
+	}
+}
+
+// Delete removes a link and advances the invalidation epoch.
+func (l *LocalLinkCache) Delete(shortCode string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.epoch++
+	if elem, ok := l.items[shortCode]; ok {
+		l.removeElement(elem)
+	}
+}
+
+// Purge removes all links, e.g. after missed invalidation messages.
+func (l *LocalLinkCache) Purge() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.epoch++
+	l.items = make(map[string]*list.Element, l.capacity)
+	l.order.Init()
+}
+
+// Len returns the number of cached links, including stale ones not yet evicted.
+func (l *LocalLinkCache) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.order.Len()
+}
+
+func (l *LocalLinkCache) removeElement(elem *list.Element) {
+	entry := elem.Value.(*localEntry)
+	delete(l.items, entry.shortCode)
+	l.order.Remove(elem)
+}
@@ -0,0 +1,95 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestLocalLinkCache_GetSet(t *testing.T) {
+	t.Parallel()
+
+	l := NewLocalLinkCache(10, time.Minute)
+	l.Set("abc", &model.CachedLink{Destination: "https://example.com"})
+
+	got, ok := l.Get("abc")
+	if !ok || got.Destination != "https://example.com" {
+		t.Fatalf("Get() = %v, %v; want cached link", got, ok)
+	}
+
+	// Returned value is a copy
+	got.Destination = "https://changed.example.com"
+	if again, _ := l.Get("abc"); again.Destination != "https://example.com" {
+		t.Errorf("cache entry was mutated through returned copy")
+	}
+
+	if _, ok := l.Get("missing"); ok {
+		t.Error("expected miss for unknown short code")
+	}
+}
+
+func TestLocalLinkCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	t.Parallel()
+
+	l := NewLocalLinkCache(2, time.Minute)
+	l.Set("a", &model.CachedLink{})
+	l.Set("b", &model.CachedLink{})
+	l.Get("a") // a is now most recently used
+	l.Set("c", &model.CachedLink{})
+
+	if _, ok := l.Get("b"); ok {
+		t.Error("expected b to be evicted")
+	}
+	if _, ok := l.Get("a"); !ok {
+		t.Error("expected a to remain")
+	}
+	if l.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", l.Len())
+	}
+}
+
+func TestLocalLinkCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	now := time.Unix(1700000000, 0)
+	l := NewLocalLinkCache(10, 30*time.Second)
+	l.now = func() time.Time { return now }
+
+	l.Set("abc", &model.CachedLink{})
+	now = now.Add(29 * time.Second)
+	if _, ok := l.Get("abc"); !ok {
+		t.Fatal("expected hit before TTL")
+	}
+
+	now = now.Add(time.Second)
+	if _, ok := l.Get("abc"); ok {
+		t.Fatal("expected miss at TTL")
+	}
+	if l.Len() != 0 {
+		t.Errorf("expired entry not removed, Len() = %d", l.Len())
+	}
+}
+
+func TestLocalLinkCache_SetIfFresh(t *testing.T) {
+	t.Parallel()
+
+	l := NewLocalLinkCache(10, time.Minute)
+
+	epoch := l.Epoch()
+	l.Delete("abc") // invalidation races with a fill
+	l.SetIfFresh("abc", &model.CachedLink{}, epoch)
+	if _, ok := l.Get("abc"); ok {
+		t.Error("stale fill should be discarded")
+	}
+
+	l.SetIfFresh("abc", &model.CachedLink{}, l.Epoch())
+	if _, ok := l.Get("abc"); !ok {
+		t.Error("fresh fill should be stored")
+	}
+
+	l.Purge()
+	if _, ok := l.Get("abc"); ok || l.Len() != 0 {
+		t.Error("Purge should remove all entries")
+	}
+}
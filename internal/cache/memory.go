@@ -0,0 +1,336 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// memorySweepInterval is how often expired entries are removed.
+const memorySweepInterval = time.Minute
+
+// Memory is an in-process cache backend for single-instance deployments.
+// Nothing is shared between processes, so it must not be used when more
+// than one replica serves the same database.
+type Memory struct {
+	links      *LocalLinkCache
+	negative   *ttlStore[struct{}]
+	auth       *ttlStore[model.AuthContext]
+	errorPages *ttlStore[*model.ErrorPageSettings]
+	buckets    *ttlStore[*tokenBucket]
+
+	clicksMu sync.Mutex
+	clicks   map[string]int64
+
+	now  func() time.Time
+	stop chan struct{}
+	once sync.Once
+}
+
+// NewMemory creates an in-process cache holding up to linkCapacity links.
+func NewMemory(linkCapacity int) *Memory {
+	if linkCapacity <= 0 {
+		linkCapacity = DefaultLocalCacheSize
+	}
+	m := &Memory{
+		links:      NewLocalLinkCache(linkCapacity, DefaultLinkTTL),
+		negative:   newTTLStore[struct{}](),
+		auth:       newTTLStore[model.AuthContext](),
+		errorPages: newTTLStore[*model.ErrorPageSettings](),
+		buckets:    newTTLStore[*tokenBucket](),
+		clicks:     make(map[string]int64),
+		now:        time.Now,
+		stop:       make(chan struct{}),
+	}
+	go m.sweepLoop()
+	return m
+}
+
+// Ping always succeeds.
+func (m *Memory) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close stops the background sweep.
+func (m *Memory) Close() error {
+	m.once.Do(func() { close(m.stop) })
+	return nil
+}
+
+func (m *Memory) sweepLoop() {
+	ticker := time.NewTicker(memorySweepInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.stop:
+			return
+		case <-ticker.C:
+			now := m.now()
+			m.negative.sweep(now)
+			m.auth.sweep(now)
+			m.errorPages.sweep(now)
+			m.buckets.sweep(now)
+		}
+	}
+}
+
+// GetLink retrieves a link by short code.
+// Returns ErrCacheMiss if not found.
+func (m *Memory) GetLink(ctx context.Context, shortCode string) (*model.CachedLink, error) {
+	cached, ok := m.links.Get(shortCode)
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	return cached, nil
+}
+
+// SetLink stores a link. Links that have already expired are dropped.
+func (m *Memory) SetLink(ctx context.Context, shortCode string, link *model.Link) error {
+	m.negative.delete(shortCode)
+	if link.ExpiresAt != nil && !link.ExpiresAt.After(m.now()) {
+		m.links.Delete(shortCode)
+		return nil
+	}
+	// Expiry is checked on every redirect, so the cache TTL need not track it
+	m.links.Set(shortCode, link.ToCachedLink())
+	return nil
+}
+
+// DeleteLink removes a link and its negative cache marker.
+func (m *Memory) DeleteLink(ctx context.Context, shortCode string) error {
+	m.links.Delete(shortCode)
+	m.negative.delete(shortCode)
+	return nil
+}
+
+// AcquireRefillLock always grants the lock: there are no other instances to
+// coordinate with, and concurrent misses are already coalesced in-process.
+func (m *Memory) AcquireRefillLock(ctx context.Context, shortCode string, ttl time.Duration) (func(), error) {
+	return func() {}, nil
+}
+
+// Local returns nil; the memory backend is itself in-process.
+func (m *Memory) Local() *LocalLinkCache {
+	return nil
+}
+
+// Degraded always reports false.
+func (m *Memory) Degraded() bool {
+	return false
+}
+
+// IsNegativelyCached checks if a short code is in negative cache.
+func (m *Memory) IsNegativelyCached(ctx context.Context, shortCode string) (bool, error) {
+	_, ok := m.negative.get(shortCode, m.now())
+	return ok, nil
+}
+
+// SetNegativeCache marks a short code as not found.
+func (m *Memory) SetNegativeCache(ctx context.Context, shortCode string) error {
+	m.negative.set(shortCode, struct{}{}, m.now().Add(NegativeCacheTTL))
+	return nil
+}
+
+// IncrementClicks increments the click counter.
+func (m *Memory) IncrementClicks(ctx context.Context, shortCode string) error {
+	m.clicksMu.Lock()
+	m.clicks[shortCode]++
+	m.clicksMu.Unlock()
+	return nil
+}
+
+// GetAndResetClicks gets the current click count and resets it.
+func (m *Memory) GetAndResetClicks(ctx context.Context, shortCode string) (int64, error) {
+	m.clicksMu.Lock()
+	defer m.clicksMu.Unlock()
+	count := m.clicks[shortCode]
+	delete(m.clicks, shortCode)
+	return count, nil
+}
+
+// ScanClickKeys returns the keys of all pending click counters, in the same
+// format as the Redis backend.
+func (m *Memory) ScanClickKeys(ctx context.Context) ([]string, error) {
+	m.clicksMu.Lock()
+	defer m.clicksMu.Unlock()
+	keys := make([]string, 0, len(m.clicks))
+	for shortCode := range m.clicks {
+		keys = append(keys, clicksKeyPrefix+shortCode)
+	}
+	return keys, nil
+}
+
+// GetAuthContext retrieves a cached auth context by cache key.
+// Returns nil if not found (cache miss).
+func (m *Memory) GetAuthContext(ctx context.Context, cacheKey string) (*model.AuthContext, error) {
+	cached, ok := m.auth.get(cacheKey, m.now())
+	if !ok {
+		return nil, nil
+	}
+	cached.Scopes = append([]string(nil), cached.Scopes...)
+	return &cached, nil
+}
+
+// SetAuthContext caches an auth context.
+func (m *Memory) SetAuthContext(ctx context.Context, cacheKey string, auth *model.AuthContext) error {
+	cached := *auth
+	cached.Scopes = append([]string(nil), auth.Scopes...)
+	m.auth.set(cacheKey, cached, m.now().Add(authCacheTTL))
+	return nil
+}
+
+// DeleteAuthContext removes a cached auth context.
+func (m *Memory) DeleteAuthContext(ctx context.Context, cacheKey string) error {
+	m.auth.delete(cacheKey)
+	return nil
+}
+
+// GetErrorPageSettings retrieves cached error page settings for a scope.
+// See Cache.GetErrorPageSettings for the meaning of found.
+func (m *Memory) GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, bool, error) {
+	settings, ok := m.errorPages.get(errorPageKey(scope, scopeID), m.now())
+	if !ok || settings == nil {
+		return nil, ok, nil
+	}
+	copied := *settings
+	return &copied, true, nil
+}
+
+// SetErrorPageSettings caches settings for a scope. Pass nil to cache
+// the absence of settings.
+func (m *Memory) SetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string, settings *model.ErrorPageSettings) error {
+	if settings != nil {
+		copied := *settings
+		settings = &copied
+	}
+	m.errorPages.set(errorPageKey(scope, scopeID), settings, m.now().Add(errorPageCacheTTL))
+	return nil
+}
+
+// DeleteErrorPageSettings removes cached settings for a scope.
+func (m *Memory) DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error {
+	m.errorPages.delete(errorPageKey(scope, scopeID))
+	return nil
+}
+
+// CheckAPIRateLimit checks and updates the rate limit for an API key.
+func (m *Memory) CheckAPIRateLimit(ctx context.Context, keyID string, ratePerMinute, burst int) (*RateLimitResult, error) {
+	// Unlimited tier
+	if ratePerMinute == 0 {
+		return &RateLimitResult{
+			Allowed:   true,
+			Remaining: int64(burst),
+			ResetAt:   m.now().Add(time.Minute),
+		}, nil
+	}
+
+	return m.checkRateLimit(rateLimitKey(rateLimitAPIPrefix, keyID), float64(ratePerMinute)/60.0, burst, rateLimitAPITTL), nil
+}
+
+// CheckIPRateLimit checks and updates the rate limit for an IP address.
+func (m *Memory) CheckIPRateLimit(ctx context.Context, ip string, ratePerSecond, burst int) (*RateLimitResult, error) {
+	return m.checkRateLimit(rateLimitKey(rateLimitIPPrefix, hashIP(ip)), float64(ratePerSecond), burst, rateLimitIPTTL), nil
+}
+
+// tokenBucket is the in-process equivalent of tokenBucketScript's hash.
+type tokenBucket struct {
+	mu         sync.Mutex
+	tokens     float64
+	lastUpdate time.Time
+}
+
+// checkRateLimit applies the same token bucket algorithm as the Redis
+// backend's Lua script.
+func (m *Memory) checkRateLimit(key string, rate float64, burst int, ttl time.Duration) *RateLimitResult {
+	now := m.now()
+	bucket := m.buckets.getOrSet(key, func() *tokenBucket {
+		return &tokenBucket{tokens: float64(burst), lastUpdate: now}
+	}, now, now.Add(ttl))
+
+	bucket.mu.Lock()
+	defer bucket.mu.Unlock()
+
+	elapsed := now.Sub(bucket.lastUpdate).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(burst), bucket.tokens+elapsed*rate)
+	}
+	bucket.lastUpdate = now
+
+	result := &RateLimitResult{
+		ResetAt: now.Add(time.Duration(float64(time.Second) / rate)),
+	}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1-bucket.tokens)/rate)) * time.Second
+	}
+	result.Remaining = int64(math.Floor(bucket.tokens))
+	return result
+}
+
+// ttlStore is a map whose entries expire. Expired entries are skipped on
+// read and removed by sweep.
+type ttlStore[V any] struct {
+	mu      sync.Mutex
+	entries map[string]ttlEntry[V]
+}
+
+type ttlEntry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+func newTTLStore[V any]() *ttlStore[V] {
+	return &ttlStore[V]{entries: make(map[string]ttlEntry[V])}
+}
+
+func (s *ttlStore[V]) get(key string, now time.Time) (V, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+func (s *ttlStore[V]) set(key string, value V, expiresAt time.Time) {
+	s.mu.Lock()
+	s.entries[key] = ttlEntry[V]{value: value, expiresAt: expiresAt}
+	s.mu.Unlock()
+}
+
+// getOrSet returns the live entry for key, creating it if needed, and
+// extends its expiry.
+func (s *ttlStore[V]) getOrSet(key string, create func() V, now, expiresAt time.Time) V {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[key]
+	if !ok || !now.Before(e.expiresAt) {
+		e.value = create()
+	}
+	e.expiresAt = expiresAt
+	s.entries[key] = e
+	return e.value
+}
+
+func (s *ttlStore[V]) delete(key string) {
+	s.mu.Lock()
+	delete(s.entries, key)
+	s.mu.Unlock()
+}
+
+func (s *ttlStore[V]) sweep(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func newTestMemory(t *testing.T) (*Memory, *time.Time) {
+	t.Helper()
+	m := NewMemory(10)
+	t.Cleanup(func() { _ = m.Close() })
+
+	now := time.Unix(1700000000, 0)
+	m.now = func() time.Time { return now }
+	return m, &now
+}
+
+func TestMemory_LinkAndNegativeCache(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, now := newTestMemory(t)
+
+	if _, err := m.GetLink(ctx, "abc"); !errors.Is(err, ErrCacheMiss) {
+		t.Fatalf("GetLink() error = %v, want ErrCacheMiss", err)
+	}
+
+	_ = m.SetNegativeCache(ctx, "abc")
+	if neg, _ := m.IsNegativelyCached(ctx, "abc"); !neg {
+		t.Fatal("expected negative cache entry")
+	}
+	*now = now.Add(NegativeCacheTTL)
+	if neg, _ := m.IsNegativelyCached(ctx, "abc"); neg {
+		t.Fatal("negative cache entry should expire")
+	}
+
+	// Caching the link clears the negative marker
+	_ = m.SetNegativeCache(ctx, "abc")
+	link := &model.Link{ID: "l1", ShortCode: "abc", Destination: "https://example.com", Enabled: true}
+	if err := m.SetLink(ctx, "abc", link); err != nil {
+		t.Fatalf("SetLink() error = %v", err)
+	}
+	if neg, _ := m.IsNegativelyCached(ctx, "abc"); neg {
+		t.Error("SetLink should clear the negative cache")
+	}
+	got, err := m.GetLink(ctx, "abc")
+	if err != nil || got.Destination != "https://example.com" {
+		t.Fatalf("GetLink() = %v, %v; want cached link", got, err)
+	}
+
+	_ = m.DeleteLink(ctx, "abc")
+	if _, err := m.GetLink(ctx, "abc"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("GetLink() after delete error = %v, want ErrCacheMiss", err)
+	}
+
+	expired := *now
+	link.ExpiresAt = &expired
+	_ = m.SetLink(ctx, "abc", link)
+	if _, err := m.GetLink(ctx, "abc"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("expired link should not be cached, error = %v", err)
+	}
+}
+
+func TestMemory_Clicks(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, _ := newTestMemory(t)
+
+	_ = m.IncrementClicks(ctx, "abc")
+	_ = m.IncrementClicks(ctx, "abc")
+
+	keys, _ := m.ScanClickKeys(ctx)
+	if len(keys) != 1 || ExtractShortCodeFromClickKey(keys[0]) != "abc" {
+		t.Fatalf("ScanClickKeys() = %v, want [clicks:abc]", keys)
+	}
+
+	if n, _ := m.GetAndResetClicks(ctx, "abc"); n != 2 {
+		t.Errorf("GetAndResetClicks() = %d, want 2", n)
+	}
+	if n, _ := m.GetAndResetClicks(ctx, "abc"); n != 0 {
+		t.Errorf("GetAndResetClicks() after reset = %d, want 0", n)
+	}
+}
+
+func TestMemory_AuthContext(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, now := newTestMemory(t)
+
+	auth := &model.AuthContext{KeyID: "k1", UserID: "u1", Scopes: []string{"read"}}
+	_ = m.SetAuthContext(ctx, "hash", auth)
+	auth.Scopes[0] = "admin" // caller's copy must not leak into the cache
+
+	got, _ := m.GetAuthContext(ctx, "hash")
+	if got == nil || got.KeyID != "k1" || got.Scopes[0] != "read" {
+		t.Fatalf("GetAuthContext() = %+v, want cached context", got)
+	}
+
+	*now = now.Add(authCacheTTL)
+	if got, _ := m.GetAuthContext(ctx, "hash"); got != nil {
+		t.Error("auth context should expire")
+	}
+}
+
+func TestMemory_RateLimit(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	m, now := newTestMemory(t)
+
+	for i := 0; i < 3; i++ {
+		res, _ := m.CheckIPRateLimit(ctx, "203.0.113.1", 1, 3)
+		if !res.Allowed {
+			t.Fatalf("request %d should be allowed within burst", i+1)
+		}
+	}
+
+	res, _ := m.CheckIPRateLimit(ctx, "203.0.113.1", 1, 3)
+	if res.Allowed || res.RetryAfter != time.Second {
+		t.Fatalf("got allowed=%v retry=%v, want denied with 1s retry", res.Allowed, res.RetryAfter)
+	}
+
+	// Other clients have their own bucket
+	if res, _ := m.CheckIPRateLimit(ctx, "203.0.113.2", 1, 3); !res.Allowed {
+		t.Error("separate IP should be allowed")
+	}
+
+	// Tokens refill over time
+	*now = now.Add(time.Second)
+	if res, _ := m.CheckIPRateLimit(ctx, "203.0.113.1", 1, 3); !res.Allowed {
+		t.Error("request should be allowed after refill")
+	}
+}
@@ -2,6 +2,8 @@ package cache
 
 import (
 	"testing"
+
+	"github.com/redis/go-redis/v9"
 )
 
 func TestHashIP_Deterministic(t *testing.T) {
@@ -138,3 +140,68 @@ func TestExtractShortCodeFromClickKey_NoPrefix(t *testing.T) {
 		})
 	}
 }
+
+func TestKeysShareHashTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		key  string
+		want string
+	}{
+		{"link", linkKey("abc"), "link:{abc}"},
+		{"negative cache", linkKey("abc") + negCacheKeySuffix, "link:{abc}:neg"},
+		{"refill lock", linkKey("abc") + refillLockSuffix, "link:{abc}:refill"},
+		{"api key bucket", rateLimitKey(rateLimitAPIPrefix, "key-1"), "ratelimit:apikey:{key-1}"},
+		{"ip bucket", rateLimitKey(rateLimitIPPrefix, "0011"), "ratelimit:ip:{0011}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.key != tt.want {
+				t.Errorf("key = %q, want %q", tt.key, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRedisClient_Modes(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		cfg     RedisConfig
+		wantErr bool
+		cluster bool
+	}{
+		{"standalone", RedisConfig{URL: "redis://localhost:6379"}, false, false},
+		{"standalone without url", RedisConfig{Mode: RedisModeStandalone}, true, false},
+		{"sentinel", RedisConfig{Mode: RedisModeSentinel, URL: "redis://:secret@/2", Addrs: []string{"s1:26379"}, MasterName: "mymaster"}, false, false},
+		{"sentinel without master", RedisConfig{Mode: RedisModeSentinel, Addrs: []string{"s1:26379"}}, true, false},
+		{"cluster", RedisConfig{Mode: RedisModeCluster, Addrs: []string{"n1:6379", "n2:6379"}}, false, true},
+		{"cluster with db", RedisConfig{Mode: RedisModeCluster, URL: "redis://localhost/3", Addrs: []string{"n1:6379"}}, true, true},
+		{"unknown", RedisConfig{Mode: "replicated"}, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			client, err := newRedisClient(tt.cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newRedisClient() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			defer client.Close()
+
+			_, isCluster := client.(*redis.ClusterClient)
+			if isCluster != tt.cluster {
+				t.Errorf("cluster client = %v, want %v", isCluster, tt.cluster)
+			}
+		})
+	}
+}
@@ -30,6 +30,7 @@ type RateLimitResult struct {
 
 // tokenBucketScript is a Lua script implementing the token bucket algorithm.
 // It's atomic and handles token refill and consumption in a single operation.
+// All state lives under KEYS[1], which keeps it valid in Redis Cluster.
 var tokenBucketScript = redis.NewScript(`
 	local key = KEYS[1]
 	local rate = tonumber(ARGV[1])      -- tokens per second
@@ -77,7 +78,7 @@ func (c *Cache) CheckAPIRateLimit(ctx context.Context, keyID string, ratePerMinu
 		}, nil
 	}
 
-	key := rateLimitAPIPrefix + keyID
+	key := rateLimitKey(rateLimitAPIPrefix, keyID)
 	ratePerSecond := float64(ratePerMinute) / 60.0
 
 	return c.checkRateLimit(ctx, key, ratePerSecond, burst, int(rateLimitAPITTL.Seconds()))
@@ -88,7 +89,7 @@ func (c *Cache) CheckAPIRateLimit(ctx context.Context, keyID string, ratePerMinu
 func (c *Cache) CheckIPRateLimit(ctx context.Context, ip string, ratePerSecond, burst int) (*RateLimitResult, error) {
 	// Hash IP for privacy
 	hashedIP := hashIP(ip)
-	key := rateLimitIPPrefix + hashedIP
+	key := rateLimitKey(rateLimitIPPrefix, hashedIP)
 
 	return c.checkRateLimit(ctx, key, float64(ratePerSecond), burst, int(rateLimitIPTTL.Seconds()))
 }
@@ -123,6 +124,13 @@ func (c *Cache) checkRateLimit(ctx context.Context, key string, rate float64, bu
 	}, nil
 }
 
+// rateLimitKey builds a bucket key with the identifier as a hash tag. The
+// token bucket script only touches KEYS[1], so it runs on whichever Redis
+// Cluster node owns that slot.
+func rateLimitKey(prefix, id string) string {
+	return prefix + "{" + id + "}"
+}
+
 // hashIP creates a truncated SHA256 hash of an IP address.
 // This provides privacy while maintaining uniqueness.
 func hashIP(ip string) string {
@@ -3,40 +3,144 @@ package cache
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/redis/go-redis/v9"
 )
 
+// Redis deployment modes accepted by RedisConfig.Mode.
+const (
+	RedisModeStandalone = "standalone"
+	RedisModeSentinel   = "sentinel"
+	RedisModeCluster    = "cluster"
+)
+
 // Cache provides Redis cache access methods.
 type Cache struct {
-	client *redis.Client
+	client redis.UniversalClient
+
+	// Optional in-process link cache, see EnableLocalCache
+	local      *LocalLinkCache
+	instanceID string
+
+	// Optional circuit breaker, see EnableBreaker
+	breaker       *Breaker
+	pendingMu     sync.Mutex
+	pendingClicks map[string]int64
 }
 
-// New creates a new Cache with a Redis client.
+// RedisConfig describes how to reach Redis.
+type RedisConfig struct {
+	// Mode is RedisModeStandalone (default), RedisModeSentinel or
+	// RedisModeCluster.
+	Mode string
+
+	// URL is the connection URL in standalone mode. In sentinel and
+	// cluster mode it is optional and only supplies credentials, TLS and,
+	// for sentinel, the database number; its host is ignored.
+	URL string
+
+	// Addrs lists sentinel addresses (sentinel mode) or cluster seed
+	// nodes (cluster mode) as host:port.
+	Addrs []string
+
+	// MasterName is the monitored master set name in sentinel mode.
+	MasterName string
+
+	// SentinelPassword authenticates to the sentinels, if they require it.
+	SentinelPassword string
+}
+
+// New creates a new Cache with a standalone Redis client.
 func New(ctx context.Context, redisURL string) (*Cache, error) {
-	opt, err := redis.ParseURL(redisURL)
+	return NewFromConfig(ctx, RedisConfig{URL: redisURL})
+}
+
+// NewFromConfig creates a new Cache for a standalone, sentinel-managed or
+// clustered Redis deployment.
+func NewFromConfig(ctx context.Context, cfg RedisConfig) (*Cache, error) {
+	client, err := newRedisClient(cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		return nil, err
 	}
 
-	// Connection pool settings
-	opt.PoolSize = 10
-	opt.MinIdleConns = 2
-	opt.PoolTimeout = 4 * time.Second
-	opt.ConnMaxIdleTime = 5 * time.Minute
-
-	client := redis.NewClient(opt)
-
 	// Verify connection
 	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
 		return nil, fmt.Errorf("failed to ping Redis: %w", err)
 	}
 
 	return &Cache{client: client}, nil
 }
 
+// newRedisClient builds the go-redis client for cfg.Mode.
+func newRedisClient(cfg RedisConfig) (redis.UniversalClient, error) {
+	base := &redis.Options{}
+	if cfg.URL != "" {
+		opt, err := redis.ParseURL(cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+		}
+		base = opt
+	}
+
+	// Connection pool settings
+	base.PoolSize = 10
+	base.MinIdleConns = 2
+	base.PoolTimeout = 4 * time.Second
+	base.ConnMaxIdleTime = 5 * time.Minute
+
+	switch cfg.Mode {
+	case "", RedisModeStandalone:
+		if cfg.URL == "" {
+			return nil, errors.New("redis URL is required in standalone mode")
+		}
+		return redis.NewClient(base), nil
+
+	case RedisModeSentinel:
+		if len(cfg.Addrs) == 0 || cfg.MasterName == "" {
+			return nil, errors.New("sentinel mode requires sentinel addresses and a master name")
+		}
+		return redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       cfg.MasterName,
+			SentinelAddrs:    cfg.Addrs,
+			SentinelPassword: cfg.SentinelPassword,
+			Username:         base.Username,
+			Password:         base.Password,
+			DB:               base.DB,
+			TLSConfig:        base.TLSConfig,
+			PoolSize:         base.PoolSize,
+			MinIdleConns:     base.MinIdleConns,
+			PoolTimeout:      base.PoolTimeout,
+			ConnMaxIdleTime:  base.ConnMaxIdleTime,
+		}), nil
+
+	case RedisModeCluster:
+		if len(cfg.Addrs) == 0 {
+			return nil, errors.New("cluster mode requires node addresses")
+		}
+		if base.DB != 0 {
+			return nil, errors.New("redis cluster only supports database 0")
+		}
+		return redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:           cfg.Addrs,
+			Username:        base.Username,
+			Password:        base.Password,
+			TLSConfig:       base.TLSConfig,
+			PoolSize:        base.PoolSize,
+			MinIdleConns:    base.MinIdleConns,
+			PoolTimeout:     base.PoolTimeout,
+			ConnMaxIdleTime: base.ConnMaxIdleTime,
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unknown Redis mode %q", cfg.Mode)
+	}
+}
+
 // Ping checks Redis connectivity.
 func (c *Cache) Ping(ctx context.Context) error {
 	return c.client.Ping(ctx).Err()
@@ -47,8 +151,9 @@ func (c *Cache) Close() error {
 	return c.client.Close()
 }
 
-// Client returns the underlying Redis client.
+// Client returns the underlying Redis client: a *redis.Client in standalone
+// and sentinel mode, a *redis.ClusterClient in cluster mode.
 // Use sparingly - prefer adding methods to Cache.
-func (c *Cache) Client() *redis.Client {
+func (c *Cache) Client() redis.UniversalClient {
 	return c.client
 }
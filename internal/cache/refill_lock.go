@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// refillLockSuffix marks the key held by the replica refilling a link.
+const refillLockSuffix = ":refill"
+
+// releaseLockScript deletes the lock only if it still holds our token, so an
+// expired lock taken over by another replica is left alone.
+var releaseLockScript = redis.NewScript(`
+	if redis.call('GET', KEYS[1]) == ARGV[1] then
+		return redis.call('DEL', KEYS[1])
+	end
+	return 0
+`)
+
+// AcquireRefillLock tries to become the only replica loading a link from the
+// database. It returns a release function when the lock was acquired, or nil
+// if another replica holds it. The lock expires after ttl in any case.
+func (c *Cache) AcquireRefillLock(ctx context.Context, shortCode string, ttl time.Duration) (func(), error) {
+	key := linkKey(shortCode) + refillLockSuffix
+	token := newInstanceID()
+
+	ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to acquire refill lock: %w", err)
+	}
+	if !ok {
+		return nil, nil
+	}
+
+	release := func() {
+		// Release even if the request context is gone
+		releaseCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), time.Second)
+		defer cancel()
+		_ = releaseLockScript.Run(releaseCtx, c.client, []string{key}, token).Err()
+	}
+	return release, nil
+}
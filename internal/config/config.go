@@ -3,6 +3,7 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -20,8 +21,40 @@ type Config struct {
 	// Database (PostgreSQL)
 	DatabaseURL string `env:"DATABASE_URL,required"`
 
-	// Cache (Redis)
-	RedisURL string `env:"REDIS_URL,required"`
+	// Optional read replica for lag-tolerant reads (analytics, link listing)
+	DatabaseReadURL       string        `env:"DATABASE_READ_URL"`
+	DatabaseCheckInterval time.Duration `env:"DATABASE_CHECK_INTERVAL" envDefault:"5s"`
+
+	// Cache backend: "redis" (default) or "memory" for a single instance
+	// without Redis. REDIS_URL is required for the redis backend.
+	CacheBackend string `env:"CACHE_BACKEND" envDefault:"redis"`
+	RedisURL     string `env:"REDIS_URL"`
+
+	// Redis deployment: "standalone" (REDIS_URL), "sentinel" or "cluster".
+	// Sentinel and cluster read node addresses from REDIS_ADDRS; REDIS_URL
+	// then only supplies credentials and TLS.
+	RedisMode             string   `env:"REDIS_MODE" envDefault:"standalone"`
+	RedisAddrs            []string `env:"REDIS_ADDRS" envSeparator:","`
+	RedisSentinelMaster   string   `env:"REDIS_SENTINEL_MASTER"`
+	RedisSentinelPassword string   `env:"REDIS_SENTINEL_PASSWORD"`
+
+	// In-process link cache in front of Redis (size 0 disables it)
+	LocalCacheSize int           `env:"LOCAL_CACHE_SIZE" envDefault:"10000"`
+	LocalCacheTTL  time.Duration `env:"LOCAL_CACHE_TTL" envDefault:"30s"`
+
+	// Cross-replica refill lock on cache misses (TTL 0 disables it)
+	CacheRefillLockTTL time.Duration `env:"CACHE_REFILL_LOCK_TTL" envDefault:"0s"`
+	CacheRefillWait    time.Duration `env:"CACHE_REFILL_WAIT" envDefault:"100ms"`
+
+	// Upper bound on a shared cache-miss lookup, including the refill wait
+	LinkLookupTimeout time.Duration `env:"LINK_LOOKUP_TIMEOUT" envDefault:"3s"`
+
+	// Redis circuit breaker (threshold 0 disables it) and the cache used
+	// for redirects while it is open
+	RedisBreakerThreshold int           `env:"REDIS_BREAKER_THRESHOLD" envDefault:"5"`
+	RedisBreakerCooldown  time.Duration `env:"REDIS_BREAKER_COOLDOWN" envDefault:"5s"`
+	DegradedCacheSize     int           `env:"DEGRADED_CACHE_SIZE" envDefault:"1000"`
+	DegradedCacheTTL      time.Duration `env:"DEGRADED_CACHE_TTL" envDefault:"5s"`
 
 	// Base URL for short links (e.g., https://pen.sh)
 	BaseURL string `env:"BASE_URL" envDefault:"http://localhost:8080"`
@@ -50,6 +83,46 @@ type Config struct {
 
 	// Webhooks
 	WebhookAllowInsecure bool `env:"WEBHOOK_ALLOW_INSECURE" envDefault:"false"`
+
+	// Geo: request header carrying the visitor's ISO country code
+	GeoCountryHeader string `env:"GEO_COUNTRY_HEADER" envDefault:"CF-IPCountry"`
+
+	// Geo: optional MaxMind DB (.mmdb) for country, region and city lookups,
+	// checked for changes every GeoIPReload (0 disables reloading)
+	GeoIPDatabase string        `env:"GEOIP_DATABASE" envDefault:""`
+	GeoIPReload   time.Duration `env:"GEOIP_RELOAD" envDefault:"1m"`
+
+	// Bot detection: optional file of extra User-Agent signatures, checked for
+	// changes every BotSignaturesReload (0 disables reloading)
+	BotSignaturesFile   string        `env:"BOT_SIGNATURES_FILE" envDefault:""`
+	BotSignaturesReload time.Duration `env:"BOT_SIGNATURES_RELOAD" envDefault:"1m"`
+
+	// Analytics: hourly stats older than AnalyticsHourlyRetention are pruned
+	// every AnalyticsRollupInterval (0 disables pruning)
+	AnalyticsHourlyRetention time.Duration `env:"ANALYTICS_HOURLY_RETENTION" envDefault:"720h"`
+	AnalyticsRollupInterval  time.Duration `env:"ANALYTICS_ROLLUP_INTERVAL" envDefault:"1h"`
+
+	// Analytics: raw click events are dropped a whole month at a time once
+	// older than this many days; daily stats are kept (0 keeps raw events)
+	AnalyticsRawRetentionDays int `env:"ANALYTICS_RAW_RETENTION_DAYS" envDefault:"0"`
+
+	// Analytics: include user agents in raw click exports (privacy: off by default)
+	AnalyticsExportUserAgents bool `env:"ANALYTICS_EXPORT_USER_AGENTS" envDefault:"false"`
+
+	// Analytics: live click streams allowed per API key on each instance,
+	// and the interval between heartbeat comments
+	AnalyticsLiveMaxStreams int           `env:"ANALYTICS_LIVE_MAX_STREAMS" envDefault:"3"`
+	AnalyticsLiveHeartbeat  time.Duration `env:"ANALYTICS_LIVE_HEARTBEAT" envDefault:"15s"`
+
+	// Analytics: optional on-disk spool for click events that cannot be added
+	// to the stream while Redis is unreachable (empty directory disables it).
+	// Sync is "always", "interval" (every AnalyticsSpoolSyncInterval) or "never".
+	AnalyticsSpoolDir            string        `env:"ANALYTICS_SPOOL_DIR" envDefault:""`
+	AnalyticsSpoolMaxBytes       int64         `env:"ANALYTICS_SPOOL_MAX_BYTES" envDefault:"268435456"`
+	AnalyticsSpoolSegmentBytes   int64         `env:"ANALYTICS_SPOOL_SEGMENT_BYTES" envDefault:"8388608"`
+	AnalyticsSpoolSync           string        `env:"ANALYTICS_SPOOL_SYNC" envDefault:"interval"`
+	AnalyticsSpoolSyncInterval   time.Duration `env:"ANALYTICS_SPOOL_SYNC_INTERVAL" envDefault:"1s"`
+	AnalyticsSpoolReplayInterval time.Duration `env:"ANALYTICS_SPOOL_REPLAY_INTERVAL" envDefault:"5s"`
 }
 
 // IsDevelopment returns true if running in development mode.
@@ -88,6 +161,53 @@ func Load() (*Config, error) {
 	if err := env.Parse(cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config: %w", err)
 	}
+
+	switch cfg.CacheBackend {
+	case "redis":
+		if err := cfg.validateRedis(); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	case "memory":
+	default:
+		return nil, fmt.Errorf("failed to parse config: unknown CACHE_BACKEND %q", cfg.CacheBackend)
+	}
+
+	if cfg.AnalyticsSpoolDir != "" {
+		if err := cfg.validateSpool(); err != nil {
+			return nil, fmt.Errorf("failed to parse config: %w", err)
+		}
+	}
+
 	return cfg, nil
 }
 
+// validateSpool checks the analytics spool sizes. The sync policy name is
+// parsed by analytics.ParseSyncPolicy when the spool is opened.
+func (c *Config) validateSpool() error {
+	if c.AnalyticsSpoolSegmentBytes <= 0 || c.AnalyticsSpoolMaxBytes < c.AnalyticsSpoolSegmentBytes {
+		return errors.New("ANALYTICS_SPOOL_MAX_BYTES must be at least ANALYTICS_SPOOL_SEGMENT_BYTES, which must be positive")
+	}
+	return nil
+}
+
+// validateRedis checks that the settings for RedisMode are present.
+func (c *Config) validateRedis() error {
+	switch c.RedisMode {
+	case "standalone":
+		if c.RedisURL == "" {
+			return errors.New("REDIS_URL is required when CACHE_BACKEND=redis")
+		}
+	case "sentinel":
+		if len(c.RedisAddrs) == 0 || c.RedisSentinelMaster == "" {
+			return errors.New("REDIS_ADDRS and REDIS_SENTINEL_MASTER are required when REDIS_MODE=sentinel")
+		}
+	case "cluster":
+		if len(c.RedisAddrs) == 0 {
+			return errors.New("REDIS_ADDRS is required when REDIS_MODE=cluster")
+		}
+	default:
+		return fmt.Errorf("unknown REDIS_MODE %q", c.RedisMode)
+	}
+	return nil
+}
+
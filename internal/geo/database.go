@@ -0,0 +1,162 @@
+package geo
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// maxCityLength bounds stored city names; longer names are dropped.
+const maxCityLength = 100
+
+// Location is where an IP address resolves to. Empty fields are unknown.
+type Location struct {
+	Country string // ISO 3166-1 alpha-2, e.g. "US"
+	Region  string // ISO 3166-2 subdivision, e.g. "US-CA"
+	City    string // English city name
+}
+
+// Locator resolves an IP address to a location.
+type Locator interface {
+	Locate(ip net.IP) Location
+}
+
+// cityRecord is the subset of the GeoIP2/GeoLite2 City schema we read.
+// Country databases have no subdivisions or city and decode fine.
+type cityRecord struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// Database locates IP addresses with a MaxMind DB (.mmdb) file such as
+// GeoLite2-City. The file is read into memory, so it can be replaced while
+// in use; Watch picks up the new copy.
+type Database struct {
+	mu      sync.RWMutex
+	reader  *maxminddb.Reader
+	modTime time.Time
+}
+
+// OpenDatabase loads the MaxMind DB file at path.
+func OpenDatabase(path string) (*Database, error) {
+	db := &Database{}
+	if err := db.LoadFile(path); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// LoadFile replaces the database with the file at path. On error the
+// previous database stays in use.
+func (d *Database) LoadFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat geoip database: %w", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read geoip database: %w", err)
+	}
+
+	reader, err := maxminddb.FromBytes(data)
+	if err != nil {
+		return fmt.Errorf("open geoip database: %w", err)
+	}
+
+	d.mu.Lock()
+	d.reader = reader
+	d.modTime = info.ModTime()
+	d.mu.Unlock()
+
+	return nil
+}
+
+// Locate implements Locator. Addresses the database does not cover, and
+// lookup errors, yield an empty Location.
+func (d *Database) Locate(ip net.IP) Location {
+	if ip == nil {
+		return Location{}
+	}
+
+	d.mu.RLock()
+	reader := d.reader
+	d.mu.RUnlock()
+	if reader == nil {
+		return Location{}
+	}
+
+	var record cityRecord
+	if err := reader.Lookup(ip, &record); err != nil {
+		return Location{}
+	}
+
+	loc := Location{Country: NormalizeCountry(record.Country.ISOCode)}
+	if city := record.City.Names["en"]; len(city) <= maxCityLength {
+		loc.City = city
+	}
+	// ISO 3166-2 subdivision codes are at most three characters
+	if loc.Country != "" && len(record.Subdivisions) > 0 {
+		if code := record.Subdivisions[0].ISOCode; code != "" && len(code) <= 3 {
+			loc.Region = loc.Country + "-" + strings.ToUpper(code)
+		}
+	}
+	return loc
+}
+
+// Watch reloads the database file whenever its modification time changes,
+// until ctx is cancelled. A file that fails to load keeps the previous database.
+func (d *Database) Watch(ctx context.Context, path string, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			info, err := os.Stat(path)
+			if err != nil {
+				logger.Warn("geoip database unavailable", "path", path, "error", err)
+				continue
+			}
+
+			d.mu.RLock()
+			unchanged := info.ModTime().Equal(d.modTime)
+			d.mu.RUnlock()
+			if unchanged {
+				continue
+			}
+
+			if err := d.LoadFile(path); err != nil {
+				logger.Error("failed to reload geoip database", "path", path, "error", err)
+				continue
+			}
+			logger.Info("geoip database reloaded", "path", path)
+		}
+	}
+}
+
+// ParseIP parses a client address as reported by proxies or RemoteAddr,
+// with or without a port. Returns nil if addr is not an IP address.
+func ParseIP(addr string) net.IP {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	return net.ParseIP(strings.Trim(addr, "[]"))
+}
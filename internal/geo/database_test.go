@@ -0,0 +1,254 @@
+package geo
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testNetwork is one entry of a fixture database.
+type testNetwork struct {
+	cidr    string
+	country string
+	region  string // Subdivision code without the country, e.g. "ENG"
+	city    string
+}
+
+// writeTestDatabase writes a minimal IPv4 MaxMind DB with the City schema.
+// Networks must not overlap.
+func writeTestDatabase(t *testing.T, path string, networks []testNetwork) {
+	t.Helper()
+
+	const empty = -1
+	nodes := [][2]int{{empty, empty}}
+	var data mmdbEncoder
+
+	for _, network := range networks {
+		_, ipNet, err := net.ParseCIDR(network.cidr)
+		if err != nil {
+			t.Fatalf("parse %s: %v", network.cidr, err)
+		}
+		ip := ipNet.IP.To4()
+		ones, _ := ipNet.Mask.Size()
+
+		// Data records are stored as -(offset + 2) until the tree size is known
+		record := -(len(data.buf) + 2)
+		data.value(map[string]any{
+			"country":      map[string]any{"iso_code": network.country},
+			"subdivisions": []any{map[string]any{"iso_code": network.region}},
+			"city":         map[string]any{"names": map[string]any{"en": network.city}},
+		})
+
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if i == ones-1 {
+				nodes[node][bit] = record
+				break
+			}
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	nodeCount := len(nodes)
+	var out []byte
+	for _, node := range nodes {
+		for _, record := range node {
+			value := record
+			switch {
+			case record == empty:
+				value = nodeCount
+			case record < 0:
+				value = nodeCount + 16 + (-record - 2)
+			}
+			out = append(out, byte(value>>16), byte(value>>8), byte(value))
+		}
+	}
+	out = append(out, make([]byte, 16)...)
+	out = append(out, data.buf...)
+	out = append(out, "\xAB\xCD\xEFMaxMind.com"...)
+
+	var metadata mmdbEncoder
+	metadata.value(map[string]any{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "Penshort-Test-City",
+		"languages":                   []any{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(time.Now().Unix()),
+		"description":                 map[string]any{"en": "test fixture"},
+	})
+	out = append(out, metadata.buf...)
+
+	if err := os.WriteFile(path, out, 0o644); err != nil {
+		t.Fatalf("write database: %v", err)
+	}
+}
+
+// mmdbEncoder encodes values in the MaxMind DB data section format.
+// Only sizes below 29 are supported, which is plenty for fixtures.
+type mmdbEncoder struct {
+	buf []byte
+}
+
+func (e *mmdbEncoder) control(typ byte, size int) {
+	if typ <= 7 {
+		e.buf = append(e.buf, typ<<5|byte(size))
+		return
+	}
+	e.buf = append(e.buf, byte(size), typ-7)
+}
+
+func (e *mmdbEncoder) uint(typ byte, v uint64) {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], v)
+	n := 8
+	for n > 0 && b[8-n] == 0 {
+		n--
+	}
+	e.control(typ, n)
+	e.buf = append(e.buf, b[8-n:]...)
+}
+
+func (e *mmdbEncoder) value(v any) {
+	switch v := v.(type) {
+	case string:
+		e.control(2, len(v))
+		e.buf = append(e.buf, v...)
+	case uint16:
+		e.uint(5, uint64(v))
+	case uint32:
+		e.uint(6, uint64(v))
+	case uint64:
+		e.uint(9, v)
+	case map[string]any:
+		e.control(7, len(v))
+		for key, value := range v {
+			e.value(key)
+			e.value(value)
+		}
+	case []any:
+		e.control(11, len(v))
+		for _, value := range v {
+			e.value(value)
+		}
+	}
+}
+
+func TestDatabase_Locate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestDatabase(t, path, []testNetwork{
+		{"81.2.69.0/24", "GB", "eng", "London"},
+		{"2.125.160.0/19", "gb", "", ""},
+	})
+
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want Location
+	}{
+		{"81.2.69.142", Location{Country: "GB", Region: "GB-ENG", City: "London"}},
+		{"2.125.160.216", Location{Country: "GB"}},
+		{"8.8.8.8", Location{}},
+		{"2001:db8::1", Location{}},
+	}
+	for _, tt := range tests {
+		if got := db.Locate(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("Locate(%s) = %+v, want %+v", tt.ip, got, tt.want)
+		}
+	}
+	if got := db.Locate(nil); got != (Location{}) {
+		t.Errorf("Locate(nil) = %+v, want empty", got)
+	}
+}
+
+func TestDatabase_LoadFileKeepsPreviousOnError(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "city.mmdb")
+	writeTestDatabase(t, path, []testNetwork{{"81.2.69.0/24", "GB", "ENG", "London"}})
+
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	broken := filepath.Join(dir, "broken.mmdb")
+	if err := os.WriteFile(broken, []byte("not a database"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.LoadFile(broken); err == nil {
+		t.Fatal("expected error loading an invalid file")
+	}
+	if got := db.Locate(net.ParseIP("81.2.69.1")); got.City != "London" {
+		t.Errorf("Locate after failed reload = %+v, want previous database", got)
+	}
+}
+
+func TestDatabase_WatchReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "city.mmdb")
+	writeTestDatabase(t, path, []testNetwork{{"81.2.69.0/24", "GB", "ENG", "London"}})
+
+	db, err := OpenDatabase(path)
+	if err != nil {
+		t.Fatalf("OpenDatabase: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go db.Watch(ctx, path, 10*time.Millisecond, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	writeTestDatabase(t, path, []testNetwork{{"81.2.69.0/24", "GB", "SCT", "Edinburgh"}})
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if got := db.Locate(net.ParseIP("81.2.69.1")); got.City == "Edinburgh" {
+			if got.Region != "GB-SCT" {
+				t.Errorf("region = %q, want GB-SCT", got.Region)
+			}
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("database was not reloaded")
+}
+
+func TestParseIP(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"203.0.113.7", "203.0.113.7"},
+		{" 203.0.113.7", "203.0.113.7"},
+		{"203.0.113.7:52100", "203.0.113.7"},
+		{"[2001:db8::1]:443", "2001:db8::1"},
+		{"2001:db8::1", "2001:db8::1"},
+		{"unknown", "<nil>"},
+		{"", "<nil>"},
+	}
+
+	for _, tt := range tests {
+		if got := ParseIP(tt.addr).String(); got != tt.want {
+			t.Errorf("ParseIP(%q) = %s, want %s", tt.addr, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+// Package geo resolves visitor locations for redirect rules and analytics.
+package geo
+
+import (
+	"net/http"
+	"strings"
+)
+
+// DefaultCountryHeader is the header set by Cloudflare with the visitor's country.
+const DefaultCountryHeader = "CF-IPCountry"
+
+// Resolver determines the visitor's country for a request.
+// Implementations return an uppercase ISO 3166-1 alpha-2 code, or "" if
+// the country is unknown.
+type Resolver interface {
+	Country(r *http.Request) string
+}
+
+// HeaderResolver reads the country from a request header set by a CDN or
+// reverse proxy.
+type HeaderResolver struct {
+	Header string
+}
+
+// NewHeaderResolver creates a resolver for the given header.
+// An empty header defaults to CF-IPCountry.
+func NewHeaderResolver(header string) *HeaderResolver {
+	if header == "" {
+		header = DefaultCountryHeader
+	}
+	return &HeaderResolver{Header: header}
+}
+
+// Country implements Resolver.
+func (h *HeaderResolver) Country(r *http.Request) string {
+	return NormalizeCountry(r.Header.Get(h.Header))
+}
+
+// ChainResolver tries resolvers in order and returns the first known country.
+type ChainResolver []Resolver
+
+// Country implements Resolver.
+func (c ChainResolver) Country(r *http.Request) string {
+	for _, resolver := range c {
+		if country := resolver.Country(r); country != "" {
+			return country
+		}
+	}
+	return ""
+}
+
+// NormalizeCountry uppercases a two-letter country code.
+// Returns "" for anything else, including Cloudflare's "XX" (unknown) and
+// "T1" (Tor) markers.
+func NormalizeCountry(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if !IsCountryCode(code) || code == "XX" || code == "T1" {
+		return ""
+	}
+	return code
+}
+
+// IsCountryCode reports whether code is two uppercase ASCII letters.
+func IsCountryCode(code string) bool {
+	if len(code) != 2 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < 'A' || code[i] > 'Z' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,60 @@
+package geo
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"US", "US"},
+		{"us", "US"},
+		{" de ", "DE"},
+		{"XX", ""},
+		{"T1", ""},
+		{"", ""},
+		{"USA", ""},
+		{"1A", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeCountry(tt.input); got != tt.want {
+			t.Errorf("NormalizeCountry(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestHeaderResolver(t *testing.T) {
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("CF-IPCountry", "vn")
+	r.Header.Set("X-Country", "FR")
+
+	if got := NewHeaderResolver("").Country(r); got != "VN" {
+		t.Errorf("default header: got %q, want VN", got)
+	}
+	if got := NewHeaderResolver("X-Country").Country(r); got != "FR" {
+		t.Errorf("custom header: got %q, want FR", got)
+	}
+}
+
+func TestChainResolver(t *testing.T) {
+	r := httptest.NewRequest("GET", "/abc", nil)
+	r.Header.Set("X-Country", "FR")
+
+	chain := ChainResolver{NewHeaderResolver(""), NewHeaderResolver("X-Country")}
+	if got := chain.Country(r); got != "FR" {
+		t.Errorf("got %q, want FR", got)
+	}
+
+	r.Header.Set("CF-IPCountry", "XX")
+	if got := chain.Country(r); got != "FR" {
+		t.Errorf("unknown first resolver: got %q, want FR", got)
+	}
+
+	if got := (ChainResolver{}).Country(r); got != "" {
+		t.Errorf("empty chain: got %q, want empty", got)
+	}
+}
@@ -1,27 +1,37 @@
 package handler
 
 import (
+	"fmt"
 	"log/slog"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/penshort/penshort/internal/handler/dto"
+	"github.com/penshort/penshort/internal/hll"
 	"github.com/penshort/penshort/internal/model"
 	"github.com/penshort/penshort/internal/repository"
+	"github.com/penshort/penshort/internal/service"
 )
 
 // AnalyticsHandler handles analytics API requests.
 type AnalyticsHandler struct {
-	repo   *repository.ClickEventRepository
+	repo   repository.ClickEventStore
+	links  linkGetter
 	logger *slog.Logger
+
+	exportUserAgents bool
 }
 
-// NewAnalyticsHandler creates a new AnalyticsHandler.
-func NewAnalyticsHandler(repo *repository.ClickEventRepository, logger *slog.Logger) *AnalyticsHandler {
+// NewAnalyticsHandler creates a new AnalyticsHandler. links is used to check
+// that exported links belong to the caller.
+func NewAnalyticsHandler(repo repository.ClickEventStore, links linkGetter, logger *slog.Logger) *AnalyticsHandler {
 	return &AnalyticsHandler{
 		repo:   repo,
+		links:  links,
 		logger: logger.With("component", "handler.analytics"),
 	}
 }
@@ -35,34 +45,307 @@ func (h *AnalyticsHandler) GetLinkAnalytics(w http.ResponseWriter, r *http.Reque
 	}
 
 	// Parse query parameters
-	from, to := h.parseTimeRange(r)
+	loc, ok := parseTimezone(r)
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "tz must be an IANA time zone name")
+		return
+	}
+	from, to := h.parseTimeRange(r, loc)
 	includes := h.parseIncludes(r)
+	granularity, ok := parseGranularity(r)
+	if !ok {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "granularity must be one of hour, day, week, month")
+		return
+	}
+
+	var summary *model.AnalyticsSummary
+	var dailyStats []*model.DailyLinkStats
+	var err error
+	if loc == time.UTC {
+		// Get summary
+		summary, err = h.repo.GetAnalyticsSummary(r.Context(), linkID, from, to)
+		if err != nil {
+			h.logger.Error("failed to get analytics summary", "link_id", linkID, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+			return
+		}
+
+		// Get daily breakdown
+		dailyStats, err = h.repo.GetDailyStats(r.Context(), linkID, from, to)
+		if err != nil {
+			h.logger.Error("failed to get daily stats", "link_id", linkID, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+			return
+		}
+	} else {
+		// Stored daily stats are UTC days, so rebuild local days from raw events
+		dailyStats, err = h.repo.GetDailyStatsInZone(r.Context(), linkID, from, to, loc)
+		if err != nil {
+			h.logger.Error("failed to get daily stats", "link_id", linkID, "tz", loc.String(), "error", err)
+			h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+			return
+		}
+		summary = summarizeDailyStats(dailyStats)
+	}
+
+	// Build response
+	response := h.buildAnalyticsResponse(linkID, from, to, summary, dailyStats, includes, r.Context())
+	response.Period.Timezone = loc.String()
+	for _, stat := range dailyStats {
+		if stat.UTC {
+			response.Period.UTCFallback = true
+			break
+		}
+	}
+	response.Granularity = granularity
+
+	if includes["daily"] {
+		switch granularity {
+		case model.GranularityHour:
+			// Hourly rows are kept for a limited window, so cap the range
+			hourFrom := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+			hourTo := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+			if hourTo.Sub(hourFrom) > maxHourlyRange {
+				hourFrom = hourTo.Add(-maxHourlyRange)
+			}
+			hourlyStats, err := h.repo.GetHourlyStats(r.Context(), linkID, hourFrom, hourTo)
+			if err != nil {
+				h.logger.Error("failed to get hourly stats", "link_id", linkID, "error", err)
+				h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+				return
+			}
+			response.Breakdown.Daily = nil
+			response.Breakdown.Timeseries = hourlyBuckets(hourlyStats, loc)
+		case model.GranularityWeek, model.GranularityMonth:
+			response.Breakdown.Daily = nil
+			response.Breakdown.Timeseries = bucketDailyStats(dailyStats, granularity)
+		}
+	}
 
-	// Get summary
-	summary, err := h.repo.GetAnalyticsSummary(r.Context(), linkID, from, to)
+	writeJSON(w, http.StatusOK, response)
+}
+
+// GetAccountAnalytics handles GET /v1/analytics.
+// Aggregates daily stats across the caller's links, optionally narrowed by
+// tag, link_ids and link creation time (created_after, created_before).
+func (h *AnalyticsHandler) GetAccountAnalytics(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	filter, ok := h.parseAccountFilter(w, r)
+	if !ok {
+		return
+	}
+	filter.OwnerID = auth.UserID
+	filter.From, filter.To = h.parseTimeRange(r, time.UTC)
+
+	ctx := r.Context()
+
+	daily, err := h.repo.GetAccountDailyStats(ctx, filter)
 	if err != nil {
-		h.logger.Error("failed to get analytics summary", "link_id", linkID, "error", err)
+		h.logger.Error("failed to get account daily stats", "user_id", auth.UserID, "error", err)
 		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
 		return
 	}
 
-	// Get daily breakdown
-	dailyStats, err := h.repo.GetDailyStats(r.Context(), linkID, from, to)
+	topLinks, err := h.repo.GetAccountTopLinks(ctx, filter, accountTopLimit)
 	if err != nil {
-		h.logger.Error("failed to get daily stats", "link_id", linkID, "error", err)
+		h.logger.Error("failed to get account top links", "user_id", auth.UserID, "error", err)
 		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
 		return
 	}
 
-	// Build response
-	response := h.buildAnalyticsResponse(linkID, from, to, summary, dailyStats, includes, r.Context())
+	referrers, err := h.repo.GetAccountTopReferrers(ctx, filter, accountTopLimit)
+	if err != nil {
+		h.logger.Error("failed to get account top referrers", "user_id", auth.UserID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+		return
+	}
+
+	countries, err := h.repo.GetAccountTopCountries(ctx, filter, accountTopLimit)
+	if err != nil {
+		h.logger.Error("failed to get account top countries", "user_id", auth.UserID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch analytics")
+		return
+	}
+
+	response := &model.AccountAnalyticsResponse{GeneratedAt: time.Now().UTC()}
+	response.Period.From = filter.From.Format("2006-01-02")
+	response.Period.To = filter.To.Format("2006-01-02")
+	for _, day := range daily {
+		response.Summary.TotalClicks += day.TotalClicks
+		response.Summary.UniqueVisitors += day.UniqueVisitors
+		response.Summary.BlockedClicks += day.BlockedClicks
+		response.Summary.BotClicks += day.BotClicks
+	}
+	if len(daily) > 0 {
+		response.Summary.AvgClicksPerDay = float64(response.Summary.TotalClicks) / float64(len(daily))
+	}
+	response.Breakdown.Daily = daily
+	response.Breakdown.TopLinks = topLinks
+	response.Breakdown.Referrers = referrers
+	response.Breakdown.Countries = countries
 
 	writeJSON(w, http.StatusOK, response)
 }
 
-// parseTimeRange extracts from/to dates from query params.
-func (h *AnalyticsHandler) parseTimeRange(r *http.Request) (time.Time, time.Time) {
-	now := time.Now().UTC()
+// accountTopLimit caps each ranking in account analytics.
+const accountTopLimit = 10
+
+// maxAccountLinkIDs caps the link_ids filter of account analytics.
+const maxAccountLinkIDs = 100
+
+// parseAccountFilter reads the account analytics link filters, writing a 400
+// response and returning false when one is invalid.
+func (h *AnalyticsHandler) parseAccountFilter(w http.ResponseWriter, r *http.Request) (repository.AccountStatsFilter, bool) {
+	var filter repository.AccountStatsFilter
+	query := r.URL.Query()
+
+	if tag := query.Get("tag"); tag != "" {
+		normalized, ok := service.NormalizeTag(tag)
+		if !ok {
+			h.writeError(w, http.StatusBadRequest, "INVALID_TAG", "Invalid tag")
+			return filter, false
+		}
+		filter.Tag = normalized
+	}
+
+	filter.LinkIDs = splitComma(query.Get("link_ids"))
+	if len(filter.LinkIDs) > maxAccountLinkIDs {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", fmt.Sprintf("link_ids accepts at most %d IDs", maxAccountLinkIDs))
+		return filter, false
+	}
+
+	for _, param := range []struct {
+		name string
+		dst  **time.Time
+	}{
+		{"created_after", &filter.CreatedAfter},
+		{"created_before", &filter.CreatedBefore},
+	} {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", param.name+" must be an RFC 3339 timestamp")
+			return filter, false
+		}
+		*param.dst = &t
+	}
+
+	return filter, true
+}
+
+// maxHourlyRange caps the time series returned for granularity=hour.
+const maxHourlyRange = 31 * 24 * time.Hour
+
+// parseTimezone reads the tz query param as an IANA zone name, defaulting to UTC.
+func parseTimezone(r *http.Request) (*time.Location, bool) {
+	loc, err := time.LoadLocation(r.URL.Query().Get("tz"))
+	if err != nil {
+		return nil, false
+	}
+	return loc, true
+}
+
+// summarizeDailyStats totals daily stats the way GetAnalyticsSummary does.
+func summarizeDailyStats(stats []*model.DailyLinkStats) *model.AnalyticsSummary {
+	summary := &model.AnalyticsSummary{}
+	visitors := hll.New()
+	for _, stat := range stats {
+		summary.TotalClicks += stat.TotalClicks
+		summary.UniqueVisitors += stat.UniqueVisitors
+		summary.BlockedClicks += stat.BlockedClicks
+		summary.BotClicks += stat.BotClicks
+
+		var sketch hll.Sketch
+		if err := sketch.UnmarshalBinary(stat.VisitorSketch); err == nil {
+			visitors.Merge(&sketch)
+		}
+	}
+	if len(stats) > 0 {
+		summary.AvgClicksPerDay = float64(summary.TotalClicks) / float64(len(stats))
+	}
+	summary.UniqueVisitorsApprox = model.NewUniqueEstimate(visitors)
+	return summary
+}
+
+// parseGranularity reads the granularity query param, defaulting to day.
+func parseGranularity(r *http.Request) (string, bool) {
+	switch g := r.URL.Query().Get("granularity"); g {
+	case "":
+		return model.GranularityDay, true
+	case model.GranularityHour, model.GranularityDay, model.GranularityWeek, model.GranularityMonth:
+		return g, true
+	default:
+		return "", false
+	}
+}
+
+// hourlyBuckets converts hourly stats into time series buckets labelled in loc.
+func hourlyBuckets(stats []*model.HourlyLinkStats, loc *time.Location) []model.TimeBucket {
+	buckets := make([]model.TimeBucket, 0, len(stats))
+	for _, stat := range stats {
+		buckets = append(buckets, model.TimeBucket{
+			Start:          stat.Hour.In(loc).Format(time.RFC3339),
+			TotalClicks:    stat.TotalClicks,
+			UniqueVisitors: stat.UniqueVisitors,
+			BlockedClicks:  stat.BlockedClicks,
+			BotClicks:      stat.BotClicks,
+		})
+	}
+	return buckets
+}
+
+// bucketDailyStats sums daily stats into ISO weeks (starting Monday) or
+// calendar months, newest first. Unique visitors are summed: visitor hashes
+// rotate daily, so a visitor on several days counts once per day.
+func bucketDailyStats(stats []*model.DailyLinkStats, granularity string) []model.TimeBucket {
+	byStart := make(map[time.Time]*model.TimeBucket)
+	for _, stat := range stats {
+		start := bucketStart(stat.Date, granularity)
+		bucket, ok := byStart[start]
+		if !ok {
+			bucket = &model.TimeBucket{Start: start.Format("2006-01-02")}
+			byStart[start] = bucket
+		}
+		bucket.TotalClicks += stat.TotalClicks
+		bucket.UniqueVisitors += stat.UniqueVisitors
+		bucket.BlockedClicks += stat.BlockedClicks
+		bucket.BotClicks += stat.BotClicks
+	}
+
+	starts := make([]time.Time, 0, len(byStart))
+	for start := range byStart {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i].After(starts[j]) })
+
+	buckets := make([]model.TimeBucket, 0, len(starts))
+	for _, start := range starts {
+		buckets = append(buckets, *byStart[start])
+	}
+	return buckets
+}
+
+// bucketStart returns the first day of the week or month containing day.
+func bucketStart(day time.Time, granularity string) time.Time {
+	day = time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	if granularity == model.GranularityMonth {
+		return day.AddDate(0, 0, 1-day.Day())
+	}
+	offset := (int(day.Weekday()) + 6) % 7 // Days since Monday
+	return day.AddDate(0, 0, -offset)
+}
+
+// parseTimeRange extracts from/to dates in loc from query params.
+func (h *AnalyticsHandler) parseTimeRange(r *http.Request, loc *time.Location) (time.Time, time.Time) {
+	now := time.Now().In(loc)
 	defaultFrom := now.AddDate(0, 0, -7) // 7 days ago
 	defaultTo := now
 
@@ -73,13 +356,13 @@ func (h *AnalyticsHandler) parseTimeRange(r *http.Request) (time.Time, time.Time
 	to := defaultTo
 
 	if fromStr != "" {
-		if parsed, err := time.Parse("2006-01-02", fromStr); err == nil {
+		if parsed, err := time.ParseInLocation("2006-01-02", fromStr, loc); err == nil {
 			from = parsed
 		}
 	}
 
 	if toStr != "" {
-		if parsed, err := time.Parse("2006-01-02", toStr); err == nil {
+		if parsed, err := time.ParseInLocation("2006-01-02", toStr, loc); err == nil {
 			to = parsed
 		}
 	}
@@ -106,7 +389,13 @@ func (h *AnalyticsHandler) parseIncludes(r *http.Request) map[string]bool {
 		// Default: include all
 		includes["referrers"] = true
 		includes["countries"] = true
+		includes["regions"] = true
 		includes["daily"] = true
+		includes["blocked"] = true
+		includes["bots"] = true
+		includes["devices"] = true
+		includes["os"] = true
+		includes["browsers"] = true
 		return includes
 	}
 
@@ -141,6 +430,9 @@ func (h *AnalyticsHandler) buildAnalyticsResponse(
 				Date:           stat.Date.Format("2006-01-02"),
 				TotalClicks:    stat.TotalClicks,
 				UniqueVisitors: stat.UniqueVisitors,
+				BlockedClicks:  stat.BlockedClicks,
+				BotClicks:      stat.BotClicks,
+				UTC:            stat.UTC,
 			})
 		}
 	}
@@ -167,6 +459,70 @@ func (h *AnalyticsHandler) buildAnalyticsResponse(
 		response.Breakdown.Countries = sortedCountryBreakdown(countryTotals, 10)
 	}
 
+	// Regions are only recorded when the GeoIP database is configured
+	if includes["regions"] {
+		regionTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for code, count := range stat.RegionBreakdown {
+				regionTotals[code] += count
+			}
+		}
+		response.Breakdown.Regions = sortedRegionBreakdown(regionTotals, 10)
+	}
+
+	// Aggregate geo-blocked attempts by country
+	if includes["blocked"] {
+		blockedTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for code, count := range stat.BlockedCountryBreakdown {
+				blockedTotals[code] += count
+			}
+		}
+		response.Breakdown.Blocked = sortedCountryBreakdown(blockedTotals, 10)
+	}
+
+	// Aggregate bot requests by family
+	if includes["bots"] {
+		botTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for family, count := range stat.BotFamilyBreakdown {
+				botTotals[family] += count
+			}
+		}
+		response.Breakdown.Bots = sortedBotBreakdown(botTotals, 10)
+	}
+
+	// Aggregate parsed User-Agents; devices include bots
+	if includes["devices"] {
+		deviceTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for device, count := range stat.DeviceBreakdown {
+				deviceTotals[device] += count
+			}
+		}
+		response.Breakdown.Devices = sortedDeviceBreakdown(deviceTotals)
+	}
+
+	if includes["os"] {
+		osTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for family, count := range stat.OSBreakdown {
+				osTotals[family] += count
+			}
+		}
+		response.Breakdown.OS = sortedFamilyBreakdown(osTotals, 10)
+	}
+
+	if includes["browsers"] {
+		browserTotals := make(map[string]int64)
+		for _, stat := range dailyStats {
+			for family, count := range stat.UAFamilyBreakdown {
+				browserTotals[family] += count
+			}
+		}
+		response.Breakdown.Browsers = sortedFamilyBreakdown(browserTotals, 10)
+	}
+
 	return response
 }
 
@@ -230,6 +586,99 @@ func sortedCountryBreakdown(m map[string]int64, limit int) []model.CountryBreakd
 	return result
 }
 
+// sortedRegionBreakdown converts map to sorted slice of RegionBreakdown.
+func sortedRegionBreakdown(m map[string]int64, limit int) []model.RegionBreakdown {
+	result := make([]model.RegionBreakdown, 0, len(m))
+	for code, clicks := range m {
+		country, _, _ := strings.Cut(code, "-")
+		result = append(result, model.RegionBreakdown{
+			Code:    code,
+			Country: country,
+			Clicks:  clicks,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Code < result[j].Code
+	})
+
+	if len(result) > limit {
+		return result[:limit]
+	}
+	return result
+}
+
+// sortedBotBreakdown converts map to sorted slice of BotBreakdown.
+func sortedBotBreakdown(m map[string]int64, limit int) []model.BotBreakdown {
+	result := make([]model.BotBreakdown, 0, len(m))
+	for family, clicks := range m {
+		result = append(result, model.BotBreakdown{
+			Family: family,
+			Clicks: clicks,
+		})
+	}
+
+	// Sort by clicks descending
+	for i := 0; i < len(result); i++ {
+		for j := i + 1; j < len(result); j++ {
+			if result[j].Clicks > result[i].Clicks {
+				result[i], result[j] = result[j], result[i]
+			}
+		}
+	}
+
+	if len(result) > limit {
+		return result[:limit]
+	}
+	return result
+}
+
+// sortedDeviceBreakdown converts map to sorted slice of DeviceBreakdown.
+// There are only a handful of device types, so none are cut.
+func sortedDeviceBreakdown(m map[string]int64) []model.DeviceBreakdown {
+	result := make([]model.DeviceBreakdown, 0, len(m))
+	for device, clicks := range m {
+		result = append(result, model.DeviceBreakdown{
+			Device: device,
+			Clicks: clicks,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Device < result[j].Device
+	})
+	return result
+}
+
+// sortedFamilyBreakdown converts map to sorted slice of FamilyBreakdown.
+func sortedFamilyBreakdown(m map[string]int64, limit int) []model.FamilyBreakdown {
+	result := make([]model.FamilyBreakdown, 0, len(m))
+	for family, clicks := range m {
+		result = append(result, model.FamilyBreakdown{
+			Family: family,
+			Clicks: clicks,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Clicks != result[j].Clicks {
+			return result[i].Clicks > result[j].Clicks
+		}
+		return result[i].Family < result[j].Family
+	})
+
+	if len(result) > limit {
+		return result[:limit]
+	}
+	return result
+}
+
 // countryName returns full name for country code.
 func countryName(code string) string {
 	names := map[string]string{
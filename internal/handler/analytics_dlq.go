@@ -0,0 +1,256 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/auth"
+)
+
+// Dead-letter listing limits.
+const (
+	defaultDeadLetterLimit = 50
+	maxDeadLetterLimit     = 500
+
+	// maxDeadLetterIDs bounds the IDs selected by one replay or purge.
+	maxDeadLetterIDs = 1000
+)
+
+// DeadLetterHandler serves the admin endpoints for the analytics
+// dead-letter queue.
+type DeadLetterHandler struct {
+	dlq    *analytics.DeadLetterQueue
+	logger *slog.Logger
+}
+
+// NewDeadLetterHandler creates a new DeadLetterHandler.
+func NewDeadLetterHandler(dlq *analytics.DeadLetterQueue, logger *slog.Logger) *DeadLetterHandler {
+	return &DeadLetterHandler{
+		dlq:    dlq,
+		logger: logger.With("component", "handler.dlq"),
+	}
+}
+
+// DeadLetterResponse is a dead-letter entry.
+type DeadLetterResponse struct {
+	ID             string     `json:"id"`
+	OriginalID     string     `json:"original_id"`
+	Reason         string     `json:"reason"`
+	Detail         string     `json:"detail"`
+	Payload        string     `json:"payload"`
+	DeadLetteredAt *time.Time `json:"dead_lettered_at,omitempty"`
+}
+
+// DeadLetterListResponse is a page of dead-letter entries.
+type DeadLetterListResponse struct {
+	Entries    []DeadLetterResponse `json:"entries"`
+	Depth      int64                `json:"depth"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// DeadLetterSelectionRequest selects entries to replay or purge: either
+// ids, or every entry with reason. Purge also accepts all.
+type DeadLetterSelectionRequest struct {
+	IDs    []string `json:"ids"`
+	Reason string   `json:"reason"`
+	All    bool     `json:"all"`
+}
+
+// DeadLetterReplayResponse reports a replay.
+type DeadLetterReplayResponse struct {
+	Replayed int `json:"replayed"`
+	Skipped  int `json:"skipped"`
+	NotFound int `json:"not_found"`
+}
+
+// DeadLetterPurgeResponse reports a purge.
+type DeadLetterPurgeResponse struct {
+	Purged int `json:"purged"`
+}
+
+// List handles GET /api/v1/admin/analytics/dlq?reason=&cursor=&limit=
+// Entries are returned oldest first.
+func (h *DeadLetterHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	cursor := query.Get("cursor")
+	if cursor != "" && !streamIDPattern.MatchString(cursor) {
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_CURSOR", "cursor must be an entry ID")
+		return
+	}
+	limit := defaultDeadLetterLimit
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxDeadLetterLimit {
+			writeErrorJSON(w, http.StatusBadRequest, "INVALID_LIMIT",
+				fmt.Sprintf("limit must be between 1 and %d", maxDeadLetterLimit))
+			return
+		}
+		limit = n
+	}
+
+	entries, next, err := h.dlq.List(r.Context(), query.Get("reason"), cursor, limit)
+	if err != nil {
+		h.logger.Error("failed to list dead letters", "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list dead letters")
+		return
+	}
+	depth, err := h.dlq.Depth(r.Context())
+	if err != nil {
+		h.logger.Error("failed to read dead-letter depth", "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to list dead letters")
+		return
+	}
+
+	resp := DeadLetterListResponse{
+		Entries:    make([]DeadLetterResponse, 0, len(entries)),
+		Depth:      depth,
+		NextCursor: next,
+	}
+	for _, entry := range entries {
+		resp.Entries = append(resp.Entries, toDeadLetterResponse(entry))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// Get handles GET /api/v1/admin/analytics/dlq/{id}
+func (h *DeadLetterHandler) Get(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !streamIDPattern.MatchString(id) {
+		writeErrorJSON(w, http.StatusNotFound, "NOT_FOUND", "Dead letter not found")
+		return
+	}
+
+	entry, err := h.dlq.Get(r.Context(), id)
+	if errors.Is(err, analytics.ErrDeadLetterNotFound) {
+		writeErrorJSON(w, http.StatusNotFound, "NOT_FOUND", "Dead letter not found")
+		return
+	}
+	if err != nil {
+		h.logger.Error("failed to get dead letter", "id", id, "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get dead letter")
+		return
+	}
+	writeJSON(w, http.StatusOK, toDeadLetterResponse(entry))
+}
+
+// Replay handles POST /api/v1/admin/analytics/dlq/replay
+// Requeues the selected entries onto the click stream.
+func (h *DeadLetterHandler) Replay(w http.ResponseWriter, r *http.Request) {
+	sel, ok := h.decodeSelection(w, r, false)
+	if !ok {
+		return
+	}
+
+	result, err := h.dlq.Replay(r.Context(), sel)
+	if err != nil {
+		h.logger.Error("failed to replay dead letters",
+			"replayed", result.Replayed,
+			"error", err,
+		)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to replay dead letters")
+		return
+	}
+
+	h.logger.Info("dead_letters_replayed",
+		"actor_id", auth.UserIDFromContext(r.Context()),
+		"ids", len(sel.IDs),
+		"reason", sel.Reason,
+		"replayed", result.Replayed,
+		"skipped", result.Skipped,
+	)
+	writeJSON(w, http.StatusOK, DeadLetterReplayResponse{
+		Replayed: result.Replayed,
+		Skipped:  result.Skipped,
+		NotFound: result.NotFound,
+	})
+}
+
+// Purge handles POST /api/v1/admin/analytics/dlq/purge
+// Deletes the selected entries.
+func (h *DeadLetterHandler) Purge(w http.ResponseWriter, r *http.Request) {
+	sel, ok := h.decodeSelection(w, r, true)
+	if !ok {
+		return
+	}
+
+	purged, err := h.dlq.Purge(r.Context(), sel)
+	if err != nil {
+		h.logger.Error("failed to purge dead letters", "purged", purged, "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to purge dead letters")
+		return
+	}
+
+	h.logger.Info("dead_letters_purged",
+		"actor_id", auth.UserIDFromContext(r.Context()),
+		"ids", len(sel.IDs),
+		"reason", sel.Reason,
+		"all", sel.All,
+		"purged", purged,
+	)
+	writeJSON(w, http.StatusOK, DeadLetterPurgeResponse{Purged: purged})
+}
+
+// decodeSelection reads a selection that names exactly one of ids, reason
+// or, when allowAll is set, all.
+func (h *DeadLetterHandler) decodeSelection(w http.ResponseWriter, r *http.Request, allowAll bool) (analytics.DeadLetterSelection, bool) {
+	var req DeadLetterSelectionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return analytics.DeadLetterSelection{}, false
+	}
+	if req.All && !allowAll {
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_REQUEST", "all is only supported by purge")
+		return analytics.DeadLetterSelection{}, false
+	}
+
+	selectors := 0
+	for _, set := range []bool{len(req.IDs) > 0, req.Reason != "", req.All} {
+		if set {
+			selectors++
+		}
+	}
+	if selectors != 1 {
+		msg := "Exactly one of ids or reason is required"
+		if allowAll {
+			msg = "Exactly one of ids, reason or all is required"
+		}
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_REQUEST", msg)
+		return analytics.DeadLetterSelection{}, false
+	}
+	if len(req.IDs) > maxDeadLetterIDs {
+		writeErrorJSON(w, http.StatusBadRequest, "TOO_MANY_IDS",
+			fmt.Sprintf("At most %d ids per request", maxDeadLetterIDs))
+		return analytics.DeadLetterSelection{}, false
+	}
+	for _, id := range req.IDs {
+		if !streamIDPattern.MatchString(id) {
+			writeErrorJSON(w, http.StatusBadRequest, "INVALID_ID", fmt.Sprintf("Invalid entry ID %q", id))
+			return analytics.DeadLetterSelection{}, false
+		}
+	}
+
+	return analytics.DeadLetterSelection{IDs: req.IDs, Reason: req.Reason, All: req.All}, true
+}
+
+func toDeadLetterResponse(entry *analytics.DeadLetter) DeadLetterResponse {
+	resp := DeadLetterResponse{
+		ID:         entry.ID,
+		OriginalID: entry.OriginalID,
+		Reason:     entry.Reason,
+		Detail:     entry.Detail,
+		Payload:    entry.Payload,
+	}
+	if !entry.DeadLetteredAt.IsZero() {
+		at := entry.DeadLetteredAt
+		resp.DeadLetteredAt = &at
+	}
+	return resp
+}
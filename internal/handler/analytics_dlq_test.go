@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/analytics"
+)
+
+func newDeadLetterTestServer(t *testing.T) (*analytics.MemoryQueue, http.Handler) {
+	t.Helper()
+	queue := analytics.NewMemoryQueue()
+	for _, reason := range []string{"unmarshal_error", "validation_error", "unmarshal_error"} {
+		if err := queue.DeadLetter(context.Background(), map[string]any{
+			"original_id":      "1-0",
+			"reason":           reason,
+			"detail":           "bad event",
+			"payload":          `{"short_code":""}`,
+			"dead_lettered_at": "2026-01-12T08:30:00Z",
+		}); err != nil {
+			t.Fatalf("DeadLetter: %v", err)
+		}
+	}
+
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	h := NewDeadLetterHandler(analytics.NewDeadLetterQueue(queue, logger), logger)
+	r := chi.NewRouter()
+	r.Get("/dlq", h.List)
+	r.Get("/dlq/{id}", h.Get)
+	r.Post("/dlq/replay", h.Replay)
+	r.Post("/dlq/purge", h.Purge)
+	return queue, r
+}
+
+func TestDeadLetterHandler_List(t *testing.T) {
+	_, server := newDeadLetterTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dlq?reason=unmarshal_error&limit=1", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var page DeadLetterListResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Entries) != 1 || page.Depth != 3 || page.NextCursor != page.Entries[0].ID {
+		t.Fatalf("page = %+v, want 1 entry of 3 with a cursor", page)
+	}
+	if entry := page.Entries[0]; entry.Reason != "unmarshal_error" || entry.DeadLetteredAt == nil {
+		t.Errorf("entry = %+v", entry)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dlq/"+page.Entries[0].ID, nil))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"detail":"bad event"`) {
+		t.Errorf("get = %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for _, path := range []string{"/dlq?limit=0", "/dlq?cursor=abc"} {
+		rec = httptest.NewRecorder()
+		server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s = %d, want 400", path, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dlq/9-9", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET missing = %d, want 404", rec.Code)
+	}
+}
+
+func TestDeadLetterHandler_ReplayAndPurge(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantBody   string
+	}{
+		{name: "replay reason", path: "/dlq/replay", body: `{"reason":"unmarshal_error"}`, wantStatus: http.StatusOK, wantBody: `"replayed":2`},
+		{name: "purge all", path: "/dlq/purge", body: `{"all":true}`, wantStatus: http.StatusOK, wantBody: `"purged":3`},
+		{name: "invalid json", path: "/dlq/replay", body: `{`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON"},
+		{name: "no selector", path: "/dlq/purge", body: `{}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "two selectors", path: "/dlq/purge", body: `{"ids":["1-0"],"reason":"x"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "replay all", path: "/dlq/replay", body: `{"all":true}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid id", path: "/dlq/replay", body: `{"ids":["abc"]}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			queue, server := newDeadLetterTestServer(t)
+
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body)))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantCode != "" {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+				if resp.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+				}
+				if depth, _ := queue.DeadLetterDepth(context.Background()); depth != 3 {
+					t.Errorf("depth = %d after a rejected request, want 3", depth)
+				}
+				return
+			}
+			if !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("body = %s, want %s", rec.Body.String(), tt.wantBody)
+			}
+		})
+	}
+}
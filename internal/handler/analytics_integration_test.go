@@ -30,7 +30,7 @@ func TestIntegrationAnalyticsIngestAndQuery(t *testing.T) {
 	dbURL := testutil.RequireEnv(t, "DATABASE_URL")
 	redisURL := testutil.RequireEnv(t, "REDIS_URL")
 
-	repo, err := repository.New(ctx, dbURL)
+	repo, err := repository.New(ctx, dbURL, "")
 	if err != nil {
 		t.Fatalf("connect db: %v", err)
 	}
@@ -68,11 +68,12 @@ func TestIntegrationAnalyticsIngestAndQuery(t *testing.T) {
 	clickRepo := repository.NewClickEventRepository(repo)
 
 	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
-	publisher := analytics.NewPublisher(cacheClient.Client(), logger, recorder)
+	queue := analytics.NewRedisQueue(cacheClient.Client())
+	publisher := analytics.NewPublisher(queue, logger, recorder)
 	redirectHandler := NewRedirectHandler(linkService, publisher, logger)
-	analyticsHandler := NewAnalyticsHandler(clickRepo, logger)
+	analyticsHandler := NewAnalyticsHandler(clickRepo, linkService, logger)
 
-	worker := analytics.NewWorker(cacheClient.Client(), clickRepo, logger, "test-consumer", recorder)
+	worker := analytics.NewWorker(queue, clickRepo, logger, "test-consumer", recorder)
 	worker.SetBlockTimeout(200 * time.Millisecond)
 	worker.SetClaimInterval(200 * time.Millisecond)
 	worker.SetMetricsInterval(200 * time.Millisecond)
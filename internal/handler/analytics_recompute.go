@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/penshort/penshort/internal/auth"
+)
+
+// maxRecomputeDays bounds the days rebuilt by one recompute request.
+const maxRecomputeDays = 366
+
+// RecomputeStatsRequest is the request body for recomputing a link's stats.
+type RecomputeStatsRequest struct {
+	LinkID string `json:"link_id"`
+	From   string `json:"from"` // UTC date, YYYY-MM-DD
+	To     string `json:"to"`   // UTC date, inclusive
+}
+
+// RecomputeStatsResponse describes a finished recompute.
+type RecomputeStatsResponse struct {
+	LinkID         string `json:"link_id"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	DaysRecomputed int    `json:"days_recomputed"`
+}
+
+// RecomputeStats handles POST /api/v1/admin/analytics/recompute.
+// Rebuilds a link's daily and hourly stats from the stored click events to
+// repair them; the worker otherwise only merges new batches in.
+func (h *AnalyticsHandler) RecomputeStats(w http.ResponseWriter, r *http.Request) {
+	var req RecomputeStatsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+	if req.LinkID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "link_id is required")
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", req.From)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_DATE", "from must be a date (YYYY-MM-DD)")
+		return
+	}
+	to, err := time.Parse("2006-01-02", req.To)
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, "INVALID_DATE", "to must be a date (YYYY-MM-DD)")
+		return
+	}
+	if to.Before(from) {
+		h.writeError(w, http.StatusBadRequest, "INVALID_DATE", "to must not be before from")
+		return
+	}
+	if to.Sub(from) >= maxRecomputeDays*24*time.Hour {
+		h.writeError(w, http.StatusBadRequest, "RANGE_TOO_LARGE",
+			fmt.Sprintf("At most %d days per recompute", maxRecomputeDays))
+		return
+	}
+
+	// Long ranges outlive the server write timeout
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Time{})
+
+	days, err := h.repo.RecomputeStats(r.Context(), req.LinkID, from, to)
+	if err != nil {
+		h.logger.Error("failed to recompute stats",
+			"link_id", req.LinkID,
+			"days_recomputed", days,
+			"error", err,
+		)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to recompute stats")
+		return
+	}
+
+	h.logger.Info("analytics_recomputed",
+		"actor_id", auth.UserIDFromContext(r.Context()),
+		"link_id", req.LinkID,
+		"from", req.From,
+		"to", req.To,
+		"days_recomputed", days,
+	)
+
+	writeJSON(w, http.StatusOK, RecomputeStatsResponse{
+		LinkID:         req.LinkID,
+		From:           req.From,
+		To:             req.To,
+		DaysRecomputed: days,
+	})
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// recomputeStore records RecomputeStats calls; other methods are not used.
+type recomputeStore struct {
+	repository.ClickEventStore
+	linkID   string
+	from, to time.Time
+}
+
+func (s *recomputeStore) RecomputeStats(ctx context.Context, linkID string, from, to time.Time) (int, error) {
+	s.linkID, s.from, s.to = linkID, from, to
+	return int(to.Sub(from)/(24*time.Hour)) + 1, nil
+}
+
+func TestAnalyticsHandler_RecomputeStats(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantCode   string
+		wantDays   int
+	}{
+		{
+			name:       "recomputes range",
+			body:       `{"link_id":"link-1","from":"2026-01-01","to":"2026-01-31"}`,
+			wantStatus: http.StatusOK,
+			wantDays:   31,
+		},
+		{name: "invalid json", body: `{`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_JSON"},
+		{name: "missing link", body: `{"from":"2026-01-01","to":"2026-01-02"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_REQUEST"},
+		{name: "invalid from", body: `{"link_id":"link-1","from":"yesterday","to":"2026-01-02"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_DATE"},
+		{name: "reversed", body: `{"link_id":"link-1","from":"2026-01-02","to":"2026-01-01"}`, wantStatus: http.StatusBadRequest, wantCode: "INVALID_DATE"},
+		{name: "too long", body: `{"link_id":"link-1","from":"2025-01-01","to":"2026-01-02"}`, wantStatus: http.StatusBadRequest, wantCode: "RANGE_TOO_LARGE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &recomputeStore{}
+			h := NewAnalyticsHandler(store, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/admin/analytics/recompute", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.RecomputeStats(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				var resp struct {
+					Code string `json:"code"`
+				}
+				_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+				if resp.Code != tt.wantCode {
+					t.Errorf("code = %q, want %q", resp.Code, tt.wantCode)
+				}
+				if store.linkID != "" {
+					t.Errorf("store called for a rejected request")
+				}
+				return
+			}
+
+			var resp RecomputeStatsResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode response: %v", err)
+			}
+			if resp.DaysRecomputed != tt.wantDays || resp.LinkID != "link-1" {
+				t.Errorf("response = %+v, want %d days", resp, tt.wantDays)
+			}
+			if !store.from.Equal(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)) || !store.to.Equal(time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)) {
+				t.Errorf("store range = %s..%s", store.from, store.to)
+			}
+		})
+	}
+}
@@ -0,0 +1,262 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/hll"
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestParseGranularity(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{query: "", want: model.GranularityDay, wantOK: true},
+		{query: "granularity=hour", want: model.GranularityHour, wantOK: true},
+		{query: "granularity=week", want: model.GranularityWeek, wantOK: true},
+		{query: "granularity=month", want: model.GranularityMonth, wantOK: true},
+		{query: "granularity=year", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/x/analytics?"+tt.query, nil)
+			got, ok := parseGranularity(req)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("parseGranularity() = %q, %v; want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestBucketDailyStats(t *testing.T) {
+	day := func(s string) time.Time {
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			t.Fatalf("parse %s: %v", s, err)
+		}
+		return d
+	}
+	stats := []*model.DailyLinkStats{
+		{Date: day("2024-03-04"), TotalClicks: 10, UniqueVisitors: 4, BotClicks: 1}, // Monday
+		{Date: day("2024-03-03"), TotalClicks: 5, UniqueVisitors: 2},                // Sunday
+		{Date: day("2024-02-26"), TotalClicks: 3, UniqueVisitors: 1, BlockedClicks: 2},
+		{Date: day("2024-02-29"), TotalClicks: 7, UniqueVisitors: 3},
+	}
+
+	tests := []struct {
+		granularity string
+		want        []model.TimeBucket
+	}{
+		{
+			granularity: model.GranularityWeek,
+			want: []model.TimeBucket{
+				{Start: "2024-03-04", TotalClicks: 10, UniqueVisitors: 4, BotClicks: 1},
+				{Start: "2024-02-26", TotalClicks: 15, UniqueVisitors: 6, BlockedClicks: 2},
+			},
+		},
+		{
+			granularity: model.GranularityMonth,
+			want: []model.TimeBucket{
+				{Start: "2024-03-01", TotalClicks: 15, UniqueVisitors: 6, BotClicks: 1},
+				{Start: "2024-02-01", TotalClicks: 10, UniqueVisitors: 4, BlockedClicks: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.granularity, func(t *testing.T) {
+			got := bucketDailyStats(stats, tt.granularity)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d buckets, want %d: %+v", len(got), len(tt.want), got)
+			}
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("bucket %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseTimezone(t *testing.T) {
+	tests := []struct {
+		query  string
+		want   string
+		wantOK bool
+	}{
+		{query: "", want: "UTC", wantOK: true},
+		{query: "tz=Asia/Tokyo", want: "Asia/Tokyo", wantOK: true},
+		{query: "tz=America/New_York", want: "America/New_York", wantOK: true},
+		{query: "tz=Mars/Olympus", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/links/x/analytics?"+tt.query, nil)
+			loc, ok := parseTimezone(req)
+			if ok != tt.wantOK {
+				t.Fatalf("parseTimezone() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && loc.String() != tt.want {
+				t.Errorf("parseTimezone() = %q, want %q", loc.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyticsHandler_ParseAccountFilter(t *testing.T) {
+	h := NewAnalyticsHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	manyIDs := strings.TrimSuffix(strings.Repeat("id,", maxAccountLinkIDs+1), ",")
+
+	tests := []struct {
+		name        string
+		query       string
+		wantOK      bool
+		wantLinkIDs int
+		wantAfter   bool
+		wantTag     string
+	}{
+		{name: "no filters", query: "", wantOK: true},
+		{name: "link ids", query: "link_ids=a,b,c", wantOK: true, wantLinkIDs: 3},
+		{name: "created after", query: "created_after=2024-01-01T00:00:00Z", wantOK: true, wantAfter: true},
+		{name: "invalid created before", query: "created_before=yesterday", wantOK: false},
+		{name: "too many link ids", query: "link_ids=" + manyIDs, wantOK: false},
+		{name: "tag", query: "tag=%20Launch", wantOK: true, wantTag: "launch"},
+		{name: "invalid tag", query: "tag=a%20b", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/analytics?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			filter, ok := h.parseAccountFilter(rec, req)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if rec.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", rec.Code)
+				}
+				return
+			}
+			if len(filter.LinkIDs) != tt.wantLinkIDs {
+				t.Errorf("LinkIDs = %v, want %d IDs", filter.LinkIDs, tt.wantLinkIDs)
+			}
+			if (filter.CreatedAfter != nil) != tt.wantAfter {
+				t.Errorf("CreatedAfter = %v, want set=%v", filter.CreatedAfter, tt.wantAfter)
+			}
+			if filter.Tag != tt.wantTag {
+				t.Errorf("Tag = %q, want %q", filter.Tag, tt.wantTag)
+			}
+		})
+	}
+}
+
+func TestBuildAnalyticsResponse_UserAgentBreakdowns(t *testing.T) {
+	h := NewAnalyticsHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	day := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	stats := []*model.DailyLinkStats{
+		{
+			Date:              day,
+			DeviceBreakdown:   map[string]int64{"mobile": 5, "desktop": 3, "bot": 2},
+			OSBreakdown:       map[string]int64{"iOS": 4, "Windows": 3, "Android": 1},
+			UAFamilyBreakdown: map[string]int64{"Safari": 4, "Chrome": 4},
+		},
+		{
+			Date:              day.AddDate(0, 0, -1),
+			DeviceBreakdown:   map[string]int64{"desktop": 4},
+			OSBreakdown:       map[string]int64{"Windows": 4},
+			UAFamilyBreakdown: map[string]int64{"Chrome": 4},
+		},
+	}
+
+	includes := map[string]bool{"devices": true, "os": true, "browsers": true}
+	resp := h.buildAnalyticsResponse("link", day, day, &model.AnalyticsSummary{}, stats, includes, nil)
+
+	wantDevices := []model.DeviceBreakdown{{Device: "desktop", Clicks: 7}, {Device: "mobile", Clicks: 5}, {Device: "bot", Clicks: 2}}
+	if len(resp.Breakdown.Devices) != len(wantDevices) {
+		t.Fatalf("devices = %+v, want %+v", resp.Breakdown.Devices, wantDevices)
+	}
+	for i, want := range wantDevices {
+		if resp.Breakdown.Devices[i] != want {
+			t.Errorf("devices[%d] = %+v, want %+v", i, resp.Breakdown.Devices[i], want)
+		}
+	}
+	if got := resp.Breakdown.OS[0]; got.Family != "Windows" || got.Clicks != 7 {
+		t.Errorf("top os = %+v, want Windows with 7", got)
+	}
+	if got := resp.Breakdown.Browsers[0]; got.Family != "Chrome" || got.Clicks != 8 {
+		t.Errorf("top browser = %+v, want Chrome with 8", got)
+	}
+	if resp.Breakdown.Referrers != nil || resp.Breakdown.Daily != nil {
+		t.Error("breakdowns not in include were returned")
+	}
+}
+
+func TestBuildAnalyticsResponse_Regions(t *testing.T) {
+	h := NewAnalyticsHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	day := time.Date(2026, 1, 12, 0, 0, 0, 0, time.UTC)
+	stats := []*model.DailyLinkStats{
+		{Date: day, RegionBreakdown: map[string]int64{"US-CA": 3, "GB-ENG": 2}},
+		{Date: day.AddDate(0, 0, -1), RegionBreakdown: map[string]int64{"GB-ENG": 1, "US-NY": 3}},
+	}
+
+	resp := h.buildAnalyticsResponse("link", day, day, &model.AnalyticsSummary{}, stats, map[string]bool{"regions": true}, nil)
+
+	want := []model.RegionBreakdown{
+		{Code: "GB-ENG", Country: "GB", Clicks: 3},
+		{Code: "US-CA", Country: "US", Clicks: 3},
+		{Code: "US-NY", Country: "US", Clicks: 3},
+	}
+	if len(resp.Breakdown.Regions) != len(want) {
+		t.Fatalf("regions = %+v, want %+v", resp.Breakdown.Regions, want)
+	}
+	for i := range want {
+		if resp.Breakdown.Regions[i] != want[i] {
+			t.Errorf("regions[%d] = %+v, want %+v", i, resp.Breakdown.Regions[i], want[i])
+		}
+	}
+}
+
+func TestSummarizeDailyStats_VisitorSketches(t *testing.T) {
+	sketchOf := func(hashes ...uint64) []byte {
+		s := hll.New()
+		for _, h := range hashes {
+			s.Add(hll.NewRegister(h))
+		}
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary: %v", err)
+		}
+		return data
+	}
+	stats := []*model.DailyLinkStats{
+		{TotalClicks: 3, UniqueVisitors: 2, VisitorSketch: sketchOf(1<<60, 1<<55)},
+		{TotalClicks: 2, UniqueVisitors: 2, VisitorSketch: sketchOf(1<<60, 1<<52)},
+		{TotalClicks: 1, UniqueVisitors: 1}, // Before sketches were recorded
+	}
+
+	summary := summarizeDailyStats(stats)
+
+	if summary.UniqueVisitors != 5 {
+		t.Errorf("UniqueVisitors = %d, want 5", summary.UniqueVisitors)
+	}
+	approx := summary.UniqueVisitorsApprox
+	if approx == nil || approx.Value != 3 || approx.Low > 3 || approx.High < 3 {
+		t.Fatalf("UniqueVisitorsApprox = %+v, want 3", approx)
+	}
+
+	if got := summarizeDailyStats(stats[2:]).UniqueVisitorsApprox; got != nil {
+		t.Errorf("UniqueVisitorsApprox without sketches = %+v, want nil", got)
+	}
+}
@@ -16,11 +16,11 @@ import (
 // APIKeyHandler handles API key management endpoints.
 type APIKeyHandler struct {
 	logger     *slog.Logger
-	repository *repository.Repository
+	repository repository.APIKeyStore
 }
 
 // NewAPIKeyHandler creates a new APIKeyHandler.
-func NewAPIKeyHandler(logger *slog.Logger, repo *repository.Repository) *APIKeyHandler {
+func NewAPIKeyHandler(logger *slog.Logger, repo repository.APIKeyStore) *APIKeyHandler {
 	return &APIKeyHandler{
 		logger:     logger,
 		repository: repo,
@@ -0,0 +1,331 @@
+package handler
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// Export formats.
+const (
+	exportFormatNDJSON = "ndjson"
+	exportFormatCSV    = "csv"
+)
+
+// exportFlushInterval is the number of rows written between flushes.
+const exportFlushInterval = 500
+
+// Export trailers, sent after the last row.
+const (
+	exportStatusTrailer = "X-Export-Status" // complete or error
+	exportCursorTrailer = "X-Export-Cursor" // Cursor of the last row sent
+)
+
+// linkGetter looks up a link by ID.
+type linkGetter interface {
+	GetLink(ctx context.Context, id string) (*model.Link, error)
+}
+
+// SetExportUserAgents controls whether click exports include user agents.
+// They are omitted by default.
+func (h *AnalyticsHandler) SetExportUserAgents(enabled bool) {
+	h.exportUserAgents = enabled
+}
+
+// ExportLinkClicks handles GET /v1/links/{id}/clicks/export.
+func (h *AnalyticsHandler) ExportLinkClicks(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	linkID := chi.URLParam(r, "id")
+	if linkID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Link ID is required")
+		return
+	}
+
+	link, err := h.links.GetLink(r.Context(), linkID)
+	if err != nil && !errors.Is(err, service.ErrLinkNotFound) {
+		h.logger.Error("failed to get link", "link_id", linkID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export clicks")
+		return
+	}
+	// Other owners' links look the same as missing ones
+	if link == nil || link.OwnerID != auth.UserID {
+		h.writeError(w, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+		return
+	}
+
+	filter, format, ok := h.parseExportRequest(w, r)
+	if !ok {
+		return
+	}
+	filter.LinkID = linkID
+
+	h.exportClicks(w, r, filter, format, "clicks-"+linkID)
+}
+
+// ExportAccountClicks handles GET /v1/clicks/export.
+// Exports the click events of every link owned by the caller.
+func (h *AnalyticsHandler) ExportAccountClicks(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	filter, format, ok := h.parseExportRequest(w, r)
+	if !ok {
+		return
+	}
+	filter.OwnerID = auth.UserID
+
+	h.exportClicks(w, r, filter, format, "clicks")
+}
+
+// exportClicks streams the click events matching filter. Rows are written
+// as they are read, so the status and resume cursor are sent as trailers.
+func (h *AnalyticsHandler) exportClicks(w http.ResponseWriter, r *http.Request, filter repository.ClickExportFilter, format, filename string) {
+	// Exports outlive the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	var enc clickEncoder
+	if format == exportFormatCSV {
+		enc = newCSVClickEncoder(w, filter.IncludeUserAgent)
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		enc = newNDJSONClickEncoder(w)
+		w.Header().Set("Content-Type", "application/x-ndjson")
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, filename, format))
+	w.Header().Set("Trailer", exportStatusTrailer+", "+exportCursorTrailer)
+	w.WriteHeader(http.StatusOK)
+
+	var last *model.ClickEvent
+	rows := 0
+	err := enc.WriteHeader()
+	if err == nil {
+		err = h.repo.ExportClickEvents(r.Context(), filter, func(event *model.ClickEvent) error {
+			if err := enc.Write(event); err != nil {
+				return err
+			}
+			last = event
+			rows++
+			if rows%exportFlushInterval == 0 {
+				if err := enc.Flush(); err != nil {
+					return err
+				}
+				_ = rc.Flush()
+			}
+			return nil
+		})
+	}
+	// Flush what was written even after an error, so the cursor stays valid
+	if flushErr := enc.Flush(); err == nil {
+		err = flushErr
+	}
+
+	if last != nil {
+		w.Header().Set(exportCursorTrailer, formatClickCursor(last))
+	}
+	if err != nil {
+		h.logger.Error("click export failed", "link_id", filter.LinkID, "owner_id", filter.OwnerID, "rows", rows, "error", err)
+		w.Header().Set(exportStatusTrailer, "error")
+		return
+	}
+	w.Header().Set(exportStatusTrailer, "complete")
+}
+
+// parseExportRequest reads the export format, time range, cursor and limit,
+// writing a 400 response and returning false when one is invalid.
+func (h *AnalyticsHandler) parseExportRequest(w http.ResponseWriter, r *http.Request) (repository.ClickExportFilter, string, bool) {
+	filter := repository.ClickExportFilter{IncludeUserAgent: h.exportUserAgents}
+	query := r.URL.Query()
+
+	format := query.Get("format")
+	switch format {
+	case "":
+		format = exportFormatNDJSON
+	case exportFormatNDJSON, exportFormatCSV:
+	default:
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "format must be csv or ndjson")
+		return filter, "", false
+	}
+
+	var err error
+	if value := query.Get("from"); value != "" {
+		if filter.From, err = parseExportTime(value, false); err != nil {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "from must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+			return filter, "", false
+		}
+	}
+	if value := query.Get("to"); value != "" {
+		if filter.To, err = parseExportTime(value, true); err != nil {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "to must be a date (YYYY-MM-DD) or RFC 3339 timestamp")
+			return filter, "", false
+		}
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		cursor, err := parseClickCursor(value)
+		if err != nil {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "cursor must be <clicked_at>,<id> from the last exported row")
+			return filter, "", false
+		}
+		filter.After = cursor
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 {
+			h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "limit must be a positive integer")
+			return filter, "", false
+		}
+		filter.Limit = limit
+	}
+
+	return filter, format, true
+}
+
+// parseExportTime parses an RFC 3339 timestamp or a UTC date. As an upper
+// bound, a date includes the whole day.
+func parseExportTime(value string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	day, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if end {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day, nil
+}
+
+// formatClickCursor returns the resume cursor of an exported row.
+func formatClickCursor(event *model.ClickEvent) string {
+	return event.ClickedAt.UTC().Format(time.RFC3339Nano) + "," + event.ID
+}
+
+// parseClickCursor parses a cursor made by formatClickCursor.
+func parseClickCursor(value string) (*repository.ClickCursor, error) {
+	clickedAt, id, ok := strings.Cut(value, ",")
+	if !ok || id == "" {
+		return nil, fmt.Errorf("malformed cursor %q", value)
+	}
+	t, err := time.Parse(time.RFC3339Nano, clickedAt)
+	if err != nil {
+		return nil, fmt.Errorf("malformed cursor time: %w", err)
+	}
+	return &repository.ClickCursor{ClickedAt: t, ID: id}, nil
+}
+
+// clickExportRow is one exported click event.
+type clickExportRow struct {
+	ID          string `json:"id"`
+	LinkID      string `json:"link_id"`
+	ShortCode   string `json:"short_code"`
+	ClickedAt   string `json:"clicked_at"`
+	Referrer    string `json:"referrer"`
+	CountryCode string `json:"country_code"`
+	Region      string `json:"region"`
+	City        string `json:"city"`
+	VisitorHash string `json:"visitor_hash"`
+	Blocked     bool   `json:"blocked"`
+	IsBot       bool   `json:"is_bot"`
+	BotFamily   string `json:"bot_family"`
+	UserAgent   string `json:"user_agent,omitempty"` // Only when enabled
+}
+
+func newClickExportRow(event *model.ClickEvent) clickExportRow {
+	return clickExportRow{
+		ID:          event.ID,
+		LinkID:      event.LinkID,
+		ShortCode:   event.ShortCode,
+		ClickedAt:   event.ClickedAt.UTC().Format(time.RFC3339Nano),
+		Referrer:    event.Referrer,
+		CountryCode: event.CountryCode,
+		Region:      event.Region,
+		City:        event.City,
+		VisitorHash: event.VisitorHash,
+		Blocked:     event.Blocked,
+		IsBot:       event.IsBot,
+		BotFamily:   event.BotFamily,
+		UserAgent:   event.UserAgent,
+	}
+}
+
+// clickEncoder writes exported click events in one format.
+type clickEncoder interface {
+	WriteHeader() error
+	Write(event *model.ClickEvent) error
+	Flush() error
+}
+
+// ndjsonClickEncoder writes one JSON object per line.
+type ndjsonClickEncoder struct {
+	enc *json.Encoder
+}
+
+func newNDJSONClickEncoder(w io.Writer) *ndjsonClickEncoder {
+	return &ndjsonClickEncoder{enc: json.NewEncoder(w)}
+}
+
+func (e *ndjsonClickEncoder) WriteHeader() error { return nil }
+
+func (e *ndjsonClickEncoder) Write(event *model.ClickEvent) error {
+	return e.enc.Encode(newClickExportRow(event))
+}
+
+func (e *ndjsonClickEncoder) Flush() error { return nil }
+
+// csvClickEncoder writes a header row, then one row per event.
+type csvClickEncoder struct {
+	w         *csv.Writer
+	userAgent bool
+}
+
+func newCSVClickEncoder(w io.Writer, userAgent bool) *csvClickEncoder {
+	return &csvClickEncoder{w: csv.NewWriter(w), userAgent: userAgent}
+}
+
+func (e *csvClickEncoder) WriteHeader() error {
+	header := []string{"id", "link_id", "short_code", "clicked_at", "referrer", "country_code",
+		"region", "city", "visitor_hash", "blocked", "is_bot", "bot_family"}
+	if e.userAgent {
+		header = append(header, "user_agent")
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvClickEncoder) Write(event *model.ClickEvent) error {
+	row := newClickExportRow(event)
+	record := []string{row.ID, row.LinkID, row.ShortCode, row.ClickedAt, row.Referrer, row.CountryCode,
+		row.Region, row.City, row.VisitorHash, strconv.FormatBool(row.Blocked), strconv.FormatBool(row.IsBot), row.BotFamily}
+	if e.userAgent {
+		record = append(record, row.UserAgent)
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvClickEncoder) Flush() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/auth"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// fakeLinks serves links from a fixed table keyed by ID.
+type fakeLinks map[string]*model.Link
+
+func (f fakeLinks) GetLink(ctx context.Context, id string) (*model.Link, error) {
+	if link, ok := f[id]; ok {
+		return link, nil
+	}
+	return nil, service.ErrLinkNotFound
+}
+
+// exportStore serves one click per export; other methods are not used.
+type exportStore struct {
+	repository.ClickEventStore
+	filters []repository.ClickExportFilter
+}
+
+func (s *exportStore) ExportClickEvents(ctx context.Context, filter repository.ClickExportFilter, fn func(*model.ClickEvent) error) error {
+	s.filters = append(s.filters, filter)
+	return fn(&model.ClickEvent{ID: "c1", LinkID: filter.LinkID, ClickedAt: time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC)})
+}
+
+func TestAnalyticsHandler_ExportLinkClicksOwnership(t *testing.T) {
+	store := &exportStore{}
+	h := NewAnalyticsHandler(store, fakeLinks{
+		"link-alice": {ID: "link-alice", OwnerID: "alice"},
+		"link-bob":   {ID: "link-bob", OwnerID: "bob"},
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.ContextWithAuth(r.Context(), &model.AuthContext{KeyID: "key-1", UserID: "alice"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Get("/links/{id}/clicks/export", h.ExportLinkClicks)
+
+	tests := []struct {
+		name       string
+		linkID     string
+		wantStatus int
+	}{
+		{name: "own link", linkID: "link-alice", wantStatus: http.StatusOK},
+		{name: "another owner's link", linkID: "link-bob", wantStatus: http.StatusNotFound},
+		{name: "missing link", linkID: "link-none", wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/links/"+tt.linkID+"/clicks/export", nil))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus == http.StatusNotFound && !strings.Contains(rec.Body.String(), "LINK_NOT_FOUND") {
+				t.Errorf("body = %s, want LINK_NOT_FOUND", rec.Body.String())
+			}
+		})
+	}
+
+	if len(store.filters) != 1 || store.filters[0].LinkID != "link-alice" {
+		t.Errorf("exports = %+v, want only link-alice", store.filters)
+	}
+}
+
+func TestAnalyticsHandler_ParseExportRequest(t *testing.T) {
+	h := NewAnalyticsHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	tests := []struct {
+		name       string
+		query      string
+		wantOK     bool
+		wantFormat string
+		wantFrom   time.Time
+		wantTo     time.Time
+		wantLimit  int
+	}{
+		{name: "defaults", query: "", wantOK: true, wantFormat: "ndjson"},
+		{name: "csv", query: "format=csv", wantOK: true, wantFormat: "csv"},
+		{name: "unknown format", query: "format=xml", wantOK: false},
+		{
+			name:       "dates include the whole to day",
+			query:      "from=2026-01-01&to=2026-01-31",
+			wantOK:     true,
+			wantFormat: "ndjson",
+			wantFrom:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			wantTo:     time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:       "timestamps",
+			query:      "from=2026-01-01T08:00:00Z&to=2026-01-01T09:30:00Z",
+			wantOK:     true,
+			wantFormat: "ndjson",
+			wantFrom:   time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC),
+			wantTo:     time.Date(2026, 1, 1, 9, 30, 0, 0, time.UTC),
+		},
+		{name: "invalid from", query: "from=last-week", wantOK: false},
+		{name: "limit", query: "limit=500", wantOK: true, wantFormat: "ndjson", wantLimit: 500},
+		{name: "zero limit", query: "limit=0", wantOK: false},
+		{name: "malformed cursor", query: "cursor=01HQXK5M7Y", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/clicks/export?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+
+			filter, format, ok := h.parseExportRequest(rec, req)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				if rec.Code != http.StatusBadRequest {
+					t.Errorf("status = %d, want 400", rec.Code)
+				}
+				return
+			}
+			if format != tt.wantFormat {
+				t.Errorf("format = %q, want %q", format, tt.wantFormat)
+			}
+			if !filter.From.Equal(tt.wantFrom) || !filter.To.Equal(tt.wantTo) {
+				t.Errorf("range = %s..%s, want %s..%s", filter.From, filter.To, tt.wantFrom, tt.wantTo)
+			}
+			if filter.Limit != tt.wantLimit {
+				t.Errorf("Limit = %d, want %d", filter.Limit, tt.wantLimit)
+			}
+			if filter.IncludeUserAgent {
+				t.Error("user agents exported without being enabled")
+			}
+		})
+	}
+}
+
+func TestClickCursorRoundTrip(t *testing.T) {
+	event := &model.ClickEvent{
+		ID:        "01HQXK5M7Y0000000000000000",
+		ClickedAt: time.Date(2026, 1, 12, 8, 30, 15, 123456000, time.UTC),
+	}
+
+	cursor, err := parseClickCursor(formatClickCursor(event))
+	if err != nil {
+		t.Fatalf("parseClickCursor: %v", err)
+	}
+	if cursor.ID != event.ID || !cursor.ClickedAt.Equal(event.ClickedAt) {
+		t.Errorf("cursor = %+v, want %s at %s", cursor, event.ID, event.ClickedAt)
+	}
+}
+
+func TestCSVClickEncoder(t *testing.T) {
+	event := &model.ClickEvent{
+		ID:          "c1",
+		LinkID:      "l1",
+		ShortCode:   "launch",
+		ClickedAt:   time.Date(2026, 1, 12, 8, 0, 0, 0, time.UTC),
+		Referrer:    "https://example.com/a,b",
+		CountryCode: "US",
+		Region:      "US-CA",
+		City:        "San Francisco",
+		VisitorHash: "abc123",
+		UserAgent:   "Mozilla/5.0",
+	}
+
+	tests := []struct {
+		name      string
+		userAgent bool
+		want      string
+	}{
+		{
+			name: "without user agent",
+			want: "id,link_id,short_code,clicked_at,referrer,country_code,region,city,visitor_hash,blocked,is_bot,bot_family\n" +
+				"c1,l1,launch,2026-01-12T08:00:00Z,\"https://example.com/a,b\",US,US-CA,San Francisco,abc123,false,false,\n",
+		},
+		{
+			name:      "with user agent",
+			userAgent: true,
+			want: "id,link_id,short_code,clicked_at,referrer,country_code,region,city,visitor_hash,blocked,is_bot,bot_family,user_agent\n" +
+				"c1,l1,launch,2026-01-12T08:00:00Z,\"https://example.com/a,b\",US,US-CA,San Francisco,abc123,false,false,,Mozilla/5.0\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			enc := newCSVClickEncoder(&buf, tt.userAgent)
+			if err := enc.WriteHeader(); err != nil {
+				t.Fatalf("WriteHeader: %v", err)
+			}
+			if err := enc.Write(event); err != nil {
+				t.Fatalf("Write: %v", err)
+			}
+			if err := enc.Flush(); err != nil {
+				t.Fatalf("Flush: %v", err)
+			}
+			if buf.String() != tt.want {
+				t.Errorf("csv =\n%s\nwant\n%s", buf.String(), tt.want)
+			}
+		})
+	}
+}
@@ -9,32 +9,47 @@ import (
 
 // CreateLinkRequest represents the request body for creating a link.
 type CreateLinkRequest struct {
-	Destination  string     `json:"destination"`
-	Alias        string     `json:"alias,omitempty"`
-	RedirectType int        `json:"redirect_type,omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Destination      string     `json:"destination"`
+	Alias            string     `json:"alias,omitempty"`
+	RedirectType     int        `json:"redirect_type,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	FallbackURL      string     `json:"fallback_url,omitempty"`
+	AllowedCountries []string   `json:"allowed_countries,omitempty"`
+	BlockedCountries []string   `json:"blocked_countries,omitempty"`
+	GeoBlockedURL    string     `json:"geo_blocked_url,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
 }
 
 // UpdateLinkRequest represents the request body for updating a link.
 type UpdateLinkRequest struct {
-	Destination  *string    `json:"destination,omitempty"`
-	RedirectType *int       `json:"redirect_type,omitempty"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
-	Enabled      *bool      `json:"enabled,omitempty"`
+	Destination      *string    `json:"destination,omitempty"`
+	RedirectType     *int       `json:"redirect_type,omitempty"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	Enabled          *bool      `json:"enabled,omitempty"`
+	FallbackURL      *string    `json:"fallback_url,omitempty"`      // "" clears the fallback
+	AllowedCountries *[]string  `json:"allowed_countries,omitempty"` // [] clears the list
+	BlockedCountries *[]string  `json:"blocked_countries,omitempty"` // [] clears the list
+	GeoBlockedURL    *string    `json:"geo_blocked_url,omitempty"`   // "" clears it
+	Tags             *[]string  `json:"tags,omitempty"`              // [] clears the tags
 }
 
 // LinkResponse represents a link in API responses.
 type LinkResponse struct {
-	ID           string     `json:"id"`
-	ShortCode    string     `json:"short_code"`
-	ShortURL     string     `json:"short_url"`
-	Destination  string     `json:"destination"`
-	RedirectType int        `json:"redirect_type"`
-	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
-	Status       string     `json:"status"`
-	ClickCount   int64      `json:"click_count"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID               string     `json:"id"`
+	ShortCode        string     `json:"short_code"`
+	ShortURL         string     `json:"short_url"`
+	Destination      string     `json:"destination"`
+	RedirectType     int        `json:"redirect_type"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	FallbackURL      *string    `json:"fallback_url,omitempty"`
+	AllowedCountries []string   `json:"allowed_countries,omitempty"`
+	BlockedCountries []string   `json:"blocked_countries,omitempty"`
+	GeoBlockedURL    *string    `json:"geo_blocked_url,omitempty"`
+	Tags             []string   `json:"tags,omitempty"`
+	Status           string     `json:"status"`
+	ClickCount       int64      `json:"click_count"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 // LinkListResponse represents a paginated list of links.
@@ -67,16 +82,21 @@ type ErrorResponse struct {
 // ToLinkResponse converts a Link model to LinkResponse DTO.
 func ToLinkResponse(link *model.Link, baseURL string) *LinkResponse {
 	return &LinkResponse{
-		ID:           link.ID,
-		ShortCode:    link.ShortCode,
-		ShortURL:     baseURL + "/" + link.ShortCode,
-		Destination:  link.Destination,
-		RedirectType: int(link.RedirectType),
-		ExpiresAt:    link.ExpiresAt,
-		Status:       string(link.Status()),
-		ClickCount:   link.ClickCount,
-		CreatedAt:    link.CreatedAt,
-		UpdatedAt:    link.UpdatedAt,
+		ID:               link.ID,
+		ShortCode:        link.ShortCode,
+		ShortURL:         baseURL + "/" + link.ShortCode,
+		Destination:      link.Destination,
+		RedirectType:     int(link.RedirectType),
+		ExpiresAt:        link.ExpiresAt,
+		FallbackURL:      link.FallbackURL,
+		AllowedCountries: link.AllowedCountries,
+		BlockedCountries: link.BlockedCountries,
+		GeoBlockedURL:    link.GeoBlockedURL,
+		Tags:             link.Tags,
+		Status:           string(link.Status()),
+		ClickCount:       link.ClickCount,
+		CreatedAt:        link.CreatedAt,
+		UpdatedAt:        link.UpdatedAt,
 	}
 }
 
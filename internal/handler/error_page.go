@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// ErrorPageHandler manages fallback and branding settings for error pages.
+type ErrorPageHandler struct {
+	svc    *service.LinkService
+	logger *slog.Logger
+}
+
+// NewErrorPageHandler creates a new ErrorPageHandler.
+func NewErrorPageHandler(svc *service.LinkService, logger *slog.Logger) *ErrorPageHandler {
+	return &ErrorPageHandler{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+// ErrorPageSettingsRequest is the request body for saving error page settings.
+type ErrorPageSettingsRequest struct {
+	FallbackURL string `json:"fallback_url"`
+	BrandName   string `json:"brand_name"`
+	LogoURL     string `json:"logo_url"`
+	AccentColor string `json:"accent_color"`
+	SupportURL  string `json:"support_url"`
+}
+
+// Get handles GET /api/v1/admin/error-pages/{scope}/{id}.
+func (h *ErrorPageHandler) Get(w http.ResponseWriter, r *http.Request) {
+	scope := model.ErrorPageScope(chi.URLParam(r, "scope"))
+	scopeID := chi.URLParam(r, "id")
+
+	settings, err := h.svc.GetErrorPageSettings(r.Context(), scope, scopeID)
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// Put handles PUT /api/v1/admin/error-pages/{scope}/{id}.
+// Replaces all settings for the scope.
+func (h *ErrorPageHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var req ErrorPageSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	settings := &model.ErrorPageSettings{
+		ScopeType:   model.ErrorPageScope(chi.URLParam(r, "scope")),
+		ScopeID:     chi.URLParam(r, "id"),
+		FallbackURL: req.FallbackURL,
+		BrandName:   req.BrandName,
+		LogoURL:     req.LogoURL,
+		AccentColor: req.AccentColor,
+		SupportURL:  req.SupportURL,
+	}
+
+	if err := h.svc.SaveErrorPageSettings(r.Context(), settings); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.logger.Info("error_page_settings_saved",
+		"scope_type", settings.ScopeType,
+		"scope_id", settings.ScopeID,
+	)
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+// Delete handles DELETE /api/v1/admin/error-pages/{scope}/{id}.
+func (h *ErrorPageHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	scope := model.ErrorPageScope(chi.URLParam(r, "scope"))
+	scopeID := chi.URLParam(r, "id")
+
+	if err := h.svc.DeleteErrorPageSettings(r.Context(), scope, scopeID); err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	h.logger.Info("error_page_settings_deleted",
+		"scope_type", scope,
+		"scope_id", scopeID,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleServiceError maps service errors to HTTP responses.
+func (h *ErrorPageHandler) handleServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrErrorPageSettingsNotFound):
+		writeErrorJSON(w, http.StatusNotFound, "NOT_FOUND", "Error page settings not found")
+	case errors.Is(err, service.ErrInvalidErrorPageScope):
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_SCOPE", "Scope must be 'owner' or 'domain' with a non-empty id")
+	case errors.Is(err, service.ErrInvalidFallbackURL):
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_FALLBACK_URL", "Invalid fallback URL")
+	case errors.Is(err, service.ErrInvalidBrandURL):
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_BRAND_URL", "Logo and support URLs must be valid http(s) URLs")
+	case errors.Is(err, service.ErrInvalidAccentColor):
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_ACCENT_COLOR", "Accent color must be in #RRGGBB format")
+	default:
+		h.logger.Error("error page settings error", "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred")
+	}
+}
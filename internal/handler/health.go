@@ -12,6 +12,14 @@ type HealthChecker interface {
 	Ping(ctx context.Context) error
 }
 
+// DegradableChecker is a HealthChecker whose dependency the service can run
+// without. When CanServeDegraded reports true, a failed Ping makes /readyz
+// report "degraded" instead of taking the instance out of rotation.
+type DegradableChecker interface {
+	HealthChecker
+	CanServeDegraded() bool
+}
+
 // HealthHandler manages health check endpoints.
 type HealthHandler struct {
 	db    HealthChecker
@@ -46,8 +54,9 @@ func (h *HealthHandler) Healthz(w http.ResponseWriter, r *http.Request) {
 }
 
 // Readyz is a readiness probe endpoint.
-// It checks all dependencies and returns 200 only if all are healthy.
-// For Kubernetes readiness probes - removes pod from LB if failing.
+// It checks all dependencies and returns 200 only if all are healthy, or if
+// Redis is down but redirects are being served from the database
+// ("degraded"). For Kubernetes readiness probes - removes pod from LB if failing.
 //
 // GET /readyz
 func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
@@ -56,6 +65,7 @@ func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
 
 	checks := make(map[string]string)
 	healthy := true
+	degraded := false
 
 	// Check PostgreSQL
 	if h.db != nil {
@@ -72,8 +82,13 @@ func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
 	// Check Redis
 	if h.cache != nil {
 		if err := h.cache.Ping(ctx); err != nil {
-			checks["redis"] = "error: " + err.Error()
-			healthy = false
+			if dc, ok := h.cache.(DegradableChecker); ok && dc.CanServeDegraded() {
+				checks["redis"] = "degraded: " + err.Error()
+				degraded = true
+			} else {
+				checks["redis"] = "error: " + err.Error()
+				healthy = false
+			}
 		} else {
 			checks["redis"] = "ok"
 		}
@@ -86,6 +101,8 @@ func (h *HealthHandler) Readyz(w http.ResponseWriter, r *http.Request) {
 	if !healthy {
 		status = "unhealthy"
 		statusCode = http.StatusServiceUnavailable
+	} else if degraded {
+		status = "degraded"
 	}
 
 	response := HealthResponse{
@@ -193,3 +193,48 @@ func TestHealthHandler_Readyz_BothDown(t *testing.T) {
 		t.Errorf("unexpected redis check: %s", response.Checks["redis"])
 	}
 }
+
+// degradableHealthChecker is a cache that can be served around.
+type degradableHealthChecker struct {
+	mockHealthChecker
+}
+
+func (m *degradableHealthChecker) CanServeDegraded() bool {
+	return true
+}
+
+func TestHealthHandler_Readyz_RedisDegraded(t *testing.T) {
+	db := &mockHealthChecker{}
+	cache := &degradableHealthChecker{mockHealthChecker{err: errors.New("redis circuit breaker open")}}
+	h := NewHealthHandler(db, cache)
+
+	req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+	rec := httptest.NewRecorder()
+
+	h.Readyz(rec, req)
+
+	// Redirects still work, so the instance stays in rotation
+	if rec.Code != http.StatusOK {
+		t.Errorf("expected status 200 when Redis is degraded, got %d", rec.Code)
+	}
+
+	var response HealthResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	if response.Status != "degraded" {
+		t.Errorf("expected status 'degraded', got %s", response.Status)
+	}
+	if response.Checks["redis"] != "degraded: redis circuit breaker open" {
+		t.Errorf("unexpected redis check: %s", response.Checks["redis"])
+	}
+
+	// A database outage still fails readiness
+	h = NewHealthHandler(&mockHealthChecker{err: errors.New("postgres down")}, cache)
+	rec = httptest.NewRecorder()
+	h.Readyz(rec, req)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("expected status 503 when postgres is down, got %d", rec.Code)
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/penshort/penshort/internal/handler/dto"
+	"github.com/penshort/penshort/internal/model"
 	"github.com/penshort/penshort/internal/service"
 )
 
@@ -29,7 +30,14 @@ func NewLinkHandler(svc *service.LinkService, logger *slog.Logger) *LinkHandler
 }
 
 // Create handles POST /api/v1/links.
+// The link is owned by the authenticated caller.
 func (h *LinkHandler) Create(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
 	var req dto.CreateLinkRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		h.writeError(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
@@ -43,11 +51,16 @@ func (h *LinkHandler) Create(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := service.CreateLinkInput{
-		Destination:  req.Destination,
-		Alias:        req.Alias,
-		RedirectType: redirectType,
-		ExpiresAt:    req.ExpiresAt,
-		OwnerID:      "system", // Phase 2 default
+		Destination:      req.Destination,
+		Alias:            req.Alias,
+		RedirectType:     redirectType,
+		ExpiresAt:        req.ExpiresAt,
+		FallbackURL:      req.FallbackURL,
+		AllowedCountries: req.AllowedCountries,
+		BlockedCountries: req.BlockedCountries,
+		GeoBlockedURL:    req.GeoBlockedURL,
+		Tags:             req.Tags,
+		OwnerID:          auth.UserID,
 	}
 
 	link, err := h.svc.CreateLink(r.Context(), input)
@@ -63,6 +76,7 @@ func (h *LinkHandler) Create(w http.ResponseWriter, r *http.Request) {
 	)
 
 	response := dto.ToLinkResponse(link, h.svc.BaseURL())
+	w.Header().Set("ETag", link.ETag())
 	writeJSON(w, http.StatusCreated, response)
 }
 
@@ -80,12 +94,26 @@ func (h *LinkHandler) Get(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	etag := link.ETag()
+	w.Header().Set("ETag", etag)
+	if model.MatchETag(r.Header.Get("If-None-Match"), etag, true) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
 	response := dto.ToLinkResponse(link, h.svc.BaseURL())
 	writeJSON(w, http.StatusOK, response)
 }
 
 // List handles GET /api/v1/links.
+// Only the authenticated caller's links are listed.
 func (h *LinkHandler) List(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
 	query := r.URL.Query()
 
 	limit := 20
@@ -96,7 +124,7 @@ func (h *LinkHandler) List(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := service.ListLinksInput{
-		OwnerID: "system", // Phase 2 default
+		OwnerID: auth.UserID,
 		Cursor:  query.Get("cursor"),
 		Limit:   limit,
 		Status:  query.Get("status"),
@@ -139,10 +167,16 @@ func (h *LinkHandler) Update(w http.ResponseWriter, r *http.Request) {
 	}
 
 	input := service.UpdateLinkInput{
-		ID:          id,
-		Destination: req.Destination,
-		ExpiresAt:   req.ExpiresAt,
-		Enabled:     req.Enabled,
+		ID:               id,
+		Destination:      req.Destination,
+		ExpiresAt:        req.ExpiresAt,
+		Enabled:          req.Enabled,
+		FallbackURL:      req.FallbackURL,
+		AllowedCountries: req.AllowedCountries,
+		BlockedCountries: req.BlockedCountries,
+		GeoBlockedURL:    req.GeoBlockedURL,
+		Tags:             req.Tags,
+		IfMatch:          r.Header.Get("If-Match"),
 	}
 
 	if req.RedirectType != nil {
@@ -161,6 +195,7 @@ func (h *LinkHandler) Update(w http.ResponseWriter, r *http.Request) {
 	)
 
 	response := dto.ToLinkResponse(link, h.svc.BaseURL())
+	w.Header().Set("ETag", link.ETag())
 	writeJSON(w, http.StatusOK, response)
 }
 
@@ -191,6 +226,14 @@ func (h *LinkHandler) handleServiceError(w http.ResponseWriter, err error) {
 		h.writeError(w, http.StatusConflict, "ALIAS_TAKEN", "Alias already exists")
 	case errors.Is(err, service.ErrInvalidDestination):
 		h.writeError(w, http.StatusBadRequest, "INVALID_DESTINATION", "Invalid destination URL")
+	case errors.Is(err, service.ErrInvalidFallbackURL):
+		h.writeError(w, http.StatusBadRequest, "INVALID_FALLBACK_URL", "Invalid fallback URL")
+	case errors.Is(err, service.ErrInvalidCountryCode):
+		h.writeError(w, http.StatusBadRequest, "INVALID_COUNTRY_CODE", "Country codes must be ISO 3166-1 alpha-2 (max 250 per list)")
+	case errors.Is(err, service.ErrInvalidGeoBlockedURL):
+		h.writeError(w, http.StatusBadRequest, "INVALID_GEO_BLOCKED_URL", "Invalid geo-blocked URL")
+	case errors.Is(err, service.ErrInvalidTag):
+		h.writeError(w, http.StatusBadRequest, "INVALID_TAG", "Tags must be 1-50 letters, digits, '.', '_' or '-' (max 20 per link)")
 	case errors.Is(err, service.ErrInvalidAlias):
 		h.writeError(w, http.StatusBadRequest, "INVALID_ALIAS", "Invalid alias format")
 	case errors.Is(err, service.ErrExpiresInPast):
@@ -199,6 +242,8 @@ func (h *LinkHandler) handleServiceError(w http.ResponseWriter, err error) {
 		h.writeError(w, http.StatusBadRequest, "INVALID_REDIRECT_TYPE", "Redirect type must be 301 or 302")
 	case errors.Is(err, service.ErrLinkExpired):
 		h.writeError(w, http.StatusConflict, "LINK_EXPIRED", "Cannot update expired link")
+	case errors.Is(err, service.ErrPreconditionFailed):
+		h.writeError(w, http.StatusPreconditionFailed, "PRECONDITION_FAILED", "Link has been modified since it was read")
 	case errors.Is(err, service.ErrURLTooLong):
 		h.writeError(w, http.StatusBadRequest, "URL_TOO_LONG", "Destination URL exceeds maximum length")
 	default:
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/penshort/penshort/internal/auth"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// ownerStore records the owners links are created and listed for; other
+// methods are not used.
+type ownerStore struct {
+	repository.Store
+	created    []*model.Link
+	listFilter repository.LinkFilter
+}
+
+func (s *ownerStore) CreateLink(ctx context.Context, link *model.Link) error {
+	s.created = append(s.created, link)
+	return nil
+}
+
+func (s *ownerStore) ListLinks(ctx context.Context, filter repository.LinkFilter, cursor string, limit int) ([]*model.Link, string, error) {
+	s.listFilter = filter
+	return nil, "", nil
+}
+
+func TestLinkHandler_OwnedByCaller(t *testing.T) {
+	store := &ownerStore{}
+	h := NewLinkHandler(service.NewLinkService(store, nil, "http://localhost:8080", nil), slog.New(slog.NewTextHandler(io.Discard, nil)))
+	withAuth := func(req *http.Request) *http.Request {
+		return req.WithContext(auth.ContextWithAuth(req.Context(), &model.AuthContext{KeyID: "key-1", UserID: "alice"}))
+	}
+
+	req := withAuth(httptest.NewRequest(http.MethodPost, "/api/v1/links", strings.NewReader(`{"destination": "https://example.com", "alias": "mine"}`)))
+	rec := httptest.NewRecorder()
+	h.Create(rec, req)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.created) != 1 || store.created[0].OwnerID != "alice" {
+		t.Fatalf("created = %+v, want one link owned by alice", store.created)
+	}
+
+	rec = httptest.NewRecorder()
+	h.List(rec, withAuth(httptest.NewRequest(http.MethodGet, "/api/v1/links", nil)))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list status = %d, want 200", rec.Code)
+	}
+	if store.listFilter.OwnerID != "alice" {
+		t.Errorf("list owner = %q, want alice", store.listFilter.OwnerID)
+	}
+
+	// Without auth there is no owner to act for
+	rec = httptest.NewRecorder()
+	h.Create(rec, httptest.NewRequest(http.MethodPost, "/api/v1/links", strings.NewReader(`{"destination": "https://example.com"}`)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("create without auth: status = %d, want 401", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	h.List(rec, httptest.NewRequest(http.MethodGet, "/api/v1/links", nil))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("list without auth: status = %d, want 401", rec.Code)
+	}
+}
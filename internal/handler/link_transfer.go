@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/penshort/penshort/internal/auth"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// LinkTransferHandler handles admin link ownership transfers.
+type LinkTransferHandler struct {
+	svc    *service.LinkService
+	logger *slog.Logger
+}
+
+// NewLinkTransferHandler creates a new LinkTransferHandler.
+func NewLinkTransferHandler(svc *service.LinkService, logger *slog.Logger) *LinkTransferHandler {
+	return &LinkTransferHandler{
+		svc:    svc,
+		logger: logger,
+	}
+}
+
+// LinkTransferRequest is the request body for transferring links.
+type LinkTransferRequest struct {
+	LinkIDs     []string `json:"link_ids"`
+	FromOwnerID string   `json:"from_owner_id"`
+	ToOwnerID   string   `json:"to_owner_id"`
+	Reason      string   `json:"reason"`
+}
+
+// LinkTransferResponse describes the result of a transfer.
+type LinkTransferResponse struct {
+	TransferID  string                `json:"transfer_id,omitempty"`
+	ToOwnerID   string                `json:"to_owner_id"`
+	Transferred int                   `json:"transferred"`
+	Links       []*model.LinkTransfer `json:"links"`
+}
+
+// LinkTransferListResponse is the response for the audit trail listing.
+type LinkTransferListResponse struct {
+	Transfers []*model.LinkTransfer `json:"transfers"`
+	Total     int                   `json:"total"`
+}
+
+// Transfer handles POST /api/v1/admin/link-transfers.
+// Moves the listed links, or all links of from_owner_id, to to_owner_id.
+func (h *LinkTransferHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	var req LinkTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_JSON", "Invalid request body")
+		return
+	}
+
+	actorID := auth.UserIDFromContext(r.Context())
+
+	transfers, err := h.svc.TransferLinks(r.Context(), service.TransferLinksInput{
+		LinkIDs:     req.LinkIDs,
+		FromOwnerID: req.FromOwnerID,
+		ToOwnerID:   req.ToOwnerID,
+		ActorID:     actorID,
+		Reason:      req.Reason,
+	})
+	if err != nil {
+		h.handleServiceError(w, err)
+		return
+	}
+
+	response := LinkTransferResponse{
+		ToOwnerID:   req.ToOwnerID,
+		Transferred: len(transfers),
+		Links:       transfers,
+	}
+	if len(transfers) > 0 {
+		response.TransferID = transfers[0].TransferID
+	}
+
+	h.logger.Info("links_transferred",
+		"transfer_id", response.TransferID,
+		"actor_id", actorID,
+		"from_owner_id", req.FromOwnerID,
+		"to_owner_id", req.ToOwnerID,
+		"count", len(transfers),
+	)
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// List handles GET /api/v1/admin/link-transfers?transfer_id=&link_id=&owner_id=&limit=
+// Returns the ownership audit trail, newest first.
+func (h *LinkTransferHandler) List(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit := 0
+	if v := query.Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 1 {
+			writeErrorJSON(w, http.StatusBadRequest, "INVALID_LIMIT", "limit must be a positive integer")
+			return
+		}
+		limit = parsed
+	}
+
+	filter := repository.LinkTransferFilter{
+		TransferID: query.Get("transfer_id"),
+		LinkID:     query.Get("link_id"),
+		OwnerID:    query.Get("owner_id"),
+	}
+
+	transfers, err := h.svc.ListLinkTransfers(r.Context(), filter, limit)
+	if err != nil {
+		h.logger.Error("failed to list link transfers", "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "failed to list link transfers")
+		return
+	}
+
+	if transfers == nil {
+		transfers = []*model.LinkTransfer{}
+	}
+
+	writeJSON(w, http.StatusOK, LinkTransferListResponse{
+		Transfers: transfers,
+		Total:     len(transfers),
+	})
+}
+
+// handleServiceError maps service errors to HTTP responses.
+func (h *LinkTransferHandler) handleServiceError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidTransfer):
+		writeErrorJSON(w, http.StatusBadRequest, "INVALID_TRANSFER", "Provide link_ids or from_owner_id, and a different to_owner_id")
+	case errors.Is(err, service.ErrTooManyTransferLinks):
+		writeErrorJSON(w, http.StatusBadRequest, "TOO_MANY_LINKS", "At most 1000 link_ids per transfer")
+	case errors.Is(err, service.ErrTransferReasonTooLong):
+		writeErrorJSON(w, http.StatusBadRequest, "REASON_TOO_LONG", "Reason exceeds 500 characters")
+	case errors.Is(err, service.ErrOwnerNotFound):
+		writeErrorJSON(w, http.StatusUnprocessableEntity, "OWNER_NOT_FOUND", "New owner does not exist")
+	case errors.Is(err, service.ErrLinkNotFound):
+		writeErrorJSON(w, http.StatusNotFound, "LINK_NOT_FOUND", "One or more links not found; nothing was transferred")
+	default:
+		h.logger.Error("link transfer error", "error", err)
+		writeErrorJSON(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred")
+	}
+}
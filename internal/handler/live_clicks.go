@@ -0,0 +1,281 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/handler/dto"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/service"
+)
+
+// Live stream defaults.
+const (
+	DefaultLiveHeartbeat  = 15 * time.Second
+	DefaultLiveMaxStreams = 3
+
+	// liveRetry is the reconnect delay suggested to clients, in milliseconds.
+	liveRetry = 3000
+)
+
+// streamIDPattern matches Redis stream IDs, which are the live event IDs.
+var streamIDPattern = regexp.MustCompile(`^\d+-\d+$`)
+
+// LiveClickHandler streams click events as Server-Sent Events.
+type LiveClickHandler struct {
+	feed   *analytics.LiveFeed
+	links  linkGetter
+	logger *slog.Logger
+
+	heartbeat  time.Duration
+	maxStreams int
+
+	mu      sync.Mutex
+	streams map[string]int // Open streams per API key
+}
+
+// NewLiveClickHandler creates a new LiveClickHandler.
+func NewLiveClickHandler(feed *analytics.LiveFeed, links linkGetter, logger *slog.Logger) *LiveClickHandler {
+	return &LiveClickHandler{
+		feed:       feed,
+		links:      links,
+		logger:     logger.With("component", "handler.live_clicks"),
+		heartbeat:  DefaultLiveHeartbeat,
+		maxStreams: DefaultLiveMaxStreams,
+		streams:    make(map[string]int),
+	}
+}
+
+// SetHeartbeat overrides the interval between heartbeat comments.
+func (h *LiveClickHandler) SetHeartbeat(interval time.Duration) {
+	if interval > 0 {
+		h.heartbeat = interval
+	}
+}
+
+// SetMaxStreams overrides the number of concurrent streams per API key.
+func (h *LiveClickHandler) SetMaxStreams(n int) {
+	if n > 0 {
+		h.maxStreams = n
+	}
+}
+
+// StreamLinkClicks handles GET /v1/links/{id}/clicks/live.
+func (h *LiveClickHandler) StreamLinkClicks(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	linkID := chi.URLParam(r, "id")
+	if linkID == "" {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Link ID is required")
+		return
+	}
+
+	link, err := h.links.GetLink(r.Context(), linkID)
+	if err != nil && !errors.Is(err, service.ErrLinkNotFound) {
+		h.logger.Error("failed to get link", "link_id", linkID, "error", err)
+		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to open click stream")
+		return
+	}
+	// Other owners' links look the same as missing ones
+	if link == nil || link.OwnerID != auth.UserID {
+		h.writeError(w, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+		return
+	}
+
+	h.stream(w, r, auth, analytics.LiveFilter{OwnerID: auth.UserID, LinkID: linkID})
+}
+
+// StreamAccountClicks handles GET /v1/clicks/live.
+// Streams the clicks on every link owned by the caller.
+func (h *LiveClickHandler) StreamAccountClicks(w http.ResponseWriter, r *http.Request) {
+	auth := getAuthContext(r.Context())
+	if auth == nil {
+		h.writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	h.stream(w, r, auth, analytics.LiveFilter{OwnerID: auth.UserID})
+}
+
+// stream sends the events matching filter until the client disconnects,
+// falls too far behind, or the server shuts down. Clients resume with
+// Last-Event-ID.
+func (h *LiveClickHandler) stream(w http.ResponseWriter, r *http.Request, auth *model.AuthContext, filter analytics.LiveFilter) {
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID != "" && !streamIDPattern.MatchString(lastEventID) {
+		h.writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "Last-Event-ID is not a valid event ID")
+		return
+	}
+
+	if !h.acquire(auth.KeyID) {
+		h.writeError(w, http.StatusTooManyRequests, "TOO_MANY_STREAMS",
+			fmt.Sprintf("At most %d live streams per API key", h.maxStreams))
+		return
+	}
+	defer h.release(auth.KeyID)
+
+	// Subscribe before replaying so no click falls in between
+	sub, err := h.feed.Subscribe(filter)
+	if err != nil {
+		h.writeError(w, http.StatusServiceUnavailable, "UNAVAILABLE", "Click stream is shutting down")
+		return
+	}
+	defer h.feed.Unsubscribe(sub)
+
+	replay := &analytics.LiveReplay{}
+	if lastEventID != "" {
+		replay, err = h.feed.Replay(r.Context(), filter, lastEventID)
+		if err != nil {
+			h.logger.Error("failed to replay clicks", "user_id", auth.UserID, "error", err)
+			h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to open click stream")
+			return
+		}
+	}
+
+	// Streams outlive the server write timeout
+	rc := http.NewResponseController(w)
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no") // Disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", liveRetry); err != nil {
+		return
+	}
+
+	lastSent := lastEventID
+	for _, event := range replay.Events {
+		if err := writeLiveEvent(w, event); err != nil {
+			return
+		}
+		lastSent = event.EventID
+	}
+	if replay.Truncated {
+		// Live events would skip what the replay left out, so end the
+		// stream; the client reconnects and resumes after the gap event
+		_ = writeLiveGap(w, replay.Next)
+		_ = rc.Flush()
+		return
+	}
+	if err := rc.Flush(); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				// Dropped or shutting down; the client reconnects and resumes
+				return
+			}
+			// Already sent in the replay
+			if lastSent != "" && !analytics.StreamIDAfter(event.EventID, lastSent) {
+				continue
+			}
+			if err := writeLiveEvent(w, event); err != nil {
+				return
+			}
+			lastSent = event.EventID
+		}
+		if err := rc.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// acquire reserves one of the key's streams.
+func (h *LiveClickHandler) acquire(keyID string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[keyID] >= h.maxStreams {
+		return false
+	}
+	h.streams[keyID]++
+	return true
+}
+
+func (h *LiveClickHandler) release(keyID string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.streams[keyID]--; h.streams[keyID] <= 0 {
+		delete(h.streams, keyID)
+	}
+}
+
+// liveClickEvent is the data of a "click" event.
+type liveClickEvent struct {
+	EventID     string `json:"event_id"`
+	LinkID      string `json:"link_id"`
+	ShortCode   string `json:"short_code"`
+	ClickedAt   string `json:"clicked_at"`
+	Referrer    string `json:"referrer"`
+	CountryCode string `json:"country_code"`
+	Region      string `json:"region"`
+	City        string `json:"city"`
+	VisitorHash string `json:"visitor_hash"`
+	Blocked     bool   `json:"blocked"`
+	IsBot       bool   `json:"is_bot"`
+	BotFamily   string `json:"bot_family"`
+}
+
+// writeLiveEvent writes event as an SSE "click" event.
+func writeLiveEvent(w http.ResponseWriter, event *model.ClickEvent) error {
+	data, err := json.Marshal(liveClickEvent{
+		EventID:     event.EventID,
+		LinkID:      event.LinkID,
+		ShortCode:   event.ShortCode,
+		ClickedAt:   event.ClickedAt.UTC().Format(time.RFC3339Nano),
+		Referrer:    event.Referrer,
+		CountryCode: event.CountryCode,
+		Region:      event.Region,
+		City:        event.City,
+		VisitorHash: event.VisitorHash,
+		Blocked:     event.Blocked,
+		IsBot:       event.IsBot,
+		BotFamily:   event.BotFamily,
+	})
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %s\nevent: click\ndata: %s\n\n", event.EventID, data)
+	return err
+}
+
+// writeLiveGap writes a "gap" event telling the client that the replay
+// stopped early and resumes after next.
+func writeLiveGap(w http.ResponseWriter, next string) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: gap\ndata: {\"resume_after\":%q}\n\n", next, next)
+	return err
+}
+
+// writeError writes a JSON error response.
+func (h *LiveClickHandler) writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, dto.ErrorResponse{
+		Error: message,
+		Code:  code,
+	})
+}
@@ -0,0 +1,210 @@
+package handler
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/auth"
+	"github.com/penshort/penshort/internal/model"
+)
+
+type liveTestEnv struct {
+	queue   *analytics.MemoryQueue
+	feed    *analytics.LiveFeed
+	handler *LiveClickHandler
+	server  *httptest.Server
+}
+
+func newLiveTestEnv(t *testing.T) *liveTestEnv {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	queue := analytics.NewMemoryQueue()
+	feed := analytics.NewLiveFeed(queue, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { _ = feed.Run(ctx) }()
+
+	h := NewLiveClickHandler(feed, fakeLinks{
+		"link-1": {ID: "link-1", OwnerID: "alice"},
+		"link-2": {ID: "link-2", OwnerID: "bob"},
+	}, logger)
+	h.SetHeartbeat(50 * time.Millisecond)
+
+	r := chi.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := auth.ContextWithAuth(r.Context(), &model.AuthContext{KeyID: "key-1", UserID: "alice"})
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	})
+	r.Get("/links/{id}/clicks/live", h.StreamLinkClicks)
+	r.Get("/clicks/live", h.StreamAccountClicks)
+
+	server := httptest.NewServer(r)
+	t.Cleanup(func() {
+		cancel()
+		server.Close()
+	})
+	return &liveTestEnv{queue: queue, feed: feed, handler: h, server: server}
+}
+
+func (env *liveTestEnv) addClick(t *testing.T, ownerID, linkID string) string {
+	t.Helper()
+	data, _ := json.Marshal(analytics.ClickEventPayload{
+		ShortCode:   "abc123",
+		LinkID:      linkID,
+		OwnerID:     ownerID,
+		VisitorHash: "0123456789abcdef",
+		ClickedAt:   time.Now().UnixMilli(),
+	})
+	id, err := env.queue.Add(context.Background(), string(data))
+	if err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	return id
+}
+
+func (env *liveTestEnv) open(t *testing.T, path, lastEventID string) *http.Response {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodGet, env.server.URL+path, nil)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+// readEventIDs reads SSE events until n event IDs were seen, reporting
+// whether a heartbeat arrived.
+func readEventIDs(t *testing.T, body io.Reader, n int) ([]string, bool) {
+	t.Helper()
+	lines := make(chan string)
+	go func() {
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+		close(lines)
+	}()
+
+	var ids []string
+	heartbeat := false
+	deadline := time.After(2 * time.Second)
+	for len(ids) < n {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				t.Fatalf("stream ended after %v", ids)
+			}
+			if id, found := strings.CutPrefix(line, "id: "); found {
+				ids = append(ids, id)
+			}
+			if strings.HasPrefix(line, ": heartbeat") {
+				heartbeat = true
+			}
+		case <-deadline:
+			t.Fatalf("timed out after events %v", ids)
+		}
+	}
+	return ids, heartbeat
+}
+
+func TestLiveClickHandler_StreamResumesAndFollows(t *testing.T) {
+	env := newLiveTestEnv(t)
+
+	first := env.addClick(t, "alice", "link-1")
+	second := env.addClick(t, "alice", "link-1")
+	_ = env.addClick(t, "bob", "link-2")
+
+	resp := env.open(t, "/links/link-1/clicks/live", first)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	time.Sleep(100 * time.Millisecond) // Past a heartbeat
+	third := env.addClick(t, "alice", "link-1")
+
+	ids, heartbeat := readEventIDs(t, resp.Body, 2)
+	if ids[0] != second || ids[1] != third {
+		t.Errorf("event IDs = %v, want replayed %s then live %s", ids, second, third)
+	}
+	if !heartbeat {
+		t.Error("no heartbeat before the live event")
+	}
+}
+
+func TestLiveClickHandler_TruncatedReplayEndsWithGap(t *testing.T) {
+	env := newLiveTestEnv(t)
+	env.feed.SetMaxReplay(1)
+
+	first := env.addClick(t, "alice", "link-1")
+	second := env.addClick(t, "alice", "link-1")
+	_ = env.addClick(t, "alice", "link-1")
+
+	resp := env.open(t, "/links/link-1/clicks/live", first)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	want := "id: " + second + "\nevent: gap\ndata: {\"resume_after\":\"" + second + "\"}\n\n"
+	if !strings.Contains(string(body), "id: "+second+"\nevent: click") || !strings.HasSuffix(string(body), want) {
+		t.Errorf("stream = %q, want the replayed click then a gap event", body)
+	}
+}
+
+func TestLiveClickHandler_AccountStreamIsOwnerScoped(t *testing.T) {
+	env := newLiveTestEnv(t)
+
+	resp := env.open(t, "/clicks/live", "")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want 200", resp.StatusCode)
+	}
+	time.Sleep(20 * time.Millisecond)
+	_ = env.addClick(t, "bob", "link-2")
+	mine := env.addClick(t, "alice", "link-1")
+
+	ids, _ := readEventIDs(t, resp.Body, 1)
+	if ids[0] != mine {
+		t.Errorf("first event = %s, want %s", ids[0], mine)
+	}
+}
+
+func TestLiveClickHandler_Rejects(t *testing.T) {
+	env := newLiveTestEnv(t)
+	env.handler.SetMaxStreams(1)
+
+	if resp := env.open(t, "/links/link-2/clicks/live", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("other owner's link: status = %d, want 404", resp.StatusCode)
+	}
+	if resp := env.open(t, "/links/missing/clicks/live", ""); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("missing link: status = %d, want 404", resp.StatusCode)
+	}
+	if resp := env.open(t, "/clicks/live", "yesterday"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("invalid Last-Event-ID: status = %d, want 400", resp.StatusCode)
+	}
+
+	if resp := env.open(t, "/clicks/live", ""); resp.StatusCode != http.StatusOK {
+		t.Fatalf("first stream: status = %d, want 200", resp.StatusCode)
+	}
+	if resp := env.open(t, "/clicks/live", ""); resp.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("second stream: status = %d, want 429", resp.StatusCode)
+	}
+}
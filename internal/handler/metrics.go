@@ -33,14 +33,36 @@ func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
 	writeMetric(w, "penshort_redirect_cache_misses_total %d\n", snap.RedirectCacheMisses)
 	writeMetric(w, "penshort_redirect_duration_seconds_count %d\n", snap.RedirectDurationCount)
 	writeMetric(w, "penshort_redirect_duration_seconds_sum %.6f\n", float64(snap.RedirectDurationTotalNs)/1e9)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"local\",result=\"hit\"} %d\n", snap.LocalCacheHits)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"local\",result=\"miss\"} %d\n", snap.LocalCacheMisses)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"redis\",result=\"hit\"} %d\n", snap.RedisCacheHits)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"redis\",result=\"miss\"} %d\n", snap.RedisCacheMisses)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"degraded\",result=\"hit\"} %d\n", snap.DegradedCacheHits)
+	writeMetric(w, "penshort_link_cache_lookups_total{tier=\"degraded\",result=\"miss\"} %d\n", snap.DegradedCacheMisses)
+	writeMetric(w, "penshort_link_lookups_coalesced_total{scope=\"process\"} %d\n", snap.LinkLookupsCoalesced)
+	writeMetric(w, "penshort_link_lookups_coalesced_total{scope=\"cluster\"} %d\n", snap.LinkLookupsWaited)
+	writeMetric(w, "penshort_link_db_lookups_total %d\n", snap.LinkDBLookups)
+	writeMetric(w, "penshort_redis_circuit_state %d\n", snap.RedisCircuitState)
 
 	// Link metrics
 	writeMetric(w, "penshort_links_created_total %d\n", snap.LinksCreated)
 	writeMetric(w, "penshort_links_updated_total %d\n", snap.LinksUpdated)
 	writeMetric(w, "penshort_links_deleted_total %d\n", snap.LinksDeleted)
 
+	// Database pool metrics
+	writeMetric(w, "penshort_db_reads_total{pool=\"primary\"} %d\n", snap.DBReadsPrimary)
+	writeMetric(w, "penshort_db_reads_total{pool=\"replica\"} %d\n", snap.DBReadsReplica)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"primary\",state=\"acquired\"} %d\n", snap.DBPrimaryConnsAcquired)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"primary\",state=\"idle\"} %d\n", snap.DBPrimaryConnsIdle)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"primary\",state=\"total\"} %d\n", snap.DBPrimaryConnsTotal)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"replica\",state=\"acquired\"} %d\n", snap.DBReplicaConnsAcquired)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"replica\",state=\"idle\"} %d\n", snap.DBReplicaConnsIdle)
+	writeMetric(w, "penshort_db_pool_connections{pool=\"replica\",state=\"total\"} %d\n", snap.DBReplicaConnsTotal)
+	writeMetric(w, "penshort_db_replica_healthy %d\n", snap.DBReplicaHealthy)
+
 	// Analytics pipeline metrics
 	writeMetric(w, "penshort_analytics_events_published_total{status=\"success\"} %d\n", snap.AnalyticsEventsPublished)
+	writeMetric(w, "penshort_analytics_events_published_total{status=\"spooled\"} %d\n", snap.AnalyticsEventsSpooled)
 	writeMetric(w, "penshort_analytics_events_published_total{status=\"dropped\"} %d\n", snap.AnalyticsEventsDropped)
 
 	writeMetric(w, "penshort_analytics_events_processed_total{status=\"success\"} %d\n", snap.AnalyticsEventsProcessed)
@@ -49,6 +71,9 @@ func (h *MetricsHandler) Metrics(w http.ResponseWriter, r *http.Request) {
 
 	writeMetric(w, "penshort_analytics_batches_total %d\n", snap.AnalyticsBatchCount)
 	writeMetric(w, "penshort_analytics_queue_depth %d\n", snap.AnalyticsQueueDepth)
+	writeMetric(w, "penshort_analytics_dlq_depth %d\n", snap.AnalyticsDeadLetterDepth)
+	writeMetric(w, "penshort_analytics_spool_depth %d\n", snap.AnalyticsSpoolDepth)
+	writeMetric(w, "penshort_analytics_spool_age_seconds %.3f\n", float64(snap.AnalyticsSpoolAgeNs)/1e9)
 	writeMetric(w, "penshort_analytics_batch_duration_seconds_count %d\n", snap.AnalyticsBatchDurationCount)
 	writeMetric(w, "penshort_analytics_batch_duration_seconds_sum %.6f\n", float64(snap.AnalyticsBatchDurationTotalNs)/1e9)
 	writeMetric(w, "penshort_analytics_ingest_lag_seconds_count %d\n", snap.AnalyticsIngestLagCount)
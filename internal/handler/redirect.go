@@ -9,7 +9,10 @@ import (
 	"github.com/go-chi/chi/v5"
 
 	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/bot"
+	"github.com/penshort/penshort/internal/geo"
 	"github.com/penshort/penshort/internal/handler/dto"
+	"github.com/penshort/penshort/internal/model"
 	"github.com/penshort/penshort/internal/service"
 )
 
@@ -17,60 +20,84 @@ import (
 type RedirectHandler struct {
 	svc       *service.LinkService
 	publisher *analytics.Publisher
+	geo       geo.Resolver
+	locator   geo.Locator // Optional GeoIP database
+	bots      *bot.Classifier
 	logger    *slog.Logger
 }
 
 // NewRedirectHandler creates a new RedirectHandler.
+// The visitor's country is read from CF-IPCountry unless SetCountryResolver
+// is called, and bots are detected with the built-in signatures unless
+// SetBotClassifier is called.
 func NewRedirectHandler(svc *service.LinkService, publisher *analytics.Publisher, logger *slog.Logger) *RedirectHandler {
 	return &RedirectHandler{
 		svc:       svc,
 		publisher: publisher,
+		geo:       geo.NewHeaderResolver(geo.DefaultCountryHeader),
+		bots:      bot.NewClassifier(),
 		logger:    logger,
 	}
 }
 
+// SetCountryResolver replaces the resolver used for country rules and analytics.
+func (h *RedirectHandler) SetCountryResolver(resolver geo.Resolver) {
+	if resolver != nil {
+		h.geo = resolver
+	}
+}
+
+// SetLocator enables IP geolocation. The located country is used when the
+// country resolver has none, and region and city are recorded with clicks.
+// The IP itself is only used for the lookup and never leaves the handler.
+func (h *RedirectHandler) SetLocator(locator geo.Locator) {
+	h.locator = locator
+}
+
+// SetBotClassifier replaces the classifier used to flag bot traffic.
+func (h *RedirectHandler) SetBotClassifier(classifier *bot.Classifier) {
+	if classifier != nil {
+		h.bots = classifier
+	}
+}
+
 // Redirect handles GET /{short_code} for URL redirection.
 func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 	shortCode := chi.URLParam(r, "shortCode")
 	if shortCode == "" {
-		h.writeError(w, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+		h.writeError(w, r, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found", nil)
 		return
 	}
 
 	start := time.Now()
+	location := h.locate(r)
+	country := location.Country
 
-	link, cacheHit, err := h.svc.ResolveRedirect(r.Context(), shortCode)
+	link, cacheHit, err := h.svc.ResolveRedirect(r.Context(), shortCode, country)
 	duration := time.Since(start)
 
 	if err != nil {
-		h.handleRedirectError(w, r, shortCode, err, duration)
+		h.handleRedirectError(w, r, shortCode, location, link, err, duration)
 		return
 	}
 
+	// Classify before publishing so bots stay out of click counts
+	visitor := h.bots.Classify(r)
+
 	// Increment click counter asynchronously
-	h.svc.IncrementClickAsync(r.Context(), shortCode)
+	if !visitor.IsBot {
+		h.svc.IncrementClickAsync(r.Context(), shortCode)
+	}
 
 	// Publish analytics event asynchronously (fire-and-forget)
-	if h.publisher != nil {
-		clickedAt := time.Now()
-		event := analytics.ClickEventPayload{
-			ShortCode:   shortCode,
-			LinkID:      link.ID,
-			OwnerID:     link.OwnerID,
-			Referrer:    analytics.SanitizeReferrer(r.Header.Get("Referer")),
-			UserAgent:   analytics.TruncateUserAgent(r.Header.Get("User-Agent")),
-			VisitorHash: analytics.GenerateVisitorHash(getClientIP(r), r.Header.Get("User-Agent"), clickedAt),
-			CountryCode: analytics.ExtractCountryCode(r.Header.Get("CF-IPCountry")),
-			ClickedAt:   clickedAt.UnixMilli(),
-		}
-		h.publisher.PublishAsync(event)
-	}
+	h.publishClick(r, shortCode, link, location, visitor, false)
 
 	// Log successful redirect
 	h.logger.Info("redirect_success",
 		"short_code", shortCode,
 		"redirect_type", link.RedirectType,
 		"cache_hit", cacheHit,
+		"bot_family", visitor.Family,
 		"duration_ms", float64(duration.Microseconds())/1000,
 	)
 
@@ -84,15 +111,63 @@ func (h *RedirectHandler) Redirect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, link.Destination, int(link.RedirectType))
 }
 
+// locate resolves the visitor's location. The country resolver wins for the
+// country; the GeoIP database, if set, fills in the rest.
+func (h *RedirectHandler) locate(r *http.Request) geo.Location {
+	location := geo.Location{Country: h.geo.Country(r)}
+	if h.locator == nil {
+		return location
+	}
+
+	found := h.locator.Locate(geo.ParseIP(getClientIP(r)))
+	if location.Country == "" {
+		location.Country = found.Country
+	}
+	// Drop region and city when they contradict the resolved country
+	if found.Country == location.Country {
+		location.Region = found.Region
+		location.City = found.City
+	}
+	return location
+}
+
+// publishClick publishes a click event for analytics. Bot and blocked events
+// (geo-blocked attempts) are counted separately from clicks.
+func (h *RedirectHandler) publishClick(r *http.Request, shortCode string, link *model.Link, location geo.Location, visitor bot.Result, blocked bool) {
+	if h.publisher == nil {
+		return
+	}
+
+	clickedAt := time.Now()
+	event := analytics.ClickEventPayload{
+		ShortCode:   shortCode,
+		LinkID:      link.ID,
+		OwnerID:     link.OwnerID,
+		Referrer:    analytics.SanitizeReferrer(r.Header.Get("Referer")),
+		UserAgent:   analytics.TruncateUserAgent(r.Header.Get("User-Agent")),
+		VisitorHash: analytics.GenerateVisitorHash(getClientIP(r), r.Header.Get("User-Agent"), clickedAt),
+		VisitorReg:  uint32(analytics.GenerateVisitorRegister(getClientIP(r), r.Header.Get("User-Agent"))),
+		CountryCode: location.Country,
+		Region:      location.Region,
+		City:        location.City,
+		ClickedAt:   clickedAt.UnixMilli(),
+		Blocked:     blocked,
+		IsBot:       visitor.IsBot,
+		BotFamily:   visitor.Family,
+	}
+	h.publisher.PublishAsync(event)
+}
+
 // handleRedirectError handles errors during redirect resolution.
-func (h *RedirectHandler) handleRedirectError(w http.ResponseWriter, r *http.Request, shortCode string, err error, duration time.Duration) {
+// link is non-nil for expired, disabled and geo-blocked links.
+func (h *RedirectHandler) handleRedirectError(w http.ResponseWriter, r *http.Request, shortCode string, location geo.Location, link *model.Link, err error, duration time.Duration) {
 	switch {
 	case errors.Is(err, service.ErrLinkNotFound):
 		h.logger.Info("redirect_not_found",
 			"short_code", shortCode,
 			"duration_ms", float64(duration.Microseconds())/1000,
 		)
-		h.writeError(w, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+		h.writeUnavailable(w, r, nil, "not_found", http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
 
 	case errors.Is(err, service.ErrLinkExpired):
 		h.logger.Info("redirect_expired",
@@ -100,7 +175,7 @@ func (h *RedirectHandler) handleRedirectError(w http.ResponseWriter, r *http.Req
 			"reason", "expired",
 			"duration_ms", float64(duration.Microseconds())/1000,
 		)
-		h.writeError(w, http.StatusGone, "LINK_EXPIRED", "Link has expired")
+		h.writeUnavailable(w, r, link, "expired", http.StatusGone, "LINK_EXPIRED", "Link has expired")
 
 	case errors.Is(err, service.ErrLinkDisabled):
 		h.logger.Info("redirect_disabled",
@@ -109,7 +184,16 @@ func (h *RedirectHandler) handleRedirectError(w http.ResponseWriter, r *http.Req
 			"duration_ms", float64(duration.Microseconds())/1000,
 		)
 		// Return 404 for disabled links (don't reveal existence)
-		h.writeError(w, http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+		h.writeUnavailable(w, r, link, "disabled", http.StatusNotFound, "LINK_NOT_FOUND", "Link not found")
+
+	case errors.Is(err, service.ErrGeoBlocked):
+		h.logger.Info("redirect_geo_blocked",
+			"short_code", shortCode,
+			"country", location.Country,
+			"duration_ms", float64(duration.Microseconds())/1000,
+		)
+		h.publishClick(r, shortCode, link, location, h.bots.Classify(r), true)
+		h.writeGeoBlocked(w, r, link)
 
 	default:
 		h.logger.Error("redirect_error",
@@ -117,16 +201,62 @@ func (h *RedirectHandler) handleRedirectError(w http.ResponseWriter, r *http.Req
 			"error", err,
 			"duration_ms", float64(duration.Microseconds())/1000,
 		)
-		h.writeError(w, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred")
+		h.writeError(w, r, http.StatusInternalServerError, "INTERNAL_ERROR", "An internal error occurred", nil)
+	}
+}
+
+// writeUnavailable sends the visitor to a fallback destination if one is
+// configured for the link, its owner or the request domain, and otherwise
+// writes an error response.
+func (h *RedirectHandler) writeUnavailable(w http.ResponseWriter, r *http.Request, link *model.Link, reason string, status int, code, message string) {
+	page := h.svc.ResolveErrorPage(r.Context(), link, r.Host)
+
+	if page.FallbackURL != "" {
+		h.logger.Info("redirect_fallback",
+			"short_code", chi.URLParam(r, "shortCode"),
+			"reason", reason,
+		)
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		w.Header().Set("Cache-Control", "private, max-age=0")
+		http.Redirect(w, r, page.FallbackURL, http.StatusFound)
+		return
 	}
+
+	h.writeError(w, r, status, code, message, page)
 }
 
-// writeError writes a JSON error response for redirect failures.
-func (h *RedirectHandler) writeError(w http.ResponseWriter, status int, code, message string) {
+// writeGeoBlocked sends a geo-blocked visitor to the link's alternative URL,
+// or responds 451 Unavailable For Legal Reasons.
+func (h *RedirectHandler) writeGeoBlocked(w http.ResponseWriter, r *http.Request, link *model.Link) {
+	if link.GeoBlockedURL != nil {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
+		w.Header().Set("Cache-Control", "private, max-age=0")
+		http.Redirect(w, r, *link.GeoBlockedURL, http.StatusFound)
+		return
+	}
+
+	// Reuse owner/domain branding; fallback destinations do not apply here
+	page := h.svc.ResolveErrorPage(r.Context(), link, r.Host)
+	h.writeError(w, r, http.StatusUnavailableForLegalReasons, "GEO_BLOCKED", "Link is not available in your country", page)
+}
+
+// writeError writes an error response for redirect failures.
+// Browsers asking for HTML get a rendered page, branded by page if set;
+// all other clients get JSON.
+func (h *RedirectHandler) writeError(w http.ResponseWriter, r *http.Request, status int, code, message string, page *model.ErrorPage) {
 	// Set security headers even on errors
 	w.Header().Set("X-Content-Type-Options", "nosniff")
 	w.Header().Set("Cache-Control", "private, max-age=0")
 
+	if wantsHTML(r) {
+		if err := writeErrorPage(w, status, message, page); err != nil {
+			h.logger.Error("error_page_render_failed", "error", err)
+		}
+		return
+	}
+
 	writeJSON(w, status, dto.ErrorResponse{
 		Error: message,
 		Code:  code,
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// defaultAccentColor is used when no branding accent color is configured.
+const defaultAccentColor = "#2563eb"
+
+// errorPageTemplate renders the HTML shown to browsers for unavailable links.
+var errorPageTemplate = template.Must(template.New("error_page").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{.Title}}{{if .BrandName}} - {{.BrandName}}{{end}}</title>
+<style>
+body{margin:0;font-family:system-ui,-apple-system,sans-serif;background:#f8fafc;color:#0f172a;display:flex;min-height:100vh;align-items:center;justify-content:center}
+main{max-width:28rem;padding:2rem;text-align:center}
+img{max-height:3rem;margin-bottom:1.5rem}
+h1{font-size:1.5rem;margin:0 0 .5rem;color:{{.AccentColor}}}
+p{margin:0 0 1.5rem;color:#475569}
+a{color:{{.AccentColor}}}
+</style>
+</head>
+<body>
+<main>
+{{if .LogoURL}}<img src="{{.LogoURL}}" alt="{{.BrandName}}">{{end}}
+<h1>{{.Title}}</h1>
+<p>{{.Message}}</p>
+{{if .SupportURL}}<a href="{{.SupportURL}}">Contact support</a>{{end}}
+</main>
+</body>
+</html>
+`))
+
+// errorPageData is the view model for errorPageTemplate.
+type errorPageData struct {
+	Title       string
+	Message     string
+	BrandName   string
+	LogoURL     string
+	AccentColor template.CSS
+	SupportURL  string
+}
+
+// wantsHTML reports whether the client prefers an HTML response,
+// which is the case for browsers following a short link.
+func wantsHTML(r *http.Request) bool {
+	accept := r.Header.Get("Accept")
+	return strings.Contains(accept, "text/html") || strings.Contains(accept, "application/xhtml+xml")
+}
+
+// writeErrorPage renders the HTML error page with optional branding.
+func writeErrorPage(w http.ResponseWriter, status int, message string, page *model.ErrorPage) error {
+	data := errorPageData{
+		Title:       http.StatusText(status),
+		Message:     message,
+		AccentColor: defaultAccentColor,
+	}
+	if page != nil {
+		data.BrandName = page.BrandName
+		data.LogoURL = page.LogoURL
+		data.SupportURL = page.SupportURL
+		// Accent color is validated as #RRGGBB before it is stored
+		if page.AccentColor != "" {
+			data.AccentColor = template.CSS(page.AccentColor)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := errorPageTemplate.Execute(&buf, data); err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	_, err := buf.WriteTo(w)
+	return err
+}
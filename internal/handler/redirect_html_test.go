@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestWantsHTML(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", true},
+		{"application/xhtml+xml", true},
+		{"application/json", false},
+		{"*/*", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/abc", nil)
+		req.Header.Set("Accept", tt.accept)
+		if got := wantsHTML(req); got != tt.want {
+			t.Errorf("wantsHTML(%q) = %v, want %v", tt.accept, got, tt.want)
+		}
+	}
+}
+
+func TestWriteErrorPage_Branded(t *testing.T) {
+	rec := httptest.NewRecorder()
+	page := &model.ErrorPage{
+		BrandName:   "Acme <Links>",
+		LogoURL:     "https://cdn.example.com/logo.png",
+		AccentColor: "#ff0000",
+		SupportURL:  "https://example.com/help",
+	}
+
+	if err := writeErrorPage(rec, http.StatusGone, "Link has expired", page); err != nil {
+		t.Fatalf("writeErrorPage() error = %v", err)
+	}
+
+	if rec.Code != http.StatusGone {
+		t.Errorf("expected status 410, got %d", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("unexpected Content-Type: %s", ct)
+	}
+
+	body := rec.Body.String()
+	for _, want := range []string{
+		"Link has expired",
+		"Acme &lt;Links&gt;",
+		"https://cdn.example.com/logo.png",
+		"https://example.com/help",
+		"#ff0000",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("body missing %q", want)
+		}
+	}
+}
+
+func TestWriteErrorPage_Default(t *testing.T) {
+	rec := httptest.NewRecorder()
+
+	if err := writeErrorPage(rec, http.StatusNotFound, "Link not found", nil); err != nil {
+		t.Fatalf("writeErrorPage() error = %v", err)
+	}
+
+	body := rec.Body.String()
+	if !strings.Contains(body, defaultAccentColor) {
+		t.Error("expected default accent color")
+	}
+	if strings.Contains(body, "<img") || strings.Contains(body, "Contact support") {
+		t.Error("unbranded page should not include logo or support link")
+	}
+}
@@ -159,7 +159,7 @@ func TestIntegrationRedirect_CacheInvalidationOnUpdate(t *testing.T) {
 	}
 
 	// Invalidate cache
-	if err := cacheClient.InvalidateLink(ctx, alias); err != nil {
+	if err := cacheClient.DeleteLink(ctx, alias); err != nil {
 		t.Fatalf("invalidate cache: %v", err)
 	}
 
@@ -280,6 +280,84 @@ func TestIntegrationRedirect_NullExpiry(t *testing.T) {
 	}
 }
 
+func TestIntegrationRedirect_CoalescesConcurrentMisses(t *testing.T) {
+	ctx, _, cacheClient, recorder, svc, _ := newRedirectTestEnv(t)
+
+	alias := fmt.Sprintf("herd-%d", time.Now().UnixNano())
+	if _, err := svc.CreateLink(ctx, service.CreateLinkInput{
+		Destination: "https://example.com/herd",
+		Alias:       alias,
+	}); err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	_ = cacheClient.DeleteLink(ctx, alias)
+
+	const concurrency = 20
+	errs := make(chan error, concurrency)
+	for i := 0; i < concurrency; i++ {
+		go func() {
+			_, _, err := svc.ResolveRedirect(ctx, alias, "")
+			errs <- err
+		}()
+	}
+	for i := 0; i < concurrency; i++ {
+		if err := <-errs; err != nil {
+			t.Fatalf("resolve: %v", err)
+		}
+	}
+
+	// Every Redis miss either queried the database or joined a lookup
+	snap := recorder.Snapshot()
+	if snap.LinkDBLookups == 0 {
+		t.Fatal("expected at least one database lookup")
+	}
+	if snap.LinkDBLookups+snap.LinkLookupsCoalesced != snap.RedisCacheMisses {
+		t.Fatalf("db=%d coalesced=%d misses=%d", snap.LinkDBLookups, snap.LinkLookupsCoalesced, snap.RedisCacheMisses)
+	}
+}
+
+func TestIntegrationRedirect_RefillLockWaitsForOtherReplica(t *testing.T) {
+	ctx, repo, cacheClient, _, svc, _ := newRedirectTestEnv(t)
+
+	alias := fmt.Sprintf("lock-%d", time.Now().UnixNano())
+	link, err := svc.CreateLink(ctx, service.CreateLinkInput{
+		Destination: "https://example.com/lock",
+		Alias:       alias,
+	})
+	if err != nil {
+		t.Fatalf("create link: %v", err)
+	}
+	_ = cacheClient.DeleteLink(ctx, alias)
+
+	// Another replica holds the lock and refills shortly
+	release, err := cacheClient.AcquireRefillLock(ctx, alias, 5*time.Second)
+	if err != nil || release == nil {
+		t.Fatalf("acquire lock: release=%v err=%v", release != nil, err)
+	}
+	defer release()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		_ = cacheClient.SetLink(context.Background(), alias, link)
+	}()
+
+	recorder := metrics.NewInMemory()
+	replica := service.NewLinkService(repo, cacheClient, "http://localhost:8080", recorder)
+	replica.SetRefillLock(5*time.Second, 2*time.Second)
+
+	got, _, err := replica.ResolveRedirect(ctx, alias, "")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got.Destination != link.Destination {
+		t.Fatalf("expected destination %q, got %q", link.Destination, got.Destination)
+	}
+
+	snap := recorder.Snapshot()
+	if snap.LinkLookupsWaited != 1 || snap.LinkDBLookups != 0 {
+		t.Fatalf("expected to wait for the refill: waited=%d db=%d", snap.LinkLookupsWaited, snap.LinkDBLookups)
+	}
+}
+
 func newRedirectTestEnv(t *testing.T) (context.Context, *repository.Repository, *cache.Cache, *metrics.InMemoryRecorder, *service.LinkService, *chi.Mux) {
 	t.Helper()
 	if testing.Short() {
@@ -290,7 +368,7 @@ func newRedirectTestEnv(t *testing.T) (context.Context, *repository.Repository,
 	dbURL := testutil.RequireEnv(t, "DATABASE_URL")
 	redisURL := testutil.RequireEnv(t, "REDIS_URL")
 
-	repo, err := repository.New(ctx, dbURL)
+	repo, err := repository.New(ctx, dbURL, "")
 	if err != nil {
 		t.Fatalf("connect db: %v", err)
 	}
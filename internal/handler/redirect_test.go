@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/penshort/penshort/internal/geo"
+)
+
+// fakeLocator serves locations from a fixed table keyed by IP.
+type fakeLocator map[string]geo.Location
+
+func (f fakeLocator) Locate(ip net.IP) geo.Location {
+	return f[ip.String()]
+}
+
+func TestRedirectHandler_Locate(t *testing.T) {
+	locator := fakeLocator{
+		"203.0.113.7": {Country: "US", Region: "US-CA", City: "San Francisco"},
+	}
+
+	tests := []struct {
+		name    string
+		locator geo.Locator
+		header  string
+		remote  string
+		want    geo.Location
+	}{
+		{
+			name:   "header only",
+			header: "DE",
+			remote: "203.0.113.7:5000",
+			want:   geo.Location{Country: "DE"},
+		},
+		{
+			name:    "database fills location",
+			locator: locator,
+			remote:  "203.0.113.7:5000",
+			want:    geo.Location{Country: "US", Region: "US-CA", City: "San Francisco"},
+		},
+		{
+			name:    "header agrees with database",
+			locator: locator,
+			header:  "us",
+			remote:  "203.0.113.7:5000",
+			want:    geo.Location{Country: "US", Region: "US-CA", City: "San Francisco"},
+		},
+		{
+			name:    "header wins over database",
+			locator: locator,
+			header:  "FR",
+			remote:  "203.0.113.7:5000",
+			want:    geo.Location{Country: "FR"},
+		},
+		{
+			name:    "unknown address",
+			locator: locator,
+			remote:  "198.51.100.1:5000",
+			want:    geo.Location{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewRedirectHandler(nil, nil, slog.New(slog.NewTextHandler(io.Discard, nil)))
+			if tt.locator != nil {
+				h.SetLocator(tt.locator)
+			}
+
+			r := httptest.NewRequest(http.MethodGet, "/abc", nil)
+			r.RemoteAddr = tt.remote
+			if tt.header != "" {
+				r.Header.Set(geo.DefaultCountryHeader, tt.header)
+			}
+
+			if got := h.locate(r); got != tt.want {
+				t.Errorf("locate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
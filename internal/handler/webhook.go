@@ -24,13 +24,13 @@ func getAuthContext(ctx context.Context) *model.AuthContext {
 
 // WebhookHandler handles webhook management endpoints.
 type WebhookHandler struct {
-	repo           *webhook.Repository
+	repo           webhook.Store
 	logger         *slog.Logger
 	allowInsecure  bool
 }
 
 // NewWebhookHandler creates a new webhook handler.
-func NewWebhookHandler(repo *webhook.Repository, logger *slog.Logger, allowInsecure bool) *WebhookHandler {
+func NewWebhookHandler(repo webhook.Store, logger *slog.Logger, allowInsecure bool) *WebhookHandler {
 	return &WebhookHandler{
 		repo:          repo,
 		logger:        logger.With("handler", "webhook"),
@@ -0,0 +1,204 @@
+// Package hll implements HyperLogLog sketches for approximate distinct counts.
+//
+// Sketches are built from Registers rather than raw keys, so callers can
+// reduce a visitor to a register at the edge and never store anything that
+// identifies them.
+package hll
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Precision is the number of hash bits used to pick a register.
+const Precision = 14
+
+const registerCount = 1 << Precision
+
+// StandardError is the relative standard error of an estimate, 1.04/√m.
+const StandardError = 0.008125
+
+// Serialization formats.
+const (
+	formatSparse byte = 1 // (index uint16, rank uint8) for each set register
+	formatDense  byte = 2 // one byte per register
+)
+
+// Register is a register update: the register index in the high bits and
+// the rank in the low byte. The zero Register is no update.
+type Register uint32
+
+// NewRegister derives the register update for a 64-bit hash.
+func NewRegister(hash uint64) Register {
+	index := hash >> (64 - Precision)
+	// The guard bit caps the rank for hashes whose remaining bits are all zero
+	rest := hash<<Precision | 1<<(Precision-1)
+	rank := bits.LeadingZeros64(rest) + 1
+	return Register(index<<8 | uint64(rank))
+}
+
+// Index returns the register index.
+func (r Register) Index() int { return int(r >> 8) }
+
+// Rank returns the position of the first set bit after the index bits.
+func (r Register) Rank() uint8 { return uint8(r) }
+
+// Valid reports whether r is a register update this package produces.
+func (r Register) Valid() bool {
+	return r.Rank() >= 1 && r.Rank() <= 64-Precision+1 && r.Index() < registerCount
+}
+
+// Sketch is a HyperLogLog sketch. The zero value is an empty sketch.
+type Sketch struct {
+	registers []uint8 // Allocated on first Add
+}
+
+// New returns an empty sketch.
+func New() *Sketch {
+	return &Sketch{}
+}
+
+// Add records a register update. Invalid registers are ignored.
+func (s *Sketch) Add(r Register) {
+	if !r.Valid() {
+		return
+	}
+	if s.registers == nil {
+		s.registers = make([]uint8, registerCount)
+	}
+	if rank := r.Rank(); rank > s.registers[r.Index()] {
+		s.registers[r.Index()] = rank
+	}
+}
+
+// Merge adds other's registers to s, so s counts the union of both.
+func (s *Sketch) Merge(other *Sketch) {
+	if other == nil || other.registers == nil {
+		return
+	}
+	if s.registers == nil {
+		s.registers = make([]uint8, registerCount)
+	}
+	for i, rank := range other.registers {
+		if rank > s.registers[i] {
+			s.registers[i] = rank
+		}
+	}
+}
+
+// Empty reports whether nothing was added to the sketch.
+func (s *Sketch) Empty() bool {
+	return s.registers == nil
+}
+
+// Estimate returns the approximate number of distinct keys added.
+func (s *Sketch) Estimate() int64 {
+	if s.registers == nil {
+		return 0
+	}
+
+	const m = float64(registerCount)
+	alpha := 0.7213 / (1 + 1.079/m)
+
+	var sum float64
+	zeros := 0
+	for _, rank := range s.registers {
+		sum += math.Ldexp(1, -int(rank))
+		if rank == 0 {
+			zeros++
+		}
+	}
+
+	estimate := alpha * m * m / sum
+	// Linear counting is more accurate while many registers are empty
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return int64(math.Round(estimate))
+}
+
+// MarshalBinary encodes the sketch, sparsely while few registers are set.
+// An empty sketch encodes to nil.
+func (s *Sketch) MarshalBinary() ([]byte, error) {
+	if s.registers == nil {
+		return nil, nil
+	}
+
+	set := 0
+	for _, rank := range s.registers {
+		if rank != 0 {
+			set++
+		}
+	}
+
+	if set*3 >= registerCount {
+		data := make([]byte, 0, 2+registerCount)
+		data = append(data, formatDense, Precision)
+		return append(data, s.registers...), nil
+	}
+
+	data := make([]byte, 0, 2+set*3)
+	data = append(data, formatSparse, Precision)
+	for i, rank := range s.registers {
+		if rank != 0 {
+			data = binary.BigEndian.AppendUint16(data, uint16(i))
+			data = append(data, rank)
+		}
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a sketch written by MarshalBinary, replacing s.
+// Empty data decodes to an empty sketch.
+func (s *Sketch) UnmarshalBinary(data []byte) error {
+	s.registers = nil
+	if len(data) == 0 {
+		return nil
+	}
+	if len(data) < 2 {
+		return errors.New("hll: truncated sketch")
+	}
+	if data[1] != Precision {
+		return fmt.Errorf("hll: unsupported precision %d", data[1])
+	}
+
+	body := data[2:]
+	switch data[0] {
+	case formatDense:
+		if len(body) != registerCount {
+			return fmt.Errorf("hll: dense sketch has %d registers, want %d", len(body), registerCount)
+		}
+		s.registers = append([]uint8(nil), body...)
+	case formatSparse:
+		if len(body)%3 != 0 {
+			return errors.New("hll: truncated sparse sketch")
+		}
+		s.registers = make([]uint8, registerCount)
+		for i := 0; i < len(body); i += 3 {
+			index := int(binary.BigEndian.Uint16(body[i:]))
+			if index >= registerCount {
+				return fmt.Errorf("hll: register %d out of range", index)
+			}
+			s.registers[index] = body[i+2]
+		}
+	default:
+		return fmt.Errorf("hll: unknown format %d", data[0])
+	}
+	return nil
+}
+
+// Merged decodes and merges encoded sketches, skipping empty ones.
+func Merged(encoded [][]byte) (*Sketch, error) {
+	merged := New()
+	for _, data := range encoded {
+		var sketch Sketch
+		if err := sketch.UnmarshalBinary(data); err != nil {
+			return nil, err
+		}
+		merged.Merge(&sketch)
+	}
+	return merged, nil
+}
@@ -0,0 +1,123 @@
+package hll
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func sketchOf(rng *rand.Rand, n int) *Sketch {
+	s := New()
+	for i := 0; i < n; i++ {
+		s.Add(NewRegister(rng.Uint64()))
+	}
+	return s
+}
+
+func TestSketch_Estimate(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	for _, n := range []int{0, 1, 100, 5000, 50000, 500000} {
+		got := sketchOf(rng, n).Estimate()
+		// Four standard errors, with slack for exact small counts
+		tolerance := 4*StandardError*float64(n) + 2
+		if math.Abs(float64(got-int64(n))) > tolerance {
+			t.Errorf("Estimate() for %d keys = %d, want within %.0f", n, got, tolerance)
+		}
+	}
+}
+
+func TestSketch_DuplicatesCountOnce(t *testing.T) {
+	s := New()
+	for i := 0; i < 1000; i++ {
+		s.Add(NewRegister(42))
+	}
+	if got := s.Estimate(); got != 1 {
+		t.Errorf("Estimate() = %d, want 1", got)
+	}
+}
+
+func TestSketch_MergeCountsUnion(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	hashes := make([]uint64, 30000)
+	for i := range hashes {
+		hashes[i] = rng.Uint64()
+	}
+
+	// Two overlapping days: 0..20000 and 10000..30000
+	first, second := New(), New()
+	for _, h := range hashes[:20000] {
+		first.Add(NewRegister(h))
+	}
+	for _, h := range hashes[10000:] {
+		second.Add(NewRegister(h))
+	}
+	first.Merge(second)
+
+	got := first.Estimate()
+	if math.Abs(float64(got-30000)) > 4*StandardError*30000 {
+		t.Errorf("merged Estimate() = %d, want about 30000", got)
+	}
+}
+
+func TestSketch_MarshalRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+
+	for _, n := range []int{0, 10, 100000} {
+		s := sketchOf(rng, n)
+		data, err := s.MarshalBinary()
+		if err != nil {
+			t.Fatalf("MarshalBinary: %v", err)
+		}
+		if n == 0 && data != nil {
+			t.Errorf("empty sketch encoded to %d bytes, want nil", len(data))
+		}
+		if n == 10 && len(data) != 2+10*3 {
+			t.Errorf("small sketch encoded to %d bytes, want sparse %d", len(data), 2+10*3)
+		}
+
+		var decoded Sketch
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary: %v", err)
+		}
+		if decoded.Estimate() != s.Estimate() {
+			t.Errorf("decoded estimate = %d, want %d", decoded.Estimate(), s.Estimate())
+		}
+	}
+}
+
+func TestSketch_UnmarshalRejectsCorruptData(t *testing.T) {
+	for _, data := range [][]byte{
+		{formatSparse},
+		{formatSparse, Precision + 1},
+		{formatSparse, Precision, 0x00},
+		{formatSparse, Precision, 0xff, 0xff, 1},
+		{formatDense, Precision, 1, 2, 3},
+		{9, Precision},
+	} {
+		var s Sketch
+		if err := s.UnmarshalBinary(data); err == nil {
+			t.Errorf("UnmarshalBinary(%v) succeeded, want error", data)
+		}
+	}
+}
+
+func TestNewRegister(t *testing.T) {
+	tests := []struct {
+		hash      uint64
+		wantIndex int
+		wantRank  uint8
+	}{
+		{0, 0, 51},
+		{math.MaxUint64, registerCount - 1, 1},
+		{1 << (63 - Precision), 0, 1},
+		{1 << (62 - Precision), 0, 2},
+	}
+
+	for _, tt := range tests {
+		r := NewRegister(tt.hash)
+		if r.Index() != tt.wantIndex || r.Rank() != tt.wantRank {
+			t.Errorf("NewRegister(%#x) = index %d rank %d, want %d %d", tt.hash, r.Index(), r.Rank(), tt.wantIndex, tt.wantRank)
+		}
+	}
+}
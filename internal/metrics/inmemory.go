@@ -11,17 +11,41 @@ type Snapshot struct {
 	RedirectCacheMisses     uint64
 	RedirectDurationCount   uint64
 	RedirectDurationTotalNs int64
+	LocalCacheHits          uint64
+	LocalCacheMisses        uint64
+	RedisCacheHits          uint64
+	RedisCacheMisses        uint64
+	DegradedCacheHits       uint64
+	DegradedCacheMisses     uint64
+	LinkLookupsCoalesced    uint64 // in-process
+	LinkLookupsWaited       uint64 // served by another replica's refill
+	LinkDBLookups           uint64
+	RedisCircuitState       int64
 	LinksCreated            uint64
 	LinksUpdated            uint64
 	LinksDeleted            uint64
+	// Database metrics
+	DBReadsPrimary         uint64
+	DBReadsReplica         uint64
+	DBPrimaryConnsAcquired int64
+	DBPrimaryConnsIdle     int64
+	DBPrimaryConnsTotal    int64
+	DBReplicaConnsAcquired int64
+	DBReplicaConnsIdle     int64
+	DBReplicaConnsTotal    int64
+	DBReplicaHealthy       int64
 	// Analytics metrics
 	AnalyticsEventsPublished        uint64
+	AnalyticsEventsSpooled          uint64
 	AnalyticsEventsDropped          uint64
 	AnalyticsEventsProcessed        uint64
 	AnalyticsEventsProcessedFailed  uint64
 	AnalyticsEventsProcessedSkipped uint64
 	AnalyticsBatchCount             uint64
 	AnalyticsQueueDepth             int64
+	AnalyticsDeadLetterDepth        int64
+	AnalyticsSpoolDepth             int64
+	AnalyticsSpoolAgeNs             int64
 	AnalyticsBatchDurationCount     uint64
 	AnalyticsBatchDurationTotalNs   int64
 	AnalyticsIngestLagCount         uint64
@@ -42,17 +66,41 @@ type InMemoryRecorder struct {
 	redirectCacheMisses     uint64
 	redirectDurationCount   uint64
 	redirectDurationTotalNs int64
+	localCacheHits          uint64
+	localCacheMisses        uint64
+	redisCacheHits          uint64
+	redisCacheMisses        uint64
+	degradedCacheHits       uint64
+	degradedCacheMisses     uint64
+	linkLookupsCoalesced    uint64
+	linkLookupsWaited       uint64
+	linkDBLookups           uint64
+	redisCircuitState       int64
 	linksCreated            uint64
 	linksUpdated            uint64
 	linksDeleted            uint64
+	// Database fields
+	dbReadsPrimary         uint64
+	dbReadsReplica         uint64
+	dbPrimaryConnsAcquired int64
+	dbPrimaryConnsIdle     int64
+	dbPrimaryConnsTotal    int64
+	dbReplicaConnsAcquired int64
+	dbReplicaConnsIdle     int64
+	dbReplicaConnsTotal    int64
+	dbReplicaHealthy       int64
 	// Analytics fields
 	analyticsEventsPublished      uint64
+	analyticsEventsSpooled        uint64
 	analyticsEventsDropped        uint64
 	analyticsEventsProcessed      uint64
 	analyticsEventsFailed         uint64
 	analyticsEventsSkipped        uint64
 	analyticsBatchCount           uint64
 	analyticsQueueDepth           int64
+	analyticsDeadLetterDepth      int64
+	analyticsSpoolDepth           int64
+	analyticsSpoolAgeNs           int64
 	analyticsBatchDurationCount   uint64
 	analyticsBatchDurationTotalNs int64
 	analyticsIngestLagCount       uint64
@@ -79,16 +127,39 @@ func (m *InMemoryRecorder) Snapshot() Snapshot {
 		RedirectCacheMisses:             atomic.LoadUint64(&m.redirectCacheMisses),
 		RedirectDurationCount:           atomic.LoadUint64(&m.redirectDurationCount),
 		RedirectDurationTotalNs:         atomic.LoadInt64(&m.redirectDurationTotalNs),
+		LocalCacheHits:                  atomic.LoadUint64(&m.localCacheHits),
+		LocalCacheMisses:                atomic.LoadUint64(&m.localCacheMisses),
+		RedisCacheHits:                  atomic.LoadUint64(&m.redisCacheHits),
+		RedisCacheMisses:                atomic.LoadUint64(&m.redisCacheMisses),
+		DegradedCacheHits:               atomic.LoadUint64(&m.degradedCacheHits),
+		DegradedCacheMisses:             atomic.LoadUint64(&m.degradedCacheMisses),
+		LinkLookupsCoalesced:            atomic.LoadUint64(&m.linkLookupsCoalesced),
+		LinkLookupsWaited:               atomic.LoadUint64(&m.linkLookupsWaited),
+		LinkDBLookups:                   atomic.LoadUint64(&m.linkDBLookups),
+		RedisCircuitState:               atomic.LoadInt64(&m.redisCircuitState),
 		LinksCreated:                    atomic.LoadUint64(&m.linksCreated),
 		LinksUpdated:                    atomic.LoadUint64(&m.linksUpdated),
 		LinksDeleted:                    atomic.LoadUint64(&m.linksDeleted),
+		DBReadsPrimary:                  atomic.LoadUint64(&m.dbReadsPrimary),
+		DBReadsReplica:                  atomic.LoadUint64(&m.dbReadsReplica),
+		DBPrimaryConnsAcquired:          atomic.LoadInt64(&m.dbPrimaryConnsAcquired),
+		DBPrimaryConnsIdle:              atomic.LoadInt64(&m.dbPrimaryConnsIdle),
+		DBPrimaryConnsTotal:             atomic.LoadInt64(&m.dbPrimaryConnsTotal),
+		DBReplicaConnsAcquired:          atomic.LoadInt64(&m.dbReplicaConnsAcquired),
+		DBReplicaConnsIdle:              atomic.LoadInt64(&m.dbReplicaConnsIdle),
+		DBReplicaConnsTotal:             atomic.LoadInt64(&m.dbReplicaConnsTotal),
+		DBReplicaHealthy:                atomic.LoadInt64(&m.dbReplicaHealthy),
 		AnalyticsEventsPublished:        atomic.LoadUint64(&m.analyticsEventsPublished),
+		AnalyticsEventsSpooled:          atomic.LoadUint64(&m.analyticsEventsSpooled),
 		AnalyticsEventsDropped:          atomic.LoadUint64(&m.analyticsEventsDropped),
 		AnalyticsEventsProcessed:        atomic.LoadUint64(&m.analyticsEventsProcessed),
 		AnalyticsEventsProcessedFailed:  atomic.LoadUint64(&m.analyticsEventsFailed),
 		AnalyticsEventsProcessedSkipped: atomic.LoadUint64(&m.analyticsEventsSkipped),
 		AnalyticsBatchCount:             atomic.LoadUint64(&m.analyticsBatchCount),
 		AnalyticsQueueDepth:             atomic.LoadInt64(&m.analyticsQueueDepth),
+		AnalyticsDeadLetterDepth:        atomic.LoadInt64(&m.analyticsDeadLetterDepth),
+		AnalyticsSpoolDepth:             atomic.LoadInt64(&m.analyticsSpoolDepth),
+		AnalyticsSpoolAgeNs:             atomic.LoadInt64(&m.analyticsSpoolAgeNs),
 		AnalyticsBatchDurationCount:     atomic.LoadUint64(&m.analyticsBatchDurationCount),
 		AnalyticsBatchDurationTotalNs:   atomic.LoadInt64(&m.analyticsBatchDurationTotalNs),
 		AnalyticsIngestLagCount:         atomic.LoadUint64(&m.analyticsIngestLagCount),
@@ -120,6 +191,77 @@ func (m *InMemoryRecorder) ObserveRedirectDuration(duration time.Duration) {
 	atomic.AddInt64(&m.redirectDurationTotalNs, duration.Nanoseconds())
 }
 
+// IncCacheLookup increments the hit or miss counter of a link cache tier.
+func (m *InMemoryRecorder) IncCacheLookup(tier string, result string) {
+	switch {
+	case tier == "local" && result == "hit":
+		atomic.AddUint64(&m.localCacheHits, 1)
+	case tier == "local" && result == "miss":
+		atomic.AddUint64(&m.localCacheMisses, 1)
+	case tier == "redis" && result == "hit":
+		atomic.AddUint64(&m.redisCacheHits, 1)
+	case tier == "redis" && result == "miss":
+		atomic.AddUint64(&m.redisCacheMisses, 1)
+	case tier == "degraded" && result == "hit":
+		atomic.AddUint64(&m.degradedCacheHits, 1)
+	case tier == "degraded" && result == "miss":
+		atomic.AddUint64(&m.degradedCacheMisses, 1)
+	}
+}
+
+// IncLinkLookupCoalesced increments the coalesced lookup counter by scope.
+func (m *InMemoryRecorder) IncLinkLookupCoalesced(scope string) {
+	switch scope {
+	case "process":
+		atomic.AddUint64(&m.linkLookupsCoalesced, 1)
+	case "cluster":
+		atomic.AddUint64(&m.linkLookupsWaited, 1)
+	}
+}
+
+// IncLinkDBLookup increments the database lookup counter for cache misses.
+func (m *InMemoryRecorder) IncLinkDBLookup() {
+	atomic.AddUint64(&m.linkDBLookups, 1)
+}
+
+// SetRedisCircuitState records the Redis circuit breaker state.
+func (m *InMemoryRecorder) SetRedisCircuitState(state int64) {
+	atomic.StoreInt64(&m.redisCircuitState, state)
+}
+
+// IncDBRead increments the replica-eligible read counter for a pool.
+func (m *InMemoryRecorder) IncDBRead(pool string) {
+	switch pool {
+	case "primary":
+		atomic.AddUint64(&m.dbReadsPrimary, 1)
+	case "replica":
+		atomic.AddUint64(&m.dbReadsReplica, 1)
+	}
+}
+
+// SetDBPoolConns records connection counts for a pool.
+func (m *InMemoryRecorder) SetDBPoolConns(pool string, acquired, idle, total int64) {
+	switch pool {
+	case "primary":
+		atomic.StoreInt64(&m.dbPrimaryConnsAcquired, acquired)
+		atomic.StoreInt64(&m.dbPrimaryConnsIdle, idle)
+		atomic.StoreInt64(&m.dbPrimaryConnsTotal, total)
+	case "replica":
+		atomic.StoreInt64(&m.dbReplicaConnsAcquired, acquired)
+		atomic.StoreInt64(&m.dbReplicaConnsIdle, idle)
+		atomic.StoreInt64(&m.dbReplicaConnsTotal, total)
+	}
+}
+
+// SetDBReplicaHealthy records the read replica health check result.
+func (m *InMemoryRecorder) SetDBReplicaHealthy(healthy bool) {
+	var v int64
+	if healthy {
+		v = 1
+	}
+	atomic.StoreInt64(&m.dbReplicaHealthy, v)
+}
+
 // IncLinkCreated increments link created counter.
 func (m *InMemoryRecorder) IncLinkCreated() {
 	atomic.AddUint64(&m.linksCreated, 1)
@@ -137,9 +279,12 @@ func (m *InMemoryRecorder) IncLinkDeleted() {
 
 // IncAnalyticsEventPublished increments event published counter.
 func (m *InMemoryRecorder) IncAnalyticsEventPublished(status string) {
-	if status == "success" {
+	switch status {
+	case "success":
 		atomic.AddUint64(&m.analyticsEventsPublished, 1)
-	} else {
+	case "spooled":
+		atomic.AddUint64(&m.analyticsEventsSpooled, 1)
+	default:
 		atomic.AddUint64(&m.analyticsEventsDropped, 1)
 	}
 }
@@ -175,6 +320,21 @@ func (m *InMemoryRecorder) SetAnalyticsQueueDepth(depth int64) {
 	atomic.StoreInt64(&m.analyticsQueueDepth, depth)
 }
 
+// SetAnalyticsDeadLetterDepth sets the current dead-letter queue depth.
+func (m *InMemoryRecorder) SetAnalyticsDeadLetterDepth(depth int64) {
+	atomic.StoreInt64(&m.analyticsDeadLetterDepth, depth)
+}
+
+// SetAnalyticsSpoolDepth sets the number of events waiting in the spool.
+func (m *InMemoryRecorder) SetAnalyticsSpoolDepth(depth int64) {
+	atomic.StoreInt64(&m.analyticsSpoolDepth, depth)
+}
+
+// SetAnalyticsSpoolAge sets the age of the oldest spooled event.
+func (m *InMemoryRecorder) SetAnalyticsSpoolAge(age time.Duration) {
+	atomic.StoreInt64(&m.analyticsSpoolAgeNs, age.Nanoseconds())
+}
+
 // ObserveAnalyticsIngestLag records ingest lag.
 func (m *InMemoryRecorder) ObserveAnalyticsIngestLag(lag time.Duration) {
 	atomic.AddUint64(&m.analyticsIngestLagCount, 1)
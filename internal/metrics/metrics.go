@@ -10,6 +10,15 @@ type Recorder interface {
 	IncRedirectCacheHit()
 	IncRedirectCacheMiss()
 	ObserveRedirectDuration(duration time.Duration)
+	IncCacheLookup(tier string, result string) // tier: "local", "redis" or "degraded"; result: "hit" or "miss"
+	IncLinkLookupCoalesced(scope string)       // scope: "process" (singleflight) or "cluster" (refill lock)
+	IncLinkDBLookup()
+	SetRedisCircuitState(state int64) // 0 closed, 1 open, 2 half-open
+
+	// Database pool metrics
+	IncDBRead(pool string) // pool: "primary" or "replica"
+	SetDBPoolConns(pool string, acquired, idle, total int64)
+	SetDBReplicaHealthy(healthy bool)
 
 	// Link management metrics
 	IncLinkCreated()
@@ -17,11 +26,14 @@ type Recorder interface {
 	IncLinkDeleted()
 
 	// Analytics pipeline metrics
-	IncAnalyticsEventPublished(status string) // status: "success" or "dropped"
+	IncAnalyticsEventPublished(status string) // status: "success", "spooled" or "dropped"
 	IncAnalyticsEventProcessed(status string) // status: "success", "failed", "skipped"
 	ObserveAnalyticsBatchSize(size int)
 	ObserveAnalyticsBatchDuration(duration time.Duration)
 	SetAnalyticsQueueDepth(depth int64)
+	SetAnalyticsDeadLetterDepth(depth int64)
+	SetAnalyticsSpoolDepth(depth int64)
+	SetAnalyticsSpoolAge(age time.Duration)
 	ObserveAnalyticsIngestLag(lag time.Duration)
 
 	// Webhook delivery metrics
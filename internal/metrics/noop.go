@@ -19,6 +19,27 @@ func (n *NoopRecorder) IncRedirectCacheMiss() {}
 // ObserveRedirectDuration is a no-op.
 func (n *NoopRecorder) ObserveRedirectDuration(duration time.Duration) {}
 
+// IncCacheLookup is a no-op.
+func (n *NoopRecorder) IncCacheLookup(tier string, result string) {}
+
+// IncLinkLookupCoalesced is a no-op.
+func (n *NoopRecorder) IncLinkLookupCoalesced(scope string) {}
+
+// IncLinkDBLookup is a no-op.
+func (n *NoopRecorder) IncLinkDBLookup() {}
+
+// SetRedisCircuitState is a no-op.
+func (n *NoopRecorder) SetRedisCircuitState(state int64) {}
+
+// IncDBRead is a no-op.
+func (n *NoopRecorder) IncDBRead(pool string) {}
+
+// SetDBPoolConns is a no-op.
+func (n *NoopRecorder) SetDBPoolConns(pool string, acquired, idle, total int64) {}
+
+// SetDBReplicaHealthy is a no-op.
+func (n *NoopRecorder) SetDBReplicaHealthy(healthy bool) {}
+
 // IncLinkCreated is a no-op.
 func (n *NoopRecorder) IncLinkCreated() {}
 
@@ -43,6 +64,15 @@ func (n *NoopRecorder) ObserveAnalyticsBatchDuration(duration time.Duration) {}
 // SetAnalyticsQueueDepth is a no-op.
 func (n *NoopRecorder) SetAnalyticsQueueDepth(depth int64) {}
 
+// SetAnalyticsDeadLetterDepth is a no-op.
+func (n *NoopRecorder) SetAnalyticsDeadLetterDepth(depth int64) {}
+
+// SetAnalyticsSpoolDepth is a no-op.
+func (n *NoopRecorder) SetAnalyticsSpoolDepth(depth int64) {}
+
+// SetAnalyticsSpoolAge is a no-op.
+func (n *NoopRecorder) SetAnalyticsSpoolAge(age time.Duration) {}
+
 // ObserveAnalyticsIngestLag is a no-op.
 func (n *NoopRecorder) ObserveAnalyticsIngestLag(lag time.Duration) {}
 
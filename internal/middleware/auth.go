@@ -20,8 +20,8 @@ const (
 // AuthConfig holds configuration for the auth middleware.
 type AuthConfig struct {
 	Logger     *slog.Logger
-	Repository *repository.Repository
-	Cache      *cache.Cache
+	Repository repository.APIKeyStore
+	Cache      cache.AuthCache
 }
 
 // Auth returns a middleware that authenticates API requests.
@@ -15,7 +15,7 @@ import (
 // RateLimitConfig holds configuration for rate limiting middleware.
 type RateLimitConfig struct {
 	Logger *slog.Logger
-	Cache  *cache.Cache
+	Cache  cache.RateLimiter
 	// API rate limiting (per API key)
 	APIEnabled bool
 	// Redirect rate limiting (per IP)
@@ -0,0 +1,16 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// ReadYourWrites starts a repository session for each request so reads
+// that follow a write in the same request are served by the primary
+// database rather than a possibly lagging replica.
+func ReadYourWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(repository.WithSession(r.Context())))
+	})
+}
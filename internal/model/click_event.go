@@ -1,7 +1,12 @@
 // Package model defines domain entities for the application.
 package model
 
-import "time"
+import (
+	"math"
+	"time"
+
+	"github.com/penshort/penshort/internal/hll"
+)
 
 // ClickEvent represents a single click/redirect event.
 type ClickEvent struct {
@@ -20,8 +25,22 @@ type ClickEvent struct {
 	// Privacy-safe visitor identification
 	VisitorHash string `json:"visitor_hash"` // SHA256(IP + UA + daily_salt)[0:16]
 
-	// Optional geo (from CF-IPCountry header)
+	// HyperLogLog register update for unique visitors across days.
+	// Zero for events recorded before sketches existed.
+	VisitorReg hll.Register `json:"-"`
+
+	// Optional geo (from CF-IPCountry header or the GeoIP database)
 	CountryCode string `json:"country_code,omitempty"` // ISO 3166-1 alpha-2
+	Region      string `json:"region,omitempty"`       // ISO 3166-2, e.g. "US-CA"
+	City        string `json:"city,omitempty"`
+
+	// Blocked is set for redirects refused by the link's country rules.
+	// Blocked events are counted separately from clicks.
+	Blocked bool `json:"blocked,omitempty"`
+
+	// Bot classification. Bot events are kept out of clicks and uniques.
+	IsBot     bool   `json:"is_bot,omitempty"`
+	BotFamily string `json:"bot_family,omitempty"` // e.g. slack, twitter, email_scanner
 
 	// Timestamps
 	ClickedAt time.Time `json:"clicked_at"` // Event timestamp
@@ -40,19 +59,109 @@ type DailyLinkStats struct {
 
 	// Breakdowns (stored as JSONB in Postgres)
 	ReferrerBreakdown  map[string]int64 `json:"referrer_breakdown,omitempty"`
-	UAFamilyBreakdown  map[string]int64 `json:"ua_family_breakdown,omitempty"`
+	UAFamilyBreakdown  map[string]int64 `json:"ua_family_breakdown,omitempty"` // Browser families
 	CountryBreakdown   map[string]int64 `json:"country_breakdown,omitempty"`
 
+	// Geo-blocked attempts, excluded from the counters above
+	BlockedClicks           int64            `json:"blocked_clicks"`
+	BlockedCountryBreakdown map[string]int64 `json:"blocked_country_breakdown,omitempty"`
+
+	// Bot requests, excluded from the counters above
+	BotClicks          int64            `json:"bot_clicks"`
+	BotFamilyBreakdown map[string]int64 `json:"bot_family_breakdown,omitempty"`
+
+	// Parsed User-Agent breakdowns. Devices also count bots, under "bot".
+	DeviceBreakdown map[string]int64 `json:"device_breakdown,omitempty"`
+	OSBreakdown     map[string]int64 `json:"os_breakdown,omitempty"`
+
+	// ISO 3166-2 regions, set only when the GeoIP database is configured
+	RegionBreakdown map[string]int64 `json:"region_breakdown,omitempty"`
+
+	// Encoded HyperLogLog sketch of the day's visitors, merged across days
+	VisitorSketch []byte `json:"-"`
+
+	// UTC is set when a time zone query returns the stored UTC day in place
+	// of a local day whose raw events have expired (not persisted)
+	UTC bool `json:"-"`
+
+	// Timestamps
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// HourlyLinkStats represents pre-aggregated hourly statistics. Rows are kept
+// for the hourly retention period, then pruned; DailyLinkStats, written
+// alongside them, keeps the day's totals.
+type HourlyLinkStats struct {
+	LinkID string    `json:"link_id"` // FK to links.id
+	Hour   time.Time `json:"hour"`    // Start of the UTC hour
+
+	// Counters, with the same meaning as in DailyLinkStats
+	TotalClicks    int64 `json:"total_clicks"`
+	UniqueVisitors int64 `json:"unique_visitors"`
+	BlockedClicks  int64 `json:"blocked_clicks"`
+	BotClicks      int64 `json:"bot_clicks"`
+
+	// Breakdowns (stored as JSONB in Postgres)
+	ReferrerBreakdown       map[string]int64 `json:"referrer_breakdown,omitempty"`
+	CountryBreakdown        map[string]int64 `json:"country_breakdown,omitempty"`
+	BlockedCountryBreakdown map[string]int64 `json:"blocked_country_breakdown,omitempty"`
+	BotFamilyBreakdown      map[string]int64 `json:"bot_family_breakdown,omitempty"`
+	UAFamilyBreakdown       map[string]int64 `json:"ua_family_breakdown,omitempty"`
+	DeviceBreakdown         map[string]int64 `json:"device_breakdown,omitempty"`
+	OSBreakdown             map[string]int64 `json:"os_breakdown,omitempty"`
+	RegionBreakdown         map[string]int64 `json:"region_breakdown,omitempty"`
+	VisitorSketch           []byte           `json:"-"` // Encoded HyperLogLog sketch
+
 	// Timestamps
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
 
+// Analytics time series granularities.
+const (
+	GranularityHour  = "hour"
+	GranularityDay   = "day"
+	GranularityWeek  = "week"
+	GranularityMonth = "month"
+)
+
 // AnalyticsSummary represents aggregated analytics for API response.
 type AnalyticsSummary struct {
 	TotalClicks     int64   `json:"total_clicks"`
 	UniqueVisitors  int64   `json:"unique_visitors"`
 	AvgClicksPerDay float64 `json:"avg_clicks_per_day"`
+	BlockedClicks   int64   `json:"blocked_clicks"` // Geo-blocked attempts, not in TotalClicks
+	BotClicks       int64   `json:"bot_clicks"`     // Bot requests, not in TotalClicks
+
+	// Distinct visitors over the whole range. UniqueVisitors adds up each
+	// day's count, so a visitor returning on another day is counted again.
+	UniqueVisitorsApprox *UniqueEstimate `json:"unique_visitors_approx,omitempty"`
+}
+
+// UniqueEstimate is an approximate distinct count with its error bound.
+type UniqueEstimate struct {
+	Value         int64   `json:"value"`
+	StandardError float64 `json:"standard_error"` // Relative, e.g. 0.008 is 0.8%
+	Low           int64   `json:"low"`            // Value minus two standard errors
+	High          int64   `json:"high"`           // Value plus two standard errors
+}
+
+// NewUniqueEstimate reports the estimate of a merged visitor sketch, or nil
+// when the sketch is empty (no clicks, or only clicks from before sketches
+// were recorded).
+func NewUniqueEstimate(sketch *hll.Sketch) *UniqueEstimate {
+	if sketch == nil || sketch.Empty() {
+		return nil
+	}
+	value := sketch.Estimate()
+	margin := int64(math.Ceil(2 * hll.StandardError * float64(value)))
+	return &UniqueEstimate{
+		Value:         value,
+		StandardError: hll.StandardError,
+		Low:           max(value-margin, 0),
+		High:          value + margin,
+	}
 }
 
 // AnalyticsResponse represents the full analytics API response.
@@ -60,21 +169,69 @@ type AnalyticsResponse struct {
 	LinkID    string `json:"link_id"`
 	ShortCode string `json:"short_code"`
 	Period    struct {
+		From     string `json:"from"`     // ISO date
+		To       string `json:"to"`       // ISO date
+		Timezone string `json:"timezone"` // IANA name the dates and buckets are in
+
+		// Some days are UTC days, because their raw events have expired
+		UTCFallback bool `json:"utc_fallback,omitempty"`
+	} `json:"period"`
+	Granularity string           `json:"granularity"`
+	Summary     AnalyticsSummary `json:"summary"`
+	Breakdown   struct {
+		Daily      []DailyBreakdown    `json:"daily,omitempty"`      // granularity=day
+		Timeseries []TimeBucket        `json:"timeseries,omitempty"` // granularity=hour, week or month
+		Referrers  []ReferrerBreakdown `json:"referrers,omitempty"`
+		Countries  []CountryBreakdown  `json:"countries,omitempty"`
+		Regions    []RegionBreakdown   `json:"regions,omitempty"` // Needs the GeoIP database
+		Blocked    []CountryBreakdown  `json:"blocked_countries,omitempty"`
+		Bots       []BotBreakdown      `json:"bots,omitempty"`
+		Devices    []DeviceBreakdown   `json:"devices,omitempty"`
+		OS         []FamilyBreakdown   `json:"os,omitempty"`
+		Browsers   []FamilyBreakdown   `json:"browsers,omitempty"`
+	} `json:"breakdown"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// DailyBreakdown represents clicks for a single day.
+type DailyBreakdown struct {
+	Date           string `json:"date"` // ISO date
+	TotalClicks    int64  `json:"total_clicks"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+	BlockedClicks  int64  `json:"blocked_clicks,omitempty"`
+	BotClicks      int64  `json:"bot_clicks,omitempty"`
+	UTC            bool   `json:"utc,omitempty"` // UTC day in place of an expired local day
+}
+
+// TimeBucket represents clicks for one hour, week or month.
+type TimeBucket struct {
+	Start          string `json:"start"` // RFC 3339 for hours, ISO date for weeks (Monday) and months
+	TotalClicks    int64  `json:"total_clicks"`
+	UniqueVisitors int64  `json:"unique_visitors"`
+	BlockedClicks  int64  `json:"blocked_clicks,omitempty"`
+	BotClicks      int64  `json:"bot_clicks,omitempty"`
+}
+
+// AccountAnalyticsResponse represents analytics across all of an owner's links.
+type AccountAnalyticsResponse struct {
+	Period struct {
 		From string `json:"from"` // ISO date
 		To   string `json:"to"`   // ISO date
 	} `json:"period"`
 	Summary   AnalyticsSummary `json:"summary"`
 	Breakdown struct {
 		Daily     []DailyBreakdown    `json:"daily,omitempty"`
+		TopLinks  []LinkClicks        `json:"top_links,omitempty"`
 		Referrers []ReferrerBreakdown `json:"referrers,omitempty"`
 		Countries []CountryBreakdown  `json:"countries,omitempty"`
 	} `json:"breakdown"`
 	GeneratedAt time.Time `json:"generated_at"`
 }
 
-// DailyBreakdown represents clicks for a single day.
-type DailyBreakdown struct {
-	Date           string `json:"date"` // ISO date
+// LinkClicks represents one link's clicks in a ranking.
+type LinkClicks struct {
+	LinkID         string `json:"link_id"`
+	ShortCode      string `json:"short_code"`
 	TotalClicks    int64  `json:"total_clicks"`
 	UniqueVisitors int64  `json:"unique_visitors"`
 }
@@ -85,9 +242,34 @@ type ReferrerBreakdown struct {
 	Clicks int64  `json:"clicks"`
 }
 
+// BotBreakdown represents requests from a bot family.
+type BotBreakdown struct {
+	Family string `json:"family"`
+	Clicks int64  `json:"clicks"`
+}
+
+// DeviceBreakdown represents requests from a device type.
+type DeviceBreakdown struct {
+	Device string `json:"device"` // desktop, mobile, tablet, bot or unknown
+	Clicks int64  `json:"clicks"`
+}
+
+// FamilyBreakdown represents clicks from an OS or browser family.
+type FamilyBreakdown struct {
+	Family string `json:"family"`
+	Clicks int64  `json:"clicks"`
+}
+
 // CountryBreakdown represents clicks from a country.
 type CountryBreakdown struct {
 	Code   string `json:"code"` // ISO 3166-1 alpha-2
 	Name   string `json:"name"` // Full country name
 	Clicks int64  `json:"clicks"`
 }
+
+// RegionBreakdown represents clicks from a country subdivision.
+type RegionBreakdown struct {
+	Code    string `json:"code"`    // ISO 3166-2, e.g. "US-CA"
+	Country string `json:"country"` // ISO 3166-1 alpha-2
+	Clicks  int64  `json:"clicks"`
+}
@@ -0,0 +1,66 @@
+// Package model defines domain entities for the application.
+package model
+
+import "time"
+
+// ErrorPageScope identifies what an ErrorPageSettings row applies to.
+type ErrorPageScope string
+
+const (
+	// ErrorPageScopeOwner applies settings to all links of an owner.
+	ErrorPageScopeOwner ErrorPageScope = "owner"
+	// ErrorPageScopeDomain applies settings to all requests for a host name.
+	ErrorPageScopeDomain ErrorPageScope = "domain"
+)
+
+// IsValid checks if the scope is a known value.
+func (s ErrorPageScope) IsValid() bool {
+	return s == ErrorPageScopeOwner || s == ErrorPageScopeDomain
+}
+
+// ErrorPageSettings holds fallback and branding configuration used when a
+// redirect cannot be served (expired, disabled or missing link).
+type ErrorPageSettings struct {
+	ScopeType   ErrorPageScope `json:"scope_type"`
+	ScopeID     string         `json:"scope_id"`
+	FallbackURL string         `json:"fallback_url,omitempty"`
+	BrandName   string         `json:"brand_name,omitempty"`
+	LogoURL     string         `json:"logo_url,omitempty"`
+	AccentColor string         `json:"accent_color,omitempty"` // #RRGGBB
+	SupportURL  string         `json:"support_url,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+}
+
+// ErrorPage is the resolved fallback and branding for a failed redirect.
+// Fields are merged from link, owner and domain settings in that order.
+type ErrorPage struct {
+	FallbackURL string
+	BrandName   string
+	LogoURL     string
+	AccentColor string
+	SupportURL  string
+}
+
+// Merge fills empty fields of p from settings. Fields already set win,
+// so callers merge the most specific scope first.
+func (p *ErrorPage) Merge(settings *ErrorPageSettings) {
+	if settings == nil {
+		return
+	}
+	if p.FallbackURL == "" {
+		p.FallbackURL = settings.FallbackURL
+	}
+	if p.BrandName == "" {
+		p.BrandName = settings.BrandName
+	}
+	if p.LogoURL == "" {
+		p.LogoURL = settings.LogoURL
+	}
+	if p.AccentColor == "" {
+		p.AccentColor = settings.AccentColor
+	}
+	if p.SupportURL == "" {
+		p.SupportURL = settings.SupportURL
+	}
+}
@@ -0,0 +1,52 @@
+package model
+
+import "testing"
+
+func TestErrorPageScope_IsValid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		scope ErrorPageScope
+		want  bool
+	}{
+		{ErrorPageScopeOwner, true},
+		{ErrorPageScopeDomain, true},
+		{"link", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.scope.IsValid(); got != tt.want {
+			t.Errorf("ErrorPageScope(%q).IsValid() = %v, want %v", tt.scope, got, tt.want)
+		}
+	}
+}
+
+func TestErrorPage_Merge(t *testing.T) {
+	t.Parallel()
+
+	page := &ErrorPage{FallbackURL: "https://link.example.com"}
+
+	page.Merge(&ErrorPageSettings{
+		FallbackURL: "https://owner.example.com",
+		BrandName:   "Owner Co",
+	})
+	page.Merge(nil)
+	page.Merge(&ErrorPageSettings{
+		BrandName:   "Domain Co",
+		AccentColor: "#112233",
+	})
+
+	if page.FallbackURL != "https://link.example.com" {
+		t.Errorf("FallbackURL = %s, want link fallback to win", page.FallbackURL)
+	}
+	if page.BrandName != "Owner Co" {
+		t.Errorf("BrandName = %s, want Owner Co", page.BrandName)
+	}
+	if page.AccentColor != "#112233" {
+		t.Errorf("AccentColor = %s, want #112233", page.AccentColor)
+	}
+	if page.LogoURL != "" || page.SupportURL != "" {
+		t.Errorf("unset fields should stay empty, got logo=%q support=%q", page.LogoURL, page.SupportURL)
+	}
+}
@@ -3,6 +3,7 @@ package model
 
 import (
 	"strconv"
+	"strings"
 	"time"
 )
 
@@ -38,10 +39,16 @@ type Link struct {
 	OwnerID      string       `json:"owner_id"`
 	Enabled      bool         `json:"enabled"`
 	ExpiresAt    *time.Time   `json:"expires_at,omitempty"`
-	DeletedAt    *time.Time   `json:"-"`
-	ClickCount   int64        `json:"click_count"`
-	CreatedAt    time.Time    `json:"created_at"`
-	UpdatedAt    time.Time    `json:"updated_at"`
+	FallbackURL  *string      `json:"fallback_url,omitempty"`
+	// Country rules (ISO 3166-1 alpha-2). Empty lists mean no restriction.
+	AllowedCountries []string   `json:"allowed_countries,omitempty"`
+	BlockedCountries []string   `json:"blocked_countries,omitempty"`
+	GeoBlockedURL    *string    `json:"geo_blocked_url,omitempty"`
+	Tags             []string   `json:"tags,omitempty"` // Lowercased labels for grouping in analytics
+	DeletedAt        *time.Time `json:"-"`
+	ClickCount       int64      `json:"click_count"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
 }
 
 // Status computes the current status of the link.
@@ -68,25 +75,109 @@ func (l *Link) IsExpired() bool {
 	return l.ExpiresAt != nil && time.Now().After(*l.ExpiresAt)
 }
 
+// AllowsCountry reports whether a visitor from country may be redirected.
+// Blocked countries always lose. With an allow list, only listed countries
+// pass, so visitors whose country is unknown ("") are refused.
+func (l *Link) AllowsCountry(country string) bool {
+	if country != "" && containsCountry(l.BlockedCountries, country) {
+		return false
+	}
+	if len(l.AllowedCountries) > 0 {
+		return country != "" && containsCountry(l.AllowedCountries, country)
+	}
+	return true
+}
+
+// HasGeoRules reports whether the link restricts visitors by country.
+func (l *Link) HasGeoRules() bool {
+	return len(l.AllowedCountries) > 0 || len(l.BlockedCountries) > 0
+}
+
+// containsCountry reports whether codes contains country, ignoring case.
+func containsCountry(codes []string, country string) bool {
+	for _, code := range codes {
+		if strings.EqualFold(code, country) {
+			return true
+		}
+	}
+	return false
+}
+
+// ETag returns the entity tag for the link's current revision.
+// It is derived from updated_at at microsecond precision, which matches
+// what PostgreSQL stores, so tags survive a database round trip.
+func (l *Link) ETag() string {
+	return `"` + strconv.FormatInt(l.UpdatedAt.UnixMicro(), 36) + `"`
+}
+
+// MatchETag reports whether an If-Match or If-None-Match header value
+// matches etag. The header may be "*" or a comma-separated list of tags.
+// With weak set, W/ prefixes are ignored (weak comparison, used for
+// If-None-Match); otherwise weak tags never match (strong comparison).
+func MatchETag(header, etag string, weak bool) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") {
+			if !weak {
+				continue
+			}
+			candidate = candidate[2:]
+		}
+		if candidate == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+
+	return false
+}
+
 // CachedLink represents link data stored in Redis cache.
 // Uses string types for Redis hash compatibility.
 type CachedLink struct {
-	Destination  string `redis:"destination"`
-	RedirectType string `redis:"redirect_type"`
-	ExpiresAt    string `redis:"expires_at"`  // Unix timestamp or empty
-	Enabled      string `redis:"enabled"`     // "1" or "0"
-	DeletedAt    string `redis:"deleted_at"`  // Unix timestamp or empty
-	UpdatedAt    string `redis:"updated_at"`  // Unix timestamp
+	ID               string `redis:"id"`
+	OwnerID          string `redis:"owner_id"`
+	Destination      string `redis:"destination"`
+	FallbackURL      string `redis:"fallback_url"`      // Empty if not set
+	AllowedCountries string `redis:"allowed_countries"` // Comma-separated
+	BlockedCountries string `redis:"blocked_countries"` // Comma-separated
+	GeoBlockedURL    string `redis:"geo_blocked_url"`   // Empty if not set
+	RedirectType     string `redis:"redirect_type"`
+	ExpiresAt        string `redis:"expires_at"` // Unix timestamp or empty
+	Enabled          string `redis:"enabled"`    // "1" or "0"
+	DeletedAt        string `redis:"deleted_at"` // Unix timestamp or empty
+	UpdatedAt        string `redis:"updated_at"` // Unix timestamp
 }
 
 // ToLink converts CachedLink to Link domain model.
 func (c *CachedLink) ToLink(shortCode string) *Link {
 	link := &Link{
+		ID:          c.ID,
 		ShortCode:   shortCode,
 		Destination: c.Destination,
+		OwnerID:     c.OwnerID,
 		Enabled:     c.Enabled == "1",
 	}
 
+	if c.FallbackURL != "" {
+		fallback := c.FallbackURL
+		link.FallbackURL = &fallback
+	}
+
+	link.AllowedCountries = splitCountries(c.AllowedCountries)
+	link.BlockedCountries = splitCountries(c.BlockedCountries)
+	if c.GeoBlockedURL != "" {
+		geoBlocked := c.GeoBlockedURL
+		link.GeoBlockedURL = &geoBlocked
+	}
+
 	// Parse redirect type
 	if c.RedirectType == "301" {
 		link.RedirectType = RedirectPermanent
@@ -123,6 +214,8 @@ func (c *CachedLink) ToLink(shortCode string) *Link {
 // ToCachedLink converts Link domain model to CachedLink.
 func (l *Link) ToCachedLink() *CachedLink {
 	cached := &CachedLink{
+		ID:           l.ID,
+		OwnerID:      l.OwnerID,
 		Destination:  l.Destination,
 		RedirectType: strconv.Itoa(int(l.RedirectType)),
 		Enabled:      boolToString(l.Enabled),
@@ -137,9 +230,27 @@ func (l *Link) ToCachedLink() *CachedLink {
 		cached.DeletedAt = strconv.FormatInt(l.DeletedAt.Unix(), 10)
 	}
 
+	if l.FallbackURL != nil {
+		cached.FallbackURL = *l.FallbackURL
+	}
+
+	cached.AllowedCountries = strings.Join(l.AllowedCountries, ",")
+	cached.BlockedCountries = strings.Join(l.BlockedCountries, ",")
+	if l.GeoBlockedURL != nil {
+		cached.GeoBlockedURL = *l.GeoBlockedURL
+	}
+
 	return cached
 }
 
+// splitCountries parses a comma-separated country list; "" yields nil.
+func splitCountries(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 // boolToString converts boolean to "1" or "0".
 func boolToString(b bool) string {
 	if b {
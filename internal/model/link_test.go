@@ -397,3 +397,155 @@ func TestRedirectType_IsValid(t *testing.T) {
 		})
 	}
 }
+
+func TestLink_ETag(t *testing.T) {
+	t.Parallel()
+
+	updatedAt := time.Date(2026, 1, 13, 8, 0, 0, 123456789, time.UTC)
+	link := &Link{UpdatedAt: updatedAt}
+
+	etag := link.ETag()
+	if etag[0] != '"' || etag[len(etag)-1] != '"' {
+		t.Fatalf("ETag() = %s, want quoted string", etag)
+	}
+
+	// Sub-microsecond differences are not persisted by PostgreSQL.
+	roundTripped := &Link{UpdatedAt: updatedAt.Truncate(time.Microsecond)}
+	if roundTripped.ETag() != etag {
+		t.Errorf("ETag() changed after microsecond truncation: %s vs %s", roundTripped.ETag(), etag)
+	}
+
+	changed := &Link{UpdatedAt: updatedAt.Add(time.Microsecond)}
+	if changed.ETag() == etag {
+		t.Error("ETag() should change when updated_at changes")
+	}
+}
+
+func TestMatchETag(t *testing.T) {
+	t.Parallel()
+
+	const etag = `"abc"`
+
+	tests := []struct {
+		name   string
+		header string
+		weak   bool
+		want   bool
+	}{
+		{"empty", "", false, false},
+		{"wildcard", "*", false, true},
+		{"exact", `"abc"`, false, true},
+		{"mismatch", `"def"`, false, false},
+		{"list", `"def", "abc"`, false, true},
+		{"weak strong compare", `W/"abc"`, false, false},
+		{"weak weak compare", `W/"abc"`, true, true},
+		{"unquoted", `abc`, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := MatchETag(tt.header, etag, tt.weak); got != tt.want {
+				t.Errorf("MatchETag(%q, %q, %v) = %v, want %v", tt.header, etag, tt.weak, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedLink_RoundTrip_IdentityAndFallback(t *testing.T) {
+	t.Parallel()
+
+	fallback := "https://example.com/gone"
+	link := &Link{
+		ID:           "link-123",
+		ShortCode:    "abc123",
+		Destination:  "https://example.com",
+		RedirectType: RedirectTemporary,
+		OwnerID:      "user-1",
+		Enabled:      true,
+		FallbackURL:  &fallback,
+		UpdatedAt:    time.Unix(1700000000, 0),
+	}
+
+	got := link.ToCachedLink().ToLink("abc123")
+
+	if got.ID != "link-123" {
+		t.Errorf("ID = %s, want link-123", got.ID)
+	}
+	if got.OwnerID != "user-1" {
+		t.Errorf("OwnerID = %s, want user-1", got.OwnerID)
+	}
+	if got.FallbackURL == nil || *got.FallbackURL != fallback {
+		t.Errorf("FallbackURL = %v, want %s", got.FallbackURL, fallback)
+	}
+
+	link.FallbackURL = nil
+	if got := link.ToCachedLink().ToLink("abc123"); got.FallbackURL != nil {
+		t.Errorf("FallbackURL should be nil, got %s", *got.FallbackURL)
+	}
+}
+
+func TestLink_AllowsCountry(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		allowed []string
+		blocked []string
+		country string
+		want    bool
+	}{
+		{"no_rules", nil, nil, "US", true},
+		{"no_rules_unknown", nil, nil, "", true},
+		{"allowed", []string{"US", "CA"}, nil, "CA", true},
+		{"not_in_allow_list", []string{"US", "CA"}, nil, "DE", false},
+		{"unknown_with_allow_list", []string{"US"}, nil, "", false},
+		{"blocked", nil, []string{"RU"}, "RU", false},
+		{"not_blocked", nil, []string{"RU"}, "US", true},
+		{"unknown_with_block_list", nil, []string{"RU"}, "", true},
+		{"blocked_wins", []string{"US"}, []string{"US"}, "US", false},
+		{"case_insensitive", []string{"us"}, nil, "US", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			link := &Link{AllowedCountries: tt.allowed, BlockedCountries: tt.blocked}
+			if got := link.AllowsCountry(tt.country); got != tt.want {
+				t.Errorf("AllowsCountry(%q) = %v, want %v", tt.country, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCachedLink_RoundTrip_GeoRules(t *testing.T) {
+	t.Parallel()
+
+	geoBlocked := "https://example.com/unavailable"
+	link := &Link{
+		ShortCode:        "abc123",
+		Destination:      "https://example.com",
+		RedirectType:     RedirectTemporary,
+		Enabled:          true,
+		AllowedCountries: []string{"US", "CA"},
+		BlockedCountries: []string{"CA"},
+		GeoBlockedURL:    &geoBlocked,
+	}
+
+	got := link.ToCachedLink().ToLink("abc123")
+
+	if len(got.AllowedCountries) != 2 || got.AllowedCountries[0] != "US" || got.AllowedCountries[1] != "CA" {
+		t.Errorf("AllowedCountries = %v, want [US CA]", got.AllowedCountries)
+	}
+	if len(got.BlockedCountries) != 1 || got.BlockedCountries[0] != "CA" {
+		t.Errorf("BlockedCountries = %v, want [CA]", got.BlockedCountries)
+	}
+	if got.GeoBlockedURL == nil || *got.GeoBlockedURL != geoBlocked {
+		t.Errorf("GeoBlockedURL = %v, want %s", got.GeoBlockedURL, geoBlocked)
+	}
+
+	plain := (&Link{ShortCode: "abc123", Enabled: true}).ToCachedLink().ToLink("abc123")
+	if plain.AllowedCountries != nil || plain.BlockedCountries != nil || plain.GeoBlockedURL != nil {
+		t.Errorf("expected no geo rules, got %+v", plain)
+	}
+}
@@ -0,0 +1,17 @@
+// Package model defines domain entities for the application.
+package model
+
+import "time"
+
+// LinkTransfer records one link moved to a new owner. Transfers made in the
+// same request share a TransferID.
+type LinkTransfer struct {
+	TransferID    string    `json:"transfer_id"`
+	LinkID        string    `json:"link_id"`
+	ShortCode     string    `json:"short_code"`
+	FromOwnerID   string    `json:"from_owner_id"`
+	ToOwnerID     string    `json:"to_owner_id"`
+	ActorID       string    `json:"actor_id"`
+	Reason        string    `json:"reason,omitempty"`
+	TransferredAt time.Time `json:"transferred_at"`
+}
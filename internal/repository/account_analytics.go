@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/penshort/penshort/internal/model"
+)
+
+// AccountStatsFilter selects the daily stats of an owner's links for
+// account-wide analytics. Deleted links are excluded.
+type AccountStatsFilter struct {
+	OwnerID string
+	From    time.Time // Inclusive date
+	To      time.Time // Inclusive date
+
+	// Optional link filters
+	LinkIDs       []string
+	Tag           string // Normalized (lowercase) tag the links must carry
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+}
+
+// postgresWhere returns the WHERE clause over daily_link_stats s joined
+// with links l, with its arguments starting at $1.
+func (f AccountStatsFilter) postgresWhere() (string, []any) {
+	clause := `
+		WHERE l.owner_id = $1 AND l.deleted_at IS NULL
+		  AND s.date >= $2 AND s.date <= $3
+	`
+	args := []any{f.OwnerID, f.From, f.To}
+
+	if len(f.LinkIDs) > 0 {
+		args = append(args, f.LinkIDs)
+		clause += fmt.Sprintf(" AND l.id = ANY($%d)", len(args))
+	}
+	if f.Tag != "" {
+		args = append(args, f.Tag)
+		clause += fmt.Sprintf(" AND l.tags @> ARRAY[$%d::text]", len(args))
+	}
+	if f.CreatedAfter != nil {
+		args = append(args, *f.CreatedAfter)
+		clause += fmt.Sprintf(" AND l.created_at >= $%d", len(args))
+	}
+	if f.CreatedBefore != nil {
+		args = append(args, *f.CreatedBefore)
+		clause += fmt.Sprintf(" AND l.created_at <= $%d", len(args))
+	}
+
+	return clause, args
+}
+
+// GetAccountDailyStats returns clicks per day summed across the filtered links,
+// newest first.
+func (r *ClickEventRepository) GetAccountDailyStats(ctx context.Context, filter AccountStatsFilter) ([]model.DailyBreakdown, error) {
+	where, args := filter.postgresWhere()
+	query := `
+		SELECT s.date, SUM(s.total_clicks), SUM(s.unique_visitors),
+			SUM(s.blocked_clicks), SUM(s.bot_clicks)
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+	` + where + `
+		GROUP BY s.date
+		ORDER BY s.date DESC
+	`
+
+	rows, err := r.repo.reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query account daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var daily []model.DailyBreakdown
+	for rows.Next() {
+		var day model.DailyBreakdown
+		var date time.Time
+		if err := rows.Scan(&date, &day.TotalClicks, &day.UniqueVisitors, &day.BlockedClicks, &day.BotClicks); err != nil {
+			return nil, fmt.Errorf("scan account daily stat: %w", err)
+		}
+		day.Date = date.Format("2006-01-02")
+		daily = append(daily, day)
+	}
+
+	return daily, rows.Err()
+}
+
+// GetAccountTopLinks returns the filtered links with the most clicks.
+func (r *ClickEventRepository) GetAccountTopLinks(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.LinkClicks, error) {
+	where, args := filter.postgresWhere()
+	args = append(args, limit)
+	query := `
+		SELECT l.id, l.short_code, SUM(s.total_clicks) AS clicks, SUM(s.unique_visitors)
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+	` + where + fmt.Sprintf(`
+		GROUP BY l.id, l.short_code
+		ORDER BY clicks DESC, l.id
+		LIMIT $%d
+	`, len(args))
+
+	rows, err := r.repo.reader(ctx).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query account top links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []model.LinkClicks
+	for rows.Next() {
+		var link model.LinkClicks
+		if err := rows.Scan(&link.LinkID, &link.ShortCode, &link.TotalClicks, &link.UniqueVisitors); err != nil {
+			return nil, fmt.Errorf("scan account top link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// GetAccountTopReferrers returns the top referrer domains across the filtered links.
+func (r *ClickEventRepository) GetAccountTopReferrers(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.ReferrerBreakdown, error) {
+	rows, err := r.queryAccountBreakdown(ctx, "referrer_breakdown", filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query account top referrers: %w", err)
+	}
+	defer rows.Close()
+
+	var referrers []model.ReferrerBreakdown
+	for rows.Next() {
+		var ref model.ReferrerBreakdown
+		if err := rows.Scan(&ref.Domain, &ref.Clicks); err != nil {
+			return nil, fmt.Errorf("scan referrer: %w", err)
+		}
+		referrers = append(referrers, ref)
+	}
+
+	return referrers, rows.Err()
+}
+
+// GetAccountTopCountries returns the top countries across the filtered links.
+func (r *ClickEventRepository) GetAccountTopCountries(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.CountryBreakdown, error) {
+	rows, err := r.queryAccountBreakdown(ctx, "country_breakdown", filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query account top countries: %w", err)
+	}
+	defer rows.Close()
+
+	var countries []model.CountryBreakdown
+	for rows.Next() {
+		var c model.CountryBreakdown
+		if err := rows.Scan(&c.Code, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("scan country: %w", err)
+		}
+		c.Name = countryName(c.Code)
+		countries = append(countries, c)
+	}
+
+	return countries, rows.Err()
+}
+
+// queryAccountBreakdown sums one JSONB breakdown column across the filtered
+// links. column is always a constant from this file.
+func (r *ClickEventRepository) queryAccountBreakdown(ctx context.Context, column string, filter AccountStatsFilter, limit int) (pgx.Rows, error) {
+	where, args := filter.postgresWhere()
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		SELECT j.key, SUM(j.value::bigint) AS clicks
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+		CROSS JOIN LATERAL jsonb_each_text(s.%s) AS j
+	`, column) + where + fmt.Sprintf(`
+		GROUP BY j.key
+		ORDER BY clicks DESC
+		LIMIT $%d
+	`, len(args))
+
+	return r.repo.reader(ctx).Query(ctx, query, args...)
+}
@@ -0,0 +1,126 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+func TestIntegrationClickEventRepository_AccountAnalytics(t *testing.T) {
+	forEachBackend(t, newAccountAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+		createLink := func(prefix, owner string, tags ...string) *model.Link {
+			link := testutil.NewTestLink(t, testutil.UniqueShortCode(prefix))
+			link.OwnerID = owner
+			link.Tags = tags
+			if err := repo.CreateLink(ctx, link); err != nil {
+				t.Fatalf("CreateLink: %v", err)
+			}
+			return link
+		}
+		busy := createLink("acct-busy", "alice")
+		quiet := createLink("acct-quiet", "alice", "launch")
+		other := createLink("acct-other", "bob", "launch")
+
+		var events []*model.ClickEvent
+		for i, visitor := range []string{"v1", "v2", "v3"} {
+			event := testClickEvent(busy.ID, visitor, day.Add(time.Duration(i)*time.Hour))
+			event.Referrer = "https://news.example.com/a"
+			events = append(events, event)
+		}
+		events = append(events,
+			testClickEvent(quiet.ID, "v1", day.Add(-24*time.Hour)),
+			testClickEvent(other.ID, "v9", day),
+		)
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateDailyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateDailyStats: %v", err)
+		}
+
+		filter := AccountStatsFilter{OwnerID: "alice", From: day.AddDate(0, 0, -7), To: day}
+
+		daily, err := clicks.GetAccountDailyStats(ctx, filter)
+		if err != nil {
+			t.Fatalf("GetAccountDailyStats: %v", err)
+		}
+		if len(daily) != 2 || daily[0].Date != day.Format("2006-01-02") || daily[0].TotalClicks != 3 || daily[1].TotalClicks != 1 {
+			t.Errorf("daily = %+v, want 3 clicks on %s then 1", daily, day.Format("2006-01-02"))
+		}
+
+		topLinks, err := clicks.GetAccountTopLinks(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("GetAccountTopLinks: %v", err)
+		}
+		if len(topLinks) != 2 || topLinks[0].LinkID != busy.ID || topLinks[0].ShortCode != busy.ShortCode {
+			t.Errorf("top links = %+v, want %s first and bob's link excluded", topLinks, busy.ID)
+		}
+
+		referrers, err := clicks.GetAccountTopReferrers(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("GetAccountTopReferrers: %v", err)
+		}
+		if len(referrers) == 0 || referrers[0].Domain != "news.example.com" || referrers[0].Clicks != 3 {
+			t.Errorf("referrers = %+v, want news.example.com with 3", referrers)
+		}
+
+		countries, err := clicks.GetAccountTopCountries(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("GetAccountTopCountries: %v", err)
+		}
+		if len(countries) != 1 || countries[0].Code != "US" || countries[0].Clicks != 4 {
+			t.Errorf("countries = %+v, want US with 4", countries)
+		}
+
+		// Narrow by link ID
+		filter.LinkIDs = []string{quiet.ID, other.ID}
+		topLinks, err = clicks.GetAccountTopLinks(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("GetAccountTopLinks by ID: %v", err)
+		}
+		if len(topLinks) != 1 || topLinks[0].LinkID != quiet.ID {
+			t.Errorf("top links by ID = %+v, want only %s", topLinks, quiet.ID)
+		}
+
+		// Narrow by tag
+		filter.LinkIDs = nil
+		filter.Tag = "launch"
+		topLinks, err = clicks.GetAccountTopLinks(ctx, filter, 10)
+		if err != nil {
+			t.Fatalf("GetAccountTopLinks by tag: %v", err)
+		}
+		if len(topLinks) != 1 || topLinks[0].LinkID != quiet.ID {
+			t.Errorf("top links by tag = %+v, want only %s", topLinks, quiet.ID)
+		}
+
+		// Narrow by link creation time
+		filter.Tag = ""
+		after := time.Now().Add(time.Hour)
+		filter.CreatedAfter = &after
+		daily, err = clicks.GetAccountDailyStats(ctx, filter)
+		if err != nil {
+			t.Fatalf("GetAccountDailyStats created_after: %v", err)
+		}
+		if len(daily) != 0 {
+			t.Errorf("expected no stats for links created in the future, got %+v", daily)
+		}
+	})
+}
+
+func newAccountAnalyticsTestEnv(t *testing.T) (context.Context, Store) {
+	t.Helper()
+
+	ctx, repo := newLinkTestEnv(t)
+	if err := testutil.ResetAnalyticsSchema(ctx, repo.(*Repository).Pool()); err != nil {
+		t.Fatalf("reset analytics schema: %v", err)
+	}
+	return ctx, repo
+}
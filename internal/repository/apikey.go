@@ -23,7 +23,7 @@ func (r *Repository) CreateAPIKey(ctx context.Context, key *model.APIKey) error
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := r.writer(ctx).Exec(ctx, query,
 		key.ID,
 		key.UserID,
 		key.KeyHash,
@@ -122,7 +122,7 @@ func (r *Repository) RevokeAPIKey(ctx context.Context, id string) error {
 		WHERE id = $1 AND revoked_at IS NULL
 	`
 
-	result, err := r.pool.Exec(ctx, query, id, time.Now())
+	result, err := r.writer(ctx).Exec(ctx, query, id, time.Now())
 	if err != nil {
 		return fmt.Errorf("failed to revoke API key: %w", err)
 	}
@@ -0,0 +1,138 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/penshort/penshort/internal/analytics"
+	"github.com/penshort/penshort/internal/hll"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+func TestIntegrationClickEventRepository_InsertPaths(t *testing.T) {
+	ctx, store := newAnalyticsTestEnv(t)
+	repo := store.(*Repository)
+	clicks := NewClickEventRepository(repo)
+	day := time.Now().UTC().Truncate(24 * time.Hour)
+
+	paths := map[string]func(context.Context, pgx.Tx, []*model.ClickEvent) ([]*model.ClickEvent, error){
+		"copy": copyClickEvents,
+		"rows": insertClickEventRows,
+	}
+	for name, insert := range paths {
+		t.Run(name, func(t *testing.T) {
+			linkID := "insert-" + name
+			stored := testClickEvent(linkID, "visitor-a", day.Add(time.Hour))
+			if err := clicks.BulkInsert(ctx, []*model.ClickEvent{stored}); err != nil {
+				t.Fatalf("BulkInsert: %v", err)
+			}
+
+			fresh := testClickEvent(linkID, "visitor-b", day.Add(2*time.Hour))
+			fresh.Region = "US-CA"
+			fresh.City = "Oakland"
+			fresh.VisitorReg = hll.NewRegister(1 << 60)
+			repeat := *fresh
+			repeat.ID += "-repeat"
+			repeat.VisitorHash = "visitor-c"
+			events := []*model.ClickEvent{stored, fresh, &repeat}
+
+			// Run each path twice: the second pass must find every event stored
+			for pass, want := range []int{1, 0} {
+				tx, err := repo.Pool().Begin(ctx)
+				if err != nil {
+					t.Fatalf("begin: %v", err)
+				}
+				inserted, err := insert(ctx, tx, events)
+				if err != nil {
+					_ = tx.Rollback(ctx)
+					t.Fatalf("pass %d: insert: %v", pass, err)
+				}
+				if err := tx.Commit(ctx); err != nil {
+					t.Fatalf("pass %d: commit: %v", pass, err)
+				}
+				if len(inserted) != want {
+					t.Fatalf("pass %d: inserted %d events, want %d", pass, len(inserted), want)
+				}
+				if want == 1 && inserted[0] != fresh {
+					t.Errorf("pass %d: inserted %+v, want the first event of the repeated ID", pass, inserted[0])
+				}
+			}
+
+			var visitorHash, region, city string
+			var visitorReg int64
+			if err := repo.Pool().QueryRow(ctx, `
+				SELECT visitor_hash, region, city, visitor_reg FROM click_events WHERE event_id = $1
+			`, fresh.EventID).Scan(&visitorHash, &region, &city, &visitorReg); err != nil {
+				t.Fatalf("read stored event: %v", err)
+			}
+			if visitorHash != "visitor-b" || region != "US-CA" || city != "Oakland" || hll.Register(visitorReg) != fresh.VisitorReg {
+				t.Errorf("stored %s %s %s %d, want the fresh event", visitorHash, region, city, visitorReg)
+			}
+		})
+	}
+}
+
+func BenchmarkIntegrationClickEventRepository_BulkInsert(b *testing.B) {
+	ctx := context.Background()
+	dbURL := testutil.RequireEnv(b, "DATABASE_URL")
+
+	repo, err := New(ctx, dbURL, "")
+	if err != nil {
+		b.Fatalf("connect db: %v", err)
+	}
+	b.Cleanup(repo.Close)
+
+	unlock, err := testutil.AcquireDBLock(ctx, repo.Pool())
+	if err != nil {
+		b.Fatalf("acquire db lock: %v", err)
+	}
+	b.Cleanup(func() {
+		_ = unlock()
+	})
+	if err := testutil.ResetAnalyticsSchema(ctx, repo.Pool()); err != nil {
+		b.Fatalf("reset analytics schema: %v", err)
+	}
+
+	benchmarks := []struct {
+		name   string
+		size   int
+		insert func(context.Context, pgx.Tx, []*model.ClickEvent) ([]*model.ClickEvent, error)
+	}{
+		{"copy", analytics.DefaultBatchSize, copyClickEvents},
+		{"rows", analytics.DefaultBatchSize, insertClickEventRows},
+		{"small_copy", minCopyBatch - 1, copyClickEvents},
+		{"small_rows", minCopyBatch - 1, insertClickEventRows},
+	}
+	for _, bm := range benchmarks {
+		b.Run(fmt.Sprintf("%s/%d", bm.name, bm.size), func(b *testing.B) {
+			now := time.Now().UTC()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				events := make([]*model.ClickEvent, bm.size)
+				for j := range events {
+					events[j] = testClickEvent("bench-link", fmt.Sprintf("visitor-%d", j), now)
+				}
+				b.StartTimer()
+
+				tx, err := repo.Pool().Begin(ctx)
+				if err != nil {
+					b.Fatalf("begin: %v", err)
+				}
+				if _, err := bm.insert(ctx, tx, events); err != nil {
+					_ = tx.Rollback(ctx)
+					b.Fatalf("insert: %v", err)
+				}
+				if err := tx.Commit(ctx); err != nil {
+					b.Fatalf("commit: %v", err)
+				}
+			}
+			b.ReportMetric(float64(b.N*bm.size)/b.Elapsed().Seconds(), "rows/s")
+		})
+	}
+}
@@ -3,11 +3,16 @@ package repository
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/penshort/penshort/internal/hll"
 	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/useragent"
 )
 
 // ClickEventRepository provides database access for click events.
@@ -21,69 +26,288 @@ func NewClickEventRepository(repo *Repository) *ClickEventRepository {
 }
 
 // BulkInsert inserts multiple click events with idempotency via ON CONFLICT DO NOTHING.
+// It leaves the stats alone; the worker records events with RecordClickEvents.
 func (r *ClickEventRepository) BulkInsert(ctx context.Context, events []*model.ClickEvent) error {
 	if len(events) == 0 {
 		return nil
 	}
 
-	// Use COPY for large batches, but for moderate sizes (< 1000), use multi-row INSERT
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin bulk insert: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	if _, err := insertClickEvents(ctx, tx, events); err != nil {
+		return err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit bulk insert: %w", err)
+	}
+	return nil
+}
+
+// minCopyBatch is the smallest batch copied through the staging table.
+// Below it, the staging round trips cost more than they save.
+const minCopyBatch = 16
+
+// clickEventColumns are the columns written by insertClickEvents, in
+// clickEventValues order.
+var clickEventColumns = []string{
+	"id", "event_id", "short_code", "link_id", "referrer", "user_agent",
+	"visitor_hash", "visitor_reg", "country_code", "region", "city",
+	"blocked", "is_bot", "bot_family", "clicked_at",
+}
+
+// insertClickEvents inserts click events, skipping event IDs that were
+// already stored, and returns the events it inserted. An event ID repeated
+// within the batch is inserted once, from its first event.
+func insertClickEvents(ctx context.Context, tx pgx.Tx, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	events, err := claimEventIDs(ctx, tx, events)
+	if err != nil {
+		return nil, err
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	if len(events) < minCopyBatch {
+		return insertClickEventRows(ctx, tx, events)
+	}
+	return copyClickEvents(ctx, tx, events)
+}
+
+// claimEventIDs records the batch's event IDs in click_event_ids and returns
+// the first event of each ID that was not recorded before. click_events is
+// partitioned, so its own unique key includes clicked_at; this keeps an
+// event requeued with a different clicked_at from being stored twice.
+func claimEventIDs(ctx context.Context, tx pgx.Tx, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	ids := make([]string, len(events))
+	for i, event := range events {
+		ids[i] = event.EventID
+	}
+
+	// IDs go in order so concurrent batches lock the primary key in the
+	// same order
+	rows, err := tx.Query(ctx, `
+		INSERT INTO click_event_ids (event_id)
+		SELECT DISTINCT unnest($1::text[])
+		ORDER BY 1
+		ON CONFLICT (event_id) DO NOTHING
+		RETURNING event_id
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("claim click event ids: %w", err)
+	}
+	claimed := make(map[string]bool, len(events))
+	for rows.Next() {
+		var eventID string
+		if err := rows.Scan(&eventID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan claimed event id: %w", err)
+		}
+		claimed[eventID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("claim click event ids: %w", err)
+	}
+
+	fresh := make([]*model.ClickEvent, 0, len(claimed))
+	for _, event := range events {
+		if claimed[event.EventID] {
+			fresh = append(fresh, event)
+			delete(claimed, event.EventID)
+		}
+	}
+	return fresh, nil
+}
+
+// copyClickEvents copies events into a session-local staging table and
+// moves them into click_events with a single INSERT ... ON CONFLICT, which
+// keeps the idempotency of the row-by-row path. The staging table is
+// emptied on commit, so it also works behind a transaction pooler.
+func copyClickEvents(ctx context.Context, tx pgx.Tx, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	if _, err := tx.Exec(ctx, `
+		CREATE TEMP TABLE IF NOT EXISTS click_events_staging (
+			ord INTEGER NOT NULL,
+			id TEXT NOT NULL,
+			event_id TEXT NOT NULL,
+			short_code TEXT NOT NULL,
+			link_id TEXT NOT NULL,
+			referrer TEXT,
+			user_agent TEXT,
+			visitor_hash TEXT NOT NULL,
+			visitor_reg INTEGER,
+			country_code TEXT,
+			region TEXT,
+			city TEXT,
+			blocked BOOLEAN NOT NULL,
+			is_bot BOOLEAN NOT NULL,
+			bot_family TEXT,
+			clicked_at TIMESTAMPTZ NOT NULL
+		) ON COMMIT DELETE ROWS
+	`); err != nil {
+		return nil, fmt.Errorf("create click staging table: %w", err)
+	}
+
+	columns := append([]string{"ord"}, clickEventColumns...)
+	if _, err := tx.CopyFrom(ctx, pgx.Identifier{"click_events_staging"}, columns,
+		pgx.CopyFromSlice(len(events), func(i int) ([]any, error) {
+			return append([]any{i}, clickEventValues(events[i])...), nil
+		}),
+	); err != nil {
+		return nil, fmt.Errorf("copy click events: %w", err)
+	}
+
+	// Rows go in event ID order so concurrent batches lock the unique
+	// index in the same order
+	columnList := strings.Join(clickEventColumns, ", ")
+	rows, err := tx.Query(ctx, `
+		INSERT INTO click_events (`+columnList+`, created_at)
+		SELECT `+columnList+`, NOW()
+		FROM (
+			SELECT DISTINCT ON (event_id) *
+			FROM click_events_staging
+			ORDER BY event_id, ord
+		) AS staged
+		ORDER BY event_id
+		ON CONFLICT (event_id, clicked_at) DO NOTHING
+		RETURNING event_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("insert staged click events: %w", err)
+	}
+	stored := make(map[string]bool, len(events))
+	for rows.Next() {
+		var eventID string
+		if err := rows.Scan(&eventID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan staged event id: %w", err)
+		}
+		stored[eventID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("insert staged click events: %w", err)
+	}
+
+	// Clear the table for a later batch in the same transaction
+	if _, err := tx.Exec(ctx, `TRUNCATE click_events_staging`); err != nil {
+		return nil, fmt.Errorf("clear click staging table: %w", err)
+	}
+
+	inserted := make([]*model.ClickEvent, 0, len(stored))
+	for _, event := range events {
+		if stored[event.EventID] {
+			inserted = append(inserted, event)
+			delete(stored, event.EventID)
+		}
+	}
+	return inserted, nil
+}
+
+// insertClickEventRows inserts events one statement each, pipelined in a
+// single batch. It is the path for small batches.
+func insertClickEventRows(ctx context.Context, tx pgx.Tx, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
 	batch := &pgx.Batch{}
 
 	query := `
-		INSERT INTO click_events (
-			id, event_id, short_code, link_id, referrer, user_agent,
-			visitor_hash, country_code, clicked_at, created_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
-		ON CONFLICT (event_id) DO NOTHING
+		INSERT INTO click_events (` + strings.Join(clickEventColumns, ", ") + `, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW())
+		ON CONFLICT (event_id, clicked_at) DO NOTHING
+		RETURNING event_id
 	`
 
 	for _, event := range events {
-		batch.Queue(query,
-			event.ID,
-			event.EventID,
-			event.ShortCode,
-			event.LinkID,
-			nullableString(event.Referrer),
-			nullableString(event.UserAgent),
-			event.VisitorHash,
-			nullableString(event.CountryCode),
-			event.ClickedAt,
-		)
+		batch.Queue(query, clickEventValues(event)...)
 	}
 
-	results := r.repo.pool.SendBatch(ctx, batch)
+	results := tx.SendBatch(ctx, batch)
 	defer results.Close()
 
-	// Check for errors in batch execution
-	for i := 0; i < len(events); i++ {
-		if _, err := results.Exec(); err != nil {
-			return fmt.Errorf("batch insert event %d: %w", i, err)
+	// Duplicates return no row
+	inserted := make([]*model.ClickEvent, 0, len(events))
+	for i, event := range events {
+		var eventID string
+		if err := results.QueryRow().Scan(&eventID); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				continue
+			}
+			return nil, fmt.Errorf("batch insert event %d: %w", i, err)
 		}
+		inserted = append(inserted, event)
 	}
 
-	return nil
+	return inserted, nil
 }
 
-// UpdateDailyStats updates the daily_link_stats table with aggregated data.
-func (r *ClickEventRepository) UpdateDailyStats(ctx context.Context, events []*model.ClickEvent) error {
-	if len(events) == 0 {
-		return nil
+// clickEventValues returns an event's values for clickEventColumns.
+func clickEventValues(event *model.ClickEvent) []any {
+	return []any{
+		event.ID,
+		event.EventID,
+		event.ShortCode,
+		event.LinkID,
+		nullableString(event.Referrer),
+		nullableString(event.UserAgent),
+		event.VisitorHash,
+		nullableRegister(event.VisitorReg),
+		nullableString(event.CountryCode),
+		nullableString(event.Region),
+		nullableString(event.City),
+		event.Blocked,
+		event.IsBot,
+		nullableString(event.BotFamily),
+		event.ClickedAt,
 	}
+}
 
-	keys := uniqueDailyKeys(events)
-	for _, key := range keys {
-		acc, err := r.recalculateDailyStat(ctx, key.linkID, key.date)
-		if err != nil {
-			return fmt.Errorf("recalculate daily stat %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
-		}
-		if err := r.upsertDailyStat(ctx, acc); err != nil {
-			return fmt.Errorf("upsert daily stat %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
+// UpdateDailyStats recomputes the daily_link_stats rows touched by events
+// from the stored click events, replacing their visitor sets. The worker
+// merges batches with RecordClickEvents instead; this is the repair path.
+func (r *ClickEventRepository) UpdateDailyStats(ctx context.Context, events []*model.ClickEvent) error {
+	for _, key := range uniqueDailyKeys(events) {
+		if err := r.recomputeDailyStat(ctx, key.linkID, key.date); err != nil {
+			return fmt.Errorf("recompute daily stat %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
 		}
 	}
 
 	return nil
 }
 
+// recomputeDailyStat rebuilds one link-day from its click events.
+func (r *ClickEventRepository) recomputeDailyStat(ctx context.Context, linkID string, date time.Time) error {
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	// Lock the row first so batches merged meanwhile wait and apply on top
+	if _, err := lockDailyStat(ctx, tx, linkID, date); err != nil {
+		return err
+	}
+	acc, err := recalculateStats(ctx, tx, linkID, date, date.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM daily_link_visitors WHERE link_id = $1 AND date = $2`,
+		linkID, date,
+	); err != nil {
+		return err
+	}
+	if _, err := addDailyVisitors(ctx, tx, linkID, date, acc.visitorHashes()); err != nil {
+		return err
+	}
+	if err := upsertDailyStat(ctx, tx, acc); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
 // dailyStatsAccumulator accumulates stats for a single link/date combination.
 type dailyStatsAccumulator struct {
 	linkID         string
@@ -92,7 +316,22 @@ type dailyStatsAccumulator struct {
 	uniqueVisitors int64
 	referrers      map[string]int64
 	countries      map[string]int64
+	regions        map[string]int64 // ISO 3166-2, from the GeoIP database
 	visitorSeen    map[string]bool
+	visitors       *hll.Sketch // Mergeable across days, unlike visitorSeen
+
+	// Geo-blocked attempts, kept out of the click counters
+	blockedClicks    int64
+	blockedCountries map[string]int64
+
+	// Bot requests, kept out of the click counters
+	botClicks   int64
+	botFamilies map[string]int64
+
+	// Parsed User-Agents; devices also count bots
+	devices    map[string]int64
+	osFamilies map[string]int64
+	browsers   map[string]int64
 }
 
 type dailyStatsKey struct {
@@ -115,17 +354,16 @@ func uniqueDailyKeys(events []*model.ClickEvent) []dailyStatsKey {
 	return keys
 }
 
-func (r *ClickEventRepository) recalculateDailyStat(ctx context.Context, linkID string, date time.Time) (*dailyStatsAccumulator, error) {
-	start := date.UTC().Truncate(24 * time.Hour)
-	end := start.Add(24 * time.Hour)
-
+// recalculateStats aggregates a link's click events in [start, end).
+func recalculateStats(ctx context.Context, db pgxQuerier, linkID string, start, end time.Time) (*dailyStatsAccumulator, error) {
 	query := `
-		SELECT COALESCE(referrer, ''), COALESCE(country_code, ''), visitor_hash
+		SELECT COALESCE(referrer, ''), COALESCE(user_agent, ''), COALESCE(country_code, ''),
+			COALESCE(region, ''), visitor_hash, COALESCE(visitor_reg, 0), blocked, is_bot, COALESCE(bot_family, '')
 		FROM click_events
 		WHERE link_id = $1 AND clicked_at >= $2 AND clicked_at < $3
 	`
 
-	rows, err := r.repo.pool.Query(ctx, query, linkID, start, end)
+	rows, err := db.Query(ctx, query, linkID, start, end)
 	if err != nil {
 		return nil, fmt.Errorf("query click events: %w", err)
 	}
@@ -133,14 +371,22 @@ func (r *ClickEventRepository) recalculateDailyStat(ctx context.Context, linkID
 
 	events := make([]*model.ClickEvent, 0)
 	for rows.Next() {
-		var referrer, country, visitorHash string
-		if err := rows.Scan(&referrer, &country, &visitorHash); err != nil {
+		var referrer, userAgent, country, region, visitorHash, botFamily string
+		var visitorReg hll.Register
+		var blocked, isBot bool
+		if err := rows.Scan(&referrer, &userAgent, &country, &region, &visitorHash, &visitorReg, &blocked, &isBot, &botFamily); err != nil {
 			return nil, fmt.Errorf("scan click event: %w", err)
 		}
 		events = append(events, &model.ClickEvent{
 			Referrer:    referrer,
+			UserAgent:   userAgent,
 			CountryCode: country,
+			Region:      region,
 			VisitorHash: visitorHash,
+			VisitorReg:  visitorReg,
+			Blocked:     blocked,
+			IsBot:       isBot,
+			BotFamily:   botFamily,
 		})
 	}
 	if err := rows.Err(); err != nil {
@@ -154,55 +400,153 @@ func (r *ClickEventRepository) recalculateDailyStat(ctx context.Context, linkID
 }
 
 func accumulateDailyStats(events []*model.ClickEvent) *dailyStatsAccumulator {
-	acc := &dailyStatsAccumulator{
-		referrers:   make(map[string]int64),
-		countries:   make(map[string]int64),
-		visitorSeen: make(map[string]bool),
+	acc := newDailyStatsAccumulator()
+	for _, event := range events {
+		acc.add(event)
 	}
+	return acc
+}
 
-	for _, event := range events {
-		acc.totalClicks++
+func newDailyStatsAccumulator() *dailyStatsAccumulator {
+	return &dailyStatsAccumulator{
+		referrers:        make(map[string]int64),
+		countries:        make(map[string]int64),
+		regions:          make(map[string]int64),
+		visitorSeen:      make(map[string]bool),
+		visitors:         hll.New(),
+		blockedCountries: make(map[string]int64),
+		botFamilies:      make(map[string]int64),
+		devices:          make(map[string]int64),
+		osFamilies:       make(map[string]int64),
+		browsers:         make(map[string]int64),
+	}
+}
 
-		if event.VisitorHash != "" && !acc.visitorSeen[event.VisitorHash] {
-			acc.visitorSeen[event.VisitorHash] = true
-			acc.uniqueVisitors++
+// add counts a single click event.
+func (acc *dailyStatsAccumulator) add(event *model.ClickEvent) {
+	// Bots are counted on their own, whether or not they were geo-blocked
+	if event.IsBot {
+		acc.botClicks++
+		family := event.BotFamily
+		if family == "" {
+			family = "other"
 		}
+		acc.botFamilies[family]++
+		acc.devices[useragent.DeviceBot]++
+		return
+	}
 
-		if event.Referrer != "" {
-			domain := extractDomain(event.Referrer)
-			acc.referrers[domain]++
-		} else {
-			acc.referrers["(direct)"]++
+	if event.Blocked {
+		acc.blockedClicks++
+		country := event.CountryCode
+		if country == "" {
+			country = "(unknown)"
 		}
+		acc.blockedCountries[country]++
+		return
+	}
 
-		if event.CountryCode != "" {
-			acc.countries[event.CountryCode]++
-		}
+	acc.totalClicks++
+
+	if event.VisitorHash != "" && !acc.visitorSeen[event.VisitorHash] {
+		acc.visitorSeen[event.VisitorHash] = true
+		acc.uniqueVisitors++
 	}
+	acc.visitors.Add(event.VisitorReg)
 
-	return acc
+	if event.Referrer != "" {
+		domain := extractDomain(event.Referrer)
+		acc.referrers[domain]++
+	} else {
+		acc.referrers["(direct)"]++
+	}
+
+	if event.CountryCode != "" {
+		acc.countries[event.CountryCode]++
+	}
+	if event.Region != "" {
+		acc.regions[event.Region]++
+	}
+
+	agent := useragent.Parse(event.UserAgent)
+	acc.devices[agent.Device]++
+	acc.osFamilies[agent.OS]++
+	acc.browsers[agent.Browser]++
+}
+
+// merge adds other's counts to acc. Unique visitors are summed, so other
+// must only count visitors acc has not seen.
+func (acc *dailyStatsAccumulator) merge(other *dailyStatsAccumulator) {
+	acc.totalClicks += other.totalClicks
+	acc.uniqueVisitors += other.uniqueVisitors
+	acc.blockedClicks += other.blockedClicks
+	acc.botClicks += other.botClicks
+	addCounts(acc.referrers, other.referrers)
+	addCounts(acc.countries, other.countries)
+	addCounts(acc.regions, other.regions)
+	addCounts(acc.blockedCountries, other.blockedCountries)
+	addCounts(acc.botFamilies, other.botFamilies)
+	addCounts(acc.devices, other.devices)
+	addCounts(acc.osFamilies, other.osFamilies)
+	addCounts(acc.browsers, other.browsers)
+	acc.visitors.Merge(other.visitors)
+}
+
+func addCounts(dst, src map[string]int64) {
+	for k, v := range src {
+		dst[k] += v
+	}
+}
+
+// visitorHashes returns the visitors counted in acc, sorted.
+func (acc *dailyStatsAccumulator) visitorHashes() []string {
+	hashes := make([]string, 0, len(acc.visitorSeen))
+	for hash := range acc.visitorSeen {
+		hashes = append(hashes, hash)
+	}
+	sort.Strings(hashes)
+	return hashes
 }
 
 // upsertDailyStat inserts or updates a daily_link_stats row.
-func (r *ClickEventRepository) upsertDailyStat(ctx context.Context, acc *dailyStatsAccumulator) error {
+func upsertDailyStat(ctx context.Context, db pgxQuerier, acc *dailyStatsAccumulator) error {
 	referrerJSON, _ := json.Marshal(acc.referrers)
 	countryJSON, _ := json.Marshal(acc.countries)
+	blockedCountryJSON, _ := json.Marshal(acc.blockedCountries)
+	botFamilyJSON, _ := json.Marshal(acc.botFamilies)
+	browserJSON, _ := json.Marshal(acc.browsers)
+	deviceJSON, _ := json.Marshal(acc.devices)
+	osJSON, _ := json.Marshal(acc.osFamilies)
+	regionJSON, _ := json.Marshal(acc.regions)
+	sketch, _ := acc.visitors.MarshalBinary()
 	id := fmt.Sprintf("%s:%s", acc.linkID, acc.date.Format("2006-01-02"))
 
 	query := `
 		INSERT INTO daily_link_stats (
 			id, link_id, date, total_clicks, unique_visitors,
-			referrer_breakdown, country_breakdown, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+			referrer_breakdown, country_breakdown,
+			blocked_clicks, blocked_country_breakdown,
+			bot_clicks, bot_family_breakdown,
+			ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown, visitor_sketch, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NOW(), NOW())
 		ON CONFLICT (link_id, date) DO UPDATE SET
 			total_clicks = EXCLUDED.total_clicks,
 			unique_visitors = EXCLUDED.unique_visitors,
 			referrer_breakdown = EXCLUDED.referrer_breakdown,
 			country_breakdown = EXCLUDED.country_breakdown,
+			blocked_clicks = EXCLUDED.blocked_clicks,
+			blocked_country_breakdown = EXCLUDED.blocked_country_breakdown,
+			bot_clicks = EXCLUDED.bot_clicks,
+			bot_family_breakdown = EXCLUDED.bot_family_breakdown,
+			ua_family_breakdown = EXCLUDED.ua_family_breakdown,
+			device_breakdown = EXCLUDED.device_breakdown,
+			os_breakdown = EXCLUDED.os_breakdown,
+			region_breakdown = EXCLUDED.region_breakdown,
+			visitor_sketch = EXCLUDED.visitor_sketch,
 			updated_at = NOW()
 	`
 
-	_, err := r.repo.pool.Exec(ctx, query,
+	_, err := db.Exec(ctx, query,
 		id,
 		acc.linkID,
 		acc.date,
@@ -210,6 +554,15 @@ func (r *ClickEventRepository) upsertDailyStat(ctx context.Context, acc *dailySt
 		acc.uniqueVisitors,
 		referrerJSON,
 		countryJSON,
+		acc.blockedClicks,
+		blockedCountryJSON,
+		acc.botClicks,
+		botFamilyJSON,
+		browserJSON,
+		deviceJSON,
+		osJSON,
+		regionJSON,
+		sketch,
 	)
 
 	return err
@@ -220,13 +573,15 @@ func (r *ClickEventRepository) GetDailyStats(ctx context.Context, linkID string,
 	query := `
 		SELECT id, link_id, date, total_clicks, unique_visitors,
 			   referrer_breakdown, ua_family_breakdown, country_breakdown,
+			   blocked_clicks, blocked_country_breakdown,
+			   bot_clicks, bot_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
 			   created_at, updated_at
 		FROM daily_link_stats
 		WHERE link_id = $1 AND date >= $2 AND date <= $3
 		ORDER BY date DESC
 	`
 
-	rows, err := r.repo.pool.Query(ctx, query, linkID, from, to)
+	rows, err := r.repo.reader(ctx).Query(ctx, query, linkID, from, to)
 	if err != nil {
 		return nil, fmt.Errorf("query daily stats: %w", err)
 	}
@@ -250,31 +605,72 @@ func (r *ClickEventRepository) GetAnalyticsSummary(ctx context.Context, linkID s
 		SELECT 
 			COALESCE(SUM(total_clicks), 0) as total_clicks,
 			COALESCE(SUM(unique_visitors), 0) as unique_visitors,
+			COALESCE(SUM(blocked_clicks), 0) as blocked_clicks,
+			COALESCE(SUM(bot_clicks), 0) as bot_clicks,
 			COUNT(*) as days
 		FROM daily_link_stats
 		WHERE link_id = $1 AND date >= $2 AND date <= $3
 	`
 
-	var totalClicks, uniqueVisitors int64
+	var totalClicks, uniqueVisitors, blockedClicks, botClicks int64
 	var days int
 
-	err := r.repo.pool.QueryRow(ctx, query, linkID, from, to).Scan(&totalClicks, &uniqueVisitors, &days)
+	err := r.repo.reader(ctx).QueryRow(ctx, query, linkID, from, to).Scan(&totalClicks, &uniqueVisitors, &blockedClicks, &botClicks, &days)
 	if err != nil {
 		return nil, fmt.Errorf("query analytics summary: %w", err)
 	}
 
+	visitors, err := r.mergeVisitorSketches(ctx, linkID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
 	var avgClicksPerDay float64
 	if days > 0 {
 		avgClicksPerDay = float64(totalClicks) / float64(days)
 	}
 
 	return &model.AnalyticsSummary{
-		TotalClicks:     totalClicks,
-		UniqueVisitors:  uniqueVisitors,
-		AvgClicksPerDay: avgClicksPerDay,
+		TotalClicks:          totalClicks,
+		UniqueVisitors:       uniqueVisitors,
+		AvgClicksPerDay:      avgClicksPerDay,
+		BlockedClicks:        blockedClicks,
+		BotClicks:            botClicks,
+		UniqueVisitorsApprox: model.NewUniqueEstimate(visitors),
 	}, nil
 }
 
+// mergeVisitorSketches merges the visitor sketches of a link's days.
+func (r *ClickEventRepository) mergeVisitorSketches(ctx context.Context, linkID string, from, to time.Time) (*hll.Sketch, error) {
+	rows, err := r.repo.reader(ctx).Query(ctx, `
+		SELECT visitor_sketch
+		FROM daily_link_stats
+		WHERE link_id = $1 AND date >= $2 AND date <= $3 AND visitor_sketch IS NOT NULL
+	`, linkID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query visitor sketches: %w", err)
+	}
+	defer rows.Close()
+
+	var encoded [][]byte
+	for rows.Next() {
+		var sketch []byte
+		if err := rows.Scan(&sketch); err != nil {
+			return nil, fmt.Errorf("scan visitor sketch: %w", err)
+		}
+		encoded = append(encoded, sketch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate visitor sketches: %w", err)
+	}
+
+	merged, err := hll.Merged(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("merge visitor sketches: %w", err)
+	}
+	return merged, nil
+}
+
 // GetTopReferrers returns the top referrer domains for a link.
 func (r *ClickEventRepository) GetTopReferrers(ctx context.Context, linkID string, from, to time.Time, limit int) ([]model.ReferrerBreakdown, error) {
 	// Aggregate JSONB from daily stats
@@ -292,7 +688,7 @@ func (r *ClickEventRepository) GetTopReferrers(ctx context.Context, linkID strin
 		LIMIT $4
 	`
 
-	rows, err := r.repo.pool.Query(ctx, query, linkID, from, to, limit)
+	rows, err := r.repo.reader(ctx).Query(ctx, query, linkID, from, to, limit)
 	if err != nil {
 		return nil, fmt.Errorf("query top referrers: %w", err)
 	}
@@ -321,7 +717,7 @@ func (r *ClickEventRepository) GetTopCountries(ctx context.Context, linkID strin
 		LIMIT $4
 	`
 
-	rows, err := r.repo.pool.Query(ctx, query, linkID, from, to, limit)
+	rows, err := r.repo.reader(ctx).Query(ctx, query, linkID, from, to, limit)
 	if err != nil {
 		return nil, fmt.Errorf("query top countries: %w", err)
 	}
@@ -343,7 +739,7 @@ func (r *ClickEventRepository) GetTopCountries(ctx context.Context, linkID strin
 // scanDailyStat scans a row into DailyLinkStats.
 func (r *ClickEventRepository) scanDailyStat(rows pgx.Rows) (*model.DailyLinkStats, error) {
 	var stat model.DailyLinkStats
-	var referrerJSON, uaJSON, countryJSON []byte
+	var referrerJSON, uaJSON, countryJSON, blockedCountryJSON, botFamilyJSON, deviceJSON, osJSON, regionJSON []byte
 
 	err := rows.Scan(
 		&stat.ID,
@@ -354,6 +750,13 @@ func (r *ClickEventRepository) scanDailyStat(rows pgx.Rows) (*model.DailyLinkSta
 		&referrerJSON,
 		&uaJSON,
 		&countryJSON,
+		&stat.BlockedClicks,
+		&blockedCountryJSON,
+		&stat.BotClicks,
+		&botFamilyJSON,
+		&deviceJSON,
+		&osJSON,
+		&regionJSON,
 		&stat.CreatedAt,
 		&stat.UpdatedAt,
 	)
@@ -371,10 +774,33 @@ func (r *ClickEventRepository) scanDailyStat(rows pgx.Rows) (*model.DailyLinkSta
 	if len(countryJSON) > 0 {
 		_ = json.Unmarshal(countryJSON, &stat.CountryBreakdown)
 	}
+	if len(blockedCountryJSON) > 0 {
+		_ = json.Unmarshal(blockedCountryJSON, &stat.BlockedCountryBreakdown)
+	}
+	if len(botFamilyJSON) > 0 {
+		_ = json.Unmarshal(botFamilyJSON, &stat.BotFamilyBreakdown)
+	}
+	if len(deviceJSON) > 0 {
+		_ = json.Unmarshal(deviceJSON, &stat.DeviceBreakdown)
+	}
+	if len(osJSON) > 0 {
+		_ = json.Unmarshal(osJSON, &stat.OSBreakdown)
+	}
+	if len(regionJSON) > 0 {
+		_ = json.Unmarshal(regionJSON, &stat.RegionBreakdown)
+	}
 
 	return &stat, nil
 }
 
+// nullableRegister returns nil for an empty register.
+func nullableRegister(r hll.Register) interface{} {
+	if r == 0 {
+		return nil
+	}
+	return int64(r)
+}
+
 // nullableString returns nil for empty strings.
 func nullableString(s string) interface{} {
 	if s == "" {
@@ -2,7 +2,9 @@ package repository
 
 import (
 	"testing"
+	"time"
 
+	"github.com/penshort/penshort/internal/hll"
 	"github.com/penshort/penshort/internal/model"
 )
 
@@ -46,3 +48,236 @@ func TestAccumulateDailyStats(t *testing.T) {
 		t.Fatalf("expected VN clicks 1, got %d", acc.countries["VN"])
 	}
 }
+
+func TestAccumulateDailyStats_ExcludesBlocked(t *testing.T) {
+	events := []*model.ClickEvent{
+		{CountryCode: "US", VisitorHash: "visitor-a"},
+		{CountryCode: "RU", VisitorHash: "visitor-b", Blocked: true},
+		{CountryCode: "RU", VisitorHash: "visitor-c", Blocked: true},
+		{CountryCode: "", VisitorHash: "visitor-d", Blocked: true},
+	}
+
+	acc := accumulateDailyStats(events)
+
+	if acc.totalClicks != 1 {
+		t.Fatalf("expected total clicks 1, got %d", acc.totalClicks)
+	}
+	if acc.uniqueVisitors != 1 {
+		t.Fatalf("expected unique visitors 1, got %d", acc.uniqueVisitors)
+	}
+	if acc.countries["RU"] != 0 {
+		t.Fatalf("expected no RU clicks, got %d", acc.countries["RU"])
+	}
+	if acc.blockedClicks != 3 {
+		t.Fatalf("expected blocked clicks 3, got %d", acc.blockedClicks)
+	}
+	if acc.blockedCountries["RU"] != 2 {
+		t.Fatalf("expected RU blocked 2, got %d", acc.blockedCountries["RU"])
+	}
+	if acc.blockedCountries["(unknown)"] != 1 {
+		t.Fatalf("expected unknown blocked 1, got %d", acc.blockedCountries["(unknown)"])
+	}
+}
+
+func TestAccumulateDailyStats_ExcludesBots(t *testing.T) {
+	events := []*model.ClickEvent{
+		{Referrer: "https://twitter.com/x", CountryCode: "US", VisitorHash: "visitor-a"},
+		{Referrer: "https://slack.com", CountryCode: "US", VisitorHash: "visitor-b", IsBot: true, BotFamily: "slack"},
+		{CountryCode: "US", VisitorHash: "visitor-c", IsBot: true, BotFamily: "slack"},
+		{CountryCode: "RU", VisitorHash: "visitor-d", IsBot: true, Blocked: true},
+	}
+
+	acc := accumulateDailyStats(events)
+
+	if acc.totalClicks != 1 {
+		t.Fatalf("expected total clicks 1, got %d", acc.totalClicks)
+	}
+	if acc.uniqueVisitors != 1 {
+		t.Fatalf("expected unique visitors 1, got %d", acc.uniqueVisitors)
+	}
+	if acc.referrers["slack.com"] != 0 {
+		t.Fatalf("expected no slack.com referrers, got %d", acc.referrers["slack.com"])
+	}
+	if acc.botClicks != 3 {
+		t.Fatalf("expected bot clicks 3, got %d", acc.botClicks)
+	}
+	if acc.botFamilies["slack"] != 2 {
+		t.Fatalf("expected slack bots 2, got %d", acc.botFamilies["slack"])
+	}
+	if acc.botFamilies["other"] != 1 {
+		t.Fatalf("expected other bots 1, got %d", acc.botFamilies["other"])
+	}
+	if acc.blockedClicks != 0 {
+		t.Fatalf("expected blocked bots to count as bots only, got %d blocked", acc.blockedClicks)
+	}
+}
+
+func TestAccumulateDailyStats_UserAgents(t *testing.T) {
+	const (
+		iPhoneSafari  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+		windowsChrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+	)
+	events := []*model.ClickEvent{
+		{VisitorHash: "visitor-a", UserAgent: iPhoneSafari},
+		{VisitorHash: "visitor-b", UserAgent: iPhoneSafari},
+		{VisitorHash: "visitor-c", UserAgent: windowsChrome},
+		{VisitorHash: "visitor-d", UserAgent: "Slackbot 1.0", IsBot: true, BotFamily: "slack"},
+		{VisitorHash: "visitor-e", UserAgent: windowsChrome, Blocked: true},
+	}
+
+	acc := accumulateDailyStats(events)
+
+	if acc.devices["mobile"] != 2 || acc.devices["desktop"] != 1 {
+		t.Fatalf("expected 2 mobile and 1 desktop, got %v", acc.devices)
+	}
+	if acc.devices["bot"] != 1 {
+		t.Fatalf("expected bots counted as bot devices, got %v", acc.devices)
+	}
+	if acc.osFamilies["iOS"] != 2 || acc.osFamilies["Windows"] != 1 || len(acc.osFamilies) != 2 {
+		t.Fatalf("expected 2 iOS and 1 Windows, got %v", acc.osFamilies)
+	}
+	if acc.browsers["Safari"] != 2 || acc.browsers["Chrome"] != 1 || len(acc.browsers) != 2 {
+		t.Fatalf("expected 2 Safari and 1 Chrome, got %v", acc.browsers)
+	}
+}
+
+func TestAccumulateDailyStats_Regions(t *testing.T) {
+	events := []*model.ClickEvent{
+		{VisitorHash: "visitor-a", CountryCode: "US", Region: "US-CA"},
+		{VisitorHash: "visitor-b", CountryCode: "US", Region: "US-CA"},
+		{VisitorHash: "visitor-c", CountryCode: "US"},
+		{VisitorHash: "visitor-d", CountryCode: "US", Region: "US-NY", IsBot: true},
+		{VisitorHash: "visitor-e", CountryCode: "US", Region: "US-NY", Blocked: true},
+	}
+
+	acc := accumulateDailyStats(events)
+
+	if acc.regions["US-CA"] != 2 || len(acc.regions) != 1 {
+		t.Fatalf("expected 2 clicks from US-CA only, got %v", acc.regions)
+	}
+}
+
+func TestAccumulateDailyStats_VisitorSketch(t *testing.T) {
+	regA, regB := hll.NewRegister(1<<60), hll.NewRegister(1<<40)
+	events := []*model.ClickEvent{
+		{VisitorHash: "visitor-a", VisitorReg: regA},
+		{VisitorHash: "visitor-a", VisitorReg: regA},
+		{VisitorHash: "visitor-b", VisitorReg: regB},
+		{VisitorHash: "visitor-c"}, // Recorded before sketches
+		{VisitorHash: "visitor-d", VisitorReg: hll.NewRegister(1 << 20), IsBot: true},
+	}
+
+	acc := accumulateDailyStats(events)
+
+	if got := acc.visitors.Estimate(); got != 2 {
+		t.Errorf("sketch estimate = %d, want 2", got)
+	}
+	if acc.uniqueVisitors != 3 {
+		t.Errorf("unique visitors = %d, want 3", acc.uniqueVisitors)
+	}
+}
+
+func TestStatsDeltas(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	events := []*model.ClickEvent{
+		{LinkID: "b", VisitorHash: "visitor-a", ClickedAt: day.Add(10 * time.Minute)},
+		{LinkID: "a", VisitorHash: "visitor-a", ClickedAt: day.Add(2 * time.Hour)},
+		{LinkID: "a", VisitorHash: "visitor-b", ClickedAt: day.Add(time.Hour)},
+		{LinkID: "a", VisitorHash: "visitor-a", ClickedAt: day.Add(time.Hour + time.Minute)},
+		{LinkID: "a", VisitorHash: "visitor-c", ClickedAt: day.Add(25 * time.Hour), IsBot: true},
+	}
+
+	daily, hourly := statsDeltas(events)
+
+	if len(daily) != 3 {
+		t.Fatalf("expected 3 daily deltas, got %d", len(daily))
+	}
+	if daily[0].linkID != "a" || !daily[0].date.Equal(day) || daily[1].linkID != "a" || daily[2].linkID != "b" {
+		t.Fatalf("daily deltas not sorted by link and day")
+	}
+	if daily[0].totalClicks != 3 || daily[0].uniqueVisitors != 2 {
+		t.Fatalf("expected 3 clicks from 2 visitors, got %d from %d", daily[0].totalClicks, daily[0].uniqueVisitors)
+	}
+	if got := daily[0].visitorHashes(); len(got) != 2 || got[0] != "visitor-a" || got[1] != "visitor-b" {
+		t.Fatalf("expected sorted visitor hashes, got %v", got)
+	}
+	if got := daily[1].visitorHashes(); len(got) != 0 {
+		t.Fatalf("expected bots to have no visitor hashes, got %v", got)
+	}
+
+	if len(hourly) != 4 {
+		t.Fatalf("expected 4 hourly deltas, got %d", len(hourly))
+	}
+	if !hourly[0].date.Equal(day.Add(time.Hour)) || hourly[0].totalClicks != 2 {
+		t.Fatalf("expected 2 clicks in the first hour of link a, got %d at %s", hourly[0].totalClicks, hourly[0].date)
+	}
+}
+
+func TestDailyStatsAccumulator_Merge(t *testing.T) {
+	acc := accumulateDailyStats([]*model.ClickEvent{
+		{CountryCode: "US", Region: "US-CA", VisitorHash: "visitor-a", VisitorReg: hll.NewRegister(1 << 60)},
+		{CountryCode: "RU", VisitorHash: "visitor-b", Blocked: true},
+	})
+	delta := accumulateDailyStats([]*model.ClickEvent{
+		{CountryCode: "US", Region: "US-NY", VisitorHash: "visitor-a", VisitorReg: hll.NewRegister(1 << 60)},
+		{CountryCode: "VN", VisitorHash: "visitor-c", VisitorReg: hll.NewRegister(1 << 55)},
+		{VisitorHash: "visitor-d", IsBot: true, BotFamily: "crawler"},
+	})
+	// visitor-a was already counted
+	delta.uniqueVisitors = 1
+
+	acc.merge(delta)
+
+	if acc.totalClicks != 3 || acc.uniqueVisitors != 2 {
+		t.Fatalf("expected 3 clicks from 2 visitors, got %d from %d", acc.totalClicks, acc.uniqueVisitors)
+	}
+	if acc.blockedClicks != 1 || acc.botClicks != 1 || acc.botFamilies["crawler"] != 1 {
+		t.Fatalf("expected blocked and bot clicks to carry over, got %d and %d", acc.blockedClicks, acc.botClicks)
+	}
+	if acc.countries["US"] != 2 || acc.countries["VN"] != 1 {
+		t.Fatalf("unexpected countries %v", acc.countries)
+	}
+	if acc.regions["US-CA"] != 1 || acc.regions["US-NY"] != 1 {
+		t.Fatalf("unexpected regions %v", acc.regions)
+	}
+	if got := acc.visitors.Estimate(); got != 2 {
+		t.Fatalf("expected sketch estimate 2, got %d", got)
+	}
+}
+
+func TestRecomputeHours(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	hours := []time.Time{
+		day.Add(time.Hour),
+		day.Add(5 * time.Hour),
+		day.Add(25 * time.Hour),
+	}
+	oldest := day.Add(24*time.Hour + 3*time.Hour)
+
+	var days, recomputed []time.Time
+	n, err := recomputeHours(hours, &oldest,
+		func(d time.Time) error { days = append(days, d); return nil },
+		func(h time.Time) error { recomputed = append(recomputed, h); return nil },
+	)
+	if err != nil {
+		t.Fatalf("recomputeHours: %v", err)
+	}
+	if n != 2 || len(days) != 2 || !days[0].Equal(day) || !days[1].Equal(day.Add(24*time.Hour)) {
+		t.Fatalf("expected both days recomputed once, got %d: %v", n, days)
+	}
+	// The first day was pruned, so only its daily row is rebuilt
+	if len(recomputed) != 1 || !recomputed[0].Equal(hours[2]) {
+		t.Fatalf("expected only the kept day's hour recomputed, got %v", recomputed)
+	}
+
+	recomputed = nil
+	if _, err := recomputeHours(hours, nil,
+		func(time.Time) error { return nil },
+		func(h time.Time) error { recomputed = append(recomputed, h); return nil },
+	); err != nil {
+		t.Fatalf("recomputeHours: %v", err)
+	}
+	if len(recomputed) != 0 {
+		t.Fatalf("expected no hours recomputed without hourly rows, got %v", recomputed)
+	}
+}
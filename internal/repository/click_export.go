@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/penshort/penshort/internal/model"
+)
+
+// exportFetchSize is the number of rows fetched per round trip by exports.
+const exportFetchSize = 1000
+
+// ClickCursor is the position of an exported click event. Exports are
+// ordered by (clicked_at, id), so a cursor resumes right after that row.
+type ClickCursor struct {
+	ClickedAt time.Time
+	ID        string
+}
+
+// ClickExportFilter selects the raw click events to export. Exactly one of
+// LinkID and OwnerID is set; an owner export skips deleted links.
+type ClickExportFilter struct {
+	LinkID  string
+	OwnerID string
+
+	// Optional clicked_at bounds, [From, To)
+	From time.Time
+	To   time.Time
+
+	After *ClickCursor
+	Limit int // 0 exports every matching row
+
+	// User agents are only exported when enabled by the privacy settings
+	IncludeUserAgent bool
+}
+
+// postgresQuery returns the export query and its arguments.
+func (f ClickExportFilter) postgresQuery() (string, []any) {
+	userAgent := "''"
+	if f.IncludeUserAgent {
+		userAgent = "COALESCE(c.user_agent, '')"
+	}
+
+	query := `
+		SELECT c.id, c.link_id, c.short_code, COALESCE(c.referrer, ''), ` + userAgent + `,
+			c.visitor_hash, COALESCE(c.country_code, ''), COALESCE(c.region, ''), COALESCE(c.city, ''),
+			c.blocked, c.is_bot, COALESCE(c.bot_family, ''), c.clicked_at
+		FROM click_events c
+	`
+	var args []any
+	if f.OwnerID != "" {
+		args = append(args, f.OwnerID)
+		query += " JOIN links l ON l.id = c.link_id WHERE l.owner_id = $1 AND l.deleted_at IS NULL"
+	} else {
+		args = append(args, f.LinkID)
+		query += " WHERE c.link_id = $1"
+	}
+
+	if !f.From.IsZero() {
+		args = append(args, f.From)
+		query += fmt.Sprintf(" AND c.clicked_at >= $%d", len(args))
+	}
+	if !f.To.IsZero() {
+		args = append(args, f.To)
+		query += fmt.Sprintf(" AND c.clicked_at < $%d", len(args))
+	}
+	if f.After != nil {
+		args = append(args, f.After.ClickedAt, f.After.ID)
+		query += fmt.Sprintf(" AND (c.clicked_at, c.id) > ($%d, $%d)", len(args)-1, len(args))
+	}
+
+	query += " ORDER BY c.clicked_at, c.id"
+	if f.Limit > 0 {
+		args = append(args, f.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+
+	return query, args
+}
+
+// ExportClickEvents streams the click events matching filter to fn in
+// (clicked_at, id) order. Rows are read through a server-side cursor, so
+// memory stays bounded however many rows match. An error from fn stops the
+// export and is returned.
+func (r *ClickEventRepository) ExportClickEvents(ctx context.Context, filter ClickExportFilter, fn func(*model.ClickEvent) error) error {
+	tx, err := r.repo.reader(ctx).BeginTx(ctx, pgx.TxOptions{AccessMode: pgx.ReadOnly})
+	if err != nil {
+		return fmt.Errorf("begin export: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // Read-only; nothing to commit
+
+	query, args := filter.postgresQuery()
+	if _, err := tx.Exec(ctx, "DECLARE click_export NO SCROLL CURSOR FOR "+query, args...); err != nil {
+		return fmt.Errorf("declare export cursor: %w", err)
+	}
+
+	fetch := fmt.Sprintf("FETCH FORWARD %d FROM click_export", exportFetchSize)
+	for {
+		rows, err := tx.Query(ctx, fetch)
+		if err != nil {
+			return fmt.Errorf("fetch click events: %w", err)
+		}
+
+		n := 0
+		for rows.Next() {
+			n++
+			var event model.ClickEvent
+			if err := rows.Scan(&event.ID, &event.LinkID, &event.ShortCode, &event.Referrer, &event.UserAgent,
+				&event.VisitorHash, &event.CountryCode, &event.Region, &event.City, &event.Blocked, &event.IsBot, &event.BotFamily, &event.ClickedAt); err != nil {
+				rows.Close()
+				return fmt.Errorf("scan click event: %w", err)
+			}
+			if err := fn(&event); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("iterate click events: %w", err)
+		}
+
+		if n < exportFetchSize {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,97 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+func TestIntegrationClickEventRepository_ExportClickEvents(t *testing.T) {
+	forEachBackend(t, newAccountAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		start := time.Now().UTC().Truncate(time.Hour).Add(-6 * time.Hour)
+
+		createLink := func(prefix, owner string) *model.Link {
+			link := testutil.NewTestLink(t, testutil.UniqueShortCode(prefix))
+			link.OwnerID = owner
+			if err := repo.CreateLink(ctx, link); err != nil {
+				t.Fatalf("CreateLink: %v", err)
+			}
+			return link
+		}
+		first := createLink("export-a", "alice")
+		second := createLink("export-b", "alice")
+		other := createLink("export-other", "bob")
+
+		var events []*model.ClickEvent
+		for i := 0; i < 5; i++ {
+			event := testClickEvent(first.ID, "v1", start.Add(time.Duration(i)*time.Minute))
+			event.UserAgent = "Mozilla/5.0"
+			events = append(events, event)
+		}
+		events = append(events,
+			testClickEvent(second.ID, "v2", start.Add(2*time.Hour)),
+			testClickEvent(other.ID, "v3", start.Add(time.Hour)),
+		)
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+
+		export := func(filter ClickExportFilter) []*model.ClickEvent {
+			t.Helper()
+			var got []*model.ClickEvent
+			err := clicks.ExportClickEvents(ctx, filter, func(event *model.ClickEvent) error {
+				got = append(got, event)
+				return nil
+			})
+			if err != nil {
+				t.Fatalf("ExportClickEvents: %v", err)
+			}
+			return got
+		}
+
+		// Page through one link with a cursor
+		var paged []*model.ClickEvent
+		filter := ClickExportFilter{LinkID: first.ID, Limit: 2}
+		for page := 0; page < 5; page++ {
+			rows := export(filter)
+			if len(rows) == 0 {
+				break
+			}
+			paged = append(paged, rows...)
+			last := rows[len(rows)-1]
+			filter.After = &ClickCursor{ClickedAt: last.ClickedAt, ID: last.ID}
+		}
+		if len(paged) != 5 {
+			t.Fatalf("paged export returned %d rows, want 5", len(paged))
+		}
+		for i, event := range paged {
+			if event.ID != events[i].ID {
+				t.Errorf("row %d = %s, want %s", i, event.ID, events[i].ID)
+			}
+			if event.UserAgent != "" {
+				t.Errorf("row %d exported user agent %q without IncludeUserAgent", i, event.UserAgent)
+			}
+		}
+
+		// Time range is [From, To)
+		rows := export(ClickExportFilter{LinkID: first.ID, From: start.Add(time.Minute), To: start.Add(3 * time.Minute), IncludeUserAgent: true})
+		if len(rows) != 2 || rows[0].ID != events[1].ID || rows[0].UserAgent != "Mozilla/5.0" {
+			t.Errorf("ranged export = %d rows, want events 1 and 2 with user agents", len(rows))
+		}
+
+		// Account export covers the owner's links only
+		rows = export(ClickExportFilter{OwnerID: "alice"})
+		if len(rows) != 6 {
+			t.Fatalf("account export returned %d rows, want 6", len(rows))
+		}
+		if rows[5].LinkID != second.ID {
+			t.Errorf("last account row is on link %s, want %s", rows[5].LinkID, second.ID)
+		}
+	})
+}
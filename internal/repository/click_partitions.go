@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// clickPartitionsLockID serializes partition changes across workers.
+const clickPartitionsLockID int64 = 480017
+
+// clickPartitionPrefix is the name prefix of click_events partitions,
+// followed by the UTC month as YYYY_MM.
+const clickPartitionPrefix = "click_events_"
+
+// clickDefaultPartition holds click events outside the monthly partitions.
+const clickDefaultPartition = "click_events_default"
+
+// CreateClickPartitions creates the monthly click_events partitions for the
+// UTC months from..through, inclusive, that do not exist yet. It returns
+// the number created.
+func (r *ClickEventRepository) CreateClickPartitions(ctx context.Context, from, through time.Time) (int, error) {
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin create partitions: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, clickPartitionsLockID); err != nil {
+		return 0, fmt.Errorf("lock partitions: %w", err)
+	}
+
+	created := 0
+	for month := monthStart(from); !month.After(monthStart(through)); month = month.AddDate(0, 1, 0) {
+		var ok bool
+		if err := tx.QueryRow(ctx, `SELECT create_click_events_partition($1)`, month).Scan(&ok); err != nil {
+			return 0, fmt.Errorf("create partition %s: %w", month.Format("2006-01"), err)
+		}
+		if ok {
+			created++
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit create partitions: %w", err)
+	}
+	return created, nil
+}
+
+// DropClickPartitions drops the click_events partitions of UTC months that
+// ended on or before before, removing their raw events, and deletes events
+// of those months from the default partition, along with the event IDs
+// recorded before the cutoff. Daily and hourly stats are kept. It returns the
+// number of partitions dropped.
+func (r *ClickEventRepository) DropClickPartitions(ctx context.Context, before time.Time) (int, error) {
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("begin drop partitions: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, clickPartitionsLockID); err != nil {
+		return 0, fmt.Errorf("lock partitions: %w", err)
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT c.relname
+		FROM pg_inherits i
+		JOIN pg_class c ON c.oid = i.inhrelid
+		WHERE i.inhparent = 'click_events'::regclass
+		ORDER BY c.relname
+	`)
+	if err != nil {
+		return 0, fmt.Errorf("query partitions: %w", err)
+	}
+	var expired []string
+	cutoff := monthStart(before)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan partition: %w", err)
+		}
+		month, ok := partitionMonth(name)
+		if ok && month.Before(cutoff) {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate partitions: %w", err)
+	}
+
+	for _, name := range expired {
+		if _, err := tx.Exec(ctx, `DROP TABLE `+pgx.Identifier{name}.Sanitize()); err != nil {
+			return 0, fmt.Errorf("drop partition %s: %w", name, err)
+		}
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM `+clickDefaultPartition+` WHERE clicked_at < $1`, cutoff); err != nil {
+		return 0, fmt.Errorf("delete expired default partition events: %w", err)
+	}
+	// IDs first recorded before the cutoff are forgotten with their events
+	if _, err := tx.Exec(ctx, `DELETE FROM click_event_ids WHERE recorded_at < $1`, cutoff); err != nil {
+		return 0, fmt.Errorf("delete expired click event ids: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("commit drop partitions: %w", err)
+	}
+	return len(expired), nil
+}
+
+// partitionMonth parses the UTC month from a partition name. Partitions
+// not named by create_click_events_partition are left alone.
+func partitionMonth(name string) (time.Time, bool) {
+	suffix, ok := strings.CutPrefix(name, clickPartitionPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	month, err := time.Parse("2006_01", suffix)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return month, true
+}
+
+// monthStart returns the start of the UTC month containing t.
+func monthStart(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,155 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_DropClickPartitions(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		thisMonth := monthStart(time.Now())
+		oldMonth := thisMonth.AddDate(0, -2, 0)
+
+		if _, err := clicks.CreateClickPartitions(ctx, oldMonth, thisMonth.AddDate(0, 1, 0)); err != nil {
+			t.Fatalf("CreateClickPartitions: %v", err)
+		}
+		// Creating them again is a no-op
+		if n, err := clicks.CreateClickPartitions(ctx, oldMonth, thisMonth); err != nil || n != 0 {
+			t.Fatalf("CreateClickPartitions(again) = %d, %v; want 0", n, err)
+		}
+
+		events := []*model.ClickEvent{
+			testClickEvent("retention-link", "visitor-a", oldMonth.Add(time.Hour)),
+			testClickEvent("retention-link", "visitor-b", thisMonth.Add(time.Hour)),
+		}
+		if _, err := clicks.RecordClickEvents(ctx, events); err != nil {
+			t.Fatalf("RecordClickEvents: %v", err)
+		}
+
+		// Only oldMonth has ended a month before the cutoff
+		dropped, err := clicks.DropClickPartitions(ctx, thisMonth.AddDate(0, -1, 0).Add(time.Hour))
+		if err != nil {
+			t.Fatalf("DropClickPartitions: %v", err)
+		}
+		if dropped != 1 {
+			t.Errorf("DropClickPartitions = %d months, want 1", dropped)
+		}
+
+		var exported []string
+		err = clicks.ExportClickEvents(ctx, ClickExportFilter{LinkID: "retention-link"}, func(event *model.ClickEvent) error {
+			exported = append(exported, event.VisitorHash)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportClickEvents: %v", err)
+		}
+		if len(exported) != 1 || exported[0] != "visitor-b" {
+			t.Errorf("raw events after retention = %v, want only visitor-b", exported)
+		}
+
+		// Daily stats of the dropped month still answer
+		daily, err := clicks.GetDailyStats(ctx, "retention-link", oldMonth, oldMonth)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 || daily[0].TotalClicks != 1 {
+			t.Errorf("daily stats of the dropped month = %+v, want 1 click", daily)
+		}
+
+		// Time zone queries fall back to the stored UTC days of the dropped
+		// month, and keep building the retained days from raw events
+		if tokyo, err := time.LoadLocation("Asia/Tokyo"); err != nil {
+			t.Logf("tzdata unavailable, skipping time zone case: %v", err)
+		} else {
+			zoned, err := clicks.GetDailyStatsInZone(ctx, "retention-link", oldMonth, thisMonth, tokyo)
+			if err != nil {
+				t.Fatalf("GetDailyStatsInZone: %v", err)
+			}
+			if len(zoned) != 2 {
+				t.Fatalf("zoned daily stats = %d days, want 2", len(zoned))
+			}
+			if !zoned[0].Date.Equal(thisMonth) || zoned[0].TotalClicks != 1 || zoned[0].UTC {
+				t.Errorf("retained day = %s with %d clicks (utc %v), want %s with 1 from raw events",
+					zoned[0].Date.Format("2006-01-02"), zoned[0].TotalClicks, zoned[0].UTC, thisMonth.Format("2006-01-02"))
+			}
+			if !zoned[1].Date.Equal(oldMonth) || zoned[1].TotalClicks != 1 || !zoned[1].UTC {
+				t.Errorf("dropped day = %s with %d clicks (utc %v), want %s with 1 from the UTC day",
+					zoned[1].Date.Format("2006-01-02"), zoned[1].TotalClicks, zoned[1].UTC, oldMonth.Format("2006-01-02"))
+			}
+		}
+
+		// A recompute leaves days without raw events as they are
+		if days, err := clicks.RecomputeStats(ctx, "retention-link", oldMonth, oldMonth); err != nil || days != 0 {
+			t.Errorf("RecomputeStats = %d, %v; want 0 days", days, err)
+		}
+
+		// A replay older than retention, whose partition is gone, is still
+		// recorded, and its raw event expires with the next drop
+		replayed := testClickEvent("retention-link", "visitor-c", oldMonth.Add(2*time.Hour))
+		if recorded, err := clicks.RecordClickEvents(ctx, []*model.ClickEvent{replayed}); err != nil || len(recorded) != 1 {
+			t.Fatalf("RecordClickEvents(expired month) = %d, %v; want 1", len(recorded), err)
+		}
+		daily, err = clicks.GetDailyStats(ctx, "retention-link", oldMonth, oldMonth)
+		if err != nil || len(daily) != 1 || daily[0].TotalClicks != 2 {
+			t.Errorf("daily stats after the replay = %+v, %v; want 2 clicks", daily, err)
+		}
+		if _, err := clicks.DropClickPartitions(ctx, thisMonth.AddDate(0, -1, 0).Add(time.Hour)); err != nil {
+			t.Fatalf("DropClickPartitions(again): %v", err)
+		}
+		exported = nil
+		err = clicks.ExportClickEvents(ctx, ClickExportFilter{LinkID: "retention-link"}, func(event *model.ClickEvent) error {
+			exported = append(exported, event.VisitorHash)
+			return nil
+		})
+		if err != nil || len(exported) != 1 {
+			t.Errorf("raw events after the second drop = %v, %v; want only visitor-b", exported, err)
+		}
+	})
+}
+
+func TestIntegrationClickEventRepository_RequeuedEventRecordedOnce(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		thisMonth := monthStart(time.Now())
+		if _, err := clicks.CreateClickPartitions(ctx, thisMonth.AddDate(0, -1, 0), thisMonth); err != nil {
+			t.Fatalf("CreateClickPartitions: %v", err)
+		}
+
+		// Small batches are inserted row by row, larger ones copied
+		for _, size := range []int{1, minCopyBatch} {
+			var events, requeued []*model.ClickEvent
+			for i := 0; i < size; i++ {
+				event := testClickEvent("requeue-link", fmt.Sprintf("visitor-%d", i), thisMonth.Add(time.Hour))
+				events = append(events, event)
+
+				// Same event ID, clicked_at in another partition
+				again := *event
+				again.ID += "-again"
+				again.ClickedAt = thisMonth.AddDate(0, -1, 0).Add(time.Hour)
+				requeued = append(requeued, &again)
+			}
+
+			if recorded, err := clicks.RecordClickEvents(ctx, events); err != nil || len(recorded) != size {
+				t.Fatalf("RecordClickEvents(%d) = %d, %v; want %d", size, len(recorded), err, size)
+			}
+			if recorded, err := clicks.RecordClickEvents(ctx, requeued); err != nil || len(recorded) != 0 {
+				t.Fatalf("RecordClickEvents(requeued %d) = %d, %v; want 0", size, len(recorded), err)
+			}
+		}
+
+		daily, err := clicks.GetDailyStats(ctx, "requeue-link", thisMonth.AddDate(0, -1, 0), thisMonth)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 || daily[0].TotalClicks != int64(1+minCopyBatch) {
+			t.Errorf("daily stats = %+v, want %d clicks on one day", daily, 1+minCopyBatch)
+		}
+	})
+}
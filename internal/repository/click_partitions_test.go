@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPartitionMonth(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   time.Time
+		wantOK bool
+	}{
+		{"click_events_2026_03", time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC), true},
+		{"click_events_2026_3", time.Time{}, false},
+		{"click_events_default", time.Time{}, false},
+		{"daily_link_stats", time.Time{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := partitionMonth(tt.name)
+		if ok != tt.wantOK || !got.Equal(tt.want) {
+			t.Errorf("partitionMonth(%q) = %s, %v; want %s, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestMonthStart(t *testing.T) {
+	loc := time.FixedZone("UTC-5", -5*3600)
+	// 2026-03-31 22:00 at UTC-5 is already April in UTC
+	got := monthStart(time.Date(2026, 3, 31, 22, 0, 0, 0, loc))
+	if want := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("monthStart = %s, want %s", got, want)
+	}
+}
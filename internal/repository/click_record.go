@@ -0,0 +1,327 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/penshort/penshort/internal/model"
+)
+
+// pgxQuerier is satisfied by *pgxpool.Pool and pgx.Tx.
+type pgxQuerier interface {
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+// statsColumns are the stats columns read back into an accumulator, in
+// scanStatsAccumulator order. Daily and hourly rows share them.
+const statsColumns = `total_clicks, unique_visitors, blocked_clicks, bot_clicks,
+	referrer_breakdown, country_breakdown, blocked_country_breakdown, bot_family_breakdown,
+	ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown, visitor_sketch`
+
+// RecordClickEvents inserts click events and merges the new ones into the
+// daily and hourly stats in one transaction. Event IDs that were already
+// stored are skipped, so a redelivered batch changes nothing. It returns
+// the events that were newly recorded.
+func (r *ClickEventRepository) RecordClickEvents(ctx context.Context, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("begin record: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	inserted, err := insertClickEvents(ctx, tx, events)
+	if err != nil {
+		return nil, err
+	}
+	if len(inserted) == 0 {
+		return nil, nil
+	}
+
+	daily, hourly := statsDeltas(inserted)
+	for _, delta := range daily {
+		if err := mergeDailyStat(ctx, tx, delta); err != nil {
+			return nil, fmt.Errorf("merge daily stat %s:%s: %w", delta.linkID, delta.date.Format("2006-01-02"), err)
+		}
+	}
+	for _, delta := range hourly {
+		if err := mergeHourlyStat(ctx, tx, delta); err != nil {
+			return nil, fmt.Errorf("merge hourly stat %s:%s: %w", delta.linkID, delta.date.Format(time.RFC3339), err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("commit record: %w", err)
+	}
+	return inserted, nil
+}
+
+// statsDeltas accumulates events per link-day and per link-hour. Both are
+// sorted by link and time so concurrent batches lock rows in the same order.
+func statsDeltas(events []*model.ClickEvent) (daily, hourly []*dailyStatsAccumulator) {
+	days := make(map[dailyStatsKey]*dailyStatsAccumulator)
+	hours := make(map[hourlyStatsKey]*dailyStatsAccumulator)
+	for _, event := range events {
+		dayKey := dailyStatsKey{linkID: event.LinkID, date: event.ClickedAt.UTC().Truncate(24 * time.Hour)}
+		day, ok := days[dayKey]
+		if !ok {
+			day = newDailyStatsAccumulator()
+			day.linkID, day.date = dayKey.linkID, dayKey.date
+			days[dayKey] = day
+			daily = append(daily, day)
+		}
+		day.add(event)
+
+		hourKey := hourlyStatsKey{linkID: event.LinkID, hour: event.ClickedAt.UTC().Truncate(time.Hour)}
+		hour, ok := hours[hourKey]
+		if !ok {
+			hour = newDailyStatsAccumulator()
+			hour.linkID, hour.date = hourKey.linkID, hourKey.hour
+			hours[hourKey] = hour
+			hourly = append(hourly, hour)
+		}
+		hour.add(event)
+	}
+
+	sortAccumulators(daily)
+	sortAccumulators(hourly)
+	return daily, hourly
+}
+
+func sortAccumulators(accs []*dailyStatsAccumulator) {
+	sort.Slice(accs, func(i, j int) bool {
+		if accs[i].linkID != accs[j].linkID {
+			return accs[i].linkID < accs[j].linkID
+		}
+		return accs[i].date.Before(accs[j].date)
+	})
+}
+
+// mergeDailyStat adds a batch's counts to a link-day. Only visitors new to
+// the day's visitor set add to unique visitors.
+func mergeDailyStat(ctx context.Context, tx pgx.Tx, delta *dailyStatsAccumulator) error {
+	current, err := lockDailyStat(ctx, tx, delta.linkID, delta.date)
+	if err != nil {
+		return err
+	}
+	if delta.uniqueVisitors, err = addDailyVisitors(ctx, tx, delta.linkID, delta.date, delta.visitorHashes()); err != nil {
+		return err
+	}
+	current.merge(delta)
+	return upsertDailyStat(ctx, tx, current)
+}
+
+// mergeHourlyStat adds a batch's counts to a link-hour.
+func mergeHourlyStat(ctx context.Context, tx pgx.Tx, delta *dailyStatsAccumulator) error {
+	current, err := lockHourlyStat(ctx, tx, delta.linkID, delta.date)
+	if err != nil {
+		return err
+	}
+	if delta.uniqueVisitors, err = addHourlyVisitors(ctx, tx, delta.linkID, delta.date, delta.visitorHashes()); err != nil {
+		return err
+	}
+	current.merge(delta)
+	return upsertHourlyStat(ctx, tx, current)
+}
+
+// lockDailyStat locks a link-day's stats row, creating it if needed, and
+// returns its counts. Batches and recomputes of the same day then apply one
+// at a time.
+func lockDailyStat(ctx context.Context, tx pgx.Tx, linkID string, date time.Time) (*dailyStatsAccumulator, error) {
+	acc := newDailyStatsAccumulator()
+	acc.linkID, acc.date = linkID, date
+
+	row := tx.QueryRow(ctx, `
+		INSERT INTO daily_link_stats (id, link_id, date, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (link_id, date) DO UPDATE SET updated_at = NOW()
+		RETURNING `+statsColumns,
+		fmt.Sprintf("%s:%s", linkID, date.Format("2006-01-02")), linkID, date,
+	)
+	if err := scanStatsAccumulator(row, acc); err != nil {
+		return nil, fmt.Errorf("lock daily stat: %w", err)
+	}
+	return acc, nil
+}
+
+// lockHourlyStat is lockDailyStat for a link-hour.
+func lockHourlyStat(ctx context.Context, tx pgx.Tx, linkID string, hour time.Time) (*dailyStatsAccumulator, error) {
+	acc := newDailyStatsAccumulator()
+	acc.linkID, acc.date = linkID, hour
+
+	row := tx.QueryRow(ctx, `
+		INSERT INTO hourly_link_stats (link_id, hour, created_at, updated_at)
+		VALUES ($1, $2, NOW(), NOW())
+		ON CONFLICT (link_id, hour) DO UPDATE SET updated_at = NOW()
+		RETURNING `+statsColumns,
+		linkID, hour,
+	)
+	if err := scanStatsAccumulator(row, acc); err != nil {
+		return nil, fmt.Errorf("lock hourly stat: %w", err)
+	}
+	return acc, nil
+}
+
+// addDailyVisitors adds visitors to a link-day's visitor set and returns
+// how many were new.
+func addDailyVisitors(ctx context.Context, db pgxQuerier, linkID string, date time.Time, hashes []string) (int64, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	tag, err := db.Exec(ctx, `
+		INSERT INTO daily_link_visitors (link_id, date, visitor_hash)
+		SELECT $1, $2, unnest($3::text[])
+		ON CONFLICT DO NOTHING
+	`, linkID, date, hashes)
+	if err != nil {
+		return 0, fmt.Errorf("add daily visitors: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// addHourlyVisitors adds visitors to a link-hour's visitor set and returns
+// how many were new.
+func addHourlyVisitors(ctx context.Context, db pgxQuerier, linkID string, hour time.Time, hashes []string) (int64, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	tag, err := db.Exec(ctx, `
+		INSERT INTO hourly_link_visitors (link_id, hour, visitor_hash)
+		SELECT $1, $2, unnest($3::text[])
+		ON CONFLICT DO NOTHING
+	`, linkID, hour, hashes)
+	if err != nil {
+		return 0, fmt.Errorf("add hourly visitors: %w", err)
+	}
+	return tag.RowsAffected(), nil
+}
+
+// scanStatsAccumulator scans a row selected with statsColumns into acc.
+// *sql.Row satisfies pgx.Row, so SQLite uses it too.
+func scanStatsAccumulator(row pgx.Row, acc *dailyStatsAccumulator) error {
+	var referrerJSON, countryJSON, blockedCountryJSON, botFamilyJSON, browserJSON, deviceJSON, osJSON, regionJSON, sketch []byte
+
+	err := row.Scan(
+		&acc.totalClicks,
+		&acc.uniqueVisitors,
+		&acc.blockedClicks,
+		&acc.botClicks,
+		&referrerJSON,
+		&countryJSON,
+		&blockedCountryJSON,
+		&botFamilyJSON,
+		&browserJSON,
+		&deviceJSON,
+		&osJSON,
+		&regionJSON,
+		&sketch,
+	)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range []struct {
+		data []byte
+		dst  map[string]int64
+	}{
+		{referrerJSON, acc.referrers},
+		{countryJSON, acc.countries},
+		{blockedCountryJSON, acc.blockedCountries},
+		{botFamilyJSON, acc.botFamilies},
+		{browserJSON, acc.browsers},
+		{deviceJSON, acc.devices},
+		{osJSON, acc.osFamilies},
+		{regionJSON, acc.regions},
+	} {
+		if len(field.data) > 0 {
+			_ = json.Unmarshal(field.data, &field.dst)
+		}
+	}
+	if err := acc.visitors.UnmarshalBinary(sketch); err != nil {
+		// Start over rather than fail the batch; a recompute repairs it
+		_ = acc.visitors.UnmarshalBinary(nil)
+	}
+
+	return nil
+}
+
+// RecomputeStats rebuilds a link's daily stats for the UTC days from..to,
+// inclusive, from the stored click events, along with the hourly stats of
+// the days not yet pruned. Days without click events are left as they
+// are. It returns the number of days recomputed.
+func (r *ClickEventRepository) RecomputeStats(ctx context.Context, linkID string, from, to time.Time) (int, error) {
+	start := from.UTC().Truncate(24 * time.Hour)
+	end := to.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	rows, err := r.repo.writer(ctx).Query(ctx, `
+		SELECT DISTINCT date_trunc('hour', clicked_at AT TIME ZONE 'UTC')
+		FROM click_events
+		WHERE link_id = $1 AND clicked_at >= $2 AND clicked_at < $3
+		ORDER BY 1
+	`, linkID, start, end)
+	if err != nil {
+		return 0, fmt.Errorf("query click hours: %w", err)
+	}
+	hours, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (time.Time, error) {
+		var hour time.Time
+		err := row.Scan(&hour)
+		return time.Date(hour.Year(), hour.Month(), hour.Day(), hour.Hour(), 0, 0, 0, time.UTC), err
+	})
+	if err != nil {
+		return 0, fmt.Errorf("scan click hours: %w", err)
+	}
+
+	// Hours before the oldest hourly row have been pruned
+	var oldest *time.Time
+	if err := r.repo.writer(ctx).QueryRow(ctx, `SELECT MIN(hour) FROM hourly_link_stats`).Scan(&oldest); err != nil {
+		return 0, fmt.Errorf("query oldest hourly stat: %w", err)
+	}
+
+	return recomputeHours(hours, oldest,
+		func(day time.Time) error { return r.recomputeDailyStat(ctx, linkID, day) },
+		func(hour time.Time) error { return r.recomputeHourlyStat(ctx, linkID, hour) },
+	)
+}
+
+// recomputeHours recomputes the days of hours, in order, then the hours on
+// days from oldest onwards. A nil oldest means no hourly rows are kept.
+func recomputeHours(hours []time.Time, oldest *time.Time, day, hour func(time.Time) error) (int, error) {
+	days := 0
+	var last time.Time
+	for _, h := range hours {
+		d := h.Truncate(24 * time.Hour)
+		if days > 0 && d.Equal(last) {
+			continue
+		}
+		if err := day(d); err != nil {
+			return days, fmt.Errorf("recompute daily stat %s: %w", d.Format("2006-01-02"), err)
+		}
+		days++
+		last = d
+	}
+
+	if oldest == nil {
+		return days, nil
+	}
+	keepFrom := oldest.UTC().Truncate(24 * time.Hour)
+	for _, h := range hours {
+		if h.Before(keepFrom) {
+			continue
+		}
+		if err := hour(h); err != nil {
+			return days, fmt.Errorf("recompute hourly stat %s: %w", h.Format(time.RFC3339), err)
+		}
+	}
+	return days, nil
+}
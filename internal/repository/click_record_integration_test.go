@@ -0,0 +1,199 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/hll"
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_RecordClickEvents(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		day := time.Now().UTC().Truncate(24 * time.Hour)
+
+		first := []*model.ClickEvent{
+			testClickEvent("record-link", "visitor-a", day.Add(time.Hour)),
+			testClickEvent("record-link", "visitor-b", day.Add(time.Hour+time.Minute)),
+			testClickEvent("record-link", "visitor-c", day.Add(2*time.Hour)),
+		}
+		first[0].Region = "US-CA"
+		first[0].VisitorReg = hll.NewRegister(1 << 60)
+		first[2].IsBot = true
+		first[2].BotFamily = "crawler"
+
+		// visitor-a returns in the second batch, within the same hour
+		second := []*model.ClickEvent{
+			testClickEvent("record-link", "visitor-a", day.Add(time.Hour+2*time.Minute)),
+			testClickEvent("record-link", "visitor-d", day.Add(2*time.Hour)),
+		}
+		second[0].Region = "US-CA"
+		second[0].VisitorReg = hll.NewRegister(1 << 60)
+		second[1].Blocked = true
+
+		if recorded, err := clicks.RecordClickEvents(ctx, first); err != nil || len(recorded) != 3 {
+			t.Fatalf("RecordClickEvents(first) = %d, %v; want 3", len(recorded), err)
+		}
+		if recorded, err := clicks.RecordClickEvents(ctx, second); err != nil || len(recorded) != 2 {
+			t.Fatalf("RecordClickEvents(second) = %d, %v; want 2", len(recorded), err)
+		}
+
+		daily, err := clicks.GetDailyStats(ctx, "record-link", day, day)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 {
+			t.Fatalf("expected 1 daily row, got %d", len(daily))
+		}
+		stat := daily[0]
+		if stat.TotalClicks != 3 || stat.UniqueVisitors != 2 {
+			t.Errorf("daily = %d clicks from %d visitors, want 3 from 2", stat.TotalClicks, stat.UniqueVisitors)
+		}
+		if stat.BotClicks != 1 || stat.BlockedClicks != 1 {
+			t.Errorf("daily bots = %d, blocked = %d; want 1 and 1", stat.BotClicks, stat.BlockedClicks)
+		}
+		if stat.RegionBreakdown["US-CA"] != 2 || stat.CountryBreakdown["US"] != 3 {
+			t.Errorf("daily regions = %v, countries = %v", stat.RegionBreakdown, stat.CountryBreakdown)
+		}
+
+		hours, err := clicks.GetHourlyStats(ctx, "record-link", day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(hours) != 2 {
+			t.Fatalf("expected 2 hourly rows, got %d", len(hours))
+		}
+		// Newest first
+		if hours[1].TotalClicks != 3 || hours[1].UniqueVisitors != 2 {
+			t.Errorf("first hour = %d clicks from %d visitors, want 3 from 2", hours[1].TotalClicks, hours[1].UniqueVisitors)
+		}
+		var sketch hll.Sketch
+		if err := sketch.UnmarshalBinary(hours[1].VisitorSketch); err != nil || sketch.Estimate() != 1 {
+			t.Errorf("first hour sketch estimate = %d, %v; want 1", sketch.Estimate(), err)
+		}
+		if hours[0].TotalClicks != 0 || hours[0].BotClicks != 1 || hours[0].BlockedClicks != 1 {
+			t.Errorf("second hour = %+v, want only a bot and a blocked click", hours[0])
+		}
+
+		// A redelivered batch, alone or mixed with new events, is not counted twice
+		if recorded, err := clicks.RecordClickEvents(ctx, second); err != nil || len(recorded) != 0 {
+			t.Fatalf("RecordClickEvents(redelivered) = %d, %v; want 0", len(recorded), err)
+		}
+		late := testClickEvent("record-link", "visitor-b", day.Add(3*time.Hour))
+		if recorded, err := clicks.RecordClickEvents(ctx, append(first, late)); err != nil || len(recorded) != 1 || recorded[0] != late {
+			t.Fatalf("RecordClickEvents(mixed) = %d, %v; want only the late event", len(recorded), err)
+		}
+
+		daily, err = clicks.GetDailyStats(ctx, "record-link", day, day)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if daily[0].TotalClicks != 4 || daily[0].UniqueVisitors != 2 || daily[0].BotClicks != 1 {
+			t.Errorf("after redelivery daily = %d clicks from %d visitors, %d bots; want 4 from 2, 1 bot",
+				daily[0].TotalClicks, daily[0].UniqueVisitors, daily[0].BotClicks)
+		}
+	})
+}
+
+func TestIntegrationClickEventRepository_RecomputeStats(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		yesterday := today.AddDate(0, 0, -1)
+
+		events := []*model.ClickEvent{
+			testClickEvent("recompute-link", "visitor-a", yesterday.Add(time.Hour)),
+			testClickEvent("recompute-link", "visitor-b", yesterday.Add(2*time.Hour)),
+			testClickEvent("recompute-link", "visitor-a", today.Add(time.Hour)),
+			testClickEvent("recompute-link", "visitor-c", today.Add(time.Hour)),
+		}
+		events[3].Region = "US-TX"
+		if _, err := clicks.RecordClickEvents(ctx, events); err != nil {
+			t.Fatalf("RecordClickEvents: %v", err)
+		}
+
+		incrementalDaily, err := clicks.GetDailyStats(ctx, "recompute-link", yesterday, today)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		incrementalHourly, err := clicks.GetHourlyStats(ctx, "recompute-link", yesterday, today.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+
+		// Events stored without stats, as after a partial restore, are only
+		// picked up by a recompute
+		missed := testClickEvent("recompute-link", "visitor-d", today.Add(2*time.Hour))
+		if err := clicks.BulkInsert(ctx, []*model.ClickEvent{missed}); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+
+		days, err := clicks.RecomputeStats(ctx, "recompute-link", yesterday, today)
+		if err != nil {
+			t.Fatalf("RecomputeStats: %v", err)
+		}
+		if days != 2 {
+			t.Errorf("RecomputeStats = %d days, want 2", days)
+		}
+
+		daily, err := clicks.GetDailyStats(ctx, "recompute-link", yesterday, today)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 2 || len(incrementalDaily) != 2 {
+			t.Fatalf("expected 2 daily rows, got %d and %d", len(daily), len(incrementalDaily))
+		}
+		// Newest first; yesterday is untouched by the missed event
+		if !sameCounts(daily[1], incrementalDaily[1]) {
+			t.Errorf("recomputed yesterday = %+v, incremental = %+v", daily[1], incrementalDaily[1])
+		}
+		if daily[0].TotalClicks != 3 || daily[0].UniqueVisitors != 3 || daily[0].RegionBreakdown["US-TX"] != 1 {
+			t.Errorf("recomputed today = %d clicks from %d visitors, regions %v; want 3 from 3",
+				daily[0].TotalClicks, daily[0].UniqueVisitors, daily[0].RegionBreakdown)
+		}
+
+		hourly, err := clicks.GetHourlyStats(ctx, "recompute-link", yesterday, today.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(hourly) != len(incrementalHourly)+1 {
+			t.Fatalf("expected the missed hour to be added, got %d rows from %d", len(hourly), len(incrementalHourly))
+		}
+		for i, hour := range incrementalHourly {
+			got := hourly[i+1]
+			if got.TotalClicks != hour.TotalClicks || got.UniqueVisitors != hour.UniqueVisitors ||
+				!reflect.DeepEqual(got.VisitorSketch, hour.VisitorSketch) {
+				t.Errorf("recomputed hour %s = %+v, incremental = %+v", hour.Hour, got, hour)
+			}
+		}
+
+		// The rebuilt visitor sets keep later batches exact
+		returning := testClickEvent("recompute-link", "visitor-d", today.Add(3*time.Hour))
+		if _, err := clicks.RecordClickEvents(ctx, []*model.ClickEvent{returning}); err != nil {
+			t.Fatalf("RecordClickEvents: %v", err)
+		}
+		daily, err = clicks.GetDailyStats(ctx, "recompute-link", today, today)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if daily[0].TotalClicks != 4 || daily[0].UniqueVisitors != 3 {
+			t.Errorf("after recompute today = %d clicks from %d visitors, want 4 from 3", daily[0].TotalClicks, daily[0].UniqueVisitors)
+		}
+	})
+}
+
+func sameCounts(a, b *model.DailyLinkStats) bool {
+	return a.TotalClicks == b.TotalClicks &&
+		a.UniqueVisitors == b.UniqueVisitors &&
+		a.BlockedClicks == b.BlockedClicks &&
+		a.BotClicks == b.BotClicks &&
+		reflect.DeepEqual(a.ReferrerBreakdown, b.ReferrerBreakdown) &&
+		reflect.DeepEqual(a.CountryBreakdown, b.CountryBreakdown) &&
+		reflect.DeepEqual(a.RegionBreakdown, b.RegionBreakdown) &&
+		reflect.DeepEqual(a.DeviceBreakdown, b.DeviceBreakdown)
+}
@@ -0,0 +1,65 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_UserAgentBreakdowns(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+		events := []*model.ClickEvent{
+			testClickEvent("ua-link", "v1", day.Add(time.Hour)),
+			testClickEvent("ua-link", "v2", day.Add(2*time.Hour)),
+			testClickEvent("ua-link", "v3", day.Add(2*time.Hour)),
+		}
+		events[0].UserAgent = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1"
+		events[1].UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36"
+		events[2].UserAgent = "Slackbot-LinkExpanding 1.0"
+		events[2].IsBot = true
+		events[2].BotFamily = "slack"
+
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateDailyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateDailyStats: %v", err)
+		}
+		if err := clicks.UpdateHourlyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateHourlyStats: %v", err)
+		}
+
+		daily, err := clicks.GetDailyStats(ctx, "ua-link", day, day)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 {
+			t.Fatalf("expected 1 daily row, got %d", len(daily))
+		}
+		stat := daily[0]
+		if stat.DeviceBreakdown["mobile"] != 1 || stat.DeviceBreakdown["desktop"] != 1 || stat.DeviceBreakdown["bot"] != 1 {
+			t.Errorf("devices = %v, want 1 mobile, 1 desktop, 1 bot", stat.DeviceBreakdown)
+		}
+		if stat.OSBreakdown["iOS"] != 1 || stat.OSBreakdown["Windows"] != 1 {
+			t.Errorf("os = %v, want 1 iOS and 1 Windows", stat.OSBreakdown)
+		}
+		if stat.UAFamilyBreakdown["Safari"] != 1 || stat.UAFamilyBreakdown["Chrome"] != 1 {
+			t.Errorf("browsers = %v, want 1 Safari and 1 Chrome", stat.UAFamilyBreakdown)
+		}
+
+		hours, err := clicks.GetHourlyStats(ctx, "ua-link", day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(hours) != 2 || hours[0].DeviceBreakdown["desktop"] != 1 || hours[1].UAFamilyBreakdown["Safari"] != 1 {
+			t.Errorf("hourly breakdowns = %+v, want desktop at 02:00 and Safari at 01:00", hours)
+		}
+	})
+}
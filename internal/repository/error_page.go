@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// ErrErrorPageSettingsNotFound is returned when no settings exist for a scope.
+var ErrErrorPageSettingsNotFound = errors.New("error page settings not found")
+
+// GetErrorPageSettings retrieves fallback and branding settings for a scope.
+func (r *Repository) GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, error) {
+	query := `
+		SELECT scope_type, scope_id,
+			   COALESCE(fallback_url, ''), COALESCE(brand_name, ''), COALESCE(logo_url, ''),
+			   COALESCE(accent_color, ''), COALESCE(support_url, ''),
+			   created_at, updated_at
+		FROM error_page_settings
+		WHERE scope_type = $1 AND scope_id = $2
+	`
+
+	var settings model.ErrorPageSettings
+	err := r.pool.QueryRow(ctx, query, scope, scopeID).Scan(
+		&settings.ScopeType,
+		&settings.ScopeID,
+		&settings.FallbackURL,
+		&settings.BrandName,
+		&settings.LogoURL,
+		&settings.AccentColor,
+		&settings.SupportURL,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrErrorPageSettingsNotFound
+		}
+		return nil, fmt.Errorf("failed to get error page settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// UpsertErrorPageSettings creates or replaces settings for a scope.
+// CreatedAt and UpdatedAt are refreshed from the database.
+func (r *Repository) UpsertErrorPageSettings(ctx context.Context, settings *model.ErrorPageSettings) error {
+	query := `
+		INSERT INTO error_page_settings (
+			scope_type, scope_id, fallback_url, brand_name, logo_url,
+			accent_color, support_url, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (scope_type, scope_id) DO UPDATE SET
+			fallback_url = EXCLUDED.fallback_url,
+			brand_name = EXCLUDED.brand_name,
+			logo_url = EXCLUDED.logo_url,
+			accent_color = EXCLUDED.accent_color,
+			support_url = EXCLUDED.support_url,
+			updated_at = NOW()
+		RETURNING created_at, updated_at
+	`
+
+	err := r.writer(ctx).QueryRow(ctx, query,
+		settings.ScopeType,
+		settings.ScopeID,
+		nullableString(settings.FallbackURL),
+		nullableString(settings.BrandName),
+		nullableString(settings.LogoURL),
+		nullableString(settings.AccentColor),
+		nullableString(settings.SupportURL),
+	).Scan(&settings.CreatedAt, &settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert error page settings: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteErrorPageSettings removes settings for a scope.
+func (r *Repository) DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error {
+	query := `DELETE FROM error_page_settings WHERE scope_type = $1 AND scope_id = $2`
+
+	result, err := r.writer(ctx).Exec(ctx, query, scope, scopeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete error page settings: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrErrorPageSettingsNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/penshort/penshort/internal/model"
+)
+
+// pruneBatchSize bounds the link-days pruned per query in PruneHourlyStats.
+const pruneBatchSize = 500
+
+type hourlyStatsKey struct {
+	linkID string
+	hour   time.Time
+}
+
+func uniqueHourlyKeys(events []*model.ClickEvent) []hourlyStatsKey {
+	seen := make(map[hourlyStatsKey]bool)
+	keys := make([]hourlyStatsKey, 0)
+	for _, event := range events {
+		key := hourlyStatsKey{linkID: event.LinkID, hour: event.ClickedAt.UTC().Truncate(time.Hour)}
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// UpdateHourlyStats recomputes the hourly_link_stats rows touched by events
+// from the stored click events, replacing their visitor sets. Like
+// UpdateDailyStats, it is the repair path.
+func (r *ClickEventRepository) UpdateHourlyStats(ctx context.Context, events []*model.ClickEvent) error {
+	for _, key := range uniqueHourlyKeys(events) {
+		if err := r.recomputeHourlyStat(ctx, key.linkID, key.hour); err != nil {
+			return fmt.Errorf("recompute hourly stat %s:%s: %w", key.linkID, key.hour.Format(time.RFC3339), err)
+		}
+	}
+
+	return nil
+}
+
+// recomputeHourlyStat rebuilds one link-hour from its click events.
+func (r *ClickEventRepository) recomputeHourlyStat(ctx context.Context, linkID string, hour time.Time) error {
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	if _, err := lockHourlyStat(ctx, tx, linkID, hour); err != nil {
+		return err
+	}
+	acc, err := recalculateStats(ctx, tx, linkID, hour, hour.Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM hourly_link_visitors WHERE link_id = $1 AND hour = $2`,
+		linkID, hour,
+	); err != nil {
+		return err
+	}
+	if _, err := addHourlyVisitors(ctx, tx, linkID, hour, acc.visitorHashes()); err != nil {
+		return err
+	}
+	if err := upsertHourlyStat(ctx, tx, acc); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// upsertHourlyStat inserts or updates an hourly_link_stats row. acc.date
+// holds the start of the hour.
+func upsertHourlyStat(ctx context.Context, db pgxQuerier, acc *dailyStatsAccumulator) error {
+	referrerJSON, _ := json.Marshal(acc.referrers)
+	countryJSON, _ := json.Marshal(acc.countries)
+	blockedCountryJSON, _ := json.Marshal(acc.blockedCountries)
+	botFamilyJSON, _ := json.Marshal(acc.botFamilies)
+	browserJSON, _ := json.Marshal(acc.browsers)
+	deviceJSON, _ := json.Marshal(acc.devices)
+	osJSON, _ := json.Marshal(acc.osFamilies)
+	regionJSON, _ := json.Marshal(acc.regions)
+	sketch, _ := acc.visitors.MarshalBinary()
+
+	query := `
+		INSERT INTO hourly_link_stats (
+			link_id, hour, total_clicks, unique_visitors, blocked_clicks, bot_clicks,
+			referrer_breakdown, country_breakdown, blocked_country_breakdown,
+			bot_family_breakdown, ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
+			visitor_sketch, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW(), NOW())
+		ON CONFLICT (link_id, hour) DO UPDATE SET
+			total_clicks = EXCLUDED.total_clicks,
+			unique_visitors = EXCLUDED.unique_visitors,
+			blocked_clicks = EXCLUDED.blocked_clicks,
+			bot_clicks = EXCLUDED.bot_clicks,
+			referrer_breakdown = EXCLUDED.referrer_breakdown,
+			country_breakdown = EXCLUDED.country_breakdown,
+			blocked_country_breakdown = EXCLUDED.blocked_country_breakdown,
+			bot_family_breakdown = EXCLUDED.bot_family_breakdown,
+			ua_family_breakdown = EXCLUDED.ua_family_breakdown,
+			device_breakdown = EXCLUDED.device_breakdown,
+			os_breakdown = EXCLUDED.os_breakdown,
+			region_breakdown = EXCLUDED.region_breakdown,
+			visitor_sketch = EXCLUDED.visitor_sketch,
+			updated_at = NOW()
+	`
+
+	_, err := db.Exec(ctx, query,
+		acc.linkID,
+		acc.date,
+		acc.totalClicks,
+		acc.uniqueVisitors,
+		acc.blockedClicks,
+		acc.botClicks,
+		referrerJSON,
+		countryJSON,
+		blockedCountryJSON,
+		botFamilyJSON,
+		browserJSON,
+		deviceJSON,
+		osJSON,
+		regionJSON,
+		sketch,
+	)
+
+	return err
+}
+
+// GetHourlyStats retrieves hourly stats for a link with hour in [from, to).
+func (r *ClickEventRepository) GetHourlyStats(ctx context.Context, linkID string, from, to time.Time) ([]*model.HourlyLinkStats, error) {
+	query := `
+		SELECT link_id, hour, total_clicks, unique_visitors, blocked_clicks, bot_clicks,
+			   referrer_breakdown, country_breakdown, blocked_country_breakdown,
+			   bot_family_breakdown, ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
+			   visitor_sketch, created_at, updated_at
+		FROM hourly_link_stats
+		WHERE link_id = $1 AND hour >= $2 AND hour < $3
+		ORDER BY hour DESC
+	`
+
+	rows, err := r.repo.reader(ctx).Query(ctx, query, linkID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("query hourly stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.HourlyLinkStats
+	for rows.Next() {
+		stat, err := scanHourlyStat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan hourly stat: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// PruneHourlyStats prunes hourly rows from days before the UTC day
+// containing before, along with their hourly visitor sets. The worker merges
+// every batch into daily_link_stats alongside the hourly rows, so the daily
+// row already holds the day's totals and is left unchanged. It returns the
+// number of link-days pruned.
+func (r *ClickEventRepository) PruneHourlyStats(ctx context.Context, before time.Time) (int, error) {
+	before = before.UTC().Truncate(24 * time.Hour)
+
+	total := 0
+	for {
+		rows, err := r.repo.writer(ctx).Query(ctx, `
+			SELECT DISTINCT link_id, date_trunc('day', hour AT TIME ZONE 'UTC')
+			FROM hourly_link_stats
+			WHERE hour < $1
+			LIMIT $2
+		`, before, pruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("query expired hourly stats: %w", err)
+		}
+
+		var keys []dailyStatsKey
+		for rows.Next() {
+			var key dailyStatsKey
+			if err := rows.Scan(&key.linkID, &key.date); err != nil {
+				rows.Close()
+				return total, fmt.Errorf("scan expired hourly stat: %w", err)
+			}
+			key.date = time.Date(key.date.Year(), key.date.Month(), key.date.Day(), 0, 0, 0, 0, time.UTC)
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, fmt.Errorf("iterate expired hourly stats: %w", err)
+		}
+
+		for _, key := range keys {
+			if err := r.pruneDay(ctx, key); err != nil {
+				return total, fmt.Errorf("prune %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
+			}
+			total++
+		}
+
+		if len(keys) < pruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// pruneDay deletes one link-day of hourly rows and hourly visitor sets in a
+// transaction.
+func (r *ClickEventRepository) pruneDay(ctx context.Context, key dailyStatsKey) error {
+	tx, err := r.repo.writer(ctx).Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	end := key.date.Add(24 * time.Hour)
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM hourly_link_stats WHERE link_id = $1 AND hour >= $2 AND hour < $3`,
+		key.linkID, key.date, end,
+	); err != nil {
+		return err
+	}
+
+	// The daily seen-set stays with the daily row so late events stay exact
+	if _, err := tx.Exec(ctx,
+		`DELETE FROM hourly_link_visitors WHERE link_id = $1 AND hour >= $2 AND hour < $3`,
+		key.linkID, key.date, end,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// scanHourlyStat scans a row into HourlyLinkStats.
+func scanHourlyStat(rows pgx.Rows) (*model.HourlyLinkStats, error) {
+	var stat model.HourlyLinkStats
+	var referrerJSON, countryJSON, blockedCountryJSON, botFamilyJSON, browserJSON, deviceJSON, osJSON, regionJSON []byte
+
+	err := rows.Scan(
+		&stat.LinkID,
+		&stat.Hour,
+		&stat.TotalClicks,
+		&stat.UniqueVisitors,
+		&stat.BlockedClicks,
+		&stat.BotClicks,
+		&referrerJSON,
+		&countryJSON,
+		&blockedCountryJSON,
+		&botFamilyJSON,
+		&browserJSON,
+		&deviceJSON,
+		&osJSON,
+		&regionJSON,
+		&stat.VisitorSketch,
+		&stat.CreatedAt,
+		&stat.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stat.Hour = stat.Hour.UTC()
+
+	if len(referrerJSON) > 0 {
+		_ = json.Unmarshal(referrerJSON, &stat.ReferrerBreakdown)
+	}
+	if len(countryJSON) > 0 {
+		_ = json.Unmarshal(countryJSON, &stat.CountryBreakdown)
+	}
+	if len(blockedCountryJSON) > 0 {
+		_ = json.Unmarshal(blockedCountryJSON, &stat.BlockedCountryBreakdown)
+	}
+	if len(botFamilyJSON) > 0 {
+		_ = json.Unmarshal(botFamilyJSON, &stat.BotFamilyBreakdown)
+	}
+	if len(browserJSON) > 0 {
+		_ = json.Unmarshal(browserJSON, &stat.UAFamilyBreakdown)
+	}
+	if len(deviceJSON) > 0 {
+		_ = json.Unmarshal(deviceJSON, &stat.DeviceBreakdown)
+	}
+	if len(osJSON) > 0 {
+		_ = json.Unmarshal(osJSON, &stat.OSBreakdown)
+	}
+	if len(regionJSON) > 0 {
+		_ = json.Unmarshal(regionJSON, &stat.RegionBreakdown)
+	}
+
+	return &stat, nil
+}
@@ -0,0 +1,210 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+// ============================================================================
+// Hourly Stats Integration Tests
+// ============================================================================
+
+func TestIntegrationClickEventRepository_UpdateHourlyStats(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		hour := time.Now().UTC().Truncate(time.Hour).Add(-2 * time.Hour)
+
+		events := []*model.ClickEvent{
+			testClickEvent("hourly-link", "v1", hour.Add(5*time.Minute)),
+			testClickEvent("hourly-link", "v1", hour.Add(10*time.Minute)),
+			testClickEvent("hourly-link", "v2", hour.Add(70*time.Minute)),
+		}
+		events[2].Referrer = "https://example.com/post"
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateHourlyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateHourlyStats: %v", err)
+		}
+		// Recalculating is idempotent
+		if err := clicks.UpdateHourlyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateHourlyStats again: %v", err)
+		}
+
+		stats, err := clicks.GetHourlyStats(ctx, "hourly-link", hour, hour.Add(2*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("expected 2 hourly rows, got %d", len(stats))
+		}
+
+		// Newest first
+		if !stats[0].Hour.Equal(hour.Add(time.Hour)) || stats[0].TotalClicks != 1 {
+			t.Errorf("latest hour = %s with %d clicks, want %s with 1", stats[0].Hour, stats[0].TotalClicks, hour.Add(time.Hour))
+		}
+		if stats[0].ReferrerBreakdown["example.com"] != 1 {
+			t.Errorf("latest hour referrers = %v, want example.com", stats[0].ReferrerBreakdown)
+		}
+		if !stats[1].Hour.Equal(hour) || stats[1].TotalClicks != 2 || stats[1].UniqueVisitors != 1 {
+			t.Errorf("first hour = %s with %d clicks / %d uniques, want %s with 2 / 1",
+				stats[1].Hour, stats[1].TotalClicks, stats[1].UniqueVisitors, hour)
+		}
+	})
+}
+
+func TestIntegrationClickEventRepository_PruneHourlyStats(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		oldDay := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -40)
+		recent := time.Now().UTC().Truncate(time.Hour).Add(-time.Hour)
+
+		// The worker writes daily rows alongside hourly ones; the second link
+		// only has hourly rows, which the prune must not turn into a daily row
+		withDaily := []*model.ClickEvent{
+			testClickEvent("prune-daily", "v1", oldDay.Add(1*time.Hour)),
+			testClickEvent("prune-daily", "v2", oldDay.Add(5*time.Hour)),
+		}
+		hourlyOnly := []*model.ClickEvent{
+			testClickEvent("prune-hourly", "v1", oldDay.Add(2*time.Hour)),
+			testClickEvent("prune-hourly", "v2", oldDay.Add(2*time.Hour)),
+			testClickEvent("prune-hourly", "v3", oldDay.Add(9*time.Hour)),
+		}
+		current := []*model.ClickEvent{testClickEvent("prune-hourly", "v1", recent)}
+
+		all := append(append(append([]*model.ClickEvent{}, withDaily...), hourlyOnly...), current...)
+		if err := clicks.BulkInsert(ctx, all); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateDailyStats(ctx, withDaily); err != nil {
+			t.Fatalf("UpdateDailyStats: %v", err)
+		}
+		if err := clicks.UpdateHourlyStats(ctx, all); err != nil {
+			t.Fatalf("UpdateHourlyStats: %v", err)
+		}
+
+		pruned, err := clicks.PruneHourlyStats(ctx, time.Now().Add(-30*24*time.Hour))
+		if err != nil {
+			t.Fatalf("PruneHourlyStats: %v", err)
+		}
+		if pruned != 2 {
+			t.Errorf("pruned %d link-days, want 2", pruned)
+		}
+
+		for _, linkID := range []string{"prune-daily", "prune-hourly"} {
+			hours, err := clicks.GetHourlyStats(ctx, linkID, oldDay, oldDay.Add(24*time.Hour))
+			if err != nil {
+				t.Fatalf("GetHourlyStats: %v", err)
+			}
+			if len(hours) != 0 {
+				t.Errorf("%s: expected old hourly rows deleted, got %d", linkID, len(hours))
+			}
+		}
+
+		// The existing daily row, built from raw events, is kept
+		daily, err := clicks.GetDailyStats(ctx, "prune-daily", oldDay, oldDay)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 || daily[0].TotalClicks != 2 || daily[0].UniqueVisitors != 2 {
+			t.Fatalf("prune-daily daily stats = %+v, want 2 clicks / 2 uniques", daily)
+		}
+
+		// The day's seen-set outlives the prune, so a late event from a known
+		// visitor adds a click but not a unique
+		late := []*model.ClickEvent{testClickEvent("prune-daily", "v1", oldDay.Add(7*time.Hour))}
+		if _, err := clicks.RecordClickEvents(ctx, late); err != nil {
+			t.Fatalf("RecordClickEvents: %v", err)
+		}
+		daily, err = clicks.GetDailyStats(ctx, "prune-daily", oldDay, oldDay)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 || daily[0].TotalClicks != 3 || daily[0].UniqueVisitors != 2 {
+			t.Fatalf("prune-daily daily stats after late event = %+v, want 3 clicks / 2 uniques", daily)
+		}
+
+		// Pruning leaves the daily rows alone
+		daily, err = clicks.GetDailyStats(ctx, "prune-hourly", oldDay, oldDay)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 0 {
+			t.Fatalf("prune-hourly daily stats = %+v, want none", daily)
+		}
+
+		hours, err := clicks.GetHourlyStats(ctx, "prune-hourly", recent, recent.Add(time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(hours) != 1 {
+			t.Errorf("expected recent hourly row kept, got %d", len(hours))
+		}
+	})
+}
+
+// clickEventStoreFor returns the ClickEventStore backed by repo.
+func clickEventStoreFor(t *testing.T, repo Store) ClickEventStore {
+	t.Helper()
+	switch r := repo.(type) {
+	case *Repository:
+		return NewClickEventRepository(r)
+	case *SQLiteStore:
+		return NewSQLiteClickEventRepository(r)
+	default:
+		t.Fatalf("unsupported store %T", repo)
+		return nil
+	}
+}
+
+var testClickEventSeq int
+
+func testClickEvent(linkID, visitor string, clickedAt time.Time) *model.ClickEvent {
+	testClickEventSeq++
+	return &model.ClickEvent{
+		ID:          fmt.Sprintf("test-click-%d-%d", time.Now().UnixNano(), testClickEventSeq),
+		EventID:     fmt.Sprintf("%d-%d", time.Now().UnixNano(), testClickEventSeq),
+		ShortCode:   linkID,
+		LinkID:      linkID,
+		VisitorHash: visitor,
+		CountryCode: "US",
+		ClickedAt:   clickedAt,
+	}
+}
+
+func newAnalyticsTestEnv(t *testing.T) (context.Context, Store) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	dbURL := testutil.RequireEnv(t, "DATABASE_URL")
+
+	repo, err := New(ctx, dbURL, "")
+	if err != nil {
+		t.Fatalf("connect db: %v", err)
+	}
+	t.Cleanup(repo.Close)
+
+	unlock, err := testutil.AcquireDBLock(ctx, repo.Pool())
+	if err != nil {
+		t.Fatalf("acquire db lock: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = unlock()
+	})
+
+	if err := testutil.ResetAnalyticsSchema(ctx, repo.Pool()); err != nil {
+		t.Fatalf("reset analytics schema: %v", err)
+	}
+
+	return ctx, repo
+}
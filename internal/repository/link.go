@@ -18,6 +18,7 @@ var (
 	ErrAliasExists      = errors.New("alias already exists")
 	ErrLinkExpired      = errors.New("link is expired")
 	ErrInvalidCursor    = errors.New("invalid pagination cursor")
+	ErrLinkModified     = errors.New("link was modified concurrently")
 )
 
 // LinkFilter defines filters for listing links.
@@ -37,11 +38,11 @@ type PaginationCursor struct {
 // CreateLink inserts a new link into the database.
 func (r *Repository) CreateLink(ctx context.Context, link *model.Link) error {
 	query := `
-		INSERT INTO links (id, short_code, destination, redirect_type, owner_id, enabled, expires_at, click_count, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		INSERT INTO links (id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, click_count, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := r.writer(ctx).Exec(ctx, query,
 		link.ID,
 		link.ShortCode,
 		link.Destination,
@@ -49,6 +50,11 @@ func (r *Repository) CreateLink(ctx context.Context, link *model.Link) error {
 		link.OwnerID,
 		link.Enabled,
 		link.ExpiresAt,
+		link.FallbackURL,
+		link.AllowedCountries,
+		link.BlockedCountries,
+		link.GeoBlockedURL,
+		link.Tags,
 		link.ClickCount,
 		link.CreatedAt,
 		link.UpdatedAt,
@@ -68,7 +74,7 @@ func (r *Repository) CreateLink(ctx context.Context, link *model.Link) error {
 // GetLinkByID retrieves a link by its ID.
 func (r *Repository) GetLinkByID(ctx context.Context, id string) (*model.Link, error) {
 	query := `
-		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, deleted_at, click_count, created_at, updated_at
+		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, deleted_at, click_count, created_at, updated_at
 		FROM links
 		WHERE id = $1 AND deleted_at IS NULL
 	`
@@ -88,7 +94,7 @@ func (r *Repository) GetLinkByID(ctx context.Context, id string) (*model.Link, e
 // This is the hot path for redirects.
 func (r *Repository) GetLinkByShortCode(ctx context.Context, shortCode string) (*model.Link, error) {
 	query := `
-		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, deleted_at, click_count, created_at, updated_at
+		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, deleted_at, click_count, created_at, updated_at
 		FROM links
 		WHERE short_code = $1 AND deleted_at IS NULL
 	`
@@ -124,7 +130,7 @@ func (r *Repository) ListLinks(ctx context.Context, filter LinkFilter, cursor st
 
 	// Build query with filters
 	query := `
-		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, deleted_at, click_count, created_at, updated_at
+		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, deleted_at, click_count, created_at, updated_at
 		FROM links
 		WHERE deleted_at IS NULL
 		  AND owner_id = $1
@@ -155,7 +161,7 @@ func (r *Repository) ListLinks(ctx context.Context, filter LinkFilter, cursor st
 	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d", argIndex)
 	args = append(args, limit+1) // Fetch one extra to determine hasMore
 
-	rows, err := r.pool.Query(ctx, query, args...)
+	rows, err := r.reader(ctx).Query(ctx, query, args...)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to list links: %w", err)
 	}
@@ -189,27 +195,55 @@ func (r *Repository) ListLinks(ctx context.Context, filter LinkFilter, cursor st
 }
 
 // UpdateLink updates a link's mutable fields.
+// On success link.UpdatedAt is refreshed from the database.
 func (r *Repository) UpdateLink(ctx context.Context, link *model.Link) error {
+	return r.updateLink(ctx, link, nil)
+}
+
+// UpdateLinkIfUnmodified updates a link only if its updated_at still equals
+// expectedUpdatedAt (compare-and-swap). Returns ErrLinkModified if another
+// writer changed the link in the meantime.
+func (r *Repository) UpdateLinkIfUnmodified(ctx context.Context, link *model.Link, expectedUpdatedAt time.Time) error {
+	return r.updateLink(ctx, link, &expectedUpdatedAt)
+}
+
+// updateLink is the shared implementation for UpdateLink and UpdateLinkIfUnmodified.
+func (r *Repository) updateLink(ctx context.Context, link *model.Link, expectedUpdatedAt *time.Time) error {
 	query := `
 		UPDATE links
-		SET destination = $2, redirect_type = $3, enabled = $4, expires_at = $5
+		SET destination = $2, redirect_type = $3, enabled = $4, expires_at = $5, fallback_url = $6,
+		    allowed_countries = $7, blocked_countries = $8, geo_blocked_url = $9, tags = $10
 		WHERE id = $1 AND deleted_at IS NULL
+		  AND ($11::timestamptz IS NULL OR updated_at = $11)
+		RETURNING updated_at
 	`
 
-	result, err := r.pool.Exec(ctx, query,
+	err := r.writer(ctx).QueryRow(ctx, query,
 		link.ID,
 		link.Destination,
 		link.RedirectType,
 		link.Enabled,
 		link.ExpiresAt,
-	)
+		link.FallbackURL,
+		link.AllowedCountries,
+		link.BlockedCountries,
+		link.GeoBlockedURL,
+		link.Tags,
+		expectedUpdatedAt,
+	).Scan(&link.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("failed to update link: %w", err)
-	}
-
-	if result.RowsAffected() == 0 {
-		return ErrLinkNotFound
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("failed to update link: %w", err)
+		}
+		if expectedUpdatedAt == nil {
+			return ErrLinkNotFound
+		}
+		// Distinguish a concurrent modification from a missing link
+		if _, getErr := r.GetLinkByID(ctx, link.ID); getErr != nil {
+			return getErr
+		}
+		return ErrLinkModified
 	}
 
 	return nil
@@ -223,7 +257,7 @@ func (r *Repository) DeleteLink(ctx context.Context, id string) error {
 		WHERE id = $1 AND deleted_at IS NULL
 	`
 
-	result, err := r.pool.Exec(ctx, query, id)
+	result, err := r.writer(ctx).Exec(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete link: %w", err)
 	}
@@ -244,7 +278,7 @@ func (r *Repository) IncrementClickCount(ctx context.Context, id string, count i
 		WHERE id = $1
 	`
 
-	_, err := r.pool.Exec(ctx, query, id, count)
+	_, err := r.writer(ctx).Exec(ctx, query, id, count)
 	if err != nil {
 		return fmt.Errorf("failed to increment click count: %w", err)
 	}
@@ -275,7 +309,7 @@ func (r *Repository) SearchLinksByDestination(ctx context.Context, destination s
 
 	// Use ILIKE for case-insensitive partial matching
 	query := `
-		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, deleted_at, click_count, created_at, updated_at
+		SELECT id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, deleted_at, click_count, created_at, updated_at
 		FROM links
 		WHERE destination ILIKE $1
 		ORDER BY created_at DESC
@@ -285,7 +319,7 @@ func (r *Repository) SearchLinksByDestination(ctx context.Context, destination s
 	// Wrap with wildcards for partial matching
 	pattern := "%" + destination + "%"
 
-	rows, err := r.pool.Query(ctx, query, pattern, limit)
+	rows, err := r.reader(ctx).Query(ctx, query, pattern, limit)
 	if err != nil {
 		return nil, fmt.Errorf("failed to search links by destination: %w", err)
 	}
@@ -318,6 +352,11 @@ func (r *Repository) scanLink(row pgx.Row) (*model.Link, error) {
 		&link.OwnerID,
 		&link.Enabled,
 		&link.ExpiresAt,
+		&link.FallbackURL,
+		&link.AllowedCountries,
+		&link.BlockedCountries,
+		&link.GeoBlockedURL,
+		&link.Tags,
 		&link.DeletedAt,
 		&link.ClickCount,
 		&link.CreatedAt,
@@ -337,6 +376,11 @@ func (r *Repository) scanLinkFromRows(rows pgx.Rows) (*model.Link, error) {
 		&link.OwnerID,
 		&link.Enabled,
 		&link.ExpiresAt,
+		&link.FallbackURL,
+		&link.AllowedCountries,
+		&link.BlockedCountries,
+		&link.GeoBlockedURL,
+		&link.Tags,
 		&link.DeletedAt,
 		&link.ClickCount,
 		&link.CreatedAt,
@@ -345,10 +389,11 @@ func (r *Repository) scanLinkFromRows(rows pgx.Rows) (*model.Link, error) {
 	return &link, err
 }
 
-// isUniqueViolation checks if the error is a PostgreSQL unique constraint violation.
+// isUniqueViolation checks if the error is a PostgreSQL or SQLite unique constraint violation.
 func isUniqueViolation(err error) bool {
 	// PostgreSQL error code 23505 is unique_violation
-	return err != nil && (contains(err.Error(), "23505") || contains(err.Error(), "unique"))
+	return err != nil && (contains(err.Error(), "23505") || contains(err.Error(), "unique") ||
+		contains(err.Error(), "UNIQUE constraint failed"))
 }
 
 // contains checks if a string contains a substring.
@@ -120,7 +120,7 @@ func newTestRepository(t *testing.T, ctx context.Context) *Repository {
 	}
 
 	dbURL := testutil.RequireEnv(t, "DATABASE_URL")
-	repo, err := New(ctx, dbURL)
+	repo, err := New(ctx, dbURL, "")
 	if err != nil {
 		t.Fatalf("create repository: %v", err)
 	}
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// LinkTransferParams selects the links to move and records who moved them.
+// LinkIDs and FromOwnerID combine with AND; at least one must be set.
+type LinkTransferParams struct {
+	TransferID  string
+	LinkIDs     []string
+	FromOwnerID string
+	ToOwnerID   string
+	ActorID     string
+	Reason      string
+}
+
+// LinkTransferFilter narrows the audit trail listing.
+type LinkTransferFilter struct {
+	TransferID string
+	LinkID     string
+	OwnerID    string // Matches either side of the transfer
+}
+
+// TransferLinks reassigns links to a new owner and writes one audit row per
+// moved link, all in a single transaction. Links already owned by ToOwnerID
+// are skipped. If any requested link ID does not exist, nothing is changed
+// and ErrLinkNotFound is returned.
+func (r *Repository) TransferLinks(ctx context.Context, params LinkTransferParams) ([]*model.LinkTransfer, error) {
+	tx, err := r.writer(ctx).Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transfer: %w", err)
+	}
+	defer func() { _ = tx.Rollback(ctx) }() // No-op after commit
+
+	// Lock the selected links so concurrent updates wait for the transfer
+	selectQuery := `
+		SELECT id, short_code, owner_id
+		FROM links
+		WHERE deleted_at IS NULL
+		  AND ($1::text[] IS NULL OR id = ANY($1))
+		  AND ($2 = '' OR owner_id = $2)
+		ORDER BY id
+		FOR UPDATE
+	`
+
+	var linkIDs []string
+	if len(params.LinkIDs) > 0 {
+		linkIDs = params.LinkIDs
+	}
+
+	rows, err := tx.Query(ctx, selectQuery, linkIDs, params.FromOwnerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select links for transfer: %w", err)
+	}
+
+	found := 0
+	var transfers []*model.LinkTransfer
+	for rows.Next() {
+		var t model.LinkTransfer
+		if err := rows.Scan(&t.LinkID, &t.ShortCode, &t.FromOwnerID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan link for transfer: %w", err)
+		}
+		found++
+		if t.FromOwnerID == params.ToOwnerID {
+			continue
+		}
+		transfers = append(transfers, &t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select links for transfer: %w", err)
+	}
+
+	if linkIDs != nil && found != countUnique(linkIDs) {
+		return nil, ErrLinkNotFound
+	}
+
+	if len(transfers) == 0 {
+		return []*model.LinkTransfer{}, nil
+	}
+
+	ids := make([]string, len(transfers))
+	shortCodes := make([]string, len(transfers))
+	fromOwners := make([]string, len(transfers))
+	for i, t := range transfers {
+		ids[i] = t.LinkID
+		shortCodes[i] = t.ShortCode
+		fromOwners[i] = t.FromOwnerID
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE links SET owner_id = $1 WHERE id = ANY($2)`,
+		params.ToOwnerID, ids,
+	); err != nil {
+		return nil, fmt.Errorf("failed to update link owners: %w", err)
+	}
+
+	transferredAt := time.Now().UTC()
+	insertQuery := `
+		INSERT INTO link_ownership_transfers (
+			transfer_id, link_id, short_code, from_owner_id, to_owner_id,
+			actor_id, reason, transferred_at
+		)
+		SELECT $1, t.link_id, t.short_code, t.from_owner_id, $5, $6, $7, $8
+		FROM unnest($2::text[], $3::text[], $4::text[]) AS t(link_id, short_code, from_owner_id)
+	`
+
+	if _, err := tx.Exec(ctx, insertQuery,
+		params.TransferID, ids, shortCodes, fromOwners,
+		params.ToOwnerID, params.ActorID, nullableString(params.Reason), transferredAt,
+	); err != nil {
+		return nil, fmt.Errorf("failed to record link transfers: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	for _, t := range transfers {
+		t.TransferID = params.TransferID
+		t.ToOwnerID = params.ToOwnerID
+		t.ActorID = params.ActorID
+		t.Reason = params.Reason
+		t.TransferredAt = transferredAt
+	}
+
+	return transfers, nil
+}
+
+// ListLinkTransfers retrieves audit trail entries, newest first.
+func (r *Repository) ListLinkTransfers(ctx context.Context, filter LinkTransferFilter, limit int) ([]*model.LinkTransfer, error) {
+	query := `
+		SELECT transfer_id, link_id, short_code, from_owner_id, to_owner_id,
+			   actor_id, COALESCE(reason, ''), transferred_at
+		FROM link_ownership_transfers
+		WHERE ($1 = '' OR transfer_id = $1)
+		  AND ($2 = '' OR link_id = $2)
+		  AND ($3 = '' OR from_owner_id = $3 OR to_owner_id = $3)
+		ORDER BY transferred_at DESC, id DESC
+		LIMIT $4
+	`
+
+	rows, err := r.pool.Query(ctx, query, filter.TransferID, filter.LinkID, filter.OwnerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*model.LinkTransfer
+	for rows.Next() {
+		var t model.LinkTransfer
+		err := rows.Scan(
+			&t.TransferID,
+			&t.LinkID,
+			&t.ShortCode,
+			&t.FromOwnerID,
+			&t.ToOwnerID,
+			&t.ActorID,
+			&t.Reason,
+			&t.TransferredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link transfer: %w", err)
+		}
+		transfers = append(transfers, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate link transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// countUnique returns the number of distinct values in ids.
+func countUnique(ids []string) int {
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		seen[id] = struct{}{}
+	}
+	return len(seen)
+}
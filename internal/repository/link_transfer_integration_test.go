@@ -0,0 +1,117 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/penshort/penshort/internal/testutil"
+)
+
+// ============================================================================
+// Link Transfer Integration Tests
+// ============================================================================
+
+func TestIntegrationLinkRepository_TransferLinks_ByOwner(t *testing.T) {
+	forEachBackend(t, newLinkTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		var ids []string
+		for _, prefix := range []string{"xfer-a", "xfer-b"} {
+			link := testutil.NewTestLink(t, testutil.UniqueShortCode(prefix))
+			link.ID = testutil.UniqueID("link")
+			link.OwnerID = "alice"
+			if err := repo.CreateLink(ctx, link); err != nil {
+				t.Fatalf("CreateLink failed: %v", err)
+			}
+			ids = append(ids, link.ID)
+		}
+		other := testutil.NewTestLink(t, testutil.UniqueShortCode("xfer-c"))
+		other.ID = testutil.UniqueID("link")
+		other.OwnerID = "carol"
+		if err := repo.CreateLink(ctx, other); err != nil {
+			t.Fatalf("CreateLink failed: %v", err)
+		}
+
+		transfers, err := repo.TransferLinks(ctx, LinkTransferParams{
+			TransferID:  "transfer-1",
+			FromOwnerID: "alice",
+			ToOwnerID:   "bob",
+			ActorID:     "admin",
+			Reason:      "offboarding",
+		})
+		if err != nil {
+			t.Fatalf("TransferLinks failed: %v", err)
+		}
+		if len(transfers) != 2 {
+			t.Fatalf("expected 2 transfers, got %d", len(transfers))
+		}
+
+		for _, id := range ids {
+			link, err := repo.GetLinkByID(ctx, id)
+			if err != nil {
+				t.Fatalf("GetLinkByID failed: %v", err)
+			}
+			if link.OwnerID != "bob" {
+				t.Errorf("OwnerID = %q, want bob", link.OwnerID)
+			}
+		}
+
+		untouched, err := repo.GetLinkByID(ctx, other.ID)
+		if err != nil {
+			t.Fatalf("GetLinkByID failed: %v", err)
+		}
+		if untouched.OwnerID != "carol" {
+			t.Errorf("other owner's link moved: OwnerID = %q", untouched.OwnerID)
+		}
+
+		audit, err := repo.ListLinkTransfers(ctx, LinkTransferFilter{TransferID: "transfer-1"}, 10)
+		if err != nil {
+			t.Fatalf("ListLinkTransfers failed: %v", err)
+		}
+		if len(audit) != 2 {
+			t.Fatalf("expected 2 audit rows, got %d", len(audit))
+		}
+		for _, row := range audit {
+			if row.FromOwnerID != "alice" || row.ToOwnerID != "bob" || row.ActorID != "admin" || row.Reason != "offboarding" {
+				t.Errorf("unexpected audit row: %+v", row)
+			}
+		}
+	})
+}
+
+func TestIntegrationLinkRepository_TransferLinks_MissingLinkRollsBack(t *testing.T) {
+	forEachBackend(t, newLinkTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		link := testutil.NewTestLink(t, testutil.UniqueShortCode("xfer-miss"))
+		link.OwnerID = "alice"
+		if err := repo.CreateLink(ctx, link); err != nil {
+			t.Fatalf("CreateLink failed: %v", err)
+		}
+
+		_, err := repo.TransferLinks(ctx, LinkTransferParams{
+			TransferID: "transfer-2",
+			LinkIDs:    []string{link.ID, "missing-link"},
+			ToOwnerID:  "bob",
+			ActorID:    "admin",
+		})
+		if !errors.Is(err, ErrLinkNotFound) {
+			t.Fatalf("expected ErrLinkNotFound, got %v", err)
+		}
+
+		retrieved, err := repo.GetLinkByID(ctx, link.ID)
+		if err != nil {
+			t.Fatalf("GetLinkByID failed: %v", err)
+		}
+		if retrieved.OwnerID != "alice" {
+			t.Errorf("OwnerID = %q, want alice after rollback", retrieved.OwnerID)
+		}
+
+		audit, err := repo.ListLinkTransfers(ctx, LinkTransferFilter{LinkID: link.ID}, 10)
+		if err != nil {
+			t.Fatalf("ListLinkTransfers failed: %v", err)
+		}
+		if len(audit) != 0 {
+			t.Errorf("expected no audit rows, got %d", len(audit))
+		}
+	})
+}
@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/penshort/penshort/internal/testutil"
@@ -26,8 +27,11 @@ func TestIntegrationMigration_ApplyAllTables(t *testing.T) {
 		"api_keys",
 		"click_events",
 		"daily_link_stats",
+		"hourly_link_stats",
 		"webhook_endpoints",
 		"webhook_deliveries",
+		"error_page_settings",
+		"link_ownership_transfers",
 	}
 
 	for _, table := range tables {
@@ -185,6 +189,39 @@ func TestIntegrationMigration_AnalyticsTablesSchema(t *testing.T) {
 	}
 }
 
+func TestIntegrationMigration_ClickEventsPartitioned(t *testing.T) {
+	ctx, pool := newMigrationTestEnv(t)
+
+	var partitioned bool
+	if err := pool.QueryRow(ctx, `
+		SELECT EXISTS (SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'click_events'::regclass)
+	`).Scan(&partitioned); err != nil {
+		t.Fatalf("query partitioning: %v", err)
+	}
+	if !partitioned {
+		t.Fatal("click_events should be partitioned")
+	}
+
+	// The current month's partition exists
+	name := clickPartitionPrefix + time.Now().UTC().Format("2006_01")
+	exists, err := tableExists(ctx, pool, name)
+	if err != nil {
+		t.Fatalf("tableExists failed: %v", err)
+	}
+	if !exists {
+		t.Errorf("Partition %q should exist after migrations", name)
+	}
+
+	// Events outside the monthly partitions have somewhere to go
+	exists, err = tableExists(ctx, pool, clickDefaultPartition)
+	if err != nil {
+		t.Fatalf("tableExists failed: %v", err)
+	}
+	if !exists {
+		t.Errorf("Partition %q should exist after migrations", clickDefaultPartition)
+	}
+}
+
 func TestIntegrationMigration_WebhookTablesSchema(t *testing.T) {
 	ctx, pool := newMigrationTestEnv(t)
 
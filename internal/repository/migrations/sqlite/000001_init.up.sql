@@ -0,0 +1,203 @@
+-- SQLite schema for single-binary deployments
+-- Migration: 000001_init.up.sql
+--
+-- Mirrors the PostgreSQL schema in /migrations as of 000011. Differences:
+--   * TEXT[] and JSONB columns are stored as JSON text
+--   * timestamps are written by the application in UTC
+--   * updated_at is set by the application instead of a trigger
+
+-- ============================================================================
+-- LINKS
+-- ============================================================================
+CREATE TABLE links (
+    id                TEXT PRIMARY KEY,                 -- ULID
+    short_code        TEXT NOT NULL,
+    destination       TEXT NOT NULL,
+    redirect_type     INTEGER NOT NULL DEFAULT 302,
+    owner_id          TEXT NOT NULL DEFAULT 'system',
+    enabled           BOOLEAN NOT NULL DEFAULT TRUE,
+    expires_at        TIMESTAMP,
+    fallback_url      TEXT,
+    allowed_countries TEXT,                             -- JSON array
+    blocked_countries TEXT,                             -- JSON array
+    geo_blocked_url   TEXT,
+    deleted_at        TIMESTAMP,
+    click_count       INTEGER NOT NULL DEFAULT 0,
+    created_at        TIMESTAMP NOT NULL,
+    updated_at        TIMESTAMP NOT NULL,
+
+    CONSTRAINT chk_redirect_type CHECK (redirect_type IN (301, 302)),
+    CONSTRAINT chk_destination_length CHECK (LENGTH(destination) <= 2048),
+    CONSTRAINT chk_short_code_length CHECK (LENGTH(short_code) BETWEEN 3 AND 50),
+    CONSTRAINT chk_fallback_url_length CHECK (fallback_url IS NULL OR LENGTH(fallback_url) <= 2048),
+    CONSTRAINT chk_geo_blocked_url_length CHECK (geo_blocked_url IS NULL OR LENGTH(geo_blocked_url) <= 2048)
+);
+
+CREATE UNIQUE INDEX idx_links_short_code
+    ON links (short_code)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_links_owner_created
+    ON links (owner_id, created_at DESC)
+    WHERE deleted_at IS NULL;
+
+CREATE INDEX idx_links_expires_at
+    ON links (expires_at)
+    WHERE expires_at IS NOT NULL AND deleted_at IS NULL;
+
+-- ============================================================================
+-- USERS AND API KEYS
+-- ============================================================================
+CREATE TABLE users (
+    id              TEXT PRIMARY KEY,                 -- ULID
+    email           TEXT UNIQUE NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE TABLE api_keys (
+    id              TEXT PRIMARY KEY,                 -- ULID
+    user_id         TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key_hash        TEXT NOT NULL,
+    key_prefix      TEXT NOT NULL,
+    scopes          TEXT NOT NULL DEFAULT '[]',       -- JSON array
+    rate_limit_tier TEXT NOT NULL DEFAULT 'free',
+    revoked_at      TIMESTAMP,
+    last_used_at    TIMESTAMP,
+    created_at      TIMESTAMP NOT NULL,
+    name            TEXT,
+
+    CONSTRAINT chk_key_prefix_length CHECK (LENGTH(key_prefix) = 6),
+    CONSTRAINT chk_rate_limit_tier CHECK (rate_limit_tier IN ('free', 'pro', 'unlimited'))
+);
+
+CREATE INDEX idx_api_keys_user_id
+    ON api_keys (user_id)
+    WHERE revoked_at IS NULL;
+
+CREATE INDEX idx_api_keys_prefix
+    ON api_keys (key_prefix)
+    WHERE revoked_at IS NULL;
+
+-- ============================================================================
+-- ANALYTICS
+-- ============================================================================
+CREATE TABLE click_events (
+    id              TEXT PRIMARY KEY,                 -- ULID
+    event_id        TEXT NOT NULL UNIQUE,             -- Queue message ID (idempotency)
+    short_code      TEXT NOT NULL,
+    link_id         TEXT NOT NULL,
+    referrer        TEXT,
+    user_agent      TEXT,
+    visitor_hash    TEXT NOT NULL,
+    country_code    TEXT,
+    blocked         BOOLEAN NOT NULL DEFAULT FALSE,
+    is_bot          BOOLEAN NOT NULL DEFAULT FALSE,
+    bot_family      TEXT,
+    clicked_at      TIMESTAMP NOT NULL,
+    created_at      TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_click_events_link_time
+    ON click_events (link_id, clicked_at DESC);
+
+CREATE TABLE daily_link_stats (
+    id                        TEXT PRIMARY KEY,       -- Composite: link_id:date
+    link_id                   TEXT NOT NULL,
+    date                      DATE NOT NULL,          -- UTC date, YYYY-MM-DD
+    total_clicks              INTEGER NOT NULL DEFAULT 0,
+    unique_visitors           INTEGER NOT NULL DEFAULT 0,
+    referrer_breakdown        TEXT DEFAULT '{}',
+    ua_family_breakdown       TEXT DEFAULT '{}',
+    country_breakdown         TEXT DEFAULT '{}',
+    blocked_clicks            INTEGER NOT NULL DEFAULT 0,
+    blocked_country_breakdown TEXT DEFAULT '{}',
+    bot_clicks                INTEGER NOT NULL DEFAULT 0,
+    bot_family_breakdown      TEXT DEFAULT '{}',
+    created_at                TIMESTAMP NOT NULL,
+    updated_at                TIMESTAMP NOT NULL,
+
+    CONSTRAINT uq_daily_link_stats UNIQUE (link_id, date)
+);
+
+-- ============================================================================
+-- WEBHOOKS
+-- ============================================================================
+CREATE TABLE webhook_endpoints (
+    id              TEXT PRIMARY KEY,                 -- ULID
+    user_id         TEXT NOT NULL,
+    target_url      TEXT NOT NULL,
+    secret_hash     TEXT NOT NULL,
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    event_types     TEXT NOT NULL DEFAULT '["click"]', -- JSON array
+    name            TEXT,
+    description     TEXT,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL,
+    deleted_at      TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_endpoints_user ON webhook_endpoints (user_id)
+    WHERE deleted_at IS NULL;
+
+CREATE TABLE webhook_deliveries (
+    id               TEXT PRIMARY KEY,                -- ULID
+    endpoint_id      TEXT NOT NULL REFERENCES webhook_endpoints(id),
+    event_id         TEXT NOT NULL,
+    event_type       TEXT NOT NULL DEFAULT 'click',
+    payload_json     TEXT NOT NULL,
+    status           TEXT NOT NULL DEFAULT 'pending',
+    attempt_count    INTEGER NOT NULL DEFAULT 0,
+    max_attempts     INTEGER NOT NULL DEFAULT 5,
+    next_retry_at    TIMESTAMP NOT NULL,
+    last_attempt_at  TIMESTAMP,
+    last_http_status INTEGER,
+    last_error       TEXT,
+    created_at       TIMESTAMP NOT NULL,
+    updated_at       TIMESTAMP NOT NULL
+);
+
+CREATE INDEX idx_webhook_deliveries_pending ON webhook_deliveries (next_retry_at)
+    WHERE status IN ('pending', 'failed');
+
+CREATE INDEX idx_webhook_deliveries_endpoint ON webhook_deliveries (endpoint_id, created_at DESC);
+
+CREATE UNIQUE INDEX idx_webhook_deliveries_event_endpoint
+    ON webhook_deliveries (event_id, endpoint_id);
+
+-- ============================================================================
+-- ERROR PAGES AND LINK TRANSFERS
+-- ============================================================================
+CREATE TABLE error_page_settings (
+    scope_type      TEXT NOT NULL,                    -- owner | domain
+    scope_id        TEXT NOT NULL,
+    fallback_url    TEXT,
+    brand_name      TEXT,
+    logo_url        TEXT,
+    accent_color    TEXT,
+    support_url     TEXT,
+    created_at      TIMESTAMP NOT NULL,
+    updated_at      TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (scope_type, scope_id),
+    CONSTRAINT chk_error_page_scope_type CHECK (scope_type IN ('owner', 'domain'))
+);
+
+CREATE TABLE link_ownership_transfers (
+    id              INTEGER PRIMARY KEY AUTOINCREMENT,
+    transfer_id     TEXT NOT NULL,
+    link_id         TEXT NOT NULL,
+    short_code      TEXT NOT NULL,
+    from_owner_id   TEXT NOT NULL,
+    to_owner_id     TEXT NOT NULL,
+    actor_id        TEXT NOT NULL,
+    reason          TEXT,
+    transferred_at  TIMESTAMP NOT NULL,
+
+    CONSTRAINT chk_transfer_reason_length CHECK (reason IS NULL OR LENGTH(reason) <= 500)
+);
+
+CREATE INDEX idx_link_ownership_transfers_transfer
+    ON link_ownership_transfers (transfer_id);
+
+CREATE INDEX idx_link_ownership_transfers_link
+    ON link_ownership_transfers (link_id, transferred_at DESC);
@@ -0,0 +1,23 @@
+-- Hourly analytics rollups
+-- Migration: 000002_hourly_link_stats.up.sql
+--
+-- Mirrors PostgreSQL migration 000012.
+
+CREATE TABLE hourly_link_stats (
+    link_id                   TEXT NOT NULL,
+    hour                      TIMESTAMP NOT NULL,     -- Start of the UTC hour
+    total_clicks              INTEGER NOT NULL DEFAULT 0,
+    unique_visitors           INTEGER NOT NULL DEFAULT 0,
+    blocked_clicks            INTEGER NOT NULL DEFAULT 0,
+    bot_clicks                INTEGER NOT NULL DEFAULT 0,
+    referrer_breakdown        TEXT DEFAULT '{}',
+    country_breakdown         TEXT DEFAULT '{}',
+    blocked_country_breakdown TEXT DEFAULT '{}',
+    bot_family_breakdown      TEXT DEFAULT '{}',
+    created_at                TIMESTAMP NOT NULL,
+    updated_at                TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (link_id, hour)
+);
+
+CREATE INDEX idx_hourly_link_stats_hour ON hourly_link_stats (hour);
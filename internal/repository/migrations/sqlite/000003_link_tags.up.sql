@@ -0,0 +1,6 @@
+-- Link tags
+-- Migration: 000003_link_tags.up.sql
+--
+-- Mirrors PostgreSQL migration 000013.
+
+ALTER TABLE links ADD COLUMN tags TEXT;                 -- JSON array
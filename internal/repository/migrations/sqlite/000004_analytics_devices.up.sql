@@ -0,0 +1,11 @@
+-- Device, OS and browser breakdowns
+-- Migration: 000004_analytics_devices.up.sql
+--
+-- Mirrors PostgreSQL migration 000014.
+
+ALTER TABLE daily_link_stats ADD COLUMN device_breakdown TEXT DEFAULT '{}';
+ALTER TABLE daily_link_stats ADD COLUMN os_breakdown TEXT DEFAULT '{}';
+
+ALTER TABLE hourly_link_stats ADD COLUMN ua_family_breakdown TEXT DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN device_breakdown TEXT DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN os_breakdown TEXT DEFAULT '{}';
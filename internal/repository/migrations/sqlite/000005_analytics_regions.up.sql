@@ -0,0 +1,10 @@
+-- Region and city from the GeoIP database
+-- Migration: 000005_analytics_regions.up.sql
+--
+-- Mirrors PostgreSQL migration 000015.
+
+ALTER TABLE click_events ADD COLUMN region TEXT;
+ALTER TABLE click_events ADD COLUMN city TEXT;
+
+ALTER TABLE daily_link_stats ADD COLUMN region_breakdown TEXT DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN region_breakdown TEXT DEFAULT '{}';
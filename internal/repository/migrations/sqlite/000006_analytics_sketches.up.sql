@@ -0,0 +1,9 @@
+-- HyperLogLog sketches for unique visitors across days
+-- Migration: 000006_analytics_sketches.up.sql
+--
+-- Mirrors PostgreSQL migration 000016.
+
+ALTER TABLE click_events ADD COLUMN visitor_reg INTEGER;
+
+ALTER TABLE daily_link_stats ADD COLUMN visitor_sketch BLOB;
+ALTER TABLE hourly_link_stats ADD COLUMN visitor_sketch BLOB;
@@ -0,0 +1,33 @@
+-- Visitor seen-sets for incremental aggregation
+-- Migration: 000007_analytics_visitor_sets.up.sql
+--
+-- Mirrors PostgreSQL migration 000017.
+
+CREATE TABLE daily_link_visitors (
+    link_id                   TEXT NOT NULL,
+    date                      DATE NOT NULL,          -- UTC date, YYYY-MM-DD
+    visitor_hash              TEXT NOT NULL,
+
+    PRIMARY KEY (link_id, date, visitor_hash)
+);
+
+CREATE TABLE hourly_link_visitors (
+    link_id                   TEXT NOT NULL,
+    hour                      TIMESTAMP NOT NULL,     -- Start of the UTC hour
+    visitor_hash              TEXT NOT NULL,
+
+    PRIMARY KEY (link_id, hour, visitor_hash)
+);
+
+-- Timestamps are stored as UTC text, so the day and hour are prefixes
+INSERT INTO daily_link_visitors (link_id, date, visitor_hash)
+SELECT DISTINCT link_id, substr(clicked_at, 1, 10), visitor_hash
+FROM click_events
+WHERE NOT blocked AND NOT is_bot AND visitor_hash <> ''
+  AND substr(clicked_at, 1, 10) >= (SELECT substr(MIN(hour), 1, 10) FROM hourly_link_stats);
+
+INSERT INTO hourly_link_visitors (link_id, hour, visitor_hash)
+SELECT DISTINCT link_id, substr(clicked_at, 1, 13) || ':00:00+00:00', visitor_hash
+FROM click_events
+WHERE NOT blocked AND NOT is_bot AND visitor_hash <> ''
+  AND substr(clicked_at, 1, 10) >= (SELECT substr(MIN(hour), 1, 10) FROM hourly_link_stats);
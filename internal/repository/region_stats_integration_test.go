@@ -0,0 +1,66 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_RegionBreakdown(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		day := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+		events := []*model.ClickEvent{
+			testClickEvent("region-link", "v1", day.Add(time.Hour)),
+			testClickEvent("region-link", "v2", day.Add(time.Hour)),
+			testClickEvent("region-link", "v3", day.Add(2*time.Hour)),
+		}
+		for _, event := range events[:2] {
+			event.CountryCode, event.Region, event.City = "US", "US-CA", "San Francisco"
+		}
+		events[2].CountryCode = "DE"
+
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateDailyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateDailyStats: %v", err)
+		}
+		if err := clicks.UpdateHourlyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateHourlyStats: %v", err)
+		}
+
+		daily, err := clicks.GetDailyStats(ctx, "region-link", day, day)
+		if err != nil {
+			t.Fatalf("GetDailyStats: %v", err)
+		}
+		if len(daily) != 1 || daily[0].RegionBreakdown["US-CA"] != 2 || len(daily[0].RegionBreakdown) != 1 {
+			t.Fatalf("daily regions = %+v, want 2 clicks from US-CA", daily)
+		}
+
+		hours, err := clicks.GetHourlyStats(ctx, "region-link", day, day.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		if len(hours) != 2 || hours[1].RegionBreakdown["US-CA"] != 2 {
+			t.Errorf("hourly regions = %+v, want US-CA at 01:00", hours)
+		}
+
+		var exported []*model.ClickEvent
+		err = clicks.ExportClickEvents(ctx, ClickExportFilter{LinkID: "region-link"}, func(event *model.ClickEvent) error {
+			exported = append(exported, event)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("ExportClickEvents: %v", err)
+		}
+		if len(exported) != 3 || exported[0].Region != "US-CA" || exported[0].City != "San Francisco" {
+			t.Errorf("exported = %+v, want region and city on the first rows", exported)
+		}
+	})
+}
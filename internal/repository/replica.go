@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"log/slog"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Pool labels used for metrics.
+const (
+	PoolPrimary = "primary"
+	PoolReplica = "replica"
+)
+
+// replicaPingTimeout bounds a single replica health check.
+const replicaPingTimeout = 2 * time.Second
+
+// sessionKey is the context key for a read-your-writes session.
+type sessionKey struct{}
+
+// session records whether a request has written through the repository.
+type session struct {
+	wrote atomic.Bool
+}
+
+// WithSession returns a context that remembers writes made through it, so
+// later replica-eligible reads with the same context stay on the primary.
+func WithSession(ctx context.Context) context.Context {
+	return context.WithValue(ctx, sessionKey{}, &session{})
+}
+
+// writer returns the primary pool and marks the request's session, if any,
+// as having written.
+func (r *Repository) writer(ctx context.Context) *pgxpool.Pool {
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok {
+		s.wrote.Store(true)
+	}
+	return r.pool
+}
+
+// reader returns the pool for a read that tolerates replication lag: the
+// replica when one is configured and healthy, otherwise the primary. Reads
+// after a write in the same session always go to the primary.
+func (r *Repository) reader(ctx context.Context) *pgxpool.Pool {
+	if r.replica == nil || !r.replicaHealthy.Load() {
+		r.metrics.IncDBRead(PoolPrimary)
+		return r.pool
+	}
+	if s, ok := ctx.Value(sessionKey{}).(*session); ok && s.wrote.Load() {
+		r.metrics.IncDBRead(PoolPrimary)
+		return r.pool
+	}
+	r.metrics.IncDBRead(PoolReplica)
+	return r.replica
+}
+
+// HasReplica reports whether a read replica is configured.
+func (r *Repository) HasReplica() bool {
+	return r.replica != nil
+}
+
+// MonitorPools pings the replica every interval, routing reads to the
+// primary while it is unreachable, and records connection stats for each
+// pool. It blocks until ctx is cancelled.
+func (r *Repository) MonitorPools(ctx context.Context, interval time.Duration, logger *slog.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		r.checkReplica(ctx, logger)
+		r.recordPoolStats()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkReplica updates replica health from a single ping.
+func (r *Repository) checkReplica(ctx context.Context, logger *slog.Logger) {
+	if r.replica == nil {
+		return
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, replicaPingTimeout)
+	err := r.replica.Ping(pingCtx)
+	cancel()
+	if ctx.Err() != nil {
+		return
+	}
+
+	healthy := err == nil
+	if r.replicaHealthy.Swap(healthy) != healthy {
+		if healthy {
+			logger.Info("read replica recovered; routing reads to replica")
+		} else {
+			logger.Warn("read replica unhealthy; routing reads to primary", "error", err)
+		}
+	}
+	r.metrics.SetDBReplicaHealthy(healthy)
+}
+
+// recordPoolStats publishes connection counts for each pool.
+func (r *Repository) recordPoolStats() {
+	stat := r.pool.Stat()
+	r.metrics.SetDBPoolConns(PoolPrimary, int64(stat.AcquiredConns()), int64(stat.IdleConns()), int64(stat.TotalConns()))
+	if r.replica != nil {
+		stat = r.replica.Stat()
+		r.metrics.SetDBPoolConns(PoolReplica, int64(stat.AcquiredConns()), int64(stat.IdleConns()), int64(stat.TotalConns()))
+	}
+}
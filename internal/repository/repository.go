@@ -4,17 +4,46 @@ package repository
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 
 	"github.com/jackc/pgx/v5/pgxpool"
+
+	"github.com/penshort/penshort/internal/metrics"
 )
 
 // Repository provides database access methods.
 type Repository struct {
-	pool *pgxpool.Pool
+	pool           *pgxpool.Pool
+	replica        *pgxpool.Pool // nil without a read URL
+	replicaHealthy atomic.Bool
+	metrics        metrics.Recorder
+}
+
+// New creates a new Repository with a connection pool. When readURL is
+// non-empty a second pool is opened against that read replica and used for
+// reads that tolerate replication lag.
+func New(ctx context.Context, databaseURL, readURL string) (*Repository, error) {
+	pool, err := openPool(ctx, databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repository{pool: pool, metrics: metrics.NewNoop()}
+	if readURL != "" {
+		replica, err := openPool(ctx, readURL)
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("read replica: %w", err)
+		}
+		r.replica = replica
+		r.replicaHealthy.Store(true)
+	}
+
+	return r, nil
 }
 
-// New creates a new Repository with a connection pool.
-func New(ctx context.Context, databaseURL string) (*Repository, error) {
+// openPool creates a connection pool and verifies connectivity.
+func openPool(ctx context.Context, databaseURL string) (*pgxpool.Pool, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse database URL: %w", err)
@@ -35,17 +64,28 @@ func New(ctx context.Context, databaseURL string) (*Repository, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	return &Repository{pool: pool}, nil
+	return pool, nil
+}
+
+// SetMetrics sets the recorder for read routing and pool metrics.
+func (r *Repository) SetMetrics(recorder metrics.Recorder) {
+	if recorder != nil {
+		r.metrics = recorder
+	}
 }
 
-// Ping checks database connectivity.
+// Ping checks primary database connectivity. An unhealthy replica does not
+// fail readiness since reads fall back to the primary.
 func (r *Repository) Ping(ctx context.Context) error {
 	return r.pool.Ping(ctx)
 }
 
-// Close closes the database connection pool.
+// Close closes the database connection pools.
 func (r *Repository) Close() {
 	r.pool.Close()
+	if r.replica != nil {
+		r.replica.Close()
+	}
 }
 
 // Pool returns the underlying connection pool.
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	// Registers the "sqlite3" database/sql driver. Requires cgo.
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// SQLiteScheme is the DATABASE_URL prefix that selects the SQLite backend,
+// e.g. sqlite:///var/lib/penshort/penshort.db or sqlite://penshort.db.
+const SQLiteScheme = "sqlite:"
+
+//go:embed migrations/sqlite/*.up.sql
+var sqliteMigrations embed.FS
+
+// SQLiteStore implements Store on a single SQLite database file. It is meant
+// for single-instance deployments; run one API process per file.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// IsSQLiteURL reports whether databaseURL selects the SQLite backend.
+func IsSQLiteURL(databaseURL string) bool {
+	return strings.HasPrefix(databaseURL, SQLiteScheme)
+}
+
+// OpenSQLite opens (creating if needed) the database file named by a
+// sqlite: URL and applies pending migrations.
+func OpenSQLite(ctx context.Context, databaseURL string) (*SQLiteStore, error) {
+	dsn, err := sqliteDSN(databaseURL)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+	db.SetMaxOpenConns(10)
+
+	if err := db.PingContext(ctx); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	if err := migrateSQLite(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return &SQLiteStore{db: db}, nil
+}
+
+// sqliteDSN converts a sqlite: URL into a go-sqlite3 DSN. WAL mode and a
+// busy timeout let readers run alongside the single writer; immediate
+// transactions avoid deadlocks when a reader upgrades to a writer.
+func sqliteDSN(databaseURL string) (string, error) {
+	rest := strings.TrimPrefix(databaseURL, SQLiteScheme)
+	rest = strings.TrimPrefix(rest, "//")
+
+	file, query, _ := strings.Cut(rest, "?")
+	if file == "" {
+		return "", fmt.Errorf("failed to parse database URL: missing sqlite file path")
+	}
+
+	dsn := "file:" + file + "?_busy_timeout=5000&_foreign_keys=on&_journal_mode=WAL&_txlock=immediate"
+	if query != "" {
+		dsn += "&" + query
+	}
+	return dsn, nil
+}
+
+// migrateSQLite applies the embedded migrations that have not run yet, each
+// in its own transaction.
+func migrateSQLite(ctx context.Context, db *sql.DB) error {
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version    INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %w", err)
+	}
+
+	files, err := fs.Glob(sqliteMigrations, "migrations/sqlite/*.up.sql")
+	if err != nil {
+		return fmt.Errorf("failed to list sqlite migrations: %w", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		name := path.Base(file)
+		prefix, _, _ := strings.Cut(name, "_")
+		version, err := strconv.Atoi(prefix)
+		if err != nil {
+			return fmt.Errorf("invalid sqlite migration name %s: %w", name, err)
+		}
+
+		script, err := sqliteMigrations.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if err := applySQLiteMigration(ctx, db, version, string(script)); err != nil {
+			return fmt.Errorf("failed to apply %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// applySQLiteMigration runs one migration unless it is already recorded.
+func applySQLiteMigration(ctx context.Context, db *sql.DB, version int, script string) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	var applied bool
+	err = tx.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM schema_migrations WHERE version = ?)`, version,
+	).Scan(&applied)
+	if err != nil {
+		return err
+	}
+	if applied {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)`,
+		version, time.Now().UTC(),
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Ping checks database connectivity.
+func (s *SQLiteStore) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() {
+	_ = s.db.Close()
+}
+
+// DB returns the underlying database, shared with the webhook repository.
+func (s *SQLiteStore) DB() *sql.DB {
+	return s.db
+}
+
+// sqliteTime normalizes a timestamp to UTC. Timestamps are stored as text,
+// so every value must share one zone for comparisons and ordering to hold.
+func sqliteTime(t time.Time) time.Time {
+	return t.UTC()
+}
+
+// sqliteNullTime is sqliteTime for optional timestamps.
+func sqliteNullTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
+
+// sqliteDate formats the DATE columns used for daily aggregates.
+func sqliteDate(t time.Time) string {
+	return t.UTC().Format("2006-01-02")
+}
+
+// sqliteStrings stores a string slice as a JSON array. A nil slice is
+// stored as NULL.
+type sqliteStrings []string
+
+// Value implements driver.Valuer.
+func (s sqliteStrings) Value() (driver.Value, error) {
+	if s == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal([]string(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan implements sql.Scanner.
+func (s *sqliteStrings) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), (*[]string)(s))
+	case []byte:
+		return json.Unmarshal(v, (*[]string)(s))
+	default:
+		return fmt.Errorf("cannot scan %T into string list", src)
+	}
+}
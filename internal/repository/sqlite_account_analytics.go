@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// sqliteWhere returns the WHERE clause over daily_link_stats s joined with
+// links l, with its positional arguments.
+func (f AccountStatsFilter) sqliteWhere() (string, []any) {
+	clause := `
+		WHERE l.owner_id = ? AND l.deleted_at IS NULL
+		  AND s.date >= ? AND s.date <= ?
+	`
+	args := []any{f.OwnerID, sqliteDate(f.From), sqliteDate(f.To)}
+
+	if len(f.LinkIDs) > 0 {
+		clause += " AND l.id IN (?" + strings.Repeat(", ?", len(f.LinkIDs)-1) + ")"
+		for _, id := range f.LinkIDs {
+			args = append(args, id)
+		}
+	}
+	if f.Tag != "" {
+		clause += " AND EXISTS (SELECT 1 FROM json_each(l.tags) WHERE json_each.value = ?)"
+		args = append(args, f.Tag)
+	}
+	if f.CreatedAfter != nil {
+		clause += " AND l.created_at >= ?"
+		args = append(args, sqliteTime(*f.CreatedAfter))
+	}
+	if f.CreatedBefore != nil {
+		clause += " AND l.created_at <= ?"
+		args = append(args, sqliteTime(*f.CreatedBefore))
+	}
+
+	return clause, args
+}
+
+// GetAccountDailyStats returns clicks per day summed across the filtered links,
+// newest first.
+func (r *SQLiteClickEventRepository) GetAccountDailyStats(ctx context.Context, filter AccountStatsFilter) ([]model.DailyBreakdown, error) {
+	where, args := filter.sqliteWhere()
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT s.date, SUM(s.total_clicks), SUM(s.unique_visitors),
+			SUM(s.blocked_clicks), SUM(s.bot_clicks)
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+	`+where+`
+		GROUP BY s.date
+		ORDER BY s.date DESC
+	`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query account daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var daily []model.DailyBreakdown
+	for rows.Next() {
+		var day model.DailyBreakdown
+		var date time.Time
+		if err := rows.Scan(&date, &day.TotalClicks, &day.UniqueVisitors, &day.BlockedClicks, &day.BotClicks); err != nil {
+			return nil, fmt.Errorf("scan account daily stat: %w", err)
+		}
+		day.Date = date.Format("2006-01-02")
+		daily = append(daily, day)
+	}
+
+	return daily, rows.Err()
+}
+
+// GetAccountTopLinks returns the filtered links with the most clicks.
+func (r *SQLiteClickEventRepository) GetAccountTopLinks(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.LinkClicks, error) {
+	where, args := filter.sqliteWhere()
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT l.id, l.short_code, SUM(s.total_clicks) AS clicks, SUM(s.unique_visitors)
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+	`+where+`
+		GROUP BY l.id, l.short_code
+		ORDER BY clicks DESC, l.id
+		LIMIT ?
+	`, append(args, limit)...)
+	if err != nil {
+		return nil, fmt.Errorf("query account top links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []model.LinkClicks
+	for rows.Next() {
+		var link model.LinkClicks
+		if err := rows.Scan(&link.LinkID, &link.ShortCode, &link.TotalClicks, &link.UniqueVisitors); err != nil {
+			return nil, fmt.Errorf("scan account top link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, rows.Err()
+}
+
+// GetAccountTopReferrers returns the top referrer domains across the filtered links.
+func (r *SQLiteClickEventRepository) GetAccountTopReferrers(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.ReferrerBreakdown, error) {
+	rows, err := r.queryAccountBreakdown(ctx, "referrer_breakdown", filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query account top referrers: %w", err)
+	}
+	defer rows.Close()
+
+	var referrers []model.ReferrerBreakdown
+	for rows.Next() {
+		var ref model.ReferrerBreakdown
+		if err := rows.Scan(&ref.Domain, &ref.Clicks); err != nil {
+			return nil, fmt.Errorf("scan referrer: %w", err)
+		}
+		referrers = append(referrers, ref)
+	}
+
+	return referrers, rows.Err()
+}
+
+// GetAccountTopCountries returns the top countries across the filtered links.
+func (r *SQLiteClickEventRepository) GetAccountTopCountries(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.CountryBreakdown, error) {
+	rows, err := r.queryAccountBreakdown(ctx, "country_breakdown", filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query account top countries: %w", err)
+	}
+	defer rows.Close()
+
+	var countries []model.CountryBreakdown
+	for rows.Next() {
+		var c model.CountryBreakdown
+		if err := rows.Scan(&c.Code, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("scan country: %w", err)
+		}
+		c.Name = countryName(c.Code)
+		countries = append(countries, c)
+	}
+
+	return countries, rows.Err()
+}
+
+// queryAccountBreakdown sums one JSON breakdown column across the filtered
+// links. column is always a constant from this file.
+func (r *SQLiteClickEventRepository) queryAccountBreakdown(ctx context.Context, column string, filter AccountStatsFilter, limit int) (*sql.Rows, error) {
+	where, args := filter.sqliteWhere()
+	query := fmt.Sprintf(`
+		SELECT j.key, SUM(j.value) AS clicks
+		FROM daily_link_stats s
+		JOIN links l ON l.id = s.link_id
+		JOIN json_each(s.%s) AS j
+	`, column) + where + `
+		GROUP BY j.key
+		ORDER BY clicks DESC
+		LIMIT ?
+	`
+
+	return r.store.db.QueryContext(ctx, query, append(args, limit)...)
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// sqliteAPIKeyColumns is the column list shared by the API key queries.
+const sqliteAPIKeyColumns = `id, user_id, key_hash, key_prefix, scopes, rate_limit_tier, name, revoked_at, last_used_at, created_at`
+
+// CreateAPIKey inserts a new API key into the database.
+func (s *SQLiteStore) CreateAPIKey(ctx context.Context, key *model.APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, key_hash, key_prefix, scopes, rate_limit_tier, name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	scopes := key.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	_, err := s.db.ExecContext(ctx, query,
+		key.ID,
+		key.UserID,
+		key.KeyHash,
+		key.KeyPrefix,
+		sqliteStrings(scopes),
+		key.RateLimitTier,
+		key.Name,
+		sqliteTime(key.CreatedAt),
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+// GetAPIKeyByID retrieves an API key by its ID.
+func (s *SQLiteStore) GetAPIKeyByID(ctx context.Context, id string) (*model.APIKey, error) {
+	query := `SELECT ` + sqliteAPIKeyColumns + ` FROM api_keys WHERE id = ?`
+
+	key, err := scanSQLiteAPIKey(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, fmt.Errorf("failed to scan API key: %w", err)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeysByPrefix retrieves all active API keys matching a prefix.
+func (s *SQLiteStore) GetAPIKeysByPrefix(ctx context.Context, prefix string) ([]*model.APIKey, error) {
+	query := `SELECT ` + sqliteAPIKeyColumns + ` FROM api_keys WHERE key_prefix = ? AND revoked_at IS NULL`
+
+	keys, err := s.queryAPIKeys(ctx, query, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API keys by prefix: %w", err)
+	}
+
+	return keys, nil
+}
+
+// ListAPIKeysByUserID retrieves all API keys for a user.
+func (s *SQLiteStore) ListAPIKeysByUserID(ctx context.Context, userID string) ([]*model.APIKey, error) {
+	query := `SELECT ` + sqliteAPIKeyColumns + ` FROM api_keys WHERE user_id = ? ORDER BY created_at DESC`
+
+	keys, err := s.queryAPIKeys(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey revokes an API key by setting revoked_at.
+func (s *SQLiteStore) RevokeAPIKey(ctx context.Context, id string) error {
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
+
+// UpdateAPIKeyLastUsed updates the last_used_at timestamp.
+func (s *SQLiteStore) UpdateAPIKeyLastUsed(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET last_used_at = ? WHERE id = ?`,
+		time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update API key last used: %w", err)
+	}
+
+	return nil
+}
+
+// queryAPIKeys runs a query selecting sqliteAPIKeyColumns.
+func (s *SQLiteStore) queryAPIKeys(ctx context.Context, query string, args ...any) ([]*model.APIKey, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []*model.APIKey
+	for rows.Next() {
+		key, err := scanSQLiteAPIKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan API key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating API keys: %w", err)
+	}
+
+	return keys, nil
+}
+
+// scanSQLiteAPIKey scans a row selected with sqliteAPIKeyColumns.
+func scanSQLiteAPIKey(row sqliteScanner) (*model.APIKey, error) {
+	var key model.APIKey
+	var name sql.NullString
+	err := row.Scan(
+		&key.ID,
+		&key.UserID,
+		&key.KeyHash,
+		&key.KeyPrefix,
+		(*sqliteStrings)(&key.Scopes),
+		&key.RateLimitTier,
+		&name,
+		&key.RevokedAt,
+		&key.LastUsedAt,
+		&key.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	key.Name = name.String
+	return &key, nil
+}
@@ -0,0 +1,442 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/hll"
+	"github.com/penshort/penshort/internal/model"
+)
+
+// SQLiteClickEventRepository implements ClickEventStore on a SQLiteStore.
+type SQLiteClickEventRepository struct {
+	store *SQLiteStore
+}
+
+// NewSQLiteClickEventRepository creates a new SQLiteClickEventRepository.
+func NewSQLiteClickEventRepository(store *SQLiteStore) *SQLiteClickEventRepository {
+	return &SQLiteClickEventRepository{store: store}
+}
+
+// BulkInsert inserts click events in one transaction, skipping event IDs
+// that were already stored. It leaves the stats alone; the worker records
+// events with RecordClickEvents.
+func (r *SQLiteClickEventRepository) BulkInsert(ctx context.Context, events []*model.ClickEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin bulk insert: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	if _, err := insertSQLiteClickEvents(ctx, tx, events); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// insertSQLiteClickEvents inserts click events, skipping event IDs that were
+// already stored, and returns the events it inserted.
+func insertSQLiteClickEvents(ctx context.Context, tx *sql.Tx, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO click_events (
+			id, event_id, short_code, link_id, referrer, user_agent,
+			visitor_hash, visitor_reg, country_code, region, city, blocked, is_bot, bot_family,
+			clicked_at, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (event_id) DO NOTHING
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("prepare bulk insert: %w", err)
+	}
+	defer stmt.Close()
+
+	now := time.Now().UTC()
+	inserted := make([]*model.ClickEvent, 0, len(events))
+	for i, event := range events {
+		result, err := stmt.ExecContext(ctx,
+			event.ID,
+			event.EventID,
+			event.ShortCode,
+			event.LinkID,
+			nullableString(event.Referrer),
+			nullableString(event.UserAgent),
+			event.VisitorHash,
+			nullableRegister(event.VisitorReg),
+			nullableString(event.CountryCode),
+			nullableString(event.Region),
+			nullableString(event.City),
+			event.Blocked,
+			event.IsBot,
+			nullableString(event.BotFamily),
+			sqliteTime(event.ClickedAt),
+			now,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("batch insert event %d: %w", i, err)
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return nil, fmt.Errorf("batch insert event %d: %w", i, err)
+		} else if n > 0 {
+			inserted = append(inserted, event)
+		}
+	}
+
+	return inserted, nil
+}
+
+// UpdateDailyStats recomputes the daily_link_stats rows touched by events
+// from the stored click events, replacing their visitor sets. The worker
+// merges batches with RecordClickEvents instead; this is the repair path.
+func (r *SQLiteClickEventRepository) UpdateDailyStats(ctx context.Context, events []*model.ClickEvent) error {
+	for _, key := range uniqueDailyKeys(events) {
+		if err := r.recomputeDailyStat(ctx, key.linkID, key.date); err != nil {
+			return fmt.Errorf("recompute daily stat %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
+		}
+	}
+
+	return nil
+}
+
+// recomputeDailyStat rebuilds one link-day from its click events.
+func (r *SQLiteClickEventRepository) recomputeDailyStat(ctx context.Context, linkID string, date time.Time) error {
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	// Writing first takes the database lock for the whole recompute
+	if _, err := lockSQLiteDailyStat(ctx, tx, linkID, date); err != nil {
+		return err
+	}
+	acc, err := recalculateSQLiteStats(ctx, tx, linkID, date, date.Add(24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM daily_link_visitors WHERE link_id = ? AND date = ?`,
+		linkID, sqliteDate(date),
+	); err != nil {
+		return err
+	}
+	if _, err := addSQLiteDailyVisitors(ctx, tx, linkID, date, acc.visitorHashes()); err != nil {
+		return err
+	}
+	if err := upsertSQLiteDailyStat(ctx, tx, acc); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// recalculateSQLiteStats aggregates a link's click events in [start, end).
+func recalculateSQLiteStats(ctx context.Context, tx *sql.Tx, linkID string, start, end time.Time) (*dailyStatsAccumulator, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT COALESCE(referrer, ''), COALESCE(user_agent, ''), COALESCE(country_code, ''),
+			COALESCE(region, ''), visitor_hash, COALESCE(visitor_reg, 0), blocked, is_bot, COALESCE(bot_family, '')
+		FROM click_events
+		WHERE link_id = ? AND clicked_at >= ? AND clicked_at < ?
+	`, linkID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query click events: %w", err)
+	}
+	defer rows.Close()
+
+	events := make([]*model.ClickEvent, 0)
+	for rows.Next() {
+		var event model.ClickEvent
+		if err := rows.Scan(&event.Referrer, &event.UserAgent, &event.CountryCode, &event.Region, &event.VisitorHash, &event.VisitorReg, &event.Blocked, &event.IsBot, &event.BotFamily); err != nil {
+			return nil, fmt.Errorf("scan click event: %w", err)
+		}
+		events = append(events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate click events: %w", err)
+	}
+
+	acc := accumulateDailyStats(events)
+	acc.linkID = linkID
+	acc.date = start
+	return acc, nil
+}
+
+// upsertSQLiteDailyStat inserts or updates a daily_link_stats row.
+func upsertSQLiteDailyStat(ctx context.Context, tx *sql.Tx, acc *dailyStatsAccumulator) error {
+	referrerJSON, _ := json.Marshal(acc.referrers)
+	countryJSON, _ := json.Marshal(acc.countries)
+	blockedCountryJSON, _ := json.Marshal(acc.blockedCountries)
+	botFamilyJSON, _ := json.Marshal(acc.botFamilies)
+	browserJSON, _ := json.Marshal(acc.browsers)
+	deviceJSON, _ := json.Marshal(acc.devices)
+	osJSON, _ := json.Marshal(acc.osFamilies)
+	regionJSON, _ := json.Marshal(acc.regions)
+	sketch, _ := acc.visitors.MarshalBinary()
+	id := fmt.Sprintf("%s:%s", acc.linkID, acc.date.Format("2006-01-02"))
+	now := time.Now().UTC()
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO daily_link_stats (
+			id, link_id, date, total_clicks, unique_visitors,
+			referrer_breakdown, country_breakdown,
+			blocked_clicks, blocked_country_breakdown,
+			bot_clicks, bot_family_breakdown,
+			ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown, visitor_sketch, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (link_id, date) DO UPDATE SET
+			total_clicks = excluded.total_clicks,
+			unique_visitors = excluded.unique_visitors,
+			referrer_breakdown = excluded.referrer_breakdown,
+			country_breakdown = excluded.country_breakdown,
+			blocked_clicks = excluded.blocked_clicks,
+			blocked_country_breakdown = excluded.blocked_country_breakdown,
+			bot_clicks = excluded.bot_clicks,
+			bot_family_breakdown = excluded.bot_family_breakdown,
+			ua_family_breakdown = excluded.ua_family_breakdown,
+			device_breakdown = excluded.device_breakdown,
+			os_breakdown = excluded.os_breakdown,
+			region_breakdown = excluded.region_breakdown,
+			visitor_sketch = excluded.visitor_sketch,
+			updated_at = excluded.updated_at
+	`,
+		id,
+		acc.linkID,
+		sqliteDate(acc.date),
+		acc.totalClicks,
+		acc.uniqueVisitors,
+		string(referrerJSON),
+		string(countryJSON),
+		acc.blockedClicks,
+		string(blockedCountryJSON),
+		acc.botClicks,
+		string(botFamilyJSON),
+		string(browserJSON),
+		string(deviceJSON),
+		string(osJSON),
+		string(regionJSON),
+		sketch,
+		now,
+		now,
+	)
+
+	return err
+}
+
+// GetDailyStats retrieves daily stats for a link within a date range.
+func (r *SQLiteClickEventRepository) GetDailyStats(ctx context.Context, linkID string, from, to time.Time) ([]*model.DailyLinkStats, error) {
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT id, link_id, date, total_clicks, unique_visitors,
+			   referrer_breakdown, ua_family_breakdown, country_breakdown,
+			   blocked_clicks, blocked_country_breakdown,
+			   bot_clicks, bot_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
+			   created_at, updated_at
+		FROM daily_link_stats
+		WHERE link_id = ? AND date >= ? AND date <= ?
+		ORDER BY date DESC
+	`, linkID, sqliteDate(from), sqliteDate(to))
+	if err != nil {
+		return nil, fmt.Errorf("query daily stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.DailyLinkStats
+	for rows.Next() {
+		stat, err := scanSQLiteDailyStat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan daily stat: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// GetAnalyticsSummary retrieves aggregated analytics for a link.
+func (r *SQLiteClickEventRepository) GetAnalyticsSummary(ctx context.Context, linkID string, from, to time.Time) (*model.AnalyticsSummary, error) {
+	var totalClicks, uniqueVisitors, blockedClicks, botClicks int64
+	var days int
+
+	err := r.store.db.QueryRowContext(ctx, `
+		SELECT
+			COALESCE(SUM(total_clicks), 0),
+			COALESCE(SUM(unique_visitors), 0),
+			COALESCE(SUM(blocked_clicks), 0),
+			COALESCE(SUM(bot_clicks), 0),
+			COUNT(*)
+		FROM daily_link_stats
+		WHERE link_id = ? AND date >= ? AND date <= ?
+	`, linkID, sqliteDate(from), sqliteDate(to)).Scan(&totalClicks, &uniqueVisitors, &blockedClicks, &botClicks, &days)
+	if err != nil {
+		return nil, fmt.Errorf("query analytics summary: %w", err)
+	}
+
+	visitors, err := r.mergeVisitorSketches(ctx, linkID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var avgClicksPerDay float64
+	if days > 0 {
+		avgClicksPerDay = float64(totalClicks) / float64(days)
+	}
+
+	return &model.AnalyticsSummary{
+		TotalClicks:          totalClicks,
+		UniqueVisitors:       uniqueVisitors,
+		AvgClicksPerDay:      avgClicksPerDay,
+		BlockedClicks:        blockedClicks,
+		BotClicks:            botClicks,
+		UniqueVisitorsApprox: model.NewUniqueEstimate(visitors),
+	}, nil
+}
+
+// mergeVisitorSketches merges the visitor sketches of a link's days.
+func (r *SQLiteClickEventRepository) mergeVisitorSketches(ctx context.Context, linkID string, from, to time.Time) (*hll.Sketch, error) {
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT visitor_sketch
+		FROM daily_link_stats
+		WHERE link_id = ? AND date >= ? AND date <= ? AND visitor_sketch IS NOT NULL
+	`, linkID, sqliteDate(from), sqliteDate(to))
+	if err != nil {
+		return nil, fmt.Errorf("query visitor sketches: %w", err)
+	}
+	defer rows.Close()
+
+	var encoded [][]byte
+	for rows.Next() {
+		var sketch []byte
+		if err := rows.Scan(&sketch); err != nil {
+			return nil, fmt.Errorf("scan visitor sketch: %w", err)
+		}
+		encoded = append(encoded, sketch)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate visitor sketches: %w", err)
+	}
+
+	merged, err := hll.Merged(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("merge visitor sketches: %w", err)
+	}
+	return merged, nil
+}
+
+// GetTopReferrers returns the top referrer domains for a link.
+func (r *SQLiteClickEventRepository) GetTopReferrers(ctx context.Context, linkID string, from, to time.Time, limit int) ([]model.ReferrerBreakdown, error) {
+	rows, err := r.queryBreakdown(ctx, "referrer_breakdown", linkID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query top referrers: %w", err)
+	}
+	defer rows.Close()
+
+	var referrers []model.ReferrerBreakdown
+	for rows.Next() {
+		var ref model.ReferrerBreakdown
+		if err := rows.Scan(&ref.Domain, &ref.Clicks); err != nil {
+			return nil, fmt.Errorf("scan referrer: %w", err)
+		}
+		referrers = append(referrers, ref)
+	}
+
+	return referrers, rows.Err()
+}
+
+// GetTopCountries returns the top countries for a link.
+func (r *SQLiteClickEventRepository) GetTopCountries(ctx context.Context, linkID string, from, to time.Time, limit int) ([]model.CountryBreakdown, error) {
+	rows, err := r.queryBreakdown(ctx, "country_breakdown", linkID, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("query top countries: %w", err)
+	}
+	defer rows.Close()
+
+	var countries []model.CountryBreakdown
+	for rows.Next() {
+		var c model.CountryBreakdown
+		if err := rows.Scan(&c.Code, &c.Clicks); err != nil {
+			return nil, fmt.Errorf("scan country: %w", err)
+		}
+		c.Name = countryName(c.Code)
+		countries = append(countries, c)
+	}
+
+	return countries, rows.Err()
+}
+
+// queryBreakdown sums one JSON breakdown column across the date range.
+// column is always a constant from this file.
+func (r *SQLiteClickEventRepository) queryBreakdown(ctx context.Context, column, linkID string, from, to time.Time, limit int) (*sql.Rows, error) {
+	query := fmt.Sprintf(`
+		SELECT j.key, SUM(j.value) AS clicks
+		FROM daily_link_stats, json_each(daily_link_stats.%s) AS j
+		WHERE link_id = ? AND date >= ? AND date <= ?
+		GROUP BY j.key
+		ORDER BY clicks DESC
+		LIMIT ?
+	`, column)
+
+	return r.store.db.QueryContext(ctx, query, linkID, sqliteDate(from), sqliteDate(to), limit)
+}
+
+// scanSQLiteDailyStat scans a row into DailyLinkStats.
+func scanSQLiteDailyStat(rows *sql.Rows) (*model.DailyLinkStats, error) {
+	var stat model.DailyLinkStats
+	var referrerJSON, uaJSON, countryJSON, blockedCountryJSON, botFamilyJSON, deviceJSON, osJSON, regionJSON []byte
+
+	err := rows.Scan(
+		&stat.ID,
+		&stat.LinkID,
+		&stat.Date,
+		&stat.TotalClicks,
+		&stat.UniqueVisitors,
+		&referrerJSON,
+		&uaJSON,
+		&countryJSON,
+		&stat.BlockedClicks,
+		&blockedCountryJSON,
+		&stat.BotClicks,
+		&botFamilyJSON,
+		&deviceJSON,
+		&osJSON,
+		&regionJSON,
+		&stat.CreatedAt,
+		&stat.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(referrerJSON) > 0 {
+		_ = json.Unmarshal(referrerJSON, &stat.ReferrerBreakdown)
+	}
+	if len(uaJSON) > 0 {
+		_ = json.Unmarshal(uaJSON, &stat.UAFamilyBreakdown)
+	}
+	if len(countryJSON) > 0 {
+		_ = json.Unmarshal(countryJSON, &stat.CountryBreakdown)
+	}
+	if len(blockedCountryJSON) > 0 {
+		_ = json.Unmarshal(blockedCountryJSON, &stat.BlockedCountryBreakdown)
+	}
+	if len(botFamilyJSON) > 0 {
+		_ = json.Unmarshal(botFamilyJSON, &stat.BotFamilyBreakdown)
+	}
+	if len(deviceJSON) > 0 {
+		_ = json.Unmarshal(deviceJSON, &stat.DeviceBreakdown)
+	}
+	if len(osJSON) > 0 {
+		_ = json.Unmarshal(osJSON, &stat.OSBreakdown)
+	}
+	if len(regionJSON) > 0 {
+		_ = json.Unmarshal(regionJSON, &stat.RegionBreakdown)
+	}
+
+	return &stat, nil
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// sqliteQuery returns the export query and its arguments.
+func (f ClickExportFilter) sqliteQuery() (string, []any) {
+	userAgent := "''"
+	if f.IncludeUserAgent {
+		userAgent = "COALESCE(c.user_agent, '')"
+	}
+
+	query := `
+		SELECT c.id, c.link_id, c.short_code, COALESCE(c.referrer, ''), ` + userAgent + `,
+			c.visitor_hash, COALESCE(c.country_code, ''), COALESCE(c.region, ''), COALESCE(c.city, ''),
+			c.blocked, c.is_bot, COALESCE(c.bot_family, ''), c.clicked_at
+		FROM click_events c
+	`
+	var args []any
+	if f.OwnerID != "" {
+		query += " JOIN links l ON l.id = c.link_id WHERE l.owner_id = ? AND l.deleted_at IS NULL"
+		args = append(args, f.OwnerID)
+	} else {
+		query += " WHERE c.link_id = ?"
+		args = append(args, f.LinkID)
+	}
+
+	if !f.From.IsZero() {
+		query += " AND c.clicked_at >= ?"
+		args = append(args, sqliteTime(f.From))
+	}
+	if !f.To.IsZero() {
+		query += " AND c.clicked_at < ?"
+		args = append(args, sqliteTime(f.To))
+	}
+	if f.After != nil {
+		query += " AND (c.clicked_at, c.id) > (?, ?)"
+		args = append(args, sqliteTime(f.After.ClickedAt), f.After.ID)
+	}
+
+	query += " ORDER BY c.clicked_at, c.id"
+	if f.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, f.Limit)
+	}
+
+	return query, args
+}
+
+// ExportClickEvents streams the click events matching filter to fn in
+// (clicked_at, id) order. SQLite steps through the result one row at a time,
+// so memory stays bounded. An error from fn stops the export and is returned.
+func (r *SQLiteClickEventRepository) ExportClickEvents(ctx context.Context, filter ClickExportFilter, fn func(*model.ClickEvent) error) error {
+	query, args := filter.sqliteQuery()
+	rows, err := r.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("query click events: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var event model.ClickEvent
+		if err := rows.Scan(&event.ID, &event.LinkID, &event.ShortCode, &event.Referrer, &event.UserAgent,
+			&event.VisitorHash, &event.CountryCode, &event.Region, &event.City, &event.Blocked, &event.IsBot, &event.BotFamily, &event.ClickedAt); err != nil {
+			return fmt.Errorf("scan click event: %w", err)
+		}
+		if err := fn(&event); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("iterate click events: %w", err)
+	}
+
+	return nil
+}
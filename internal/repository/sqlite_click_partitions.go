@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// CreateClickPartitions is a no-op: SQLite keeps click events in a single
+// table.
+func (r *SQLiteClickEventRepository) CreateClickPartitions(ctx context.Context, from, through time.Time) (int, error) {
+	return 0, nil
+}
+
+// DropClickPartitions deletes the click events of UTC months that ended on
+// or before before, matching the partitions dropped on PostgreSQL. Daily and
+// hourly stats are kept. It returns the number of months removed.
+func (r *SQLiteClickEventRepository) DropClickPartitions(ctx context.Context, before time.Time) (int, error) {
+	cutoff := sqliteTime(monthStart(before))
+
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin drop click events: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	// Timestamps are stored as UTC text, so the month is a prefix
+	var months int
+	if err := tx.QueryRowContext(ctx, `
+		SELECT COUNT(DISTINCT substr(clicked_at, 1, 7)) FROM click_events WHERE clicked_at < ?
+	`, cutoff).Scan(&months); err != nil {
+		return 0, fmt.Errorf("count expired months: %w", err)
+	}
+	if months == 0 {
+		return 0, nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM click_events WHERE clicked_at < ?`, cutoff); err != nil {
+		return 0, fmt.Errorf("delete expired click events: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit drop click events: %w", err)
+	}
+	return months, nil
+}
@@ -0,0 +1,215 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// RecordClickEvents inserts click events and merges the new ones into the
+// daily and hourly stats in one transaction. Event IDs that were already
+// stored are skipped, so a redelivered batch changes nothing. It returns
+// the events that were newly recorded.
+func (r *SQLiteClickEventRepository) RecordClickEvents(ctx context.Context, events []*model.ClickEvent) ([]*model.ClickEvent, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin record: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	inserted, err := insertSQLiteClickEvents(ctx, tx, events)
+	if err != nil {
+		return nil, err
+	}
+	if len(inserted) == 0 {
+		return nil, nil
+	}
+
+	daily, hourly := statsDeltas(inserted)
+	for _, delta := range daily {
+		if err := mergeSQLiteDailyStat(ctx, tx, delta); err != nil {
+			return nil, fmt.Errorf("merge daily stat %s:%s: %w", delta.linkID, delta.date.Format("2006-01-02"), err)
+		}
+	}
+	for _, delta := range hourly {
+		if err := mergeSQLiteHourlyStat(ctx, tx, delta); err != nil {
+			return nil, fmt.Errorf("merge hourly stat %s:%s: %w", delta.linkID, delta.date.Format(time.RFC3339), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit record: %w", err)
+	}
+	return inserted, nil
+}
+
+// mergeSQLiteDailyStat adds a batch's counts to a link-day. Only visitors
+// new to the day's visitor set add to unique visitors.
+func mergeSQLiteDailyStat(ctx context.Context, tx *sql.Tx, delta *dailyStatsAccumulator) error {
+	current, err := lockSQLiteDailyStat(ctx, tx, delta.linkID, delta.date)
+	if err != nil {
+		return err
+	}
+	if delta.uniqueVisitors, err = addSQLiteDailyVisitors(ctx, tx, delta.linkID, delta.date, delta.visitorHashes()); err != nil {
+		return err
+	}
+	current.merge(delta)
+	return upsertSQLiteDailyStat(ctx, tx, current)
+}
+
+// mergeSQLiteHourlyStat adds a batch's counts to a link-hour.
+func mergeSQLiteHourlyStat(ctx context.Context, tx *sql.Tx, delta *dailyStatsAccumulator) error {
+	current, err := lockSQLiteHourlyStat(ctx, tx, delta.linkID, delta.date)
+	if err != nil {
+		return err
+	}
+	if delta.uniqueVisitors, err = addSQLiteHourlyVisitors(ctx, tx, delta.linkID, delta.date, delta.visitorHashes()); err != nil {
+		return err
+	}
+	current.merge(delta)
+	return upsertSQLiteHourlyStat(ctx, tx, current)
+}
+
+// lockSQLiteDailyStat reads a link-day's stats row, creating it if needed.
+// SQLite has a single writer, so the write also serializes the transaction.
+func lockSQLiteDailyStat(ctx context.Context, tx *sql.Tx, linkID string, date time.Time) (*dailyStatsAccumulator, error) {
+	acc := newDailyStatsAccumulator()
+	acc.linkID, acc.date = linkID, date
+	now := time.Now().UTC()
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO daily_link_stats (id, link_id, date, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (link_id, date) DO UPDATE SET updated_at = excluded.updated_at
+		RETURNING `+statsColumns,
+		fmt.Sprintf("%s:%s", linkID, date.Format("2006-01-02")), linkID, sqliteDate(date), now, now,
+	)
+	if err := scanStatsAccumulator(row, acc); err != nil {
+		return nil, fmt.Errorf("lock daily stat: %w", err)
+	}
+	return acc, nil
+}
+
+// lockSQLiteHourlyStat is lockSQLiteDailyStat for a link-hour.
+func lockSQLiteHourlyStat(ctx context.Context, tx *sql.Tx, linkID string, hour time.Time) (*dailyStatsAccumulator, error) {
+	acc := newDailyStatsAccumulator()
+	acc.linkID, acc.date = linkID, hour
+	now := time.Now().UTC()
+
+	row := tx.QueryRowContext(ctx, `
+		INSERT INTO hourly_link_stats (link_id, hour, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (link_id, hour) DO UPDATE SET updated_at = excluded.updated_at
+		RETURNING `+statsColumns,
+		linkID, sqliteTime(hour), now, now,
+	)
+	if err := scanStatsAccumulator(row, acc); err != nil {
+		return nil, fmt.Errorf("lock hourly stat: %w", err)
+	}
+	return acc, nil
+}
+
+// addSQLiteDailyVisitors adds visitors to a link-day's visitor set and
+// returns how many were new.
+func addSQLiteDailyVisitors(ctx context.Context, tx *sql.Tx, linkID string, date time.Time, hashes []string) (int64, error) {
+	return addSQLiteVisitors(ctx, tx,
+		`INSERT INTO daily_link_visitors (link_id, date, visitor_hash) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		linkID, sqliteDate(date), hashes)
+}
+
+// addSQLiteHourlyVisitors adds visitors to a link-hour's visitor set and
+// returns how many were new.
+func addSQLiteHourlyVisitors(ctx context.Context, tx *sql.Tx, linkID string, hour time.Time, hashes []string) (int64, error) {
+	return addSQLiteVisitors(ctx, tx,
+		`INSERT INTO hourly_link_visitors (link_id, hour, visitor_hash) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+		linkID, sqliteTime(hour), hashes)
+}
+
+func addSQLiteVisitors(ctx context.Context, tx *sql.Tx, query, linkID string, period any, hashes []string) (int64, error) {
+	if len(hashes) == 0 {
+		return 0, nil
+	}
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("prepare add visitors: %w", err)
+	}
+	defer stmt.Close()
+
+	var added int64
+	for _, hash := range hashes {
+		result, err := stmt.ExecContext(ctx, linkID, period, hash)
+		if err != nil {
+			return 0, fmt.Errorf("add visitor: %w", err)
+		}
+		n, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("add visitor: %w", err)
+		}
+		added += n
+	}
+	return added, nil
+}
+
+// RecomputeStats rebuilds a link's daily stats for the UTC days from..to,
+// inclusive, from the stored click events, along with the hourly stats of
+// the days not yet pruned. Days without click events are left as they
+// are. It returns the number of days recomputed.
+func (r *SQLiteClickEventRepository) RecomputeStats(ctx context.Context, linkID string, from, to time.Time) (int, error) {
+	start := from.UTC().Truncate(24 * time.Hour)
+	end := to.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+
+	// Timestamps are stored as UTC text, so the hour is a prefix
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT DISTINCT substr(clicked_at, 1, 13)
+		FROM click_events
+		WHERE link_id = ? AND clicked_at >= ? AND clicked_at < ?
+		ORDER BY 1
+	`, linkID, sqliteTime(start), sqliteTime(end))
+	if err != nil {
+		return 0, fmt.Errorf("query click hours: %w", err)
+	}
+	var hours []time.Time
+	for rows.Next() {
+		var prefix string
+		if err := rows.Scan(&prefix); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan click hour: %w", err)
+		}
+		hour, err := time.Parse("2006-01-02 15", prefix)
+		if err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("parse click hour %q: %w", prefix, err)
+		}
+		hours = append(hours, hour)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("iterate click hours: %w", err)
+	}
+
+	// Hours before the oldest hourly row have been pruned
+	var oldestDay sql.NullString
+	if err := r.store.db.QueryRowContext(ctx, `SELECT substr(MIN(hour), 1, 10) FROM hourly_link_stats`).Scan(&oldestDay); err != nil {
+		return 0, fmt.Errorf("query oldest hourly stat: %w", err)
+	}
+	var oldest *time.Time
+	if oldestDay.Valid {
+		day, err := time.Parse("2006-01-02", oldestDay.String)
+		if err != nil {
+			return 0, fmt.Errorf("parse oldest hourly stat %q: %w", oldestDay.String, err)
+		}
+		oldest = &day
+	}
+
+	return recomputeHours(hours, oldest,
+		func(day time.Time) error { return r.recomputeDailyStat(ctx, linkID, day) },
+		func(hour time.Time) error { return r.recomputeHourlyStat(ctx, linkID, hour) },
+	)
+}
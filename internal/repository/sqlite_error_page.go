@@ -0,0 +1,98 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// GetErrorPageSettings retrieves fallback and branding settings for a scope.
+func (s *SQLiteStore) GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, error) {
+	query := `
+		SELECT scope_type, scope_id,
+			   COALESCE(fallback_url, ''), COALESCE(brand_name, ''), COALESCE(logo_url, ''),
+			   COALESCE(accent_color, ''), COALESCE(support_url, ''),
+			   created_at, updated_at
+		FROM error_page_settings
+		WHERE scope_type = ? AND scope_id = ?
+	`
+
+	var settings model.ErrorPageSettings
+	err := s.db.QueryRowContext(ctx, query, scope, scopeID).Scan(
+		&settings.ScopeType,
+		&settings.ScopeID,
+		&settings.FallbackURL,
+		&settings.BrandName,
+		&settings.LogoURL,
+		&settings.AccentColor,
+		&settings.SupportURL,
+		&settings.CreatedAt,
+		&settings.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrErrorPageSettingsNotFound
+		}
+		return nil, fmt.Errorf("failed to get error page settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// UpsertErrorPageSettings creates or replaces settings for a scope.
+// CreatedAt and UpdatedAt are refreshed from the database.
+func (s *SQLiteStore) UpsertErrorPageSettings(ctx context.Context, settings *model.ErrorPageSettings) error {
+	query := `
+		INSERT INTO error_page_settings (
+			scope_type, scope_id, fallback_url, brand_name, logo_url,
+			accent_color, support_url, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (scope_type, scope_id) DO UPDATE SET
+			fallback_url = excluded.fallback_url,
+			brand_name = excluded.brand_name,
+			logo_url = excluded.logo_url,
+			accent_color = excluded.accent_color,
+			support_url = excluded.support_url,
+			updated_at = excluded.updated_at
+		RETURNING created_at, updated_at
+	`
+
+	now := time.Now().UTC()
+	err := s.db.QueryRowContext(ctx, query,
+		settings.ScopeType,
+		settings.ScopeID,
+		nullableString(settings.FallbackURL),
+		nullableString(settings.BrandName),
+		nullableString(settings.LogoURL),
+		nullableString(settings.AccentColor),
+		nullableString(settings.SupportURL),
+		now,
+		now,
+	).Scan(&settings.CreatedAt, &settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to upsert error page settings: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteErrorPageSettings removes settings for a scope.
+func (s *SQLiteStore) DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error {
+	result, err := s.db.ExecContext(ctx,
+		`DELETE FROM error_page_settings WHERE scope_type = ? AND scope_id = ?`,
+		scope, scopeID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete error page settings: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrErrorPageSettingsNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// UpdateHourlyStats recomputes the hourly_link_stats rows touched by events
+// from the stored click events, replacing their visitor sets. Like
+// UpdateDailyStats, it is the repair path.
+func (r *SQLiteClickEventRepository) UpdateHourlyStats(ctx context.Context, events []*model.ClickEvent) error {
+	for _, key := range uniqueHourlyKeys(events) {
+		if err := r.recomputeHourlyStat(ctx, key.linkID, key.hour); err != nil {
+			return fmt.Errorf("recompute hourly stat %s:%s: %w", key.linkID, key.hour.Format(time.RFC3339), err)
+		}
+	}
+
+	return nil
+}
+
+// recomputeHourlyStat rebuilds one link-hour from its click events.
+func (r *SQLiteClickEventRepository) recomputeHourlyStat(ctx context.Context, linkID string, hour time.Time) error {
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	if _, err := lockSQLiteHourlyStat(ctx, tx, linkID, hour); err != nil {
+		return err
+	}
+	acc, err := recalculateSQLiteStats(ctx, tx, linkID, hour, hour.Add(time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM hourly_link_visitors WHERE link_id = ? AND hour = ?`,
+		linkID, sqliteTime(hour),
+	); err != nil {
+		return err
+	}
+	if _, err := addSQLiteHourlyVisitors(ctx, tx, linkID, hour, acc.visitorHashes()); err != nil {
+		return err
+	}
+	if err := upsertSQLiteHourlyStat(ctx, tx, acc); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// upsertSQLiteHourlyStat inserts or updates an hourly_link_stats row. acc.date
+// holds the start of the hour.
+func upsertSQLiteHourlyStat(ctx context.Context, tx *sql.Tx, acc *dailyStatsAccumulator) error {
+	referrerJSON, _ := json.Marshal(acc.referrers)
+	countryJSON, _ := json.Marshal(acc.countries)
+	blockedCountryJSON, _ := json.Marshal(acc.blockedCountries)
+	botFamilyJSON, _ := json.Marshal(acc.botFamilies)
+	browserJSON, _ := json.Marshal(acc.browsers)
+	deviceJSON, _ := json.Marshal(acc.devices)
+	osJSON, _ := json.Marshal(acc.osFamilies)
+	regionJSON, _ := json.Marshal(acc.regions)
+	sketch, _ := acc.visitors.MarshalBinary()
+	now := time.Now().UTC()
+
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO hourly_link_stats (
+			link_id, hour, total_clicks, unique_visitors, blocked_clicks, bot_clicks,
+			referrer_breakdown, country_breakdown, blocked_country_breakdown,
+			bot_family_breakdown, ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
+			visitor_sketch, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (link_id, hour) DO UPDATE SET
+			total_clicks = excluded.total_clicks,
+			unique_visitors = excluded.unique_visitors,
+			blocked_clicks = excluded.blocked_clicks,
+			bot_clicks = excluded.bot_clicks,
+			referrer_breakdown = excluded.referrer_breakdown,
+			country_breakdown = excluded.country_breakdown,
+			blocked_country_breakdown = excluded.blocked_country_breakdown,
+			bot_family_breakdown = excluded.bot_family_breakdown,
+			ua_family_breakdown = excluded.ua_family_breakdown,
+			device_breakdown = excluded.device_breakdown,
+			os_breakdown = excluded.os_breakdown,
+			region_breakdown = excluded.region_breakdown,
+			visitor_sketch = excluded.visitor_sketch,
+			updated_at = excluded.updated_at
+	`,
+		acc.linkID,
+		sqliteTime(acc.date),
+		acc.totalClicks,
+		acc.uniqueVisitors,
+		acc.blockedClicks,
+		acc.botClicks,
+		string(referrerJSON),
+		string(countryJSON),
+		string(blockedCountryJSON),
+		string(botFamilyJSON),
+		string(browserJSON),
+		string(deviceJSON),
+		string(osJSON),
+		string(regionJSON),
+		sketch,
+		now,
+		now,
+	)
+
+	return err
+}
+
+// GetHourlyStats retrieves hourly stats for a link with hour in [from, to).
+func (r *SQLiteClickEventRepository) GetHourlyStats(ctx context.Context, linkID string, from, to time.Time) ([]*model.HourlyLinkStats, error) {
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT link_id, hour, total_clicks, unique_visitors, blocked_clicks, bot_clicks,
+			   referrer_breakdown, country_breakdown, blocked_country_breakdown,
+			   bot_family_breakdown, ua_family_breakdown, device_breakdown, os_breakdown, region_breakdown,
+			   visitor_sketch, created_at, updated_at
+		FROM hourly_link_stats
+		WHERE link_id = ? AND hour >= ? AND hour < ?
+		ORDER BY hour DESC
+	`, linkID, sqliteTime(from), sqliteTime(to))
+	if err != nil {
+		return nil, fmt.Errorf("query hourly stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []*model.HourlyLinkStats
+	for rows.Next() {
+		stat, err := scanSQLiteHourlyStat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan hourly stat: %w", err)
+		}
+		stats = append(stats, stat)
+	}
+
+	return stats, rows.Err()
+}
+
+// PruneHourlyStats prunes hourly rows from days before the UTC day
+// containing before, along with their hourly visitor sets. Daily rows, which the
+// worker writes alongside the hourly ones, are left unchanged. It returns the
+// number of link-days pruned.
+func (r *SQLiteClickEventRepository) PruneHourlyStats(ctx context.Context, before time.Time) (int, error) {
+	before = before.UTC().Truncate(24 * time.Hour)
+
+	total := 0
+	for {
+		// Hours are stored as UTC text, so the first ten characters are the day
+		rows, err := r.store.db.QueryContext(ctx, `
+			SELECT DISTINCT link_id, substr(hour, 1, 10)
+			FROM hourly_link_stats
+			WHERE hour < ?
+			LIMIT ?
+		`, sqliteTime(before), pruneBatchSize)
+		if err != nil {
+			return total, fmt.Errorf("query expired hourly stats: %w", err)
+		}
+
+		var keys []dailyStatsKey
+		for rows.Next() {
+			var key dailyStatsKey
+			var day string
+			if err := rows.Scan(&key.linkID, &day); err != nil {
+				rows.Close()
+				return total, fmt.Errorf("scan expired hourly stat: %w", err)
+			}
+			if key.date, err = time.Parse("2006-01-02", day); err != nil {
+				rows.Close()
+				return total, fmt.Errorf("parse expired hourly stat day %q: %w", day, err)
+			}
+			keys = append(keys, key)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return total, fmt.Errorf("iterate expired hourly stats: %w", err)
+		}
+
+		for _, key := range keys {
+			if err := r.pruneDay(ctx, key); err != nil {
+				return total, fmt.Errorf("prune %s:%s: %w", key.linkID, key.date.Format("2006-01-02"), err)
+			}
+			total++
+		}
+
+		if len(keys) < pruneBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// pruneDay deletes one link-day of hourly rows and hourly visitor sets in a
+// transaction.
+func (r *SQLiteClickEventRepository) pruneDay(ctx context.Context, key dailyStatsKey) error {
+	tx, err := r.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	start, end := sqliteTime(key.date), sqliteTime(key.date.Add(24*time.Hour))
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM hourly_link_stats WHERE link_id = ? AND hour >= ? AND hour < ?`,
+		key.linkID, start, end,
+	); err != nil {
+		return err
+	}
+
+	// The daily seen-set stays with the daily row so late events stay exact
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM hourly_link_visitors WHERE link_id = ? AND hour >= ? AND hour < ?`,
+		key.linkID, start, end,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// scanSQLiteHourlyStat scans a row into HourlyLinkStats.
+func scanSQLiteHourlyStat(rows *sql.Rows) (*model.HourlyLinkStats, error) {
+	var stat model.HourlyLinkStats
+	var referrerJSON, countryJSON, blockedCountryJSON, botFamilyJSON, browserJSON, deviceJSON, osJSON, regionJSON []byte
+
+	err := rows.Scan(
+		&stat.LinkID,
+		&stat.Hour,
+		&stat.TotalClicks,
+		&stat.UniqueVisitors,
+		&stat.BlockedClicks,
+		&stat.BotClicks,
+		&referrerJSON,
+		&countryJSON,
+		&blockedCountryJSON,
+		&botFamilyJSON,
+		&browserJSON,
+		&deviceJSON,
+		&osJSON,
+		&regionJSON,
+		&stat.VisitorSketch,
+		&stat.CreatedAt,
+		&stat.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	stat.Hour = stat.Hour.UTC()
+
+	if len(referrerJSON) > 0 {
+		_ = json.Unmarshal(referrerJSON, &stat.ReferrerBreakdown)
+	}
+	if len(countryJSON) > 0 {
+		_ = json.Unmarshal(countryJSON, &stat.CountryBreakdown)
+	}
+	if len(blockedCountryJSON) > 0 {
+		_ = json.Unmarshal(blockedCountryJSON, &stat.BlockedCountryBreakdown)
+	}
+	if len(botFamilyJSON) > 0 {
+		_ = json.Unmarshal(botFamilyJSON, &stat.BotFamilyBreakdown)
+	}
+	if len(browserJSON) > 0 {
+		_ = json.Unmarshal(browserJSON, &stat.UAFamilyBreakdown)
+	}
+	if len(deviceJSON) > 0 {
+		_ = json.Unmarshal(deviceJSON, &stat.DeviceBreakdown)
+	}
+	if len(osJSON) > 0 {
+		_ = json.Unmarshal(osJSON, &stat.OSBreakdown)
+	}
+	if len(regionJSON) > 0 {
+		_ = json.Unmarshal(regionJSON, &stat.RegionBreakdown)
+	}
+
+	return &stat, nil
+}
@@ -0,0 +1,306 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// sqliteLinkColumns is the column list shared by the link queries.
+const sqliteLinkColumns = `id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, deleted_at, click_count, created_at, updated_at`
+
+// CreateLink inserts a new link into the database.
+func (s *SQLiteStore) CreateLink(ctx context.Context, link *model.Link) error {
+	query := `
+		INSERT INTO links (id, short_code, destination, redirect_type, owner_id, enabled, expires_at, fallback_url, allowed_countries, blocked_countries, geo_blocked_url, tags, click_count, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.ExecContext(ctx, query,
+		link.ID,
+		link.ShortCode,
+		link.Destination,
+		link.RedirectType,
+		link.OwnerID,
+		link.Enabled,
+		sqliteNullTime(link.ExpiresAt),
+		link.FallbackURL,
+		sqliteStrings(link.AllowedCountries),
+		sqliteStrings(link.BlockedCountries),
+		link.GeoBlockedURL,
+		sqliteStrings(link.Tags),
+		link.ClickCount,
+		sqliteTime(link.CreatedAt),
+		sqliteTime(link.UpdatedAt),
+	)
+
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrAliasExists
+		}
+		return fmt.Errorf("failed to create link: %w", err)
+	}
+
+	return nil
+}
+
+// GetLinkByID retrieves a link by its ID.
+func (s *SQLiteStore) GetLinkByID(ctx context.Context, id string) (*model.Link, error) {
+	query := `SELECT ` + sqliteLinkColumns + ` FROM links WHERE id = ? AND deleted_at IS NULL`
+
+	link, err := scanSQLiteLink(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by ID: %w", err)
+	}
+
+	return link, nil
+}
+
+// GetLinkByShortCode retrieves a link by its short code.
+func (s *SQLiteStore) GetLinkByShortCode(ctx context.Context, shortCode string) (*model.Link, error) {
+	query := `SELECT ` + sqliteLinkColumns + ` FROM links WHERE short_code = ? AND deleted_at IS NULL`
+
+	link, err := scanSQLiteLink(s.db.QueryRowContext(ctx, query, shortCode))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, fmt.Errorf("failed to get link by short code: %w", err)
+	}
+
+	return link, nil
+}
+
+// GetLinkByCode is an alias for GetLinkByShortCode.
+func (s *SQLiteStore) GetLinkByCode(ctx context.Context, shortCode string) (*model.Link, error) {
+	return s.GetLinkByShortCode(ctx, shortCode)
+}
+
+// ListLinks retrieves a paginated list of links.
+func (s *SQLiteStore) ListLinks(ctx context.Context, filter LinkFilter, cursor string, limit int) ([]*model.Link, string, error) {
+	var cursorData *PaginationCursor
+	if cursor != "" {
+		var err error
+		cursorData, err = decodeCursor(cursor)
+		if err != nil {
+			return nil, "", ErrInvalidCursor
+		}
+	}
+
+	query := `SELECT ` + sqliteLinkColumns + ` FROM links WHERE deleted_at IS NULL AND owner_id = ?`
+	args := []any{filter.OwnerID}
+
+	if cursorData != nil {
+		query += " AND (created_at, id) < (?, ?)"
+		args = append(args, sqliteTime(cursorData.CreatedAt), cursorData.ID)
+	}
+
+	if filter.CreatedAfter != nil {
+		query += " AND created_at >= ?"
+		args = append(args, sqliteTime(*filter.CreatedAfter))
+	}
+
+	if filter.CreatedBefore != nil {
+		query += " AND created_at <= ?"
+		args = append(args, sqliteTime(*filter.CreatedBefore))
+	}
+
+	query += " ORDER BY created_at DESC, id DESC LIMIT ?"
+	args = append(args, limit+1) // Fetch one extra to determine hasMore
+
+	links, err := s.queryLinks(ctx, query, args...)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list links: %w", err)
+	}
+
+	var nextCursor string
+	if len(links) > limit {
+		links = links[:limit]
+		lastLink := links[len(links)-1]
+		nextCursor = encodeCursor(&PaginationCursor{
+			ID:        lastLink.ID,
+			CreatedAt: lastLink.CreatedAt,
+		})
+	}
+
+	return links, nextCursor, nil
+}
+
+// UpdateLink updates a link's mutable fields.
+// On success link.UpdatedAt is refreshed.
+func (s *SQLiteStore) UpdateLink(ctx context.Context, link *model.Link) error {
+	return s.updateLink(ctx, link, nil)
+}
+
+// UpdateLinkIfUnmodified updates a link only if its updated_at still equals
+// expectedUpdatedAt. Returns ErrLinkModified if another writer changed the
+// link in the meantime.
+func (s *SQLiteStore) UpdateLinkIfUnmodified(ctx context.Context, link *model.Link, expectedUpdatedAt time.Time) error {
+	return s.updateLink(ctx, link, &expectedUpdatedAt)
+}
+
+// updateLink is the shared implementation for UpdateLink and UpdateLinkIfUnmodified.
+func (s *SQLiteStore) updateLink(ctx context.Context, link *model.Link, expectedUpdatedAt *time.Time) error {
+	query := `
+		UPDATE links
+		SET destination = ?, redirect_type = ?, enabled = ?, expires_at = ?, fallback_url = ?,
+		    allowed_countries = ?, blocked_countries = ?, geo_blocked_url = ?, tags = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+		  AND (? IS NULL OR updated_at = ?)
+		RETURNING updated_at
+	`
+
+	expected := sqliteNullTime(expectedUpdatedAt)
+	err := s.db.QueryRowContext(ctx, query,
+		link.Destination,
+		link.RedirectType,
+		link.Enabled,
+		sqliteNullTime(link.ExpiresAt),
+		link.FallbackURL,
+		sqliteStrings(link.AllowedCountries),
+		sqliteStrings(link.BlockedCountries),
+		link.GeoBlockedURL,
+		sqliteStrings(link.Tags),
+		time.Now().UTC(),
+		link.ID,
+		expected,
+		expected,
+	).Scan(&link.UpdatedAt)
+
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("failed to update link: %w", err)
+		}
+		if expectedUpdatedAt == nil {
+			return ErrLinkNotFound
+		}
+		// Distinguish a concurrent modification from a missing link
+		if _, getErr := s.GetLinkByID(ctx, link.ID); getErr != nil {
+			return getErr
+		}
+		return ErrLinkModified
+	}
+
+	return nil
+}
+
+// DeleteLink performs a soft delete on a link.
+func (s *SQLiteStore) DeleteLink(ctx context.Context, id string) error {
+	now := time.Now().UTC()
+	result, err := s.db.ExecContext(ctx,
+		`UPDATE links SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		now, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to delete link: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrLinkNotFound
+	}
+
+	return nil
+}
+
+// IncrementClickCount increments the click counter for a link.
+func (s *SQLiteStore) IncrementClickCount(ctx context.Context, id string, count int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE links SET click_count = click_count + ?, updated_at = ? WHERE id = ?`,
+		count, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to increment click count: %w", err)
+	}
+
+	return nil
+}
+
+// ShortCodeExists checks if a short code already exists.
+func (s *SQLiteStore) ShortCodeExists(ctx context.Context, shortCode string) (bool, error) {
+	var exists bool
+	err := s.db.QueryRowContext(ctx,
+		`SELECT EXISTS(SELECT 1 FROM links WHERE short_code = ? AND deleted_at IS NULL)`, shortCode,
+	).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check short code existence: %w", err)
+	}
+
+	return exists, nil
+}
+
+// SearchLinksByDestination searches links by destination URL (case-insensitive
+// partial match). SQLite's LIKE is case-insensitive for ASCII.
+func (s *SQLiteStore) SearchLinksByDestination(ctx context.Context, destination string, limit int) ([]*model.Link, error) {
+	if limit <= 0 || limit > 50 {
+		limit = 20 // Default limit
+	}
+
+	query := `SELECT ` + sqliteLinkColumns + ` FROM links WHERE destination LIKE ? ORDER BY created_at DESC LIMIT ?`
+
+	links, err := s.queryLinks(ctx, query, "%"+destination+"%", limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search links by destination: %w", err)
+	}
+
+	return links, nil
+}
+
+// queryLinks runs a query selecting sqliteLinkColumns.
+func (s *SQLiteStore) queryLinks(ctx context.Context, query string, args ...any) ([]*model.Link, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []*model.Link
+	for rows.Next() {
+		link, err := scanSQLiteLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating links: %w", err)
+	}
+
+	return links, nil
+}
+
+// sqliteScanner is satisfied by *sql.Row and *sql.Rows.
+type sqliteScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanSQLiteLink scans a row selected with sqliteLinkColumns.
+func scanSQLiteLink(row sqliteScanner) (*model.Link, error) {
+	var link model.Link
+	err := row.Scan(
+		&link.ID,
+		&link.ShortCode,
+		&link.Destination,
+		&link.RedirectType,
+		&link.OwnerID,
+		&link.Enabled,
+		&link.ExpiresAt,
+		&link.FallbackURL,
+		(*sqliteStrings)(&link.AllowedCountries),
+		(*sqliteStrings)(&link.BlockedCountries),
+		&link.GeoBlockedURL,
+		(*sqliteStrings)(&link.Tags),
+		&link.DeletedAt,
+		&link.ClickCount,
+		&link.CreatedAt,
+		&link.UpdatedAt,
+	)
+	return &link, err
+}
@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// TransferLinks reassigns links to a new owner and writes one audit row per
+// moved link, all in a single transaction. Semantics match
+// Repository.TransferLinks; the immediate transaction holds SQLite's write
+// lock, so no row locks are needed.
+func (s *SQLiteStore) TransferLinks(ctx context.Context, params LinkTransferParams) ([]*model.LinkTransfer, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transfer: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }() // No-op after commit
+
+	selectQuery := `
+		SELECT id, short_code, owner_id
+		FROM links
+		WHERE deleted_at IS NULL
+		  AND (? = '' OR owner_id = ?)
+	`
+	args := []any{params.FromOwnerID, params.FromOwnerID}
+	if len(params.LinkIDs) > 0 {
+		selectQuery += " AND id IN (" + sqlitePlaceholders(len(params.LinkIDs)) + ")"
+		for _, id := range params.LinkIDs {
+			args = append(args, id)
+		}
+	}
+	selectQuery += " ORDER BY id"
+
+	rows, err := tx.QueryContext(ctx, selectQuery, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select links for transfer: %w", err)
+	}
+
+	found := 0
+	var transfers []*model.LinkTransfer
+	for rows.Next() {
+		var t model.LinkTransfer
+		if err := rows.Scan(&t.LinkID, &t.ShortCode, &t.FromOwnerID); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan link for transfer: %w", err)
+		}
+		found++
+		if t.FromOwnerID == params.ToOwnerID {
+			continue
+		}
+		transfers = append(transfers, &t)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to select links for transfer: %w", err)
+	}
+
+	if len(params.LinkIDs) > 0 && found != countUnique(params.LinkIDs) {
+		return nil, ErrLinkNotFound
+	}
+
+	if len(transfers) == 0 {
+		return []*model.LinkTransfer{}, nil
+	}
+
+	transferredAt := time.Now().UTC()
+	for _, t := range transfers {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE links SET owner_id = ?, updated_at = ? WHERE id = ?`,
+			params.ToOwnerID, transferredAt, t.LinkID,
+		); err != nil {
+			return nil, fmt.Errorf("failed to update link owners: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO link_ownership_transfers (
+				transfer_id, link_id, short_code, from_owner_id, to_owner_id,
+				actor_id, reason, transferred_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			params.TransferID, t.LinkID, t.ShortCode, t.FromOwnerID, params.ToOwnerID,
+			params.ActorID, nullableString(params.Reason), transferredAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to record link transfers: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transfer: %w", err)
+	}
+
+	for _, t := range transfers {
+		t.TransferID = params.TransferID
+		t.ToOwnerID = params.ToOwnerID
+		t.ActorID = params.ActorID
+		t.Reason = params.Reason
+		t.TransferredAt = transferredAt
+	}
+
+	return transfers, nil
+}
+
+// ListLinkTransfers retrieves audit trail entries, newest first.
+func (s *SQLiteStore) ListLinkTransfers(ctx context.Context, filter LinkTransferFilter, limit int) ([]*model.LinkTransfer, error) {
+	query := `
+		SELECT transfer_id, link_id, short_code, from_owner_id, to_owner_id,
+			   actor_id, COALESCE(reason, ''), transferred_at
+		FROM link_ownership_transfers
+		WHERE (?1 = '' OR transfer_id = ?1)
+		  AND (?2 = '' OR link_id = ?2)
+		  AND (?3 = '' OR from_owner_id = ?3 OR to_owner_id = ?3)
+		ORDER BY transferred_at DESC, id DESC
+		LIMIT ?4
+	`
+
+	rows, err := s.db.QueryContext(ctx, query, filter.TransferID, filter.LinkID, filter.OwnerID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list link transfers: %w", err)
+	}
+	defer rows.Close()
+
+	var transfers []*model.LinkTransfer
+	for rows.Next() {
+		var t model.LinkTransfer
+		err := rows.Scan(
+			&t.TransferID,
+			&t.LinkID,
+			&t.ShortCode,
+			&t.FromOwnerID,
+			&t.ToOwnerID,
+			&t.ActorID,
+			&t.Reason,
+			&t.TransferredAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan link transfer: %w", err)
+		}
+		transfers = append(transfers, &t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate link transfers: %w", err)
+	}
+
+	return transfers, nil
+}
+
+// sqlitePlaceholders returns n comma-separated "?" placeholders.
+func sqlitePlaceholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// CreateUser inserts a new user into the database.
+func (s *SQLiteStore) CreateUser(ctx context.Context, user *model.User) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO users (id, email, created_at) VALUES (?, ?, ?)`,
+		user.ID, user.Email, sqliteTime(user.CreatedAt),
+	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrEmailExists
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+// GetUserByID retrieves a user by their ID.
+func (s *SQLiteStore) GetUserByID(ctx context.Context, id string) (*model.User, error) {
+	user, err := s.getUser(ctx, `SELECT id, email, created_at FROM users WHERE id = ?`, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by ID: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetUserByEmail retrieves a user by their email address.
+func (s *SQLiteStore) GetUserByEmail(ctx context.Context, email string) (*model.User, error) {
+	user, err := s.getUser(ctx, `SELECT id, email, created_at FROM users WHERE email = ?`, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrUserNotFound
+		}
+		return nil, fmt.Errorf("failed to get user by email: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetOrCreateUser gets a user by email or creates one if not found.
+func (s *SQLiteStore) GetOrCreateUser(ctx context.Context, user *model.User) (*model.User, error) {
+	existing, err := s.GetUserByEmail(ctx, user.Email)
+	if err == nil {
+		return existing, nil
+	}
+	if !errors.Is(err, ErrUserNotFound) {
+		return nil, err
+	}
+
+	user.CreatedAt = time.Now()
+	if err := s.CreateUser(ctx, user); err != nil {
+		// Handle race condition - another request may have created it
+		if errors.Is(err, ErrEmailExists) {
+			return s.GetUserByEmail(ctx, user.Email)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *SQLiteStore) getUser(ctx context.Context, query string, arg string) (*model.User, error) {
+	var user model.User
+	err := s.db.QueryRowContext(ctx, query, arg).Scan(&user.ID, &user.Email, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
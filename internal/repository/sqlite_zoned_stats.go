@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// GetDailyStatsInZone computes daily stats for the calendar days from..to
+// in loc from raw click events, since daily_link_stats rows are UTC days.
+// Local days whose raw events have expired are returned as the stored UTC
+// day of the same date, marked UTC.
+func (r *SQLiteClickEventRepository) GetDailyStatsInZone(ctx context.Context, linkID string, from, to time.Time, loc *time.Location) ([]*model.DailyLinkStats, error) {
+	start, end := zonedRange(from, to, loc)
+
+	rows, err := r.store.db.QueryContext(ctx, `
+		SELECT clicked_at, COALESCE(referrer, ''), COALESCE(user_agent, ''), COALESCE(country_code, ''),
+			COALESCE(region, ''), visitor_hash, COALESCE(visitor_reg, 0), blocked, is_bot, COALESCE(bot_family, '')
+		FROM click_events
+		WHERE link_id = ? AND clicked_at >= ? AND clicked_at < ?
+	`, linkID, sqliteTime(start), sqliteTime(end))
+	if err != nil {
+		return nil, fmt.Errorf("query click events: %w", err)
+	}
+	defer rows.Close()
+
+	days := newZonedDays(linkID, loc)
+	for rows.Next() {
+		var event model.ClickEvent
+		if err := rows.Scan(&event.ClickedAt, &event.Referrer, &event.UserAgent, &event.CountryCode, &event.Region, &event.VisitorHash, &event.VisitorReg, &event.Blocked, &event.IsBot, &event.BotFamily); err != nil {
+			return nil, fmt.Errorf("scan click event: %w", err)
+		}
+		days.add(&event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate click events: %w", err)
+	}
+
+	firstUTC, lastUTC := utcDateRange(from, to, loc)
+	daily, err := r.GetDailyStats(ctx, linkID, firstUTC, lastUTC)
+	if err != nil {
+		return nil, err
+	}
+	days.fallBackToUTC(from, to, daily)
+
+	return days.stats(), nil
+}
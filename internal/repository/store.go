@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// Store is the storage backend used by services, handlers and middleware.
+// It is implemented by Repository (PostgreSQL) and SQLiteStore.
+type Store interface {
+	LinkStore
+	APIKeyStore
+	UserStore
+	ErrorPageStore
+	LinkTransferStore
+
+	// Ping checks database connectivity.
+	Ping(ctx context.Context) error
+
+	// Close releases the database connections.
+	Close()
+}
+
+// LinkStore persists short links.
+type LinkStore interface {
+	CreateLink(ctx context.Context, link *model.Link) error
+	GetLinkByID(ctx context.Context, id string) (*model.Link, error)
+	GetLinkByShortCode(ctx context.Context, shortCode string) (*model.Link, error)
+	GetLinkByCode(ctx context.Context, shortCode string) (*model.Link, error)
+	ListLinks(ctx context.Context, filter LinkFilter, cursor string, limit int) ([]*model.Link, string, error)
+	UpdateLink(ctx context.Context, link *model.Link) error
+	UpdateLinkIfUnmodified(ctx context.Context, link *model.Link, expectedUpdatedAt time.Time) error
+	DeleteLink(ctx context.Context, id string) error
+	IncrementClickCount(ctx context.Context, id string, count int64) error
+	ShortCodeExists(ctx context.Context, shortCode string) (bool, error)
+	SearchLinksByDestination(ctx context.Context, destination string, limit int) ([]*model.Link, error)
+}
+
+// APIKeyStore persists API keys.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *model.APIKey) error
+	GetAPIKeyByID(ctx context.Context, id string) (*model.APIKey, error)
+	GetAPIKeysByPrefix(ctx context.Context, prefix string) ([]*model.APIKey, error)
+	ListAPIKeysByUserID(ctx context.Context, userID string) ([]*model.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+	UpdateAPIKeyLastUsed(ctx context.Context, id string) error
+}
+
+// UserStore persists users.
+type UserStore interface {
+	CreateUser(ctx context.Context, user *model.User) error
+	GetUserByID(ctx context.Context, id string) (*model.User, error)
+	GetUserByEmail(ctx context.Context, email string) (*model.User, error)
+	GetOrCreateUser(ctx context.Context, user *model.User) (*model.User, error)
+}
+
+// ErrorPageStore persists fallback and branding settings.
+type ErrorPageStore interface {
+	GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, error)
+	UpsertErrorPageSettings(ctx context.Context, settings *model.ErrorPageSettings) error
+	DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error
+}
+
+// LinkTransferStore moves links between owners and keeps the audit trail.
+type LinkTransferStore interface {
+	TransferLinks(ctx context.Context, params LinkTransferParams) ([]*model.LinkTransfer, error)
+	ListLinkTransfers(ctx context.Context, filter LinkTransferFilter, limit int) ([]*model.LinkTransfer, error)
+}
+
+// ClickEventStore persists click events and serves the aggregated analytics.
+// It is implemented by ClickEventRepository and SQLiteClickEventRepository.
+type ClickEventStore interface {
+	BulkInsert(ctx context.Context, events []*model.ClickEvent) error
+	RecordClickEvents(ctx context.Context, events []*model.ClickEvent) ([]*model.ClickEvent, error)
+	UpdateDailyStats(ctx context.Context, events []*model.ClickEvent) error
+	UpdateHourlyStats(ctx context.Context, events []*model.ClickEvent) error
+	RecomputeStats(ctx context.Context, linkID string, from, to time.Time) (int, error)
+	PruneHourlyStats(ctx context.Context, before time.Time) (int, error)
+	CreateClickPartitions(ctx context.Context, from, through time.Time) (int, error)
+	DropClickPartitions(ctx context.Context, before time.Time) (int, error)
+	GetDailyStats(ctx context.Context, linkID string, from, to time.Time) ([]*model.DailyLinkStats, error)
+	GetHourlyStats(ctx context.Context, linkID string, from, to time.Time) ([]*model.HourlyLinkStats, error)
+	GetDailyStatsInZone(ctx context.Context, linkID string, from, to time.Time, loc *time.Location) ([]*model.DailyLinkStats, error)
+	GetAccountDailyStats(ctx context.Context, filter AccountStatsFilter) ([]model.DailyBreakdown, error)
+	GetAccountTopLinks(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.LinkClicks, error)
+	GetAccountTopReferrers(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.ReferrerBreakdown, error)
+	GetAccountTopCountries(ctx context.Context, filter AccountStatsFilter, limit int) ([]model.CountryBreakdown, error)
+	ExportClickEvents(ctx context.Context, filter ClickExportFilter, fn func(*model.ClickEvent) error) error
+	GetAnalyticsSummary(ctx context.Context, linkID string, from, to time.Time) (*model.AnalyticsSummary, error)
+	GetTopReferrers(ctx context.Context, linkID string, from, to time.Time, limit int) ([]model.ReferrerBreakdown, error)
+	GetTopCountries(ctx context.Context, linkID string, from, to time.Time, limit int) ([]model.CountryBreakdown, error)
+}
+
+var (
+	_ Store           = (*Repository)(nil)
+	_ Store           = (*SQLiteStore)(nil)
+	_ ClickEventStore = (*ClickEventRepository)(nil)
+	_ ClickEventStore = (*SQLiteClickEventRepository)(nil)
+)
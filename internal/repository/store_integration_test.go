@@ -0,0 +1,42 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// forEachBackend runs fn against a fresh SQLite database and against
+// PostgreSQL prepared by postgresEnv. The PostgreSQL run is skipped when
+// DATABASE_URL is not set.
+func forEachBackend(t *testing.T, postgresEnv func(t *testing.T) (context.Context, Store), fn func(t *testing.T, ctx context.Context, repo Store)) {
+	t.Helper()
+
+	t.Run("sqlite", func(t *testing.T) {
+		ctx, repo := newSQLiteTestEnv(t)
+		fn(t, ctx, repo)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		ctx, repo := postgresEnv(t)
+		fn(t, ctx, repo)
+	})
+}
+
+// newSQLiteTestEnv opens a migrated SQLite database in a temporary directory.
+func newSQLiteTestEnv(t *testing.T) (context.Context, Store) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping integration tests in short mode")
+	}
+
+	ctx := context.Background()
+	store, err := OpenSQLite(ctx, SQLiteScheme+filepath.Join(t.TempDir(), "penshort.db"))
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+	t.Cleanup(store.Close)
+
+	return ctx, store
+}
@@ -23,7 +23,7 @@ func (r *Repository) CreateUser(ctx context.Context, user *model.User) error {
 		VALUES ($1, $2, $3)
 	`
 
-	_, err := r.pool.Exec(ctx, query,
+	_, err := r.writer(ctx).Exec(ctx, query,
 		user.ID,
 		user.Email,
 		user.CreatedAt,
@@ -0,0 +1,80 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/hll"
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_UniqueVisitorsAcrossDays(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		today := time.Now().UTC().Truncate(24 * time.Hour)
+		yesterday := today.AddDate(0, 0, -1)
+		returning, other := hll.NewRegister(1<<60), hll.NewRegister(1<<40)
+
+		// The returning visitor gets a new daily hash but the same register
+		events := []*model.ClickEvent{
+			testClickEvent("sketch-link", "day1-returning", yesterday.Add(time.Hour)),
+			testClickEvent("sketch-link", "day1-other", yesterday.Add(2*time.Hour)),
+			testClickEvent("sketch-link", "day2-returning", today.Add(time.Hour)),
+			testClickEvent("sketch-link", "legacy", today.Add(time.Hour)),
+		}
+		events[0].VisitorReg = returning
+		events[1].VisitorReg = other
+		events[2].VisitorReg = returning
+
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+		if err := clicks.UpdateDailyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateDailyStats: %v", err)
+		}
+		if err := clicks.UpdateHourlyStats(ctx, events); err != nil {
+			t.Fatalf("UpdateHourlyStats: %v", err)
+		}
+
+		summary, err := clicks.GetAnalyticsSummary(ctx, "sketch-link", yesterday, today)
+		if err != nil {
+			t.Fatalf("GetAnalyticsSummary: %v", err)
+		}
+		if summary.UniqueVisitors != 4 {
+			t.Errorf("UniqueVisitors = %d, want 4 summed daily", summary.UniqueVisitors)
+		}
+		approx := summary.UniqueVisitorsApprox
+		if approx == nil || approx.Value != 2 || approx.StandardError != hll.StandardError {
+			t.Fatalf("UniqueVisitorsApprox = %+v, want 2 visitors with sketches", approx)
+		}
+
+		hours, err := clicks.GetHourlyStats(ctx, "sketch-link", yesterday, today.Add(24*time.Hour))
+		if err != nil {
+			t.Fatalf("GetHourlyStats: %v", err)
+		}
+		merged := hll.New()
+		for _, hour := range hours {
+			var sketch hll.Sketch
+			if err := sketch.UnmarshalBinary(hour.VisitorSketch); err != nil {
+				t.Fatalf("decode hourly sketch: %v", err)
+			}
+			merged.Merge(&sketch)
+		}
+		if got := merged.Estimate(); got != 2 {
+			t.Errorf("merged hourly estimate = %d, want 2", got)
+		}
+
+		zoned, err := clicks.GetDailyStatsInZone(ctx, "sketch-link", yesterday, today, time.UTC)
+		if err != nil {
+			t.Fatalf("GetDailyStatsInZone: %v", err)
+		}
+		for _, day := range zoned {
+			if len(day.VisitorSketch) == 0 {
+				t.Errorf("zoned day %s has no visitor sketch", day.Date.Format("2006-01-02"))
+			}
+		}
+	})
+}
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// zonedRange returns the instants bounding the calendar days from..to
+// (inclusive) in loc.
+func zonedRange(from, to time.Time, loc *time.Location) (time.Time, time.Time) {
+	start := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, loc)
+	end := time.Date(to.Year(), to.Month(), to.Day()+1, 0, 0, 0, 0, loc)
+	return start, end
+}
+
+// calendarDate returns t's calendar date in t's location at midnight UTC,
+// the way DailyLinkStats.Date is keyed.
+func calendarDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// zonedDays accumulates click events into the calendar days of one time zone.
+type zonedDays struct {
+	linkID string
+	loc    *time.Location
+	days   map[time.Time]*dailyStatsAccumulator
+
+	// Raw events per UTC day, compared with the stored daily rows
+	utcEvents map[time.Time]int64
+	// Stored UTC days standing in for local days with expired raw events
+	fallback map[time.Time]*model.DailyLinkStats
+}
+
+func newZonedDays(linkID string, loc *time.Location) *zonedDays {
+	return &zonedDays{
+		linkID:    linkID,
+		loc:       loc,
+		days:      make(map[time.Time]*dailyStatsAccumulator),
+		utcEvents: make(map[time.Time]int64),
+		fallback:  make(map[time.Time]*model.DailyLinkStats),
+	}
+}
+
+// add counts event in its local day. Visitor hashes are salted per UTC day,
+// so a visitor seen on both sides of UTC midnight counts as two uniques in
+// a local day spanning it (see docs/analytics.md, Time Zones).
+func (z *zonedDays) add(event *model.ClickEvent) {
+	z.utcEvents[calendarDate(event.ClickedAt.UTC())]++
+	day := calendarDate(event.ClickedAt.In(z.loc))
+
+	acc, ok := z.days[day]
+	if !ok {
+		acc = newDailyStatsAccumulator()
+		acc.linkID = z.linkID
+		acc.date = day
+		z.days[day] = acc
+	}
+	acc.add(event)
+}
+
+// stats returns one DailyLinkStats per local day, newest first.
+func (z *zonedDays) stats() []*model.DailyLinkStats {
+	stats := make([]*model.DailyLinkStats, 0, len(z.days))
+	for _, acc := range z.days {
+		sketch, _ := acc.visitors.MarshalBinary()
+		stats = append(stats, &model.DailyLinkStats{
+			ID:                      fmt.Sprintf("%s:%s", acc.linkID, acc.date.Format("2006-01-02")),
+			LinkID:                  acc.linkID,
+			Date:                    acc.date,
+			TotalClicks:             acc.totalClicks,
+			UniqueVisitors:          acc.uniqueVisitors,
+			ReferrerBreakdown:       acc.referrers,
+			CountryBreakdown:        acc.countries,
+			BlockedClicks:           acc.blockedClicks,
+			BlockedCountryBreakdown: acc.blockedCountries,
+			BotClicks:               acc.botClicks,
+			BotFamilyBreakdown:      acc.botFamilies,
+			UAFamilyBreakdown:       acc.browsers,
+			DeviceBreakdown:         acc.devices,
+			RegionBreakdown:         acc.regions,
+			OSBreakdown:             acc.osFamilies,
+			VisitorSketch:           sketch,
+		})
+	}
+	for _, stat := range z.fallback {
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Date.After(stats[j].Date) })
+	return stats
+}
+
+// fallBackToUTC replaces the local days from..to that overlap a UTC day
+// with fewer raw events than its stored daily row, meaning the day's raw
+// events have expired, with the stored UTC day of the same date. daily
+// holds the stored rows of every UTC day the range overlaps.
+func (z *zonedDays) fallBackToUTC(from, to time.Time, daily []*model.DailyLinkStats) {
+	stored := make(map[time.Time]*model.DailyLinkStats, len(daily))
+	expired := make(map[time.Time]bool)
+	for _, stat := range daily {
+		date := calendarDate(stat.Date.UTC())
+		stored[date] = stat
+		if z.utcEvents[date] < stat.TotalClicks+stat.BlockedClicks+stat.BotClicks {
+			expired[date] = true
+		}
+	}
+	if len(expired) == 0 {
+		return
+	}
+
+	start, end := zonedRange(from, to, z.loc)
+	for dayStart := start; dayStart.Before(end); dayStart = dayStart.AddDate(0, 0, 1) {
+		lastInstant := dayStart.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		if !expired[calendarDate(dayStart.UTC())] && !expired[calendarDate(lastInstant.UTC())] {
+			continue
+		}
+
+		day := calendarDate(dayStart)
+		delete(z.days, day)
+		if stat, ok := stored[day]; ok {
+			utc := *stat
+			utc.UTC = true
+			z.fallback[day] = &utc
+		}
+	}
+}
+
+// utcDateRange returns the first and last UTC dates overlapping the calendar
+// days from..to in loc.
+func utcDateRange(from, to time.Time, loc *time.Location) (time.Time, time.Time) {
+	start, end := zonedRange(from, to, loc)
+	return calendarDate(start.UTC()), calendarDate(end.Add(-time.Nanosecond).UTC())
+}
+
+// GetDailyStatsInZone computes daily stats for the calendar days from..to
+// in loc from raw click events, since daily_link_stats rows are UTC days.
+// Local days whose raw events have expired are returned as the stored UTC
+// day of the same date, marked UTC.
+func (r *ClickEventRepository) GetDailyStatsInZone(ctx context.Context, linkID string, from, to time.Time, loc *time.Location) ([]*model.DailyLinkStats, error) {
+	start, end := zonedRange(from, to, loc)
+
+	query := `
+		SELECT clicked_at, COALESCE(referrer, ''), COALESCE(user_agent, ''), COALESCE(country_code, ''),
+			COALESCE(region, ''), visitor_hash, COALESCE(visitor_reg, 0), blocked, is_bot, COALESCE(bot_family, '')
+		FROM click_events
+		WHERE link_id = $1 AND clicked_at >= $2 AND clicked_at < $3
+	`
+
+	rows, err := r.repo.reader(ctx).Query(ctx, query, linkID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("query click events: %w", err)
+	}
+	defer rows.Close()
+
+	days := newZonedDays(linkID, loc)
+	for rows.Next() {
+		var event model.ClickEvent
+		if err := rows.Scan(&event.ClickedAt, &event.Referrer, &event.UserAgent, &event.CountryCode, &event.Region, &event.VisitorHash, &event.VisitorReg, &event.Blocked, &event.IsBot, &event.BotFamily); err != nil {
+			return nil, fmt.Errorf("scan click event: %w", err)
+		}
+		days.add(&event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate click events: %w", err)
+	}
+
+	firstUTC, lastUTC := utcDateRange(from, to, loc)
+	daily, err := r.GetDailyStats(ctx, linkID, firstUTC, lastUTC)
+	if err != nil {
+		return nil, err
+	}
+	days.fallBackToUTC(from, to, daily)
+
+	return days.stats(), nil
+}
@@ -0,0 +1,47 @@
+//go:build integration
+
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestIntegrationClickEventRepository_GetDailyStatsInZone(t *testing.T) {
+	forEachBackend(t, newAnalyticsTestEnv, func(t *testing.T, ctx context.Context, repo Store) {
+		clicks := clickEventStoreFor(t, repo)
+		tokyo, err := time.LoadLocation("Asia/Tokyo")
+		if err != nil {
+			t.Skipf("tzdata unavailable: %v", err)
+		}
+
+		// 2024-03-03 20:00 UTC is already Monday 2024-03-04 in Tokyo
+		events := []*model.ClickEvent{
+			testClickEvent("zoned-link", "v1", time.Date(2024, 3, 3, 14, 0, 0, 0, time.UTC)),
+			testClickEvent("zoned-link", "v2", time.Date(2024, 3, 3, 20, 0, 0, 0, time.UTC)),
+			testClickEvent("zoned-link", "v3", time.Date(2024, 3, 4, 10, 0, 0, 0, time.UTC)),
+		}
+		if err := clicks.BulkInsert(ctx, events); err != nil {
+			t.Fatalf("BulkInsert: %v", err)
+		}
+
+		day := func(d int) time.Time { return time.Date(2024, 3, d, 0, 0, 0, 0, tokyo) }
+		stats, err := clicks.GetDailyStatsInZone(ctx, "zoned-link", day(3), day(4), tokyo)
+		if err != nil {
+			t.Fatalf("GetDailyStatsInZone: %v", err)
+		}
+		if len(stats) != 2 {
+			t.Fatalf("expected 2 local days, got %d", len(stats))
+		}
+
+		if got := stats[0].Date.Format("2006-01-02"); got != "2024-03-04" || stats[0].TotalClicks != 2 {
+			t.Errorf("latest day = %s with %d clicks, want 2024-03-04 with 2", got, stats[0].TotalClicks)
+		}
+		if got := stats[1].Date.Format("2006-01-02"); got != "2024-03-03" || stats[1].TotalClicks != 1 {
+			t.Errorf("first day = %s with %d clicks, want 2024-03-03 with 1", got, stats[1].TotalClicks)
+		}
+	})
+}
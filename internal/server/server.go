@@ -58,6 +58,13 @@ func (s *Server) OnShutdown(name string, fn ShutdownFunc) {
 	})
 }
 
+// OnDrain registers a function called as soon as shutdown begins, before
+// the HTTP server waits for active requests to finish. Use it to end
+// long-lived responses such as event streams.
+func (s *Server) OnDrain(fn func()) {
+	s.httpServer.RegisterOnShutdown(fn)
+}
+
 // Run starts the server and blocks until shutdown signal is received.
 // It handles graceful shutdown on SIGINT/SIGTERM.
 func (s *Server) Run() error {
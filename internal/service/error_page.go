@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// Error page settings errors.
+var (
+	ErrErrorPageSettingsNotFound = errors.New("error page settings not found")
+	ErrInvalidErrorPageScope     = errors.New("invalid error page scope")
+	ErrInvalidAccentColor        = errors.New("accent color must be #RRGGBB")
+	ErrInvalidBrandURL           = errors.New("invalid branding URL")
+)
+
+// accentColorRegex matches #RRGGBB hex colors.
+var accentColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// ResolveErrorPage determines the fallback destination and branding for a
+// redirect that cannot be served. link may be nil when the short code does
+// not exist. Precedence is link, then owner, then domain settings.
+// Lookup failures degrade to the built-in page rather than erroring.
+func (s *LinkService) ResolveErrorPage(ctx context.Context, link *model.Link, host string) *model.ErrorPage {
+	page := &model.ErrorPage{}
+
+	if link != nil {
+		if link.FallbackURL != nil {
+			page.FallbackURL = *link.FallbackURL
+		}
+		if link.OwnerID != "" {
+			page.Merge(s.lookupErrorPageSettings(ctx, model.ErrorPageScopeOwner, link.OwnerID))
+		}
+	}
+
+	if domain := normalizeHost(host); domain != "" {
+		page.Merge(s.lookupErrorPageSettings(ctx, model.ErrorPageScopeDomain, domain))
+	}
+
+	return page
+}
+
+// GetErrorPageSettings retrieves settings for a scope.
+func (s *LinkService) GetErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) (*model.ErrorPageSettings, error) {
+	if !scope.IsValid() || scopeID == "" {
+		return nil, ErrInvalidErrorPageScope
+	}
+
+	settings, err := s.repo.GetErrorPageSettings(ctx, scope, normalizeScopeID(scope, scopeID))
+	if err != nil {
+		if errors.Is(err, repository.ErrErrorPageSettingsNotFound) {
+			return nil, ErrErrorPageSettingsNotFound
+		}
+		return nil, err
+	}
+
+	return settings, nil
+}
+
+// SaveErrorPageSettings validates and stores settings for a scope.
+func (s *LinkService) SaveErrorPageSettings(ctx context.Context, settings *model.ErrorPageSettings) error {
+	if !settings.ScopeType.IsValid() || settings.ScopeID == "" {
+		return ErrInvalidErrorPageScope
+	}
+	settings.ScopeID = normalizeScopeID(settings.ScopeType, settings.ScopeID)
+
+	if settings.FallbackURL != "" {
+		if err := s.validateDestination(settings.FallbackURL); err != nil {
+			return ErrInvalidFallbackURL
+		}
+	}
+	for _, brandURL := range []string{settings.LogoURL, settings.SupportURL} {
+		if brandURL == "" {
+			continue
+		}
+		if err := s.validateDestination(brandURL); err != nil {
+			return ErrInvalidBrandURL
+		}
+	}
+	if settings.AccentColor != "" && !accentColorRegex.MatchString(settings.AccentColor) {
+		return ErrInvalidAccentColor
+	}
+
+	if err := s.repo.UpsertErrorPageSettings(ctx, settings); err != nil {
+		return err
+	}
+
+	// Invalidate cache
+	_ = s.cache.DeleteErrorPageSettings(ctx, settings.ScopeType, settings.ScopeID)
+
+	return nil
+}
+
+// DeleteErrorPageSettings removes settings for a scope.
+func (s *LinkService) DeleteErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) error {
+	if !scope.IsValid() || scopeID == "" {
+		return ErrInvalidErrorPageScope
+	}
+	scopeID = normalizeScopeID(scope, scopeID)
+
+	if err := s.repo.DeleteErrorPageSettings(ctx, scope, scopeID); err != nil {
+		if errors.Is(err, repository.ErrErrorPageSettingsNotFound) {
+			return ErrErrorPageSettingsNotFound
+		}
+		return err
+	}
+
+	// Invalidate cache
+	_ = s.cache.DeleteErrorPageSettings(ctx, scope, scopeID)
+
+	return nil
+}
+
+// lookupErrorPageSettings reads settings through the cache.
+// Returns nil when no settings exist or the lookup fails.
+func (s *LinkService) lookupErrorPageSettings(ctx context.Context, scope model.ErrorPageScope, scopeID string) *model.ErrorPageSettings {
+	if settings, found, _ := s.cache.GetErrorPageSettings(ctx, scope, scopeID); found {
+		return settings
+	}
+
+	settings, err := s.repo.GetErrorPageSettings(ctx, scope, scopeID)
+	if err != nil {
+		if !errors.Is(err, repository.ErrErrorPageSettingsNotFound) {
+			return nil
+		}
+		settings = nil // Cache the absence
+	}
+
+	_ = s.cache.SetErrorPageSettings(ctx, scope, scopeID, settings)
+	return settings
+}
+
+// normalizeScopeID canonicalizes domain scope IDs so lookups by request
+// host match regardless of case or port.
+func normalizeScopeID(scope model.ErrorPageScope, scopeID string) string {
+	if scope == model.ErrorPageScopeDomain {
+		return normalizeHost(scopeID)
+	}
+	return scopeID
+}
+
+// normalizeHost lowercases a host and strips any port.
+func normalizeHost(host string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
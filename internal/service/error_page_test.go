@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+func TestNormalizeHost(t *testing.T) {
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"go.Example.com", "go.example.com"},
+		{"go.example.com:8080", "go.example.com"},
+		{"go.example.com.", "go.example.com"},
+		{"[::1]:8080", "::1"},
+		{"", ""},
+	}
+
+	for _, test := range tests {
+		if got := normalizeHost(test.host); got != test.want {
+			t.Errorf("normalizeHost(%q) = %q, want %q", test.host, got, test.want)
+		}
+	}
+}
+
+func TestSaveErrorPageSettingsValidationErrors(t *testing.T) {
+	svc := &LinkService{}
+
+	tests := []struct {
+		name     string
+		settings model.ErrorPageSettings
+		wantErr  error
+	}{
+		{"invalid_scope", model.ErrorPageSettings{ScopeType: "link", ScopeID: "x"}, ErrInvalidErrorPageScope},
+		{"empty_scope_id", model.ErrorPageSettings{ScopeType: model.ErrorPageScopeOwner}, ErrInvalidErrorPageScope},
+		{"invalid_fallback", model.ErrorPageSettings{ScopeType: model.ErrorPageScopeOwner, ScopeID: "u1", FallbackURL: "ftp://x"}, ErrInvalidFallbackURL},
+		{"invalid_logo", model.ErrorPageSettings{ScopeType: model.ErrorPageScopeOwner, ScopeID: "u1", LogoURL: "javascript:alert(1)"}, ErrInvalidBrandURL},
+		{"invalid_color", model.ErrorPageSettings{ScopeType: model.ErrorPageScopeDomain, ScopeID: "go.example.com", AccentColor: "red"}, ErrInvalidAccentColor},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			settings := test.settings
+			err := svc.SaveErrorPageSettings(context.Background(), &settings)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
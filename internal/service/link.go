@@ -13,44 +13,67 @@ import (
 	"time"
 
 	"github.com/penshort/penshort/internal/cache"
+	"github.com/penshort/penshort/internal/geo"
 	"github.com/penshort/penshort/internal/metrics"
 	"github.com/penshort/penshort/internal/model"
 	"github.com/penshort/penshort/internal/repository"
+	"golang.org/x/sync/singleflight"
 )
 
 // Service errors.
 var (
-	ErrInvalidDestination = errors.New("invalid destination URL")
-	ErrInvalidAlias       = errors.New("invalid alias format")
-	ErrAliasExists        = errors.New("alias already exists")
-	ErrLinkNotFound       = errors.New("link not found")
-	ErrLinkExpired        = errors.New("link is expired")
-	ErrLinkDisabled       = errors.New("link is disabled")
-	ErrExpiresInPast      = errors.New("expires_at must be in the future")
-	ErrInvalidRedirectType = errors.New("invalid redirect type")
-	ErrURLTooLong         = errors.New("destination URL too long")
+	ErrInvalidDestination   = errors.New("invalid destination URL")
+	ErrInvalidAlias         = errors.New("invalid alias format")
+	ErrAliasExists          = errors.New("alias already exists")
+	ErrLinkNotFound         = errors.New("link not found")
+	ErrLinkExpired          = errors.New("link is expired")
+	ErrLinkDisabled         = errors.New("link is disabled")
+	ErrExpiresInPast        = errors.New("expires_at must be in the future")
+	ErrInvalidRedirectType  = errors.New("invalid redirect type")
+	ErrURLTooLong           = errors.New("destination URL too long")
+	ErrPreconditionFailed   = errors.New("link has been modified")
+	ErrInvalidFallbackURL   = errors.New("invalid fallback URL")
+	ErrGeoBlocked           = errors.New("link is not available in visitor's country")
+	ErrInvalidCountryCode   = errors.New("invalid country code")
+	ErrInvalidGeoBlockedURL = errors.New("invalid geo-blocked URL")
+	ErrInvalidTag           = errors.New("invalid tag")
 )
 
 // Alias validation regex: 3-50 chars, alphanumeric + hyphen.
 var aliasRegex = regexp.MustCompile(`^[a-zA-Z0-9-]{3,50}$`)
 
+// Tag validation regex, applied after lowercasing: 1-50 chars, starting
+// with a letter or digit.
+var tagRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,49}$`)
+
 const (
 	maxDestinationLength = 2048
 	aliasLength          = 7
 	aliasAlphabet        = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789"
 	maxAliasRetries      = 3
+	maxCountryRules      = 250
+	maxLinkTags          = 20
 )
 
 // LinkService handles link business logic.
 type LinkService struct {
-	repo    *repository.Repository
-	cache   *cache.Cache
+	repo    repository.Store
+	cache   cache.Backend
 	baseURL string
 	metrics metrics.Recorder
+
+	// Cache-miss coalescing, see loadLink
+	lookups       singleflight.Group
+	refillLockTTL time.Duration
+	refillWait    time.Duration
+	lookupTimeout time.Duration
+
+	// Used instead of Redis while its circuit breaker is open
+	degraded *cache.LocalLinkCache
 }
 
 // NewLinkService creates a new LinkService.
-func NewLinkService(repo *repository.Repository, cache *cache.Cache, baseURL string, recorder metrics.Recorder) *LinkService {
+func NewLinkService(repo repository.Store, cache cache.Backend, baseURL string, recorder metrics.Recorder) *LinkService {
 	if recorder == nil {
 		recorder = metrics.NewNoop()
 	}
@@ -59,16 +82,23 @@ func NewLinkService(repo *repository.Repository, cache *cache.Cache, baseURL str
 		cache:   cache,
 		baseURL: strings.TrimSuffix(baseURL, "/"),
 		metrics: recorder,
+
+		lookupTimeout: defaultLookupTimeout,
 	}
 }
 
 // CreateLinkInput defines input for creating a link.
 type CreateLinkInput struct {
-	Destination  string
-	Alias        string
-	RedirectType int
-	ExpiresAt    *time.Time
-	OwnerID      string
+	Destination      string
+	Alias            string
+	RedirectType     int
+	ExpiresAt        *time.Time
+	FallbackURL      string
+	AllowedCountries []string
+	BlockedCountries []string
+	GeoBlockedURL    string
+	Tags             []string
+	OwnerID          string
 }
 
 // CreateLink creates a new short link.
@@ -92,6 +122,37 @@ func (s *LinkService) CreateLink(ctx context.Context, input CreateLinkInput) (*m
 		return nil, ErrExpiresInPast
 	}
 
+	// Validate optional fallback destination
+	var fallbackURL *string
+	if input.FallbackURL != "" {
+		if err := s.validateDestination(input.FallbackURL); err != nil {
+			return nil, ErrInvalidFallbackURL
+		}
+		fallbackURL = &input.FallbackURL
+	}
+
+	// Validate optional country rules
+	allowedCountries, err := normalizeCountries(input.AllowedCountries)
+	if err != nil {
+		return nil, err
+	}
+	blockedCountries, err := normalizeCountries(input.BlockedCountries)
+	if err != nil {
+		return nil, err
+	}
+	var geoBlockedURL *string
+	if input.GeoBlockedURL != "" {
+		if err := s.validateDestination(input.GeoBlockedURL); err != nil {
+			return nil, ErrInvalidGeoBlockedURL
+		}
+		geoBlockedURL = &input.GeoBlockedURL
+	}
+
+	tags, err := normalizeTags(input.Tags)
+	if err != nil {
+		return nil, err
+	}
+
 	// Handle alias
 	alias := input.Alias
 	if alias != "" {
@@ -116,16 +177,21 @@ func (s *LinkService) CreateLink(ctx context.Context, input CreateLinkInput) (*m
 
 	// Create link model
 	link := &model.Link{
-		ID:           generateULID(),
-		ShortCode:    alias,
-		Destination:  input.Destination,
-		RedirectType: redirectType,
-		OwnerID:      ownerID,
-		Enabled:      true,
-		ExpiresAt:    input.ExpiresAt,
-		ClickCount:   0,
-		CreatedAt:    time.Now().UTC(),
-		UpdatedAt:    time.Now().UTC(),
+		ID:               generateULID(),
+		ShortCode:        alias,
+		Destination:      input.Destination,
+		RedirectType:     redirectType,
+		OwnerID:          ownerID,
+		Enabled:          true,
+		ExpiresAt:        input.ExpiresAt,
+		FallbackURL:      fallbackURL,
+		AllowedCountries: allowedCountries,
+		BlockedCountries: blockedCountries,
+		GeoBlockedURL:    geoBlockedURL,
+		Tags:             tags,
+		ClickCount:       0,
+		CreatedAt:        time.Now().UTC(),
+		UpdatedAt:        time.Now().UTC(),
 	}
 
 	// Insert into database
@@ -213,12 +279,18 @@ func (s *LinkService) ListLinks(ctx context.Context, input ListLinksInput) (*Lis
 
 // UpdateLinkInput defines input for updating a link.
 type UpdateLinkInput struct {
-	ID           string
-	Destination  *string
-	RedirectType *int
-	ExpiresAt    *time.Time
-	Enabled      *bool
-	ClearExpiry  bool // If true, set expires_at to nil
+	ID               string
+	Destination      *string
+	RedirectType     *int
+	ExpiresAt        *time.Time
+	Enabled          *bool
+	FallbackURL      *string   // Empty string clears the fallback
+	AllowedCountries *[]string // Empty list clears the allow list
+	BlockedCountries *[]string // Empty list clears the deny list
+	GeoBlockedURL    *string   // Empty string clears it (blocked visitors get 451)
+	Tags             *[]string // Replaces the tags; empty list clears them
+	ClearExpiry      bool      // If true, set expires_at to nil
+	IfMatch          string    // If set, only update when the current ETag matches
 }
 
 // UpdateLink updates a link's mutable fields.
@@ -232,6 +304,12 @@ func (s *LinkService) UpdateLink(ctx context.Context, input UpdateLinkInput) (*m
 		return nil, err
 	}
 
+	// Check optimistic concurrency precondition
+	if input.IfMatch != "" && !model.MatchETag(input.IfMatch, link.ETag(), false) {
+		return nil, ErrPreconditionFailed
+	}
+	readUpdatedAt := link.UpdatedAt
+
 	// Check if expired
 	if link.IsExpired() {
 		return nil, ErrLinkExpired
@@ -266,14 +344,68 @@ func (s *LinkService) UpdateLink(ctx context.Context, input UpdateLinkInput) (*m
 		link.Enabled = *input.Enabled
 	}
 
-	// Update in database
-	if err := s.repo.UpdateLink(ctx, link); err != nil {
+	if input.FallbackURL != nil {
+		if *input.FallbackURL == "" {
+			link.FallbackURL = nil
+		} else {
+			if err := s.validateDestination(*input.FallbackURL); err != nil {
+				return nil, ErrInvalidFallbackURL
+			}
+			fallback := *input.FallbackURL
+			link.FallbackURL = &fallback
+		}
+	}
+
+	if input.AllowedCountries != nil {
+		if link.AllowedCountries, err = normalizeCountries(*input.AllowedCountries); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.BlockedCountries != nil {
+		if link.BlockedCountries, err = normalizeCountries(*input.BlockedCountries); err != nil {
+			return nil, err
+		}
+	}
+
+	if input.GeoBlockedURL != nil {
+		if *input.GeoBlockedURL == "" {
+			link.GeoBlockedURL = nil
+		} else {
+			if err := s.validateDestination(*input.GeoBlockedURL); err != nil {
+				return nil, ErrInvalidGeoBlockedURL
+			}
+			geoBlocked := *input.GeoBlockedURL
+			link.GeoBlockedURL = &geoBlocked
+		}
+	}
+
+	if input.Tags != nil {
+		if link.Tags, err = normalizeTags(*input.Tags); err != nil {
+			return nil, err
+		}
+	}
+
+	// Update in database; with If-Match, guard against writes since our read
+	if input.IfMatch != "" {
+		err = s.repo.UpdateLinkIfUnmodified(ctx, link, readUpdatedAt)
+	} else {
+		err = s.repo.UpdateLink(ctx, link)
+	}
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrLinkModified):
+			return nil, ErrPreconditionFailed
+		case errors.Is(err, repository.ErrLinkNotFound):
+			return nil, ErrLinkNotFound
+		}
 		return nil, err
 	}
 
 	s.metrics.IncLinkUpdated()
 
 	// Invalidate cache
+	s.evictDegraded(link.ShortCode)
 	if err := s.cache.DeleteLink(ctx, link.ShortCode); err != nil {
 		// Log but don't fail - eventual consistency is acceptable
 		_ = err
@@ -301,6 +433,7 @@ func (s *LinkService) DeleteLink(ctx context.Context, id string) error {
 	s.metrics.IncLinkDeleted()
 
 	// Invalidate cache
+	s.evictDegraded(link.ShortCode)
 	if err := s.cache.DeleteLink(ctx, link.ShortCode); err != nil {
 		_ = err // Log but don't fail
 	}
@@ -310,7 +443,11 @@ func (s *LinkService) DeleteLink(ctx context.Context, id string) error {
 
 // ResolveRedirect resolves a short code to its destination for redirect.
 // This is the hot path - optimized for speed with cache-first lookup.
-func (s *LinkService) ResolveRedirect(ctx context.Context, shortCode string) (*model.Link, bool, error) {
+// country is the visitor's ISO country code ("" if unknown) and is checked
+// against the link's country rules.
+// For ErrLinkExpired, ErrLinkDisabled and ErrGeoBlocked the link is returned
+// alongside the error so callers can apply its fallback destination.
+func (s *LinkService) ResolveRedirect(ctx context.Context, shortCode, country string) (*model.Link, bool, error) {
 	start := time.Now()
 	defer func() {
 		s.metrics.ObserveRedirectDuration(time.Since(start))
@@ -318,22 +455,48 @@ func (s *LinkService) ResolveRedirect(ctx context.Context, shortCode string) (*m
 
 	cacheHit := false
 
-	// Step 1: Try cache
+	if s.cache.Degraded() {
+		return s.resolveDegraded(ctx, shortCode, country)
+	}
+
+	// Step 1: Try the in-process cache. The epoch is taken first so a fill
+	// racing with an invalidation is discarded.
+	local := s.cache.Local()
+	var epoch uint64
+	if local != nil {
+		epoch = local.Epoch()
+		if cached, ok := local.Get(shortCode); ok {
+			cacheHit = true
+			s.metrics.IncCacheLookup("local", "hit")
+			s.metrics.IncRedirectCacheHit()
+			link := cached.ToLink(shortCode)
+			validated, err := s.validateRedirectLink(ctx, link, shortCode, country)
+			return validated, cacheHit, err
+		}
+		s.metrics.IncCacheLookup("local", "miss")
+	}
+
+	// Step 2: Try Redis
 	cached, err := s.cache.GetLink(ctx, shortCode)
 	if err == nil {
 		// Cache hit - validate and return
 		cacheHit = true
+		s.metrics.IncCacheLookup("redis", "hit")
 		s.metrics.IncRedirectCacheHit()
+		if local != nil {
+			local.SetIfFresh(shortCode, cached, epoch)
+		}
 		link := cached.ToLink(shortCode)
-		validated, err := s.validateRedirectLink(ctx, link, shortCode)
+		validated, err := s.validateRedirectLink(ctx, link, shortCode, country)
 		return validated, cacheHit, err
 	}
 
-	// Step 2: Check negative cache
+	// Step 3: Check negative cache
 	if !errors.Is(err, cache.ErrCacheMiss) {
 		// Redis error - fall through to DB
 		// In production, log this error
 	} else {
+		s.metrics.IncCacheLookup("redis", "miss")
 		s.metrics.IncRedirectCacheMiss()
 		// Check negative cache
 		isNegative, _ := s.cache.IsNegativelyCached(ctx, shortCode)
@@ -342,25 +505,19 @@ func (s *LinkService) ResolveRedirect(ctx context.Context, shortCode string) (*m
 		}
 	}
 
-	// Step 3: DB lookup
-	link, err := s.repo.GetLinkByShortCode(ctx, shortCode)
+	// Step 4: DB lookup, shared by concurrent misses, and Redis backfill
+	link, err := s.loadLink(ctx, shortCode)
 	if err != nil {
-		if errors.Is(err, repository.ErrLinkNotFound) {
-			// Set negative cache
-			_ = s.cache.SetNegativeCache(ctx, shortCode)
-			return nil, cacheHit, ErrLinkNotFound
-		}
 		return nil, cacheHit, err
 	}
 
-	// Step 4: Backfill cache
-	if err := s.cache.SetLink(ctx, shortCode, link); err != nil {
-		// Log but don't fail
-		_ = err
+	// Step 5: Backfill the in-process cache
+	if local != nil {
+		local.SetIfFresh(shortCode, link.ToCachedLink(), epoch)
 	}
 
-	// Step 5: Validate and return
-	validated, err := s.validateRedirectLink(ctx, link, shortCode)
+	// Step 6: Validate and return
+	validated, err := s.validateRedirectLink(ctx, link, shortCode, country)
 	return validated, cacheHit, err
 }
 
@@ -378,7 +535,8 @@ func (s *LinkService) BaseURL() string {
 }
 
 // validateRedirectLink validates a link for redirect and handles cleanup.
-func (s *LinkService) validateRedirectLink(ctx context.Context, link *model.Link, shortCode string) (*model.Link, error) {
+// Expired, disabled and geo-blocked links are returned with their error.
+func (s *LinkService) validateRedirectLink(ctx context.Context, link *model.Link, shortCode, country string) (*model.Link, error) {
 	// Check deleted
 	if link.DeletedAt != nil {
 		return nil, ErrLinkNotFound
@@ -386,19 +544,85 @@ func (s *LinkService) validateRedirectLink(ctx context.Context, link *model.Link
 
 	// Check disabled
 	if !link.Enabled {
-		return nil, ErrLinkDisabled
+		return link, ErrLinkDisabled
 	}
 
 	// Check expired
 	if link.IsExpired() {
 		// Evict from cache
+		s.evictDegraded(shortCode)
 		_ = s.cache.DeleteLink(ctx, shortCode)
-		return nil, ErrLinkExpired
+		return link, ErrLinkExpired
+	}
+
+	// Check country rules
+	if !link.AllowsCountry(country) {
+		return link, ErrGeoBlocked
 	}
 
 	return link, nil
 }
 
+// normalizeCountries uppercases, validates and de-duplicates country codes.
+// An empty list normalizes to nil (no restriction).
+func normalizeCountries(codes []string) ([]string, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+	if len(codes) > maxCountryRules {
+		return nil, ErrInvalidCountryCode
+	}
+
+	normalized := make([]string, 0, len(codes))
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !geo.IsCountryCode(code) {
+			return nil, ErrInvalidCountryCode
+		}
+		if seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+
+	return normalized, nil
+}
+
+// normalizeTags trims, lowercases, validates and de-duplicates link tags.
+// An empty list normalizes to nil (untagged).
+func normalizeTags(tags []string) ([]string, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if len(tags) > maxLinkTags {
+		return nil, ErrInvalidTag
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag, ok := NormalizeTag(tag)
+		if !ok {
+			return nil, ErrInvalidTag
+		}
+		if seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+
+	return normalized, nil
+}
+
+// NormalizeTag trims and lowercases a tag, reporting whether it is valid.
+func NormalizeTag(tag string) (string, bool) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	return tag, tagRegex.MatchString(tag)
+}
+
 // validateDestination validates a destination URL.
 func (s *LinkService) validateDestination(dest string) error {
 	if dest == "" {
@@ -472,4 +696,3 @@ func cryptoRandInt(max int) (int, error) {
 	}
 	return int(n.Int64()), nil
 }
-
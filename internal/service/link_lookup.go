@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/penshort/penshort/internal/cache"
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// refillPollInterval is how often a replica waiting on another replica's
+// refill checks Redis.
+const refillPollInterval = 10 * time.Millisecond
+
+// defaultLookupTimeout bounds a shared cache-miss lookup unless
+// SetLookupTimeout overrides it.
+const defaultLookupTimeout = 3 * time.Second
+
+// SetRefillLock makes replicas take a Redis lock before loading a missed link
+// from the database, so only one of them refills the cache. Replicas that lose
+// the race poll Redis for up to wait before querying the database themselves.
+// A zero ttl disables the lock.
+func (s *LinkService) SetRefillLock(ttl, wait time.Duration) {
+	s.refillLockTTL = ttl
+	s.refillWait = wait
+}
+
+// SetLookupTimeout bounds how long a cache-miss lookup, shared by every
+// caller waiting on the same short code, may take. Zero or negative keeps
+// the default.
+func (s *LinkService) SetLookupTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultLookupTimeout
+	}
+	s.lookupTimeout = timeout
+}
+
+// loadLink loads a link after a cache miss and refills Redis. Concurrent
+// misses for the same short code in this process share a single lookup.
+func (s *LinkService) loadLink(ctx context.Context, shortCode string) (*model.Link, error) {
+	leader := false
+	v, err, shared := s.lookups.Do(shortCode, func() (any, error) {
+		leader = true
+		// Followers depend on this lookup; don't let the leader's
+		// cancellation fail them, but don't let a hung query hold them
+		// forever either.
+		lookupCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.lookupTimeout)
+		defer cancel()
+		return s.refillLink(lookupCtx, shortCode)
+	})
+	if shared && !leader {
+		s.metrics.IncLinkLookupCoalesced("process")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Each caller gets its own copy
+	link := *v.(*model.Link)
+	return &link, nil
+}
+
+// refillLink queries the database and backfills the Redis cache, or waits
+// for another replica to do so when the refill lock is enabled.
+func (s *LinkService) refillLink(ctx context.Context, shortCode string) (*model.Link, error) {
+	if s.refillLockTTL > 0 {
+		release, err := s.cache.AcquireRefillLock(ctx, shortCode, s.refillLockTTL)
+		switch {
+		case err != nil:
+			// Redis trouble - go straight to the database
+		case release != nil:
+			defer release()
+		default:
+			link, done, err := s.waitForRefill(ctx, shortCode)
+			if done {
+				s.metrics.IncLinkLookupCoalesced("cluster")
+				return link, err
+			}
+		}
+	}
+
+	s.metrics.IncLinkDBLookup()
+	link, err := s.repo.GetLinkByShortCode(ctx, shortCode)
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			// Set negative cache
+			_ = s.cache.SetNegativeCache(ctx, shortCode)
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.cache.SetLink(ctx, shortCode, link); err != nil {
+		// Log but don't fail
+		_ = err
+	}
+
+	return link, nil
+}
+
+// waitForRefill polls Redis until another replica has cached the link (or
+// its absence). done is false if nothing appeared within the wait window.
+func (s *LinkService) waitForRefill(ctx context.Context, shortCode string) (*model.Link, bool, error) {
+	ticker := time.NewTicker(refillPollInterval)
+	defer ticker.Stop()
+	deadline := time.NewTimer(s.refillWait)
+	defer deadline.Stop()
+
+	for {
+		select {
+		case <-deadline.C:
+			return nil, false, nil
+		case <-ticker.C:
+			if cached, err := s.cache.GetLink(ctx, shortCode); err == nil {
+				return cached.ToLink(shortCode), true, nil
+			}
+			if negative, _ := s.cache.IsNegativelyCached(ctx, shortCode); negative {
+				return nil, true, ErrLinkNotFound
+			}
+		}
+	}
+}
+
+// SetDegradedCache sets the in-process cache used while the Redis circuit
+// breaker is open. Without it, every redirect hits the database during an
+// outage.
+func (s *LinkService) SetDegradedCache(c *cache.LocalLinkCache) {
+	s.degraded = c
+}
+
+// resolveDegraded serves a redirect without Redis. The shared in-process
+// cache is skipped as well, since invalidations cannot reach it while Redis
+// is down; the degraded cache has a short TTL instead.
+func (s *LinkService) resolveDegraded(ctx context.Context, shortCode, country string) (*model.Link, bool, error) {
+	if s.degraded != nil {
+		if cached, ok := s.degraded.Get(shortCode); ok {
+			s.metrics.IncCacheLookup("degraded", "hit")
+			s.metrics.IncRedirectCacheHit()
+			validated, err := s.validateRedirectLink(ctx, cached.ToLink(shortCode), shortCode, country)
+			return validated, true, err
+		}
+		s.metrics.IncCacheLookup("degraded", "miss")
+	}
+	s.metrics.IncRedirectCacheMiss()
+
+	var epoch uint64
+	if s.degraded != nil {
+		epoch = s.degraded.Epoch()
+	}
+
+	// Redis writes in the refill path fail fast while the circuit is open
+	link, err := s.loadLink(ctx, shortCode)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if s.degraded != nil {
+		s.degraded.SetIfFresh(shortCode, link.ToCachedLink(), epoch)
+	}
+
+	validated, err := s.validateRedirectLink(ctx, link, shortCode, country)
+	return validated, false, err
+}
+
+// evictDegraded drops a changed link from the degraded cache. Other
+// replicas' copies expire with the cache TTL.
+func (s *LinkService) evictDegraded(shortCode string) {
+	if s.degraded != nil {
+		s.degraded.Delete(shortCode)
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// blockingStore hangs on short code lookups until the context ends; other
+// methods are not used.
+type blockingStore struct {
+	repository.Store
+	started chan struct{}
+	once    sync.Once
+}
+
+func (s *blockingStore) GetLinkByShortCode(ctx context.Context, shortCode string) (*model.Link, error) {
+	s.once.Do(func() { close(s.started) })
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestLoadLinkTimesOutHungLookup(t *testing.T) {
+	store := &blockingStore{started: make(chan struct{})}
+	svc := NewLinkService(store, nil, "http://localhost:8080", nil)
+	svc.SetLookupTimeout(50 * time.Millisecond)
+
+	// The leader's own context never ends
+	errs := make(chan error, 2)
+	go func() {
+		_, err := svc.loadLink(context.Background(), "hung")
+		errs <- err
+	}()
+	<-store.started
+	go func() {
+		_, err := svc.loadLink(context.Background(), "hung")
+		errs <- err
+	}()
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errs:
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("lookup did not time out")
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/penshort/penshort/internal/model"
+	"github.com/penshort/penshort/internal/repository"
+)
+
+// Link transfer errors.
+var (
+	ErrInvalidTransfer       = errors.New("transfer needs link_ids or from_owner_id and a different to_owner_id")
+	ErrTooManyTransferLinks  = errors.New("too many link IDs in one transfer")
+	ErrTransferReasonTooLong = errors.New("transfer reason too long")
+	ErrOwnerNotFound         = errors.New("new owner not found")
+)
+
+const (
+	maxTransferLinkIDs   = 1000
+	maxTransferReasonLen = 500
+	defaultTransferLimit = 100
+	maxTransferLimit     = 1000
+)
+
+// TransferLinksInput defines which links move to which owner.
+// Set LinkIDs for one or several links, FromOwnerID for all links of a user,
+// or both to restrict the listed links to that user.
+type TransferLinksInput struct {
+	LinkIDs     []string
+	FromOwnerID string
+	ToOwnerID   string
+	ActorID     string
+	Reason      string
+}
+
+// TransferLinks reassigns links to a new owner in one transaction and records
+// each move in the audit trail. Cached links are evicted so redirects, and the
+// analytics events they publish, pick up the new owner immediately.
+func (s *LinkService) TransferLinks(ctx context.Context, input TransferLinksInput) ([]*model.LinkTransfer, error) {
+	if input.ToOwnerID == "" || (len(input.LinkIDs) == 0 && input.FromOwnerID == "") {
+		return nil, ErrInvalidTransfer
+	}
+	if input.FromOwnerID == input.ToOwnerID {
+		return nil, ErrInvalidTransfer
+	}
+	if len(input.LinkIDs) > maxTransferLinkIDs {
+		return nil, ErrTooManyTransferLinks
+	}
+	if len(input.Reason) > maxTransferReasonLen {
+		return nil, ErrTransferReasonTooLong
+	}
+
+	if _, err := s.repo.GetUserByID(ctx, input.ToOwnerID); err != nil {
+		if errors.Is(err, repository.ErrUserNotFound) {
+			return nil, ErrOwnerNotFound
+		}
+		return nil, err
+	}
+
+	transfers, err := s.repo.TransferLinks(ctx, repository.LinkTransferParams{
+		TransferID:  generateULID(),
+		LinkIDs:     input.LinkIDs,
+		FromOwnerID: input.FromOwnerID,
+		ToOwnerID:   input.ToOwnerID,
+		ActorID:     input.ActorID,
+		Reason:      input.Reason,
+	})
+	if err != nil {
+		if errors.Is(err, repository.ErrLinkNotFound) {
+			return nil, ErrLinkNotFound
+		}
+		return nil, err
+	}
+
+	// Invalidate cache
+	for _, t := range transfers {
+		s.evictDegraded(t.ShortCode)
+		_ = s.cache.DeleteLink(ctx, t.ShortCode)
+	}
+
+	return transfers, nil
+}
+
+// ListLinkTransfers returns ownership audit trail entries, newest first.
+func (s *LinkService) ListLinkTransfers(ctx context.Context, filter repository.LinkTransferFilter, limit int) ([]*model.LinkTransfer, error) {
+	if limit <= 0 {
+		limit = defaultTransferLimit
+	}
+	if limit > maxTransferLimit {
+		limit = maxTransferLimit
+	}
+
+	return s.repo.ListLinkTransfers(ctx, filter, limit)
+}
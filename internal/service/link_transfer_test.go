@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTransferLinksValidationErrors(t *testing.T) {
+	svc := &LinkService{}
+
+	tooMany := make([]string, maxTransferLinkIDs+1)
+	for i := range tooMany {
+		tooMany[i] = "link"
+	}
+
+	tests := []struct {
+		name    string
+		input   TransferLinksInput
+		wantErr error
+	}{
+		{"missing_target", TransferLinksInput{LinkIDs: []string{"l1"}}, ErrInvalidTransfer},
+		{"missing_selection", TransferLinksInput{ToOwnerID: "bob"}, ErrInvalidTransfer},
+		{"same_owner", TransferLinksInput{FromOwnerID: "bob", ToOwnerID: "bob"}, ErrInvalidTransfer},
+		{"too_many_links", TransferLinksInput{LinkIDs: tooMany, ToOwnerID: "bob"}, ErrTooManyTransferLinks},
+		{"reason_too_long", TransferLinksInput{FromOwnerID: "alice", ToOwnerID: "bob", Reason: strings.Repeat("a", maxTransferReasonLen+1)}, ErrTransferReasonTooLong},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := svc.TransferLinks(context.Background(), test.input)
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("expected %v, got %v", test.wantErr, err)
+			}
+		})
+	}
+}
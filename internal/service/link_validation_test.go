@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"testing"
 	"time"
@@ -72,6 +73,25 @@ func TestCreateLinkValidationErrors(t *testing.T) {
 			},
 			wantErr: ErrExpiresInPast,
 		},
+		{
+			name: "invalid_country_code",
+			input: CreateLinkInput{
+				Destination:      "https://example.com",
+				Alias:            "valid-alias",
+				AllowedCountries: []string{"US", "USA"},
+			},
+			wantErr: ErrInvalidCountryCode,
+		},
+		{
+			name: "invalid_geo_blocked_url",
+			input: CreateLinkInput{
+				Destination:      "https://example.com",
+				Alias:            "valid-alias",
+				BlockedCountries: []string{"DE"},
+				GeoBlockedURL:    "javascript:alert(1)",
+			},
+			wantErr: ErrInvalidGeoBlockedURL,
+		},
 	}
 
 	for _, test := range tests {
@@ -83,3 +103,51 @@ func TestCreateLinkValidationErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestNormalizeCountries(t *testing.T) {
+	got, err := normalizeCountries([]string{"us", " DE ", "US"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "US" || got[1] != "DE" {
+		t.Fatalf("expected [US DE], got %v", got)
+	}
+
+	if got, err := normalizeCountries([]string{}); err != nil || got != nil {
+		t.Fatalf("expected nil for empty list, got %v, %v", got, err)
+	}
+
+	for _, bad := range []string{"", "U", "U1", "usa"} {
+		if _, err := normalizeCountries([]string{bad}); !errors.Is(err, ErrInvalidCountryCode) {
+			t.Fatalf("%q: expected ErrInvalidCountryCode, got %v", bad, err)
+		}
+	}
+}
+
+func TestNormalizeTags(t *testing.T) {
+	got, err := normalizeTags([]string{"Launch", " q1-2026 ", "launch"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0] != "launch" || got[1] != "q1-2026" {
+		t.Fatalf("expected [launch q1-2026], got %v", got)
+	}
+
+	if got, err := normalizeTags([]string{}); err != nil || got != nil {
+		t.Fatalf("expected nil for empty list, got %v, %v", got, err)
+	}
+
+	for _, bad := range []string{"", "-lead", "two words", strings.Repeat("a", 51)} {
+		if _, err := normalizeTags([]string{bad}); !errors.Is(err, ErrInvalidTag) {
+			t.Fatalf("%q: expected ErrInvalidTag, got %v", bad, err)
+		}
+	}
+
+	tooMany := make([]string, maxLinkTags+1)
+	for i := range tooMany {
+		tooMany[i] = fmt.Sprintf("t%d", i)
+	}
+	if _, err := normalizeTags(tooMany); !errors.Is(err, ErrInvalidTag) {
+		t.Fatalf("expected ErrInvalidTag for %d tags, got %v", len(tooMany), err)
+	}
+}
@@ -49,62 +49,70 @@ func AcquireDBLock(ctx context.Context, pool *pgxpool.Pool) (func() error, error
 	return unlock, nil
 }
 
+// linksMigrations lists the migrations that shape the links schema, in apply order.
+var linksMigrations = []string{
+	"000002_links",
+	"000007_error_pages",
+	"000008_link_ownership_transfers",
+	"000009_link_geo_rules",
+	"000013_link_tags",
+}
+
 // ResetLinksSchema drops and recreates the links schema for tests.
 func ResetLinksSchema(ctx context.Context, pool *pgxpool.Pool) error {
+	return resetMigrations(ctx, pool, linksMigrations)
+}
+
+// resetMigrations applies the down migrations in reverse order, then the up
+// migrations in order.
+func resetMigrations(ctx context.Context, pool *pgxpool.Pool, names []string) error {
 	root, err := ProjectRoot()
 	if err != nil {
 		return err
 	}
 
-	downPath := filepath.Join(root, "migrations", "000002_links.down.sql")
-	upPath := filepath.Join(root, "migrations", "000002_links.up.sql")
-
-	downSQL, err := os.ReadFile(downPath)
-	if err != nil {
-		return fmt.Errorf("read down migration: %w", err)
-	}
-	if _, err := pool.Exec(ctx, string(downSQL)); err != nil {
-		return fmt.Errorf("apply down migration: %w", err)
+	for i := len(names) - 1; i >= 0; i-- {
+		downPath := filepath.Join(root, "migrations", names[i]+".down.sql")
+		downSQL, err := os.ReadFile(downPath)
+		if err != nil {
+			return fmt.Errorf("read %s down migration: %w", names[i], err)
+		}
+		if _, err := pool.Exec(ctx, string(downSQL)); err != nil {
+			return fmt.Errorf("apply %s down migration: %w", names[i], err)
+		}
 	}
 
-	upSQL, err := os.ReadFile(upPath)
-	if err != nil {
-		return fmt.Errorf("read up migration: %w", err)
-	}
-	if _, err := pool.Exec(ctx, string(upSQL)); err != nil {
-		return fmt.Errorf("apply up migration: %w", err)
+	for _, name := range names {
+		upPath := filepath.Join(root, "migrations", name+".up.sql")
+		upSQL, err := os.ReadFile(upPath)
+		if err != nil {
+			return fmt.Errorf("read %s up migration: %w", name, err)
+		}
+		if _, err := pool.Exec(ctx, string(upSQL)); err != nil {
+			return fmt.Errorf("apply %s up migration: %w", name, err)
+		}
 	}
 
 	return nil
 }
 
+// analyticsMigrations lists the migrations that shape the analytics schema, in apply order.
+var analyticsMigrations = []string{
+	"000005_analytics",
+	"000010_analytics_geo_blocked",
+	"000011_analytics_bots",
+	"000012_hourly_link_stats",
+	"000014_analytics_devices",
+	"000015_analytics_regions",
+	"000016_analytics_sketches",
+	"000017_analytics_visitor_sets",
+	"000018_click_events_partitions",
+	"000019_click_event_ids",
+}
+
 // ResetAnalyticsSchema drops and recreates the analytics schema for tests.
 func ResetAnalyticsSchema(ctx context.Context, pool *pgxpool.Pool) error {
-	root, err := ProjectRoot()
-	if err != nil {
-		return err
-	}
-
-	downPath := filepath.Join(root, "migrations", "000005_analytics.down.sql")
-	upPath := filepath.Join(root, "migrations", "000005_analytics.up.sql")
-
-	downSQL, err := os.ReadFile(downPath)
-	if err != nil {
-		return fmt.Errorf("read analytics down migration: %w", err)
-	}
-	if _, err := pool.Exec(ctx, string(downSQL)); err != nil {
-		return fmt.Errorf("apply analytics down migration: %w", err)
-	}
-
-	upSQL, err := os.ReadFile(upPath)
-	if err != nil {
-		return fmt.Errorf("read analytics up migration: %w", err)
-	}
-	if _, err := pool.Exec(ctx, string(upSQL)); err != nil {
-		return fmt.Errorf("apply analytics up migration: %w", err)
-	}
-
-	return nil
+	return resetMigrations(ctx, pool, analyticsMigrations)
 }
 
 // ResetAPIKeysSchema drops and recreates the api_keys schema for tests.
@@ -195,7 +203,7 @@ func ResetUsersSchema(ctx context.Context, pool *pgxpool.Pool) error {
 }
 
 // FlushRedis clears the current Redis database.
-func FlushRedis(ctx context.Context, client *redis.Client) error {
+func FlushRedis(ctx context.Context, client redis.UniversalClient) error {
 	return client.FlushDB(ctx).Err()
 }
 
@@ -245,7 +253,7 @@ func NewTestAPIKey(t testing.TB, userID string) *model.APIKey {
 		ID:            fmt.Sprintf("key-%d", now.UnixNano()),
 		UserID:        userID,
 		KeyHash:       fmt.Sprintf("hash-%d", now.UnixNano()),
-		KeyPrefix:     "pk_test_",
+		KeyPrefix:     "pktest",
 		Scopes:        []string{model.ScopeRead, model.ScopeWrite},
 		RateLimitTier: model.TierFree,
 		Name:          "Test Key",
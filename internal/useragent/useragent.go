@@ -0,0 +1,121 @@
+// Package useragent derives the device type, OS family and browser family
+// of a User-Agent string for analytics breakdowns.
+package useragent
+
+import "strings"
+
+// Device types.
+const (
+	DeviceDesktop = "desktop"
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceBot     = "bot"
+	DeviceUnknown = "unknown"
+)
+
+// FamilyOther is the OS or browser family of an unrecognised User-Agent.
+const FamilyOther = "Other"
+
+// Agent is a parsed User-Agent.
+type Agent struct {
+	Device  string
+	OS      string
+	Browser string
+}
+
+// rule maps a case-insensitive User-Agent substring to a family.
+type rule struct {
+	family  string
+	pattern string
+}
+
+// osRules are checked in order. iOS comes before macOS because iOS agents
+// claim to be "like Mac OS X"; Android before Linux for the same reason.
+var osRules = []rule{
+	{"Windows Phone", "windows phone"},
+	{"Windows", "windows"},
+	{"iOS", "iphone"},
+	{"iOS", "ipad"},
+	{"iOS", "ipod"},
+	{"Chrome OS", "cros"},
+	{"Android", "android"},
+	{"macOS", "macintosh"},
+	{"macOS", "mac os x"},
+	{"Linux", "linux"},
+}
+
+// browserRules are checked in order. Most browsers include "Chrome/" or
+// "Safari/" in their agent, so those two come last.
+var browserRules = []rule{
+	{"Edge", "edg/"},
+	{"Edge", "edge/"},
+	{"Edge", "edga/"},
+	{"Edge", "edgios/"},
+	{"Opera", "opr/"},
+	{"Opera", "opera"},
+	{"Samsung Internet", "samsungbrowser"},
+	{"Yandex Browser", "yabrowser"},
+	{"UC Browser", "ucbrowser"},
+	{"Firefox", "firefox/"},
+	{"Firefox", "fxios/"},
+	{"Internet Explorer", "msie "},
+	{"Internet Explorer", "trident/"},
+	{"Chrome", "crios/"},
+	{"Chrome", "chrome/"},
+	{"Chrome", "chromium/"},
+	{"Safari", "safari/"},
+}
+
+var (
+	botPatterns    = []string{"bot", "crawler", "spider", "slurp"}
+	tabletPatterns = []string{"ipad", "tablet", "kindle", "silk/", "playbook"}
+	mobilePatterns = []string{"mobi", "iphone", "ipod", "android", "windows phone", "blackberry", "opera mini"}
+)
+
+// Parse derives the device type, OS family and browser family of ua.
+func Parse(ua string) Agent {
+	if strings.TrimSpace(ua) == "" {
+		return Agent{Device: DeviceUnknown, OS: FamilyOther, Browser: FamilyOther}
+	}
+
+	lower := strings.ToLower(ua)
+	return Agent{
+		Device:  device(lower),
+		OS:      match(lower, osRules),
+		Browser: match(lower, browserRules),
+	}
+}
+
+func device(lower string) string {
+	switch {
+	case containsAny(lower, botPatterns):
+		return DeviceBot
+	case containsAny(lower, tabletPatterns):
+		return DeviceTablet
+	// Android tablets omit "Mobile" from their agent
+	case strings.Contains(lower, "android") && !strings.Contains(lower, "mobile"):
+		return DeviceTablet
+	case containsAny(lower, mobilePatterns):
+		return DeviceMobile
+	default:
+		return DeviceDesktop
+	}
+}
+
+func match(lower string, rules []rule) string {
+	for _, r := range rules {
+		if strings.Contains(lower, r.pattern) {
+			return r.family
+		}
+	}
+	return FamilyOther
+}
+
+func containsAny(s string, patterns []string) bool {
+	for _, p := range patterns {
+		if strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
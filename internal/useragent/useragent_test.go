@@ -0,0 +1,82 @@
+package useragent
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		ua   string
+		want Agent
+	}{
+		{
+			"chrome windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			Agent{DeviceDesktop, "Windows", "Chrome"},
+		},
+		{
+			"edge windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36 Edg/120.0",
+			Agent{DeviceDesktop, "Windows", "Edge"},
+		},
+		{
+			"safari macos",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 14_2) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+			Agent{DeviceDesktop, "macOS", "Safari"},
+		},
+		{
+			"firefox linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:121.0) Gecko/20100101 Firefox/121.0",
+			Agent{DeviceDesktop, "Linux", "Firefox"},
+		},
+		{
+			"safari iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			Agent{DeviceMobile, "iOS", "Safari"},
+		},
+		{
+			"chrome iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/120.0 Mobile/15E148 Safari/604.1",
+			Agent{DeviceMobile, "iOS", "Chrome"},
+		},
+		{
+			"ipad",
+			"Mozilla/5.0 (iPad; CPU OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+			Agent{DeviceTablet, "iOS", "Safari"},
+		},
+		{
+			"samsung android phone",
+			"Mozilla/5.0 (Linux; Android 14; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/23.0 Chrome/115.0 Mobile Safari/537.36",
+			Agent{DeviceMobile, "Android", "Samsung Internet"},
+		},
+		{
+			"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X710) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			Agent{DeviceTablet, "Android", "Chrome"},
+		},
+		{
+			"chrome os",
+			"Mozilla/5.0 (X11; CrOS x86_64 14541.0.0) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Safari/537.36",
+			Agent{DeviceDesktop, "Chrome OS", "Chrome"},
+		},
+		{
+			"internet explorer",
+			"Mozilla/5.0 (Windows NT 10.0; Trident/7.0; rv:11.0) like Gecko",
+			Agent{DeviceDesktop, "Windows", "Internet Explorer"},
+		},
+		{
+			"crawler",
+			"Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)",
+			Agent{DeviceBot, FamilyOther, FamilyOther},
+		},
+		{"unrecognised", "SomeClient/1.0", Agent{DeviceDesktop, FamilyOther, FamilyOther}},
+		{"empty", "", Agent{DeviceUnknown, FamilyOther, FamilyOther}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.ua); got != tt.want {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -12,12 +12,12 @@ import (
 
 // Publisher creates webhook delivery records when events occur.
 type Publisher struct {
-	repo   *Repository
+	repo   Store
 	logger *slog.Logger
 }
 
 // NewPublisher creates a new webhook publisher.
-func NewPublisher(repo *Repository, logger *slog.Logger) *Publisher {
+func NewPublisher(repo Store, logger *slog.Logger) *Publisher {
 	return &Publisher{
 		repo:   repo,
 		logger: logger.With("component", "webhook.publisher"),
@@ -47,9 +47,14 @@ func (p *Publisher) PublishClickEvent(ctx context.Context, userID string, click
 			"link_id":      click.LinkID,
 			"referrer":     extractReferrerDomain(click.Referrer),
 			"country_code": click.CountryCode,
+			"is_bot":       click.IsBot,
 		},
 	}
 
+	if click.BotFamily != "" {
+		payload.Data["bot_family"] = click.BotFamily
+	}
+
 	payloadJSON, err := json.Marshal(payload)
 	if err != nil {
 		return fmt.Errorf("marshal payload: %w", err)
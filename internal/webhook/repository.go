@@ -303,7 +303,7 @@ func (r *Repository) GetPendingDeliveries(ctx context.Context, limit int) ([]*mo
 	query := `
 		SELECT d.id, d.endpoint_id, d.event_id, d.event_type, d.payload_json,
 			   d.status, d.attempt_count, d.max_attempts, d.next_retry_at,
-			   d.last_attempt_at, d.last_http_status, d.last_error,
+			   d.last_attempt_at, d.last_http_status, COALESCE(d.last_error, ''),
 			   d.created_at, d.updated_at
 		FROM webhook_deliveries d
 		JOIN webhook_endpoints e ON d.endpoint_id = e.id
@@ -383,7 +383,7 @@ func (r *Repository) GetDeliveryWithEndpoint(ctx context.Context, deliveryID str
 	deliveryQuery := `
 		SELECT id, endpoint_id, event_id, event_type, payload_json,
 			   status, attempt_count, max_attempts, next_retry_at,
-			   last_attempt_at, last_http_status, last_error,
+			   last_attempt_at, last_http_status, COALESCE(last_error, ''),
 			   created_at, updated_at
 		FROM webhook_deliveries
 		WHERE id = $1
@@ -453,7 +453,7 @@ func (r *Repository) ListDeliveriesByEndpoint(ctx context.Context, endpointID st
 	query := fmt.Sprintf(`
 		SELECT id, endpoint_id, event_id, event_type, payload_json,
 			   status, attempt_count, max_attempts, next_retry_at,
-			   last_attempt_at, last_http_status, last_error,
+			   last_attempt_at, last_http_status, COALESCE(last_error, ''),
 			   created_at, updated_at
 		FROM webhook_deliveries
 		%s
@@ -0,0 +1,448 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// SQLiteRepository implements Store on the database opened by
+// repository.OpenSQLite, which also creates the webhook tables.
+type SQLiteRepository struct {
+	db *sql.DB
+}
+
+// NewSQLiteRepository creates a webhook repository on a SQLite database.
+func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
+	return &SQLiteRepository{db: db}
+}
+
+// sqliteEndpointColumns is the column list shared by the endpoint queries.
+const sqliteEndpointColumns = `id, user_id, target_url, secret_hash, enabled, event_types, name, description, created_at, updated_at, deleted_at`
+
+// sqliteDeliveryColumns is the column list shared by the delivery queries.
+const sqliteDeliveryColumns = `id, endpoint_id, event_id, event_type, payload_json,
+	status, attempt_count, max_attempts, next_retry_at,
+	last_attempt_at, last_http_status, COALESCE(last_error, ''),
+	created_at, updated_at`
+
+// CreateEndpoint creates a new webhook endpoint.
+func (r *SQLiteRepository) CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	query := `
+		INSERT INTO webhook_endpoints (
+			id, user_id, target_url, secret_hash, enabled,
+			event_types, name, description, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	eventTypes, err := encodeEventTypes(endpoint.EventTypes)
+	if err != nil {
+		return fmt.Errorf("insert webhook endpoint: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx, query,
+		endpoint.ID,
+		endpoint.UserID,
+		endpoint.TargetURL,
+		endpoint.SecretHash,
+		endpoint.Enabled,
+		eventTypes,
+		endpoint.Name,
+		endpoint.Description,
+		endpoint.CreatedAt.UTC(),
+		endpoint.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook endpoint: %w", err)
+	}
+	return nil
+}
+
+// GetEndpoint retrieves a webhook endpoint by ID.
+func (r *SQLiteRepository) GetEndpoint(ctx context.Context, id string) (*model.WebhookEndpoint, error) {
+	query := `SELECT ` + sqliteEndpointColumns + ` FROM webhook_endpoints WHERE id = ? AND deleted_at IS NULL`
+
+	endpoint, err := scanSQLiteEndpoint(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrEndpointNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("query webhook endpoint: %w", err)
+	}
+
+	return endpoint, nil
+}
+
+// ListEndpointsByUser retrieves all webhook endpoints for a user.
+func (r *SQLiteRepository) ListEndpointsByUser(ctx context.Context, userID string) ([]*model.WebhookEndpoint, error) {
+	query := `
+		SELECT ` + sqliteEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE user_id = ? AND deleted_at IS NULL
+		ORDER BY created_at DESC
+	`
+
+	endpoints, err := r.queryEndpoints(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("query webhooks by user: %w", err)
+	}
+	return endpoints, nil
+}
+
+// ListActiveEndpointsByUserAndEvent retrieves enabled endpoints for user/event.
+func (r *SQLiteRepository) ListActiveEndpointsByUserAndEvent(ctx context.Context, userID string, eventType model.EventType) ([]*model.WebhookEndpoint, error) {
+	query := `
+		SELECT ` + sqliteEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE user_id = ?
+		  AND deleted_at IS NULL
+		  AND enabled = TRUE
+		  AND EXISTS (SELECT 1 FROM json_each(event_types) WHERE value = ?)
+		ORDER BY created_at
+	`
+
+	endpoints, err := r.queryEndpoints(ctx, query, userID, string(eventType))
+	if err != nil {
+		return nil, fmt.Errorf("query active webhooks: %w", err)
+	}
+	return endpoints, nil
+}
+
+// UpdateEndpoint updates a webhook endpoint.
+func (r *SQLiteRepository) UpdateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error {
+	query := `
+		UPDATE webhook_endpoints
+		SET target_url = ?, enabled = ?, event_types = ?,
+			name = ?, description = ?, updated_at = ?
+		WHERE id = ? AND deleted_at IS NULL
+	`
+
+	eventTypes, err := encodeEventTypes(endpoint.EventTypes)
+	if err != nil {
+		return fmt.Errorf("update webhook endpoint: %w", err)
+	}
+
+	result, err := r.db.ExecContext(ctx, query,
+		endpoint.TargetURL,
+		endpoint.Enabled,
+		eventTypes,
+		endpoint.Name,
+		endpoint.Description,
+		time.Now().UTC(),
+		endpoint.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update webhook endpoint: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrEndpointNotFound
+	}
+
+	return nil
+}
+
+// UpdateEndpointSecret updates the secret hash for an endpoint.
+func (r *SQLiteRepository) UpdateEndpointSecret(ctx context.Context, id, secretHash string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_endpoints SET secret_hash = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		secretHash, time.Now().UTC(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("update endpoint secret: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrEndpointNotFound
+	}
+
+	return nil
+}
+
+// DeleteEndpoint soft-deletes a webhook endpoint.
+func (r *SQLiteRepository) DeleteEndpoint(ctx context.Context, id string) error {
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_endpoints SET deleted_at = ?, updated_at = ? WHERE id = ? AND deleted_at IS NULL`,
+		now, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("delete webhook endpoint: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrEndpointNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery creates a new delivery record.
+func (r *SQLiteRepository) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	query := `
+		INSERT INTO webhook_deliveries (
+			id, endpoint_id, event_id, event_type, payload_json,
+			status, attempt_count, max_attempts, next_retry_at,
+			created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (event_id, endpoint_id) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query,
+		delivery.ID,
+		delivery.EndpointID,
+		delivery.EventID,
+		string(delivery.EventType),
+		delivery.PayloadJSON,
+		string(delivery.Status),
+		delivery.AttemptCount,
+		delivery.MaxAttempts,
+		delivery.NextRetryAt.UTC(),
+		delivery.CreatedAt.UTC(),
+		delivery.UpdatedAt.UTC(),
+	)
+	if err != nil {
+		return fmt.Errorf("insert webhook delivery: %w", err)
+	}
+	return nil
+}
+
+// GetPendingDeliveries retrieves deliveries ready to be sent. SQLite
+// deployments run a single worker, so rows are not locked.
+func (r *SQLiteRepository) GetPendingDeliveries(ctx context.Context, limit int) ([]*model.WebhookDelivery, error) {
+	query := `
+		SELECT d.id, d.endpoint_id, d.event_id, d.event_type, d.payload_json,
+			   d.status, d.attempt_count, d.max_attempts, d.next_retry_at,
+			   d.last_attempt_at, d.last_http_status, COALESCE(d.last_error, ''),
+			   d.created_at, d.updated_at
+		FROM webhook_deliveries d
+		JOIN webhook_endpoints e ON d.endpoint_id = e.id
+		WHERE d.status IN ('pending', 'failed')
+		  AND d.next_retry_at <= ?
+		  AND e.deleted_at IS NULL
+		  AND e.enabled = TRUE
+		ORDER BY d.next_retry_at
+		LIMIT ?
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, time.Now().UTC(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("query pending deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	return scanDeliveries(rows)
+}
+
+// UpdateDeliverySuccess marks a delivery as successful.
+func (r *SQLiteRepository) UpdateDeliverySuccess(ctx context.Context, id string, httpStatus int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'success',
+			attempt_count = attempt_count + 1,
+			last_attempt_at = ?1,
+			last_http_status = ?2,
+			last_error = NULL,
+			updated_at = ?1
+		WHERE id = ?3
+	`
+
+	_, err := r.db.ExecContext(ctx, query, time.Now().UTC(), httpStatus, id)
+	if err != nil {
+		return fmt.Errorf("update delivery success: %w", err)
+	}
+	return nil
+}
+
+// UpdateDeliveryFailure marks a delivery as failed and schedules retry.
+func (r *SQLiteRepository) UpdateDeliveryFailure(ctx context.Context, id string, httpStatus *int, errMsg string, nextRetryAt time.Time, exhausted bool) error {
+	status := "failed"
+	if exhausted {
+		status = "exhausted"
+	}
+
+	// Truncate error message
+	if len(errMsg) > 500 {
+		errMsg = errMsg[:500]
+	}
+
+	query := `
+		UPDATE webhook_deliveries
+		SET status = ?1,
+			attempt_count = attempt_count + 1,
+			last_attempt_at = ?2,
+			last_http_status = ?3,
+			last_error = ?4,
+			next_retry_at = ?5,
+			updated_at = ?2
+		WHERE id = ?6
+	`
+
+	_, err := r.db.ExecContext(ctx, query, status, time.Now().UTC(), httpStatus, errMsg, nextRetryAt.UTC(), id)
+	if err != nil {
+		return fmt.Errorf("update delivery failure: %w", err)
+	}
+	return nil
+}
+
+// GetDeliveryWithEndpoint retrieves a delivery with its endpoint for sending.
+func (r *SQLiteRepository) GetDeliveryWithEndpoint(ctx context.Context, deliveryID string) (*model.WebhookDelivery, *model.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+sqliteDeliveryColumns+` FROM webhook_deliveries WHERE id = ?`, deliveryID,
+	)
+	if err != nil {
+		return nil, nil, fmt.Errorf("query delivery: %w", err)
+	}
+	deliveries, err := scanDeliveries(rows)
+	rows.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(deliveries) == 0 {
+		return nil, nil, ErrDeliveryNotFound
+	}
+
+	endpoint, err := r.GetEndpoint(ctx, deliveries[0].EndpointID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return deliveries[0], endpoint, nil
+}
+
+// ListDeliveriesByEndpoint retrieves deliveries for an endpoint with pagination.
+func (r *SQLiteRepository) ListDeliveriesByEndpoint(ctx context.Context, endpointID string, statuses []string, limit, offset int) ([]*model.WebhookDelivery, int, error) {
+	where := "WHERE endpoint_id = ?"
+	args := []interface{}{endpointID}
+
+	if len(statuses) > 0 {
+		where += " AND status IN (" + strings.TrimSuffix(strings.Repeat("?,", len(statuses)), ",") + ")"
+		for _, s := range statuses {
+			args = append(args, s)
+		}
+	}
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM webhook_deliveries `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("count deliveries: %w", err)
+	}
+
+	query := `SELECT ` + sqliteDeliveryColumns + ` FROM webhook_deliveries ` + where +
+		` ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	args = append(args, limit, offset)
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries, err := scanDeliveries(rows)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return deliveries, total, nil
+}
+
+// ResetDeliveryForRetry resets a delivery for manual retry.
+func (r *SQLiteRepository) ResetDeliveryForRetry(ctx context.Context, id string) error {
+	now := time.Now().UTC()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries SET status = 'pending', next_retry_at = ?, updated_at = ? WHERE id = ? AND status = 'exhausted'`,
+		now, now, id,
+	)
+	if err != nil {
+		return fmt.Errorf("reset delivery: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrDeliveryNotFound
+	}
+
+	return nil
+}
+
+// GetQueueDepth returns the count of pending and failed deliveries.
+func (r *SQLiteRepository) GetQueueDepth(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM webhook_deliveries WHERE status IN ('pending', 'failed')`,
+	).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("count queue depth: %w", err)
+	}
+	return count, nil
+}
+
+// queryEndpoints runs a query selecting sqliteEndpointColumns.
+func (r *SQLiteRepository) queryEndpoints(ctx context.Context, query string, args ...any) ([]*model.WebhookEndpoint, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var endpoints []*model.WebhookEndpoint
+	for rows.Next() {
+		endpoint, err := scanSQLiteEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+
+	return endpoints, rows.Err()
+}
+
+// scanSQLiteEndpoint scans a row selected with sqliteEndpointColumns.
+func scanSQLiteEndpoint(row interface{ Scan(dest ...any) error }) (*model.WebhookEndpoint, error) {
+	var endpoint model.WebhookEndpoint
+	var eventTypes string
+	var name, description sql.NullString
+
+	if err := row.Scan(
+		&endpoint.ID,
+		&endpoint.UserID,
+		&endpoint.TargetURL,
+		&endpoint.SecretHash,
+		&endpoint.Enabled,
+		&eventTypes,
+		&name,
+		&description,
+		&endpoint.CreatedAt,
+		&endpoint.UpdatedAt,
+		&endpoint.DeletedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal([]byte(eventTypes), &endpoint.EventTypes); err != nil {
+		return nil, fmt.Errorf("decode event types: %w", err)
+	}
+	endpoint.Name = name.String
+	endpoint.Description = description.String
+
+	return &endpoint, nil
+}
+
+// encodeEventTypes stores event types as a JSON array.
+func encodeEventTypes(eventTypes []model.EventType) (string, error) {
+	if eventTypes == nil {
+		eventTypes = []model.EventType{}
+	}
+	data, err := json.Marshal(eventTypes)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
@@ -0,0 +1,34 @@
+package webhook
+
+import (
+	"context"
+	"time"
+
+	"github.com/penshort/penshort/internal/model"
+)
+
+// Store persists webhook endpoints and the delivery outbox. It is
+// implemented by Repository (PostgreSQL) and SQLiteRepository.
+type Store interface {
+	CreateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error
+	GetEndpoint(ctx context.Context, id string) (*model.WebhookEndpoint, error)
+	ListEndpointsByUser(ctx context.Context, userID string) ([]*model.WebhookEndpoint, error)
+	ListActiveEndpointsByUserAndEvent(ctx context.Context, userID string, eventType model.EventType) ([]*model.WebhookEndpoint, error)
+	UpdateEndpoint(ctx context.Context, endpoint *model.WebhookEndpoint) error
+	UpdateEndpointSecret(ctx context.Context, id, secretHash string) error
+	DeleteEndpoint(ctx context.Context, id string) error
+
+	CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	GetPendingDeliveries(ctx context.Context, limit int) ([]*model.WebhookDelivery, error)
+	UpdateDeliverySuccess(ctx context.Context, id string, httpStatus int) error
+	UpdateDeliveryFailure(ctx context.Context, id string, httpStatus *int, errMsg string, nextRetryAt time.Time, exhausted bool) error
+	GetDeliveryWithEndpoint(ctx context.Context, deliveryID string) (*model.WebhookDelivery, *model.WebhookEndpoint, error)
+	ListDeliveriesByEndpoint(ctx context.Context, endpointID string, statuses []string, limit, offset int) ([]*model.WebhookDelivery, int, error)
+	ResetDeliveryForRetry(ctx context.Context, id string) error
+	GetQueueDepth(ctx context.Context) (int64, error)
+}
+
+var (
+	_ Store = (*Repository)(nil)
+	_ Store = (*SQLiteRepository)(nil)
+)
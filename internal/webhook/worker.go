@@ -26,7 +26,7 @@ const (
 
 // Worker processes webhook deliveries.
 type Worker struct {
-	repo            *Repository
+	repo            Store
 	client          *http.Client
 	logger          *slog.Logger
 	metrics         metrics.Recorder
@@ -38,7 +38,7 @@ type Worker struct {
 }
 
 // NewWorker creates a new webhook delivery worker.
-func NewWorker(repo *Repository, logger *slog.Logger, recorder metrics.Recorder) *Worker {
+func NewWorker(repo Store, logger *slog.Logger, recorder metrics.Recorder) *Worker {
 	if recorder == nil {
 		recorder = metrics.NewNoop()
 	}
@@ -0,0 +1,7 @@
+-- Phase 6: Fallback destinations and branded error pages rollback
+-- Migration: 000007_error_pages.down.sql
+
+DROP TABLE IF EXISTS error_page_settings;
+
+ALTER TABLE IF EXISTS links DROP CONSTRAINT IF EXISTS chk_fallback_url_length;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS fallback_url;
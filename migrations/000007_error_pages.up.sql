@@ -0,0 +1,40 @@
+-- Phase 6: Fallback destinations and branded error pages
+-- Migration: 000007_error_pages.up.sql
+
+-- Per-link fallback destination used when the link cannot redirect
+ALTER TABLE links ADD COLUMN fallback_url TEXT;
+
+ALTER TABLE links ADD CONSTRAINT chk_fallback_url_length
+    CHECK (fallback_url IS NULL OR LENGTH(fallback_url) <= 2048);
+
+-- ============================================================================
+-- ERROR PAGE SETTINGS TABLE (per owner or per domain)
+-- ============================================================================
+CREATE TABLE error_page_settings (
+    scope_type      TEXT NOT NULL,                    -- owner | domain
+    scope_id        TEXT NOT NULL,                    -- owner_id or host name
+
+    -- Default fallback when a link is expired, disabled or missing
+    fallback_url    TEXT,
+
+    -- Branding for server-rendered HTML error pages
+    brand_name      TEXT,
+    logo_url        TEXT,
+    accent_color    TEXT,                             -- #RRGGBB
+    support_url     TEXT,
+
+    -- Timestamps
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (scope_type, scope_id),
+    CONSTRAINT chk_error_page_scope_type CHECK (scope_type IN ('owner', 'domain')),
+    CONSTRAINT chk_error_page_accent_color CHECK (accent_color IS NULL OR accent_color ~ '^#[0-9a-fA-F]{6}$')
+);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN links.fallback_url IS 'Redirect target when the link is expired or disabled; NULL uses owner/domain default';
+COMMENT ON TABLE error_page_settings IS 'Fallback destinations and HTML error page branding per owner or domain';
+COMMENT ON COLUMN error_page_settings.scope_type IS 'owner (matches links.owner_id) or domain (matches request host)';
@@ -0,0 +1,4 @@
+-- Phase 6: Link ownership transfers rollback
+-- Migration: 000008_link_ownership_transfers.down.sql
+
+DROP TABLE IF EXISTS link_ownership_transfers;
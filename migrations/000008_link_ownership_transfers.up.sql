@@ -0,0 +1,45 @@
+-- Phase 6: Link ownership transfers with audit trail
+-- Migration: 000008_link_ownership_transfers.up.sql
+
+-- ============================================================================
+-- LINK OWNERSHIP TRANSFERS TABLE (Audit Trail)
+-- ============================================================================
+-- One row per link moved. Rows sharing a transfer_id were moved together
+-- in a single transaction. No FK to links so history survives hard deletes.
+CREATE TABLE link_ownership_transfers (
+    id              BIGSERIAL PRIMARY KEY,
+    transfer_id     TEXT NOT NULL,                    -- ULID, groups one request
+
+    -- Link snapshot
+    link_id         TEXT NOT NULL,
+    short_code      TEXT NOT NULL,
+
+    -- Ownership change
+    from_owner_id   TEXT NOT NULL,
+    to_owner_id     TEXT NOT NULL,
+
+    -- Who and why
+    actor_id        TEXT NOT NULL,                    -- Admin user ID
+    reason          TEXT,
+
+    -- Timestamps
+    transferred_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT chk_transfer_reason_length CHECK (reason IS NULL OR LENGTH(reason) <= 500)
+);
+
+-- ============================================================================
+-- INDEXES
+-- ============================================================================
+CREATE INDEX idx_link_ownership_transfers_transfer
+    ON link_ownership_transfers (transfer_id);
+
+CREATE INDEX idx_link_ownership_transfers_link
+    ON link_ownership_transfers (link_id, transferred_at DESC);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON TABLE link_ownership_transfers IS 'Audit trail of admin link ownership reassignments';
+COMMENT ON COLUMN link_ownership_transfers.transfer_id IS 'Shared by all links moved in one transfer request';
+COMMENT ON COLUMN link_ownership_transfers.actor_id IS 'User ID of the admin that performed the transfer';
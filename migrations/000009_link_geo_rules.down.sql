@@ -0,0 +1,7 @@
+-- Phase 6: Per-link country allow and deny lists rollback
+-- Migration: 000009_link_geo_rules.down.sql
+
+ALTER TABLE IF EXISTS links DROP CONSTRAINT IF EXISTS chk_geo_blocked_url_length;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS geo_blocked_url;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS blocked_countries;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS allowed_countries;
@@ -0,0 +1,19 @@
+-- Phase 6: Per-link country allow and deny lists
+-- Migration: 000009_link_geo_rules.up.sql
+
+-- ISO 3166-1 alpha-2 codes. NULL or empty means no restriction.
+ALTER TABLE links ADD COLUMN allowed_countries TEXT[];
+ALTER TABLE links ADD COLUMN blocked_countries TEXT[];
+
+-- Redirect target for visitors outside the allowed countries; NULL returns 451
+ALTER TABLE links ADD COLUMN geo_blocked_url TEXT;
+
+ALTER TABLE links ADD CONSTRAINT chk_geo_blocked_url_length
+    CHECK (geo_blocked_url IS NULL OR LENGTH(geo_blocked_url) <= 2048);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN links.allowed_countries IS 'If non-empty, only visitors from these countries are redirected';
+COMMENT ON COLUMN links.blocked_countries IS 'Visitors from these countries are never redirected';
+COMMENT ON COLUMN links.geo_blocked_url IS 'Redirect target for geo-blocked visitors; NULL returns 451';
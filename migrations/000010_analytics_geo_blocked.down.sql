@@ -0,0 +1,6 @@
+-- Phase 6: Track geo-blocked redirect attempts separately rollback
+-- Migration: 000010_analytics_geo_blocked.down.sql
+
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS blocked_country_breakdown;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS blocked_clicks;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS blocked;
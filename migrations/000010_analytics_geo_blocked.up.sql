@@ -0,0 +1,15 @@
+-- Phase 6: Track geo-blocked redirect attempts separately
+-- Migration: 000010_analytics_geo_blocked.up.sql
+
+-- Blocked attempts are stored as click events but excluded from click totals
+ALTER TABLE click_events ADD COLUMN blocked BOOLEAN NOT NULL DEFAULT FALSE;
+
+ALTER TABLE daily_link_stats ADD COLUMN blocked_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE daily_link_stats ADD COLUMN blocked_country_breakdown JSONB DEFAULT '{}';
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN click_events.blocked IS 'True if the redirect was refused by the link''s country rules';
+COMMENT ON COLUMN daily_link_stats.blocked_clicks IS 'Geo-blocked attempts; not included in total_clicks';
+COMMENT ON COLUMN daily_link_stats.blocked_country_breakdown IS 'JSON object: country code → blocked attempts';
@@ -0,0 +1,7 @@
+-- Phase 6: Classify bot traffic and keep it out of click totals rollback
+-- Migration: 000011_analytics_bots.down.sql
+
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS bot_family_breakdown;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS bot_clicks;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS bot_family;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS is_bot;
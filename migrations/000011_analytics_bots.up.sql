@@ -0,0 +1,16 @@
+-- Phase 6: Classify bot traffic and keep it out of click totals
+-- Migration: 000011_analytics_bots.up.sql
+
+ALTER TABLE click_events ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE click_events ADD COLUMN bot_family VARCHAR(50);
+
+ALTER TABLE daily_link_stats ADD COLUMN bot_clicks BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE daily_link_stats ADD COLUMN bot_family_breakdown JSONB DEFAULT '{}';
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN click_events.is_bot IS 'True if the request came from a crawler, unfurler or other automated client';
+COMMENT ON COLUMN click_events.bot_family IS 'Bot family, e.g. slack, twitter, email_scanner';
+COMMENT ON COLUMN daily_link_stats.bot_clicks IS 'Bot requests; not included in total_clicks or unique_visitors';
+COMMENT ON COLUMN daily_link_stats.bot_family_breakdown IS 'JSON object: bot family → requests';
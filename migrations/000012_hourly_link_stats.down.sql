@@ -0,0 +1,4 @@
+-- Phase 7: Hourly analytics rollups for intraday charts rollback
+-- Migration: 000012_hourly_link_stats.down.sql
+
+DROP TABLE IF EXISTS hourly_link_stats;
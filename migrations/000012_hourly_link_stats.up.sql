@@ -0,0 +1,38 @@
+-- Phase 7: Hourly analytics rollups for intraday charts
+-- Migration: 000012_hourly_link_stats.up.sql
+
+-- ============================================================================
+-- HOURLY LINK STATS TABLE (Pre-Aggregated)
+-- ============================================================================
+CREATE TABLE hourly_link_stats (
+    link_id         TEXT NOT NULL,
+    hour            TIMESTAMPTZ NOT NULL,             -- Start of the UTC hour
+
+    -- Counters
+    total_clicks    BIGINT NOT NULL DEFAULT 0,
+    unique_visitors BIGINT NOT NULL DEFAULT 0,
+    blocked_clicks  BIGINT NOT NULL DEFAULT 0,
+    bot_clicks      BIGINT NOT NULL DEFAULT 0,
+
+    -- Breakdowns, same shape as daily_link_stats
+    referrer_breakdown        JSONB DEFAULT '{}',
+    country_breakdown         JSONB DEFAULT '{}',
+    blocked_country_breakdown JSONB DEFAULT '{}',
+    bot_family_breakdown      JSONB DEFAULT '{}',
+
+    -- Timestamps
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (link_id, hour)
+);
+
+-- Index for the retention rollup, which scans by age
+CREATE INDEX idx_hourly_link_stats_hour
+    ON hourly_link_stats (hour);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON TABLE hourly_link_stats IS 'Pre-aggregated hourly statistics; rolled up into daily_link_stats after the retention period';
+COMMENT ON COLUMN hourly_link_stats.hour IS 'Start of the UTC hour the counters cover';
@@ -0,0 +1,5 @@
+-- Phase 7: Link tags rollback
+-- Migration: 000013_link_tags.down.sql
+
+DROP INDEX IF EXISTS idx_links_tags;
+ALTER TABLE IF EXISTS links DROP COLUMN IF EXISTS tags;
@@ -0,0 +1,13 @@
+-- Phase 7: Link tags
+-- Migration: 000013_link_tags.up.sql
+
+-- Lowercased labels; NULL or empty means untagged
+ALTER TABLE links ADD COLUMN tags TEXT[];
+
+-- Account analytics filter on a single tag (tags @> ARRAY[...])
+CREATE INDEX idx_links_tags ON links USING GIN (tags) WHERE deleted_at IS NULL;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN links.tags IS 'Owner-defined labels used to group links in analytics';
@@ -0,0 +1,8 @@
+-- Phase 7: Device, OS and browser breakdowns parsed from the User-Agent rollback
+-- Migration: 000014_analytics_devices.down.sql
+
+ALTER TABLE IF EXISTS hourly_link_stats DROP COLUMN IF EXISTS os_breakdown;
+ALTER TABLE IF EXISTS hourly_link_stats DROP COLUMN IF EXISTS device_breakdown;
+ALTER TABLE IF EXISTS hourly_link_stats DROP COLUMN IF EXISTS ua_family_breakdown;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS os_breakdown;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS device_breakdown;
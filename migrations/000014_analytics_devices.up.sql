@@ -0,0 +1,19 @@
+-- Phase 7: Device, OS and browser breakdowns parsed from the User-Agent
+-- Migration: 000014_analytics_devices.up.sql
+
+-- Browser families go in the existing ua_family_breakdown column
+ALTER TABLE daily_link_stats ADD COLUMN device_breakdown JSONB DEFAULT '{}';
+ALTER TABLE daily_link_stats ADD COLUMN os_breakdown JSONB DEFAULT '{}';
+
+ALTER TABLE hourly_link_stats ADD COLUMN ua_family_breakdown JSONB DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN device_breakdown JSONB DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN os_breakdown JSONB DEFAULT '{}';
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN daily_link_stats.device_breakdown IS 'JSON object: device type (desktop, mobile, tablet, bot) → requests';
+COMMENT ON COLUMN daily_link_stats.os_breakdown IS 'JSON object: OS family → click count';
+COMMENT ON COLUMN hourly_link_stats.ua_family_breakdown IS 'JSON object: browser family → click count';
+COMMENT ON COLUMN hourly_link_stats.device_breakdown IS 'JSON object: device type (desktop, mobile, tablet, bot) → requests';
+COMMENT ON COLUMN hourly_link_stats.os_breakdown IS 'JSON object: OS family → click count';
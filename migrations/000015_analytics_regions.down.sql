@@ -0,0 +1,7 @@
+-- Phase 7: Region and city from the GeoIP database rollback
+-- Migration: 000015_analytics_regions.down.sql
+
+ALTER TABLE IF EXISTS hourly_link_stats DROP COLUMN IF EXISTS region_breakdown;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS region_breakdown;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS city;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS region;
@@ -0,0 +1,16 @@
+-- Phase 7: Region and city from the GeoIP database
+-- Migration: 000015_analytics_regions.up.sql
+
+ALTER TABLE click_events ADD COLUMN region VARCHAR(6);
+ALTER TABLE click_events ADD COLUMN city VARCHAR(100);
+
+ALTER TABLE daily_link_stats ADD COLUMN region_breakdown JSONB DEFAULT '{}';
+ALTER TABLE hourly_link_stats ADD COLUMN region_breakdown JSONB DEFAULT '{}';
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN click_events.region IS 'ISO 3166-2 subdivision code (e.g. US-CA), resolved from the GeoIP database';
+COMMENT ON COLUMN click_events.city IS 'City name, resolved from the GeoIP database';
+COMMENT ON COLUMN daily_link_stats.region_breakdown IS 'JSON object: ISO 3166-2 region → click count';
+COMMENT ON COLUMN hourly_link_stats.region_breakdown IS 'JSON object: ISO 3166-2 region → click count';
@@ -0,0 +1,6 @@
+-- Phase 7: HyperLogLog sketches for unique visitors across days rollback
+-- Migration: 000016_analytics_sketches.down.sql
+
+ALTER TABLE IF EXISTS hourly_link_stats DROP COLUMN IF EXISTS visitor_sketch;
+ALTER TABLE IF EXISTS daily_link_stats DROP COLUMN IF EXISTS visitor_sketch;
+ALTER TABLE IF EXISTS click_events DROP COLUMN IF EXISTS visitor_reg;
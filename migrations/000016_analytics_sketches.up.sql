@@ -0,0 +1,14 @@
+-- Phase 7: HyperLogLog sketches for unique visitors across days
+-- Migration: 000016_analytics_sketches.up.sql
+
+ALTER TABLE click_events ADD COLUMN visitor_reg INTEGER;
+
+ALTER TABLE daily_link_stats ADD COLUMN visitor_sketch BYTEA;
+ALTER TABLE hourly_link_stats ADD COLUMN visitor_sketch BYTEA;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON COLUMN click_events.visitor_reg IS 'HyperLogLog register update (index << 8 | rank); not a visitor identifier';
+COMMENT ON COLUMN daily_link_stats.visitor_sketch IS 'HyperLogLog sketch of the day''s visitors, merged for multi-day uniques';
+COMMENT ON COLUMN hourly_link_stats.visitor_sketch IS 'HyperLogLog sketch of the hour''s visitors';
@@ -0,0 +1,5 @@
+-- Phase 7: Visitor seen-sets for incremental aggregation rollback
+-- Migration: 000017_analytics_visitor_sets.down.sql
+
+DROP TABLE IF EXISTS hourly_link_visitors;
+DROP TABLE IF EXISTS daily_link_visitors;
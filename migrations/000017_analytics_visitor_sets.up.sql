@@ -0,0 +1,42 @@
+-- Phase 7: Visitor seen-sets for incremental aggregation
+-- Migration: 000017_analytics_visitor_sets.up.sql
+
+-- ============================================================================
+-- VISITOR SEEN-SETS
+-- ============================================================================
+-- The worker merges each batch into the stats rows rather than recounting
+-- the period. A visitor adds to unique_visitors only when its row here is new.
+CREATE TABLE daily_link_visitors (
+    link_id         TEXT NOT NULL,
+    date            DATE NOT NULL,                    -- UTC date
+    visitor_hash    TEXT NOT NULL,
+
+    PRIMARY KEY (link_id, date, visitor_hash)
+);
+
+CREATE TABLE hourly_link_visitors (
+    link_id         TEXT NOT NULL,
+    hour            TIMESTAMPTZ NOT NULL,             -- Start of the UTC hour
+    visitor_hash    TEXT NOT NULL,
+
+    PRIMARY KEY (link_id, hour, visitor_hash)
+);
+
+-- Backfill the days that still have hourly rows; older days are closed
+INSERT INTO daily_link_visitors (link_id, date, visitor_hash)
+SELECT DISTINCT link_id, (clicked_at AT TIME ZONE 'UTC')::date, visitor_hash
+FROM click_events
+WHERE NOT blocked AND NOT is_bot AND visitor_hash <> ''
+  AND clicked_at >= (SELECT date_trunc('day', MIN(hour) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' FROM hourly_link_stats);
+
+INSERT INTO hourly_link_visitors (link_id, hour, visitor_hash)
+SELECT DISTINCT link_id, date_trunc('hour', clicked_at AT TIME ZONE 'UTC') AT TIME ZONE 'UTC', visitor_hash
+FROM click_events
+WHERE NOT blocked AND NOT is_bot AND visitor_hash <> ''
+  AND clicked_at >= (SELECT date_trunc('day', MIN(hour) AT TIME ZONE 'UTC') AT TIME ZONE 'UTC' FROM hourly_link_stats);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON TABLE daily_link_visitors IS 'Visitors already counted in daily_link_stats.unique_visitors; kept as long as the daily rows';
+COMMENT ON TABLE hourly_link_visitors IS 'Visitors already counted in hourly_link_stats.unique_visitors; pruned with the hourly rows';
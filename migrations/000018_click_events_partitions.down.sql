@@ -0,0 +1,55 @@
+-- Phase 7: Monthly partitions for raw click events rollback
+-- Migration: 000018_click_events_partitions.down.sql
+
+-- Move the events back into a single table, if click_events is partitioned
+DO $$
+BEGIN
+    IF to_regclass('click_events') IS NULL OR NOT EXISTS (
+        SELECT 1 FROM pg_partitioned_table WHERE partrelid = 'click_events'::regclass
+    ) THEN
+        RETURN;
+    END IF;
+
+    ALTER TABLE click_events RENAME TO click_events_partitioned;
+    ALTER INDEX click_events_pkey RENAME TO click_events_partitioned_pkey;
+    ALTER INDEX click_events_event_id_key RENAME TO click_events_partitioned_event_id_key;
+
+    CREATE TABLE click_events (
+        id              TEXT PRIMARY KEY,
+        event_id        TEXT NOT NULL UNIQUE,
+        short_code      TEXT NOT NULL,
+        link_id         TEXT NOT NULL,
+        referrer        TEXT,
+        user_agent      TEXT,
+        visitor_hash    TEXT NOT NULL,
+        country_code    CHAR(2),
+        clicked_at      TIMESTAMPTZ NOT NULL,
+        created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+        blocked         BOOLEAN NOT NULL DEFAULT FALSE,
+        is_bot          BOOLEAN NOT NULL DEFAULT FALSE,
+        bot_family      VARCHAR(50),
+        region          VARCHAR(6),
+        city            VARCHAR(100),
+        visitor_reg     INTEGER
+    );
+
+    INSERT INTO click_events (
+        id, event_id, short_code, link_id, referrer, user_agent, visitor_hash, country_code,
+        clicked_at, created_at, blocked, is_bot, bot_family, region, city, visitor_reg
+    )
+    SELECT
+        id, event_id, short_code, link_id, referrer, user_agent, visitor_hash, country_code,
+        clicked_at, created_at, blocked, is_bot, bot_family, region, city, visitor_reg
+    FROM click_events_partitioned;
+
+    DROP TABLE click_events_partitioned;
+
+    CREATE INDEX idx_click_events_link_time
+        ON click_events (link_id, clicked_at DESC);
+    CREATE INDEX idx_click_events_short_code_time
+        ON click_events (short_code, clicked_at DESC);
+    CREATE INDEX idx_click_events_clicked_at
+        ON click_events (clicked_at, link_id);
+END $$;
+
+DROP FUNCTION IF EXISTS create_click_events_partition(DATE);
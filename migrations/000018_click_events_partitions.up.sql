@@ -0,0 +1,127 @@
+-- Phase 7: Monthly partitions for raw click events
+-- Migration: 000018_click_events_partitions.up.sql
+
+-- ============================================================================
+-- PARTITION HELPER
+-- ============================================================================
+-- Creates the partition holding the UTC month containing month, named
+-- click_events_YYYY_MM. Returns false if it already exists. The analytics
+-- worker calls this ahead of time for the coming months. Events of that month
+-- already in the default partition are moved into the new one.
+CREATE OR REPLACE FUNCTION create_click_events_partition(month DATE)
+RETURNS BOOLEAN AS $$
+DECLARE
+    first_day DATE := date_trunc('month', month)::DATE;
+    partition_name TEXT := 'click_events_' || to_char(first_day, 'YYYY_MM');
+    range_start TIMESTAMPTZ := first_day::TIMESTAMP AT TIME ZONE 'UTC';
+    range_end TIMESTAMPTZ := (first_day + INTERVAL '1 month')::TIMESTAMP AT TIME ZONE 'UTC';
+BEGIN
+    IF to_regclass(partition_name) IS NOT NULL THEN
+        RETURN FALSE;
+    END IF;
+    EXECUTE format('CREATE TABLE %I (LIKE click_events INCLUDING DEFAULTS)', partition_name);
+    IF to_regclass('click_events_default') IS NOT NULL THEN
+        EXECUTE format(
+            'WITH moved AS (DELETE FROM click_events_default WHERE clicked_at >= %L AND clicked_at < %L RETURNING *)
+             INSERT INTO %I SELECT * FROM moved',
+            range_start, range_end, partition_name
+        );
+    END IF;
+    EXECUTE format(
+        'ALTER TABLE click_events ATTACH PARTITION %I FOR VALUES FROM (%L) TO (%L)',
+        partition_name, range_start, range_end
+    );
+    RETURN TRUE;
+END;
+$$ LANGUAGE plpgsql;
+
+-- ============================================================================
+-- CLICK EVENTS TABLE (Partitioned by month)
+-- ============================================================================
+ALTER TABLE click_events RENAME TO click_events_unpartitioned;
+ALTER INDEX click_events_pkey RENAME TO click_events_unpartitioned_pkey;
+ALTER INDEX click_events_event_id_key RENAME TO click_events_unpartitioned_event_id_key;
+
+-- This copies every click event into the new table in one transaction, so
+-- click_events is locked for the duration and needs about twice its disk
+-- space until the copy commits. See docs/deployment.md.
+--
+-- Unique keys on a partitioned table must include the partition key, so the
+-- unique key here is (event_id, clicked_at). Migration 000019 adds the
+-- click_event_ids table that keeps event_id alone unique.
+CREATE TABLE click_events (
+    id              TEXT NOT NULL,                    -- ULID
+    event_id        TEXT NOT NULL,                    -- Redis stream ID (idempotency)
+    short_code      TEXT NOT NULL,
+    link_id         TEXT NOT NULL,
+    referrer        TEXT,
+    user_agent      TEXT,
+    visitor_hash    TEXT NOT NULL,
+    country_code    CHAR(2),
+    clicked_at      TIMESTAMPTZ NOT NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    blocked         BOOLEAN NOT NULL DEFAULT FALSE,
+    is_bot          BOOLEAN NOT NULL DEFAULT FALSE,
+    bot_family      VARCHAR(50),
+    region          VARCHAR(6),
+    city            VARCHAR(100),
+    visitor_reg     INTEGER,
+
+    CONSTRAINT click_events_pkey PRIMARY KEY (id, clicked_at),
+    CONSTRAINT click_events_event_id_key UNIQUE (event_id, clicked_at)
+) PARTITION BY RANGE (clicked_at);
+
+-- Events outside the monthly partitions, such as replays older than the
+-- retention period or clocks far ahead, land here instead of failing the
+-- batch. The worker deletes expired ones with the expired partitions.
+CREATE TABLE click_events_default PARTITION OF click_events DEFAULT;
+
+-- Partitions from the oldest stored event through three months ahead
+DO $$
+DECLARE
+    month DATE;
+BEGIN
+    SELECT date_trunc('month', COALESCE(MIN(clicked_at), NOW()) AT TIME ZONE 'UTC')::DATE
+    INTO month
+    FROM click_events_unpartitioned;
+
+    WHILE month <= (NOW() AT TIME ZONE 'UTC')::DATE + INTERVAL '3 months' LOOP
+        PERFORM create_click_events_partition(month);
+        month := month + INTERVAL '1 month';
+    END LOOP;
+END $$;
+
+INSERT INTO click_events (
+    id, event_id, short_code, link_id, referrer, user_agent, visitor_hash, country_code,
+    clicked_at, created_at, blocked, is_bot, bot_family, region, city, visitor_reg
+)
+SELECT
+    id, event_id, short_code, link_id, referrer, user_agent, visitor_hash, country_code,
+    clicked_at, created_at, blocked, is_bot, bot_family, region, city, visitor_reg
+FROM click_events_unpartitioned;
+
+DROP TABLE click_events_unpartitioned;
+
+-- Indexes are created on every partition
+CREATE INDEX idx_click_events_link_time
+    ON click_events (link_id, clicked_at DESC);
+
+CREATE INDEX idx_click_events_short_code_time
+    ON click_events (short_code, clicked_at DESC);
+
+CREATE INDEX idx_click_events_clicked_at
+    ON click_events (clicked_at, link_id);
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON TABLE click_events IS 'Raw click events, partitioned by UTC month; old partitions are dropped after ANALYTICS_RAW_RETENTION_DAYS';
+COMMENT ON COLUMN click_events.event_id IS 'Stream or spool event ID; unique with clicked_at for idempotency';
+COMMENT ON COLUMN click_events.visitor_hash IS 'SHA256(IP+UA+daily_salt)[0:16], rotates daily';
+COMMENT ON COLUMN click_events.country_code IS 'ISO 3166-1 alpha-2 from CF-IPCountry or IP lookup';
+COMMENT ON COLUMN click_events.blocked IS 'True if the redirect was refused by the link''s country rules';
+COMMENT ON COLUMN click_events.is_bot IS 'True if the request came from a crawler, unfurler or other automated client';
+COMMENT ON COLUMN click_events.bot_family IS 'Bot family, e.g. slack, twitter, email_scanner';
+COMMENT ON COLUMN click_events.region IS 'ISO 3166-2 subdivision code (e.g. US-CA), resolved from the GeoIP database';
+COMMENT ON COLUMN click_events.city IS 'City name, resolved from the GeoIP database';
+COMMENT ON COLUMN click_events.visitor_reg IS 'HyperLogLog register update (index << 8 | rank); not a visitor identifier';
@@ -0,0 +1,11 @@
+-- Phase 7: Event ID dedupe for partitioned click events rollback
+-- Migration: 000019_click_event_ids.down.sql
+
+DROP TABLE IF EXISTS click_event_ids;
+
+DO $$
+BEGIN
+    IF to_regclass('click_events') IS NOT NULL THEN
+        COMMENT ON COLUMN click_events.event_id IS 'Stream or spool event ID; unique with clicked_at for idempotency';
+    END IF;
+END $$;
@@ -0,0 +1,28 @@
+-- Phase 7: Event ID dedupe for partitioned click events
+-- Migration: 000019_click_event_ids.up.sql
+
+-- ============================================================================
+-- CLICK EVENT IDS TABLE
+-- ============================================================================
+-- click_events can only be unique on (event_id, clicked_at) because it is
+-- partitioned. The worker claims each event ID here, in the same transaction
+-- as the insert, so an event requeued with a different clicked_at is still
+-- recorded once.
+CREATE TABLE click_event_ids (
+    event_id        TEXT PRIMARY KEY,                 -- Redis stream or spool event ID
+    recorded_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Pruned with the expired click_events partitions
+CREATE INDEX idx_click_event_ids_recorded_at ON click_event_ids (recorded_at);
+
+INSERT INTO click_event_ids (event_id, recorded_at)
+SELECT event_id, MIN(created_at)
+FROM click_events
+GROUP BY event_id;
+
+-- ============================================================================
+-- COMMENTS
+-- ============================================================================
+COMMENT ON TABLE click_event_ids IS 'Event IDs of recorded clicks; the idempotency key for click_events';
+COMMENT ON COLUMN click_events.event_id IS 'Stream or spool event ID; deduplicated through click_event_ids';
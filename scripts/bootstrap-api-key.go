@@ -27,7 +27,7 @@ type output struct {
 
 func main() {
 	var (
-		databaseURL = flag.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
+		databaseURL = flag.String("database-url", os.Getenv("DATABASE_URL"), "PostgreSQL connection string or sqlite: URL")
 		userID      = flag.String("user-id", "system", "User ID to own the API key")
 		email       = flag.String("email", "system@penshort.local", "User email")
 		name        = flag.String("name", "bootstrap", "API key name")
@@ -50,7 +50,7 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	repo, err := repository.New(ctx, *databaseURL)
+	repo, err := openStore(ctx, *databaseURL)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, "connect database:", err)
 		os.Exit(1)
@@ -138,7 +138,15 @@ func isValidScope(scope string) bool {
 	return false
 }
 
-func ensureUser(ctx context.Context, repo *repository.Repository, userID, email string) error {
+// openStore opens the backend selected by the database URL scheme.
+func openStore(ctx context.Context, databaseURL string) (repository.Store, error) {
+	if repository.IsSQLiteURL(databaseURL) {
+		return repository.OpenSQLite(ctx, databaseURL)
+	}
+	return repository.New(ctx, databaseURL, "")
+}
+
+func ensureUser(ctx context.Context, repo repository.Store, userID, email string) error {
 	existing, err := repo.GetUserByID(ctx, userID)
 	if err == nil {
 		if existing.Email != email {
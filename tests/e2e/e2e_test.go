@@ -85,7 +85,7 @@ func bootstrapAdminKey(t *testing.T, dbURL string) string {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	repo, err := repository.New(ctx, dbURL)
+	repo, err := repository.New(ctx, dbURL, "")
 	if err != nil {
 		t.Fatalf("connect db: %v", err)
 	}
@@ -425,7 +425,7 @@ func TestE2ERateLimiting(t *testing.T) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	repo, err := repository.New(ctx, dbURL)
+	repo, err := repository.New(ctx, dbURL, "")
 	if err != nil {
 		t.Fatalf("connect db: %v", err)
 	}